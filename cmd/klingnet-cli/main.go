@@ -106,6 +106,8 @@ dispatch:
 		cmdBalance(client, cmdArgs, chainID)
 	case "mempool":
 		cmdMempool(client, chainID)
+	case "fee":
+		cmdFee(client, cmdArgs, chainID)
 	case "peers":
 		cmdPeers(client)
 	case "wallet":
@@ -144,12 +146,15 @@ Commands:
   status                          Show chain status
   block <hash|height>             Show block details
   tx <hash>                       Show transaction details
-  send --wallet <w> --to <addr> --amount <amt>
+  tx bump <hash> --wallet <w> [--fee-rate <n>]
+                                  Replace a stuck wallet tx with a higher fee
+  send --wallet <w> --to <addr> --amount <amt> [--fee-rate <n> | --target <blocks>] [--replaceable]
                                   Send a transaction
-  sendmany --wallet <w> --recipients <file.json>
+  sendmany --wallet <w> --recipients <file.json> [--fee-rate <n> | --target <blocks>] [--replaceable]
                                   Send to multiple recipients (JSON file)
   balance <address>               Show address balance
   mempool                         Show mempool stats
+  fee [--target <blocks>]         Estimate the fee rate to confirm within a target
   peers                           Show connected peers
 
   wallet create --name <n>        Create a new wallet
@@ -275,7 +280,7 @@ func cmdBlock(client *rpcclient.Client, args []string, chainID string) {
 
 func cmdTx(client *rpcclient.Client, args []string, ksDir, rpcURL, chainID string) {
 	if len(args) < 1 {
-		fatal("Usage: klingnet-cli tx <hash> | send --wallet <w> --to <addr> --amount <amt>")
+		fatal("Usage: klingnet-cli tx <hash> | send --wallet <w> --to <addr> --amount <amt> | bump <hash> --wallet <w>")
 	}
 
 	if args[0] == "send" {
@@ -283,6 +288,10 @@ func cmdTx(client *rpcclient.Client, args []string, ksDir, rpcURL, chainID strin
 		cmdSend(args[1:], ksDir, rpcURL, chainID)
 		return
 	}
+	if args[0] == "bump" {
+		cmdTxBump(client, args[1:], chainID)
+		return
+	}
 
 	// Show transaction by hash.
 	hash := args[0]
@@ -324,6 +333,44 @@ func cmdTx(client *rpcclient.Client, args []string, ksDir, rpcURL, chainID strin
 	}
 }
 
+// ── tx bump ─────────────────────────────────────────────────────────────
+
+func cmdTxBump(client *rpcclient.Client, args []string, chainID string) {
+	const usage = "Usage: klingnet-cli tx bump <hash> --wallet <name> [--fee-rate <units/byte>]"
+	if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+		fatal(usage)
+	}
+	hash := args[0]
+
+	fs := flag.NewFlagSet("tx bump", flag.ExitOnError)
+	walletName := fs.String("wallet", "", "Wallet name")
+	feeRate := fs.Uint64("fee-rate", 0, "Fee rate in base units per byte (default: minimum for replacement)")
+	fs.Parse(args[1:])
+
+	if *walletName == "" {
+		fatal(usage)
+	}
+
+	password, err := readPassword("Enter password: ")
+	if err != nil {
+		fatal("read password: %v", err)
+	}
+
+	var result rpc.WalletBumpFeeResult
+	if err := client.Call("wallet_bumpFee", rpc.WalletBumpFeeParam{
+		Name:     *walletName,
+		Password: string(password),
+		TxHash:   hash,
+		FeeRate:  *feeRate,
+		ChainID:  chainID,
+	}, &result); err != nil {
+		fatal("wallet_bumpFee: %v", err)
+	}
+	fmt.Printf("Replaced:  %s\n", result.ReplacedTxHash)
+	fmt.Printf("Submitted: %s\n", result.TxHash)
+	fmt.Printf("Fee:       %s -> %s\n", formatAmount(result.OldFee), formatAmount(result.NewFee))
+}
+
 // ── send (top-level) ────────────────────────────────────────────────────
 
 func cmdSend(args []string, ksDir, rpcURL, chainID string) {
@@ -331,6 +378,9 @@ func cmdSend(args []string, ksDir, rpcURL, chainID string) {
 	walletName := fs.String("wallet", "", "Wallet name")
 	toAddr := fs.String("to", "", "Recipient address")
 	amountStr := fs.String("amount", "", "Amount to send (e.g. 1.5)")
+	feeRate := fs.Uint64("fee-rate", 0, "Fee rate in base units per byte (default: estimated)")
+	target := fs.Int("target", 0, "Confirmation target in blocks for the fee estimate (default: 6)")
+	replaceable := fs.Bool("replaceable", false, "Signal replace-by-fee so the tx can be bumped")
 	fs.Parse(args)
 
 	if *walletName == "" || *toAddr == "" || *amountStr == "" {
@@ -361,10 +411,13 @@ func cmdSend(args []string, ksDir, rpcURL, chainID string) {
 		client := rpcclient.New(rpcURL)
 		var result rpc.WalletSendResult
 		if err := client.Call("wallet_send", rpc.WalletSendParam{
-			Name:     *walletName,
-			Password: string(password),
-			To:       *toAddr,
-			Amount:   amount,
+			Name:         *walletName,
+			Password:     string(password),
+			To:           *toAddr,
+			Amount:       amount,
+			FeeRate:      *feeRate,
+			TargetBlocks: *target,
+			Replaceable:  *replaceable,
 		}, &result); err != nil {
 			fatal("wallet_send: %v", err)
 		}
@@ -372,16 +425,21 @@ func cmdSend(args []string, ksDir, rpcURL, chainID string) {
 		return
 	}
 
+	if *feeRate != 0 || *target != 0 {
+		fatal("--fee-rate and --target are not supported on sub-chains")
+	}
+
 	// Sub-chain sends should use subchain_send so all wallet accounts
 	// (external + change) are considered during coin selection.
 	client := rpcclient.New(rpcURL)
 	var result rpc.SubChainSendResult
 	if err := client.Call("subchain_send", rpc.SubChainSendParam{
-		ChainID:  chainID,
-		Name:     *walletName,
-		Password: string(password),
-		To:       recipientAddr.String(),
-		Amount:   amount,
+		ChainID:     chainID,
+		Name:        *walletName,
+		Password:    string(password),
+		To:          recipientAddr.String(),
+		Amount:      amount,
+		Replaceable: *replaceable,
 	}, &result); err != nil {
 		fatal("subchain_send: %v", err)
 	}
@@ -394,6 +452,9 @@ func cmdSendMany(args []string, ksDir, rpcURL, chainID string) {
 	fs := flag.NewFlagSet("sendmany", flag.ExitOnError)
 	walletName := fs.String("wallet", "", "Wallet name")
 	recipientsFile := fs.String("recipients", "", "Path to JSON recipients file")
+	feeRate := fs.Uint64("fee-rate", 0, "Fee rate in base units per byte (default: estimated)")
+	target := fs.Int("target", 0, "Confirmation target in blocks for the fee estimate (default: 6)")
+	replaceable := fs.Bool("replaceable", false, "Signal replace-by-fee so the tx can be bumped")
 	fs.Parse(args)
 
 	if *walletName == "" || *recipientsFile == "" {
@@ -452,9 +513,12 @@ func cmdSendMany(args []string, ksDir, rpcURL, chainID string) {
 	// Submit via RPC.
 	client := rpcclient.New(rpcURL)
 	params := rpc.WalletSendManyParam{
-		Name:       *walletName,
-		Password:   string(password),
-		Recipients: recipients,
+		Name:         *walletName,
+		Password:     string(password),
+		Recipients:   recipients,
+		FeeRate:      *feeRate,
+		TargetBlocks: *target,
+		Replaceable:  *replaceable,
 	}
 	if chainID != "" {
 		fatal("sendmany on sub-chains is not yet supported")
@@ -513,6 +577,14 @@ func cmdMempool(client *rpcclient.Client, chainID string) {
 
 	fmt.Printf("Count:   %d\n", info.Count)
 	fmt.Printf("Min Fee Rate: %d per byte\n", info.MinFeeRate)
+	if info.MaxBytes > 0 {
+		fmt.Printf("Size:    %d / %d bytes\n", info.Bytes, info.MaxBytes)
+	} else {
+		fmt.Printf("Size:    %d bytes\n", info.Bytes)
+	}
+	r := info.Removed
+	fmt.Printf("Removed: %d confirmed, %d conflict, %d expired, %d evicted, %d replaced, %d invalid\n",
+		r.Confirmed, r.Conflict, r.Expired, r.Evicted, r.Replaced, r.Invalid)
 
 	if info.Count > 0 {
 		var content rpc.MempoolContentResult
@@ -526,6 +598,25 @@ func cmdMempool(client *rpcclient.Client, chainID string) {
 	}
 }
 
+// ── fee ─────────────────────────────────────────────────────────────────
+
+func cmdFee(client *rpcclient.Client, args []string, chainID string) {
+	fs := flag.NewFlagSet("fee", flag.ExitOnError)
+	target := fs.Int("target", 0, "Confirmation target in blocks (default: 6)")
+	fs.Parse(args)
+
+	var result rpc.FeeEstimateResult
+	if err := client.Call("fee_estimate", rpc.FeeEstimateParam{TargetBlocks: *target, ChainID: chainID}, &result); err != nil {
+		fatal("fee_estimate: %v", err)
+	}
+
+	fmt.Printf("Target:   %d blocks\n", result.TargetBlocks)
+	fmt.Printf("Fee Rate: %d per byte\n", result.FeeRate)
+	if !result.Estimated {
+		fmt.Println("Not enough data yet; showing the minimum fee rate.")
+	}
+}
+
 // ── peers ───────────────────────────────────────────────────────────────
 
 func cmdPeers(client *rpcclient.Client) {
@@ -535,9 +626,13 @@ func cmdPeers(client *rpcclient.Client) {
 	}
 
 	fmt.Printf("Node ID: %s\n", node.ID)
-	for _, a := range node.Addrs {
+	fmt.Printf("Reachability: %s\n", node.Reachability)
+	for _, a := range node.ListenAddrs {
 		fmt.Printf("  Listen: %s\n", a)
 	}
+	for _, a := range node.Addrs {
+		fmt.Printf("  Advertised: %s\n", a)
+	}
 
 	var peers rpc.PeerInfoResult
 	if err := client.Call("net_getPeerInfo", nil, &peers); err != nil {
@@ -546,7 +641,8 @@ func cmdPeers(client *rpcclient.Client) {
 
 	fmt.Printf("Peers:   %d\n", peers.Count)
 	for _, p := range peers.Peers {
-		fmt.Printf("  %s (connected: %s)\n", p.ID, p.ConnectedAt)
+		fmt.Printf("  %s (connected: %s, in: %d B, out: %d B)\n",
+			p.ID, p.ConnectedAt, p.Bandwidth.TotalIn, p.Bandwidth.TotalOut)
 	}
 }
 
@@ -52,6 +52,9 @@ export default function NetworkStatus() {
               </span>
               {node?.id && <CopyButton text={node.id} />}
             </div>
+            <p className="text-xs text-muted-foreground">
+              Reachability: {node?.reachability || 'unknown'}
+            </p>
             {node?.addrs && node.addrs.length > 0 && (
               <div className="space-y-1">
                 <Button
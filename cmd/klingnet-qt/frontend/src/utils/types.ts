@@ -84,6 +84,7 @@ export interface SendRequest {
   password: string;
   to_address: string;
   amount: string;
+  target_blocks?: number;
 }
 
 export interface SendResult {
@@ -99,6 +100,7 @@ export interface SendManyRequest {
   wallet_name: string;
   password: string;
   recipients: SendManyRecipient[];
+  target_blocks?: number;
 }
 
 export interface SendManyResult {
@@ -108,6 +110,8 @@ export interface SendManyResult {
 export interface NodeInfo {
   id: string;
   addrs: string[];
+  listen_addrs: string[];
+  reachability: string;
 }
 
 export interface PeerEntry {
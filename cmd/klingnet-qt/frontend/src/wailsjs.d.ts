@@ -33,7 +33,7 @@ declare module '*/wailsjs/go/main/WalletService' {
   export function GetUTXOs(address: string): Promise<UTXOInfo[]>;
   export function SendTransaction(req: SendRequest): Promise<SendResult>;
   export function SendManyTransaction(req: SendManyRequest): Promise<SendManyResult>;
-  export function EstimateFee(): Promise<string>;
+  export function EstimateFee(targetBlocks: number): Promise<string>;
   export function StakeTransaction(req: StakeRequest): Promise<StakeResult>;
   export function UnstakeTransaction(req: UnstakeRequest): Promise<UnstakeResult>;
   export function MintToken(req: MintTokenRequest): Promise<MintTokenResult>;
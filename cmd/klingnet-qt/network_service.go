@@ -11,8 +11,10 @@ type NetworkService struct {
 
 // NodeInfo describes the local node.
 type NodeInfo struct {
-	ID    string   `json:"id"`
-	Addrs []string `json:"addrs"`
+	ID           string   `json:"id"`
+	Addrs        []string `json:"addrs"`
+	ListenAddrs  []string `json:"listen_addrs"`
+	Reachability string   `json:"reachability"` // public, private or unknown
 }
 
 // PeerEntry describes a connected peer.
@@ -44,7 +46,12 @@ func (n *NetworkService) GetNodeInfo() (*NodeInfo, error) {
 	if err := n.app.rpcClient().Call("net_getNodeInfo", nil, &result); err != nil {
 		return nil, err
 	}
-	return &NodeInfo{ID: result.ID, Addrs: result.Addrs}, nil
+	return &NodeInfo{
+		ID:           result.ID,
+		Addrs:        result.Addrs,
+		ListenAddrs:  result.ListenAddrs,
+		Reachability: result.Reachability,
+	}, nil
 }
 
 // GetPeers returns connected peer information.
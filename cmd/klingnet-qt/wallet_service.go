@@ -46,6 +46,8 @@ type SendRequest struct {
 	Password   string `json:"password"`
 	ToAddress  string `json:"to_address"`
 	Amount     string `json:"amount"`
+	// TargetBlocks is the confirmation target for the fee (0 = node default).
+	TargetBlocks int `json:"target_blocks,omitempty"`
 }
 
 // SendResult is returned after a transaction is submitted.
@@ -73,6 +75,8 @@ type SendManyRequest struct {
 	WalletName string              `json:"wallet_name"`
 	Password   string              `json:"password"`
 	Recipients []SendManyRecipient `json:"recipients"`
+	// TargetBlocks is the confirmation target for the fee (0 = node default).
+	TargetBlocks int `json:"target_blocks,omitempty"`
 }
 
 // SendManyResult is returned after a sendmany transaction is submitted.
@@ -336,10 +340,11 @@ func (w *WalletService) SendTransaction(req SendRequest) (*SendResult, error) {
 
 	var result rpc.WalletSendResult
 	if err := w.app.rpcClient().Call("wallet_send", rpc.WalletSendParam{
-		Name:     req.WalletName,
-		Password: req.Password,
-		To:       req.ToAddress,
-		Amount:   amount,
+		Name:         req.WalletName,
+		Password:     req.Password,
+		To:           req.ToAddress,
+		Amount:       amount,
+		TargetBlocks: req.TargetBlocks,
 	}, &result); err != nil {
 		return nil, err
 	}
@@ -378,22 +383,24 @@ func (w *WalletService) SendManyTransaction(req SendManyRequest) (*SendManyResul
 
 	var result rpc.WalletSendManyResult
 	if err := w.app.rpcClient().Call("wallet_sendMany", rpc.WalletSendManyParam{
-		Name:       req.WalletName,
-		Password:   req.Password,
-		Recipients: recipients,
+		Name:         req.WalletName,
+		Password:     req.Password,
+		Recipients:   recipients,
+		TargetBlocks: req.TargetBlocks,
 	}, &result); err != nil {
 		return nil, err
 	}
 	return &SendManyResult{TxHash: result.TxHash}, nil
 }
 
-// EstimateFee returns the estimated fee for a typical 1-in/2-out transaction.
-func (w *WalletService) EstimateFee() (string, error) {
-	var info rpc.MempoolInfoResult
-	if err := w.app.rpcClient().Call("mempool_getInfo", nil, &info); err != nil {
+// EstimateFee returns the estimated fee for a typical 1-in/2-out transaction
+// to confirm within targetBlocks (0 = node default).
+func (w *WalletService) EstimateFee(targetBlocks int) (string, error) {
+	var est rpc.FeeEstimateResult
+	if err := w.app.rpcClient().Call("fee_estimate", rpc.FeeEstimateParam{TargetBlocks: targetBlocks}, &est); err != nil {
 		return "", err
 	}
-	rate := info.MinFeeRate
+	rate := est.FeeRate
 	if rate == 0 {
 		rate = 1 // Fallback: 1 base unit per byte.
 	}
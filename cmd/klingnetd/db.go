@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/Klingon-tech/klingnet-chain/config"
+	"github.com/Klingon-tech/klingnet-chain/internal/chain"
+	"github.com/Klingon-tech/klingnet-chain/internal/storage"
+	"github.com/Klingon-tech/klingnet-chain/internal/subchain"
+)
+
+// runDB handles the "klingnetd db <command>" maintenance subcommands.
+// They operate on the database directly and must not run while a node
+// is using it.
+func runDB(args []string) int {
+	if len(args) == 0 {
+		dbUsage()
+		return 1
+	}
+	switch args[0] {
+	case "migrate":
+		return dbMigrate(args[1:])
+	case "verify":
+		return dbVerify(args[1:])
+	case "help", "--help", "-h":
+		dbUsage()
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown db command %q\n", args[0])
+		dbUsage()
+		return 1
+	}
+}
+
+func dbUsage() {
+	fmt.Print(`Usage:
+  klingnetd db migrate --from <engine> --to <engine> [--datadir DIR] [--network NET]
+  klingnetd db verify [--engine <engine>] [--repair] [--json] [--datadir DIR] [--network NET]
+
+Commands:
+  migrate   Copy every key (root chain and sub-chain namespaces) from one
+            storage engine to another and verify key counts.
+  verify    Replay the root chain and every sub-chain from genesis and check
+            the height and tx indexes, undo records, UTXO set and its
+            address/stake indexes, and the sub-chain namespaces against the
+            registry. --repair fixes what can be rebuilt from block data.
+            Exits 2 if inconsistencies remain.
+
+Engines: badger, pebble, bolt (verify defaults to storage.engine from klingnet.conf)
+
+After a successful migration set "storage.engine = <to>" in klingnet.conf.
+The source database is left untouched and can be removed once the node
+runs on the new engine.
+`)
+}
+
+// dbConfig resolves the data directory layout for db subcommands.
+func dbConfig(dataDir, network string) *config.Config {
+	net := config.Mainnet
+	if network == string(config.Testnet) {
+		net = config.Testnet
+	}
+	cfg := config.Default(net)
+	if dataDir != "" {
+		cfg.DataDir = dataDir
+	}
+	return cfg
+}
+
+func dbMigrate(args []string) int {
+	fs := flag.NewFlagSet("db migrate", flag.ContinueOnError)
+	from := fs.String("from", "", "Source storage engine")
+	to := fs.String("to", "", "Destination storage engine")
+	dataDir := fs.String("datadir", "", "Data directory path")
+	network := fs.String("network", "mainnet", "Network type (mainnet or testnet)")
+	testnet := fs.Bool("testnet", false, "Use testnet (shorthand for --network=testnet)")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if *testnet {
+		*network = string(config.Testnet)
+	}
+
+	src, err := storage.ParseEngine(*from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --from: %v\n", err)
+		return 1
+	}
+	dst, err := storage.ParseEngine(*to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --to: %v\n", err)
+		return 1
+	}
+	if src == dst {
+		fmt.Fprintf(os.Stderr, "Error: --from and --to must differ\n")
+		return 1
+	}
+
+	cfg := dbConfig(*dataDir, *network)
+	srcDir := cfg.DatabaseDirFor(string(src))
+	dstDir := cfg.DatabaseDirFor(string(dst))
+
+	srcDB, err := storage.Open(src, srcDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: open source: %v\n", err)
+		return 1
+	}
+	defer srcDB.Close()
+
+	dstDB, err := storage.Open(dst, dstDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: open destination: %v\n", err)
+		return 1
+	}
+	defer dstDB.Close()
+
+	fmt.Printf("Migrating %s (%s) -> %s (%s)\n", src, srcDir, dst, dstDir)
+	counts, err := storage.Copy(dstDB, srcDB)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: migrate: %v\n", err)
+		return 1
+	}
+
+	namespaces := make([]string, 0, len(counts))
+	for ns := range counts {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	for _, ns := range namespaces {
+		name := ns
+		if name == "" {
+			name = "(root chain)"
+		}
+		fmt.Printf("  %-68s %d keys\n", name, counts[ns])
+	}
+	fmt.Printf("Copied and verified %d keys.\n", counts.Total())
+	fmt.Printf("Set \"storage.engine = %s\" in %s to use the new database.\n", dst, cfg.ConfigFile())
+	return 0
+}
+
+// dbChainReport is the verify result for one chain.
+type dbChainReport struct {
+	Chain string `json:"chain"` // "root" or the sub-chain ID
+	*chain.VerifyReport
+}
+
+// dbVerifyReport is the machine-readable output of "db verify --json".
+type dbVerifyReport struct {
+	Engine         string              `json:"engine"`
+	Path           string              `json:"path"`
+	Repair         bool                `json:"repair"`
+	Chains         []dbChainReport     `json:"chains"`
+	SubChainIssues []chain.VerifyIssue `json:"subchain_issues"`
+	Unrepaired     int                 `json:"unrepaired"`
+}
+
+func dbVerify(args []string) int {
+	fs := flag.NewFlagSet("db verify", flag.ContinueOnError)
+	engineName := fs.String("engine", "", "Storage engine (default: storage.engine from klingnet.conf)")
+	repair := fs.Bool("repair", false, "Repair inconsistencies that can be rebuilt from block data")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	dataDir := fs.String("datadir", "", "Data directory path")
+	network := fs.String("network", "mainnet", "Network type (mainnet or testnet)")
+	testnet := fs.Bool("testnet", false, "Use testnet (shorthand for --network=testnet)")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if *testnet {
+		*network = string(config.Testnet)
+	}
+
+	cfg := dbConfig(*dataDir, *network)
+	if *engineName == "" {
+		values, err := config.LoadFile(cfg.ConfigFile())
+		if err == nil {
+			err = config.ApplyFileConfig(cfg, values)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: read %s: %v\n", cfg.ConfigFile(), err)
+			return 1
+		}
+		*engineName = cfg.Storage.Engine
+	}
+	engine, err := storage.ParseEngine(*engineName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --engine: %v\n", err)
+		return 1
+	}
+
+	path := cfg.DatabaseDirFor(string(engine))
+	db, err := storage.Open(engine, path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: open database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	report := dbVerifyReport{Engine: string(engine), Path: path, Repair: *repair}
+
+	// The root chain goes first so that a repaired UTXO set is what the
+	// sub-chain registrations are checked against.
+	root, err := chain.Verify(db, *repair)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: verify root chain: %v\n", err)
+		return 1
+	}
+	report.Chains = append(report.Chains, dbChainReport{Chain: "root", VerifyReport: root})
+
+	ids, issues, err := subchain.VerifyPrefixes(db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: verify sub-chain namespaces: %v\n", err)
+		return 1
+	}
+	report.SubChainIssues = issues
+	for _, id := range ids {
+		r, err := chain.Verify(subchain.NamespaceDB(db, id), *repair)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: verify sub-chain %s: %v\n", id, err)
+			return 1
+		}
+		report.Chains = append(report.Chains, dbChainReport{Chain: hex.EncodeToString(id[:]), VerifyReport: r})
+	}
+
+	for _, c := range report.Chains {
+		report.Unrepaired += c.Unrepaired()
+	}
+	report.Unrepaired += len(report.SubChainIssues)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: encode report: %v\n", err)
+			return 1
+		}
+	} else {
+		printVerifyReport(&report)
+	}
+	if report.Unrepaired > 0 {
+		return 2
+	}
+	return 0
+}
+
+func printVerifyReport(r *dbVerifyReport) {
+	fmt.Printf("Verifying %s (%s)\n", r.Engine, r.Path)
+	for _, c := range r.Chains {
+		fmt.Printf("  %-68s height %d, %d txs, %d utxos, %d issues\n",
+			c.Chain, c.TipHeight, c.Transactions, c.UTXOs, len(c.Issues))
+		for _, is := range c.Issues {
+			printVerifyIssue(is)
+		}
+	}
+	if len(r.SubChainIssues) > 0 {
+		fmt.Printf("  %-68s %d issues\n", "(sub-chain namespaces)", len(r.SubChainIssues))
+		for _, is := range r.SubChainIssues {
+			printVerifyIssue(is)
+		}
+	}
+	switch {
+	case r.Unrepaired == 0:
+		fmt.Println("Database is consistent.")
+	case r.Repair:
+		fmt.Printf("%d issues could not be repaired.\n", r.Unrepaired)
+	default:
+		fmt.Printf("%d issues found. Run with --repair to fix those that can be rebuilt from block data.\n", r.Unrepaired)
+	}
+}
+
+func printVerifyIssue(is chain.VerifyIssue) {
+	status := ""
+	if is.Repaired {
+		status = " [repaired]"
+	}
+	fmt.Printf("    %-22s %s%s\n", is.Kind, is.Detail, status)
+}
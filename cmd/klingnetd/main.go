@@ -3,6 +3,8 @@
 // Usage:
 //
 //	klingnetd [--mine --validator-key=...] Run node
+//	klingnetd db migrate --from --to       Convert the database engine
+//	klingnetd db verify [--repair]         Check (and repair) database integrity
 //	klingnetd --help                       Show help
 package main
 
@@ -17,6 +19,10 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "db" {
+		os.Exit(runDB(os.Args[2:]))
+	}
+
 	cfg, _, err := config.Load()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
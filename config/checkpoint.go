@@ -0,0 +1,129 @@
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// Checkpoint pins the hash of the active chain's block at a height. Nodes
+// reject any chain that disagrees with a checkpoint. Checkpoints are local
+// policy rather than protocol rules, so they are not part of the genesis
+// hash and can be extended per node.
+type Checkpoint struct {
+	Height uint64 `json:"height"`
+	Hash   string `json:"hash"`
+}
+
+// AssumeValidNone disables assume-valid in sync.assumevalid.
+const AssumeValidNone = "none"
+
+// mainnetCheckpoints are the hard-coded mainnet checkpoints, extended at
+// release time with recent blocks of the canonical chain.
+var mainnetCheckpoints = []Checkpoint{}
+
+// testnetCheckpoints are the hard-coded testnet checkpoints.
+var testnetCheckpoints = []Checkpoint{}
+
+// ParseCheckpoint parses a "height:hash" checkpoint.
+func ParseCheckpoint(s string) (Checkpoint, error) {
+	heightStr, hashStr, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return Checkpoint{}, fmt.Errorf("checkpoint %q: want height:hash", s)
+	}
+	height, err := strconv.ParseUint(heightStr, 10, 64)
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("checkpoint %q: invalid height", s)
+	}
+	cp := Checkpoint{Height: height, Hash: strings.ToLower(hashStr)}
+	if err := cp.Validate(); err != nil {
+		return Checkpoint{}, err
+	}
+	return cp, nil
+}
+
+// Validate checks that the checkpoint hash is a 32-byte hex string.
+func (c Checkpoint) Validate() error {
+	b, err := hex.DecodeString(c.Hash)
+	if err != nil || len(b) != types.HashSize {
+		return fmt.Errorf("checkpoint at height %d: hash must be 32-byte hex", c.Height)
+	}
+	return nil
+}
+
+// BlockHash returns the checkpoint hash. The checkpoint must be valid.
+func (c Checkpoint) BlockHash() types.Hash {
+	var h types.Hash
+	b, _ := hex.DecodeString(c.Hash)
+	copy(h[:], b)
+	return h
+}
+
+// String formats the checkpoint as "height:hash".
+func (c Checkpoint) String() string {
+	return fmt.Sprintf("%d:%s", c.Height, c.Hash)
+}
+
+// ResolveCheckpoints merges the genesis checkpoints with the node's
+// sync.checkpoints and resolves sync.assumevalid. The result is sorted by
+// height. An empty assumevalid selects the highest checkpoint, "none"
+// disables it, and an explicit "height:hash" is also enforced as a
+// checkpoint. Returns nil for assumeValid when it is disabled or there are
+// no checkpoints.
+func ResolveCheckpoints(gen *Genesis, sync SyncConfig) (cps []Checkpoint, assumeValid *Checkpoint, err error) {
+	byHeight := make(map[uint64]string)
+	add := func(cp Checkpoint) error {
+		if err := cp.Validate(); err != nil {
+			return err
+		}
+		hash := strings.ToLower(cp.Hash)
+		if prev, ok := byHeight[cp.Height]; ok && prev != hash {
+			return fmt.Errorf("conflicting checkpoints at height %d: %s and %s", cp.Height, prev, hash)
+		}
+		byHeight[cp.Height] = hash
+		return nil
+	}
+
+	for _, cp := range gen.Checkpoints {
+		if err := add(cp); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, s := range sync.Checkpoints {
+		cp, err := ParseCheckpoint(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := add(cp); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	switch sync.AssumeValid {
+	case "", AssumeValidNone:
+	default:
+		cp, err := ParseCheckpoint(sync.AssumeValid)
+		if err != nil {
+			return nil, nil, fmt.Errorf("sync.assumevalid: %w", err)
+		}
+		if err := add(cp); err != nil {
+			return nil, nil, fmt.Errorf("sync.assumevalid: %w", err)
+		}
+		assumeValid = &cp
+	}
+
+	for h, hash := range byHeight {
+		cps = append(cps, Checkpoint{Height: h, Hash: hash})
+	}
+	sort.Slice(cps, func(i, j int) bool { return cps[i].Height < cps[j].Height })
+
+	if sync.AssumeValid == "" && len(cps) > 0 {
+		last := cps[len(cps)-1]
+		assumeValid = &last
+	}
+	return cps, assumeValid, nil
+}
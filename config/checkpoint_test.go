@@ -0,0 +1,78 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+var (
+	testCPHashA = strings.Repeat("aa", 32)
+	testCPHashB = strings.Repeat("bb", 32)
+)
+
+func TestParseCheckpoint(t *testing.T) {
+	cp, err := ParseCheckpoint("100:" + strings.ToUpper(testCPHashA))
+	if err != nil {
+		t.Fatalf("ParseCheckpoint: %v", err)
+	}
+	if cp.Height != 100 || cp.Hash != testCPHashA {
+		t.Errorf("got %s, want 100:%s", cp, testCPHashA)
+	}
+
+	for _, s := range []string{"", "100", "x:" + testCPHashA, "100:abcd", "100:zz"} {
+		if _, err := ParseCheckpoint(s); err == nil {
+			t.Errorf("ParseCheckpoint(%q): expected error", s)
+		}
+	}
+}
+
+func TestResolveCheckpoints(t *testing.T) {
+	gen := &Genesis{Checkpoints: []Checkpoint{{Height: 200, Hash: testCPHashB}}}
+
+	cps, av, err := ResolveCheckpoints(gen, SyncConfig{Checkpoints: []string{"100:" + testCPHashA}})
+	if err != nil {
+		t.Fatalf("ResolveCheckpoints: %v", err)
+	}
+	if len(cps) != 2 || cps[0].Height != 100 || cps[1].Height != 200 {
+		t.Fatalf("checkpoints = %v, want heights 100, 200", cps)
+	}
+	if av == nil || av.Height != 200 {
+		t.Errorf("assumevalid = %v, want highest checkpoint", av)
+	}
+
+	_, av, err = ResolveCheckpoints(gen, SyncConfig{AssumeValid: AssumeValidNone})
+	if err != nil || av != nil {
+		t.Errorf("assumevalid none: got %v, %v", av, err)
+	}
+
+	cps, av, err = ResolveCheckpoints(gen, SyncConfig{AssumeValid: "300:" + testCPHashA})
+	if err != nil {
+		t.Fatalf("explicit assumevalid: %v", err)
+	}
+	if av == nil || av.Height != 300 || len(cps) != 2 || cps[1].Height != 300 {
+		t.Errorf("explicit assumevalid: got %v, checkpoints %v", av, cps)
+	}
+
+	if _, _, err := ResolveCheckpoints(gen, SyncConfig{Checkpoints: []string{"200:" + testCPHashA}}); err == nil {
+		t.Error("expected error for conflicting checkpoint")
+	}
+}
+
+func TestGenesis_HashIgnoresCheckpoints(t *testing.T) {
+	gen := TestnetGenesis()
+	want, err := gen.Hash()
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	gen.Checkpoints = append(gen.Checkpoints, Checkpoint{Height: 1, Hash: testCPHashA})
+	got, err := gen.Hash()
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if got != want {
+		t.Error("checkpoints must not change the genesis hash")
+	}
+	if err := gen.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
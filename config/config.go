@@ -30,6 +30,9 @@ type Config struct {
 	Network NetworkType `conf:"network"`
 	DataDir string      `conf:"datadir"`
 
+	// Storage backend
+	Storage StorageConfig
+
 	// P2P networking
 	P2P P2PConfig
 
@@ -42,6 +45,15 @@ type Config struct {
 	// Mining/Validation (operational, not consensus rules)
 	Mining MiningConfig
 
+	// Block sync (checkpoints, assume-valid)
+	Sync SyncConfig
+
+	// Mempool persistence
+	Mempool MempoolConfig
+
+	// Mempool acceptance policy (per-node standardness rules)
+	Policy PolicyConfig
+
 	// Sub-chain sync (operational — which sub-chains to run locally)
 	SubChainSync SubChainSyncConfig
 
@@ -59,6 +71,30 @@ type Config struct {
 // Each miner is CPU-intensive, so unlimited mining would be catastrophic.
 const MaxSubChainMiners = 8
 
+// SyncConfig holds root chain sync settings (per-node, not consensus).
+type SyncConfig struct {
+	Checkpoints []string `conf:"sync.checkpoints"` // Extra "height:hash" checkpoints
+	AssumeValid string   `conf:"sync.assumevalid"` // "height:hash", "none", or "" for the highest checkpoint
+}
+
+// MempoolConfig holds transaction pool settings (per-node, not consensus).
+type MempoolConfig struct {
+	Persist bool `conf:"mempool.persist"` // Save the pool on shutdown and reload it at startup
+	MaxSize int  `conf:"mempool.maxsize"` // Budget in MB of transaction signing bytes (0 = unlimited)
+	Expiry  int  `conf:"mempool.expiry"`  // Hours a transaction may stay unconfirmed (0 = forever)
+}
+
+// PolicyConfig holds the node's mempool acceptance rules (per-node, not
+// consensus). Transactions breaking them are valid in blocks but not
+// accepted or relayed by this node.
+type PolicyConfig struct {
+	Dust            uint64   `conf:"policy.dust"`            // Min value of a native P2PKH/P2SH output in base units (0 = none)
+	MaxScriptData   int      `conf:"policy.maxscriptdata"`   // Max script data bytes per output (0 = consensus limit)
+	MaxTokenOutputs int      `conf:"policy.maxtokenoutputs"` // Max token-carrying outputs per tx (0 = unlimited)
+	ScriptTypes     []string `conf:"policy.scripttypes"`     // Allowed output script types (empty = all)
+	AddressRate     int      `conf:"policy.addressrate"`     // Max txs per spending address per minute (0 = unlimited)
+}
+
 // SubChainSyncMode controls which sub-chains a node syncs.
 type SubChainSyncMode string
 
@@ -74,6 +110,18 @@ type SubChainSyncConfig struct {
 	ChainIDs []string         `conf:"subchain.chain_ids"` // Hex chain IDs (when mode=list)
 }
 
+// Storage engine names accepted by storage.engine.
+const (
+	StorageBadger = "badger"
+	StoragePebble = "pebble"
+	StorageBolt   = "bolt"
+)
+
+// StorageConfig holds database backend settings.
+type StorageConfig struct {
+	Engine string `conf:"storage.engine"` // badger (default), pebble, or bolt
+}
+
 // P2PConfig holds peer-to-peer network settings.
 type P2PConfig struct {
 	Enabled    bool     `conf:"p2p.enabled"`
@@ -84,6 +132,41 @@ type P2PConfig struct {
 	NoDiscover bool     `conf:"p2p.nodiscover"`
 	DHTServer  bool     `conf:"p2p.dhtserver"` // Run DHT in server mode (for seeds/validators)
 	ClearBans  bool     // Clear all peer bans on startup (not persisted in config file).
+
+	// Multiaddr listening and advertising. ListenAddrs replaces listen/port
+	// when set, e.g. /ip6/::/tcp/30303 or /ip4/0.0.0.0/udp/30303/quic-v1.
+	ListenAddrs   []string `conf:"p2p.listenaddrs"` // Multiaddrs to listen on
+	AnnounceAddrs []string `conf:"p2p.announce"`    // Multiaddrs advertised instead of the listen addresses
+	ExternalAddrs []string `conf:"p2p.external"`    // Multiaddrs advertised in addition to the listen addresses
+
+	// NAT traversal.
+	NATPortMap   bool `conf:"p2p.nat"`          // Map the P2P port with UPnP/NAT-PMP
+	AutoNAT      bool `conf:"p2p.autonat"`      // Detect reachability and answer peers' AutoNAT probes
+	Relay        bool `conf:"p2p.relay"`        // Reserve circuit relay slots on seeds while unreachable
+	RelayService bool `conf:"p2p.relayservice"` // Act as a circuit relay for other peers (seed nodes)
+	HolePunch    bool `conf:"p2p.holepunch"`    // Upgrade relayed connections with DCUtR hole punching
+
+	// Sentry topology. A validator lists its sentries as private peers and
+	// talks to nobody else; sentries list the validator as a persistent and
+	// hidden peer.
+	PrivatePeers    []string `conf:"p2p.privatepeers"`    // Multiaddrs with /p2p/ IDs; connect only to these
+	PersistentPeers []string `conf:"p2p.persistentpeers"` // Multiaddrs with /p2p/ IDs; always reconnected, exempt from maxpeers
+	HiddenPeers     []string `conf:"p2p.hiddenpeers"`     // Peer IDs never advertised to other peers
+
+	// Privacy of locally submitted transactions.
+	Dandelion      bool `conf:"p2p.dandelion"`      // Relay own txs through a stem peer before announcing
+	BroadcastDelay int  `conf:"p2p.broadcastdelay"` // Max random delay in seconds before announcing own txs (0 = none)
+
+	// Upload caps on serving blocks to syncing peers.
+	UploadRate     int `conf:"p2p.uploadrate"`     // KiB/s across all peers (0 = unlimited)
+	PeerUploadRate int `conf:"p2p.peeruploadrate"` // KiB/s to a single peer (0 = unlimited)
+
+	// Per-peer limits on relayed transactions.
+	TxRelayRate  int `conf:"p2p.txrelayrate"`  // Transactions per second (0 = default)
+	TxRelayBurst int `conf:"p2p.txrelayburst"` // Transactions accepted in a burst (0 = default)
+
+	// Serve headers and proofs to light clients.
+	LightServer bool `conf:"p2p.lightserver"`
 }
 
 // RPCConfig holds RPC server settings.
@@ -97,8 +180,9 @@ type RPCConfig struct {
 
 // WalletConfig holds wallet settings.
 type WalletConfig struct {
-	Enabled  bool   `conf:"wallet.enabled"`
-	FilePath string `conf:"wallet.file"`
+	Enabled     bool   `conf:"wallet.enabled"`
+	FilePath    string `conf:"wallet.file"`
+	Rebroadcast int    `conf:"wallet.rebroadcast"` // Minutes between rebroadcasts of unconfirmed wallet txs (0 = never)
 }
 
 // MiningConfig holds block production settings.
@@ -150,6 +234,24 @@ func (c *Config) ChainDataDir() string {
 	return filepath.Join(c.DataDir, string(c.Network))
 }
 
+// DatabaseDir returns the database directory for the configured engine.
+func (c *Config) DatabaseDir() string {
+	return c.DatabaseDirFor(c.Storage.Engine)
+}
+
+// DatabaseDirFor returns the database directory for the given engine.
+// Badger lives directly in the chain data directory (the historical
+// layout); other engines get their own subdirectory so several can
+// coexist during a migration.
+func (c *Config) DatabaseDirFor(engine string) string {
+	switch engine {
+	case StorageBadger, "":
+		return c.ChainDataDir()
+	default:
+		return filepath.Join(c.ChainDataDir(), "db-"+engine)
+	}
+}
+
 // BlocksDir returns the blocks storage directory.
 func (c *Config) BlocksDir() string {
 	return filepath.Join(c.ChainDataDir(), "blocks")
@@ -170,6 +272,16 @@ func (c *Config) KeystoreDir() string {
 	return filepath.Join(c.ChainDataDir(), "keystore")
 }
 
+// MempoolFile returns the path of the saved mempool.
+func (c *Config) MempoolFile() string {
+	return filepath.Join(c.ChainDataDir(), "mempool.json")
+}
+
+// FeeEstimatesFile returns the path of the saved fee estimator statistics.
+func (c *Config) FeeEstimatesFile() string {
+	return filepath.Join(c.ChainDataDir(), "fee_estimates.json")
+}
+
 // LogsDir returns the logs directory.
 func (c *Config) LogsDir() string {
 	return filepath.Join(c.DataDir, "logs")
@@ -5,11 +5,18 @@ func DefaultMainnet() *Config {
 	return &Config{
 		Network: Mainnet,
 		DataDir: DefaultDataDir(),
+		Storage: StorageConfig{
+			Engine: StorageBadger,
+		},
 		P2P: P2PConfig{
 			Enabled:    true,
 			ListenAddr: "0.0.0.0",
 			Port:       30303,
 			MaxPeers:   50,
+			NATPortMap: true,
+			AutoNAT:    true,
+			Relay:      true,
+			HolePunch:  true,
 			// Bootnodes are seed nodes that help new peers join the network.
 			// Format: multiaddr strings, e.g.:
 			//   "/ip4/203.0.113.1/tcp/30303/p2p/12D3KooW..."
@@ -26,12 +33,18 @@ func DefaultMainnet() *Config {
 			AllowedIPs: []string{"127.0.0.1"},
 		},
 		Wallet: WalletConfig{
-			Enabled: false,
+			Enabled:     false,
+			Rebroadcast: 30,
 		},
 		Mining: MiningConfig{
 			Enabled: false,
 			Threads: 1,
 		},
+		Mempool: MempoolConfig{
+			Persist: true,
+			MaxSize: 300,
+			Expiry:  336,
+		},
 		SubChainSync: SubChainSyncConfig{
 			Mode: SubChainSyncNone,
 		},
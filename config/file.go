@@ -76,6 +76,10 @@ func setConfigValue(cfg *Config, key, value string) error {
 	case "datadir":
 		cfg.DataDir = value
 
+	// Storage
+	case "storage.engine":
+		cfg.Storage.Engine = strings.ToLower(value)
+
 	// P2P
 	case "p2p.enabled", "p2p":
 		cfg.P2P.Enabled = parseBool(value)
@@ -87,6 +91,28 @@ func setConfigValue(cfg *Config, key, value string) error {
 			return err
 		}
 		cfg.P2P.Port = port
+	case "p2p.listenaddrs":
+		cfg.P2P.ListenAddrs = parseStringList(value)
+	case "p2p.announce":
+		cfg.P2P.AnnounceAddrs = parseStringList(value)
+	case "p2p.external":
+		cfg.P2P.ExternalAddrs = parseStringList(value)
+	case "p2p.nat":
+		cfg.P2P.NATPortMap = parseBool(value)
+	case "p2p.autonat":
+		cfg.P2P.AutoNAT = parseBool(value)
+	case "p2p.relay":
+		cfg.P2P.Relay = parseBool(value)
+	case "p2p.relayservice":
+		cfg.P2P.RelayService = parseBool(value)
+	case "p2p.holepunch":
+		cfg.P2P.HolePunch = parseBool(value)
+	case "p2p.privatepeers", "p2p.private_peers":
+		cfg.P2P.PrivatePeers = parseStringList(value)
+	case "p2p.persistentpeers", "p2p.persistent_peers":
+		cfg.P2P.PersistentPeers = parseStringList(value)
+	case "p2p.hiddenpeers", "p2p.hidden_peers":
+		cfg.P2P.HiddenPeers = parseStringList(value)
 	case "p2p.seeds":
 		cfg.P2P.Seeds = parseStringList(value)
 	case "p2p.maxpeers":
@@ -99,6 +125,40 @@ func setConfigValue(cfg *Config, key, value string) error {
 		cfg.P2P.NoDiscover = parseBool(value)
 	case "p2p.dhtserver":
 		cfg.P2P.DHTServer = parseBool(value)
+	case "p2p.dandelion":
+		cfg.P2P.Dandelion = parseBool(value)
+	case "p2p.lightserver":
+		cfg.P2P.LightServer = parseBool(value)
+	case "p2p.broadcastdelay":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		cfg.P2P.BroadcastDelay = n
+	case "p2p.uploadrate", "p2p.upload_rate":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		cfg.P2P.UploadRate = n
+	case "p2p.peeruploadrate", "p2p.peer_upload_rate":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		cfg.P2P.PeerUploadRate = n
+	case "p2p.txrelayrate", "p2p.tx_relay_rate":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		cfg.P2P.TxRelayRate = n
+	case "p2p.txrelayburst", "p2p.tx_relay_burst":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		cfg.P2P.TxRelayBurst = n
 
 	// RPC
 	case "rpc.enabled", "rpc":
@@ -121,6 +181,12 @@ func setConfigValue(cfg *Config, key, value string) error {
 		cfg.Wallet.Enabled = parseBool(value)
 	case "wallet.file":
 		cfg.Wallet.FilePath = value
+	case "wallet.rebroadcast":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		cfg.Wallet.Rebroadcast = n
 
 	// Mining (operational, not consensus rules)
 	case "mining.enabled", "mine":
@@ -136,6 +202,56 @@ func setConfigValue(cfg *Config, key, value string) error {
 		}
 		cfg.Mining.Threads = n
 
+	// Sync
+	case "sync.checkpoints":
+		cfg.Sync.Checkpoints = parseStringList(value)
+	case "sync.assumevalid":
+		cfg.Sync.AssumeValid = strings.ToLower(value)
+
+	// Mempool
+	case "mempool.persist":
+		cfg.Mempool.Persist = parseBool(value)
+	case "mempool.maxsize":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		cfg.Mempool.MaxSize = n
+	case "mempool.expiry":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		cfg.Mempool.Expiry = n
+
+	// Mempool policy
+	case "policy.dust":
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		cfg.Policy.Dust = n
+	case "policy.maxscriptdata":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		cfg.Policy.MaxScriptData = n
+	case "policy.maxtokenoutputs":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		cfg.Policy.MaxTokenOutputs = n
+	case "policy.scripttypes":
+		cfg.Policy.ScriptTypes = parseStringList(value)
+	case "policy.addressrate":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		cfg.Policy.AddressRate = n
+
 	// Sub-chains (operational)
 	case "subchain.sync":
 		switch strings.ToLower(value) {
@@ -202,6 +318,15 @@ network = ` + string(network) + `
 # Data directory (default: ~/.klingnet)
 # datadir = ~/.klingnet
 
+# ============================================================================
+# Storage
+# ============================================================================
+
+# Database engine: badger (default), pebble, or bolt.
+# Switching engines requires migrating existing data first:
+#   klingnetd db migrate --from badger --to pebble
+# storage.engine = badger
+
 # ============================================================================
 # P2P Network
 # ============================================================================
@@ -211,6 +336,15 @@ p2p.listen = 0.0.0.0
 p2p.port = ` + defaultPort(network) + `
 p2p.maxpeers = 50
 
+# Listen on several multiaddrs instead of listen/port, e.g. TCP and QUIC
+# over IPv4 and IPv6 (comma-separated)
+# p2p.listenaddrs = /ip4/0.0.0.0/tcp/` + defaultPort(network) + `,/ip6/::/tcp/` + defaultPort(network) + `,/ip4/0.0.0.0/udp/` + defaultPort(network) + `/quic-v1
+
+# Addresses advertised to peers instead of the listen addresses (e.g. a
+# public IP behind NAT), or in addition to them (comma-separated multiaddrs)
+# p2p.announce =
+# p2p.external =
+
 # Seed nodes (comma-separated libp2p multiaddrs)
 p2p.seeds = /ip4/149.102.158.68/tcp/30303/p2p/12D3KooWSU83DfT1QXgji1XiC2d9RtkGFKt5zwG4sQQVN2R3nNGG
 
@@ -220,6 +354,44 @@ p2p.seeds = /ip4/149.102.158.68/tcp/30303/p2p/12D3KooWSU83DfT1QXgji1XiC2d9RtkGFK
 # Run DHT in server mode (for seed nodes/validators)
 # p2p.dhtserver = false
 
+# NAT traversal: map the port on the router (UPnP/NAT-PMP), detect
+# reachability (AutoNAT), reserve relay slots on seeds while unreachable
+# and upgrade relayed connections by hole punching (DCUtR)
+# p2p.nat = true
+# p2p.autonat = true
+# p2p.relay = true
+# p2p.holepunch = true
+
+# Relay connections for peers behind NAT (for seed nodes)
+# p2p.relayservice = false
+
+# Sentry topology. On a validator, list its sentry nodes here: it then
+# connects to nobody else (no seeds, DHT, mDNS or NAT traversal).
+# p2p.privatepeers = /ip4/10.0.0.2/tcp/30303/p2p/12D3KooW...
+# On a sentry, keep the validator connected and never advertise it.
+# p2p.persistentpeers = /ip4/10.0.0.1/tcp/30303/p2p/12D3KooW...
+# p2p.hiddenpeers = 12D3KooW...
+
+# Send own transactions to one random peer first (Dandelion stem) so they
+# are announced from elsewhere, not from this node
+# p2p.dandelion = false
+
+# Wait a random 0..N seconds before announcing own transactions
+# p2p.broadcastdelay = 0
+
+# Cap the upload speed, in KiB/s, when serving blocks to syncing peers,
+# in total and to each peer (0 = unlimited)
+# p2p.uploadrate = 0
+# p2p.peeruploadrate = 0
+
+# Transactions each peer may relay per second, and in a burst, before the
+# rest are dropped (0 = default: 20 per second, bursts of 200)
+# p2p.txrelayrate = 0
+# p2p.txrelayburst = 0
+
+# Serve headers, transaction proofs and stake changes to light clients
+# p2p.lightserver = false
+
 # ============================================================================
 # RPC Server
 # ============================================================================
@@ -238,6 +410,10 @@ rpc.allowed = 127.0.0.1
 wallet.enabled = false
 # wallet.file = wallet.dat
 
+# Minutes between rebroadcasts of wallet transactions that are still
+# unconfirmed (0 = never)
+# wallet.rebroadcast = 30
+
 # ============================================================================
 # Mining / Block Production
 # ============================================================================
@@ -254,6 +430,58 @@ mining.enabled = false
 # Mining threads (for PoW, if enabled on this chain)
 # mining.threads = 1
 
+# ============================================================================
+# Sync
+# ============================================================================
+
+# Extra checkpoints (comma-separated height:blockhash). Blocks that conflict
+# with a checkpoint, and forks below one, are rejected.
+# sync.checkpoints =
+
+# Skip transaction script/signature checks for this checkpoint and its
+# ancestors while syncing (the UTXO set is still built in full). Ancestry
+# is proven by a header chain fetched from peers; other blocks are fully
+# verified.
+# Default: the highest checkpoint. "none" verifies everything.
+# sync.assumevalid =
+
+# ============================================================================
+# Mempool
+# ============================================================================
+
+# Save pending transactions to mempool.json on shutdown (and periodically)
+# and re-validate them at startup
+# mempool.persist = true
+
+# Memory budget in MB of transaction data (0 = unlimited). Lowest fee-rate
+# transactions are evicted first.
+# mempool.maxsize = 300
+
+# Hours before an unconfirmed transaction is dropped (0 = never)
+# mempool.expiry = 336
+
+# ============================================================================
+# Mempool Policy
+# ============================================================================
+# Standardness rules for transactions this node accepts and relays. They do
+# not affect block validation.
+
+# Minimum value in base units of a native P2PKH/P2SH output (0 = none)
+# policy.dust = 0
+
+# Maximum script data bytes per output (0 = consensus limit)
+# policy.maxscriptdata = 0
+
+# Maximum token-carrying outputs per transaction (0 = unlimited)
+# policy.maxtokenoutputs = 0
+
+# Allowed output script types, comma-separated (empty = all):
+# p2pkh, p2sh, mint, burn, anchor, register, bridge, stake
+# policy.scripttypes =
+
+# Maximum transactions per spending address per minute (0 = unlimited)
+# policy.addressrate = 0
+
 # ============================================================================
 # Sub-Chains
 # ============================================================================
@@ -19,12 +19,30 @@ type Flags struct {
 	Config  string
 
 	// P2P
-	P2P        bool
-	P2PPort    int
-	Seeds      string
-	MaxPeers   int
-	NoDiscover bool
-	DHTServer  bool
+	P2P             bool
+	P2PPort         int
+	P2PListen       string
+	P2PAnnounce     string
+	P2PExternal     string
+	Seeds           string
+	MaxPeers        int
+	NoDiscover      bool
+	DHTServer       bool
+	NAT             bool
+	AutoNAT         bool
+	Relay           bool
+	RelayService    bool
+	HolePunch       bool
+	PrivatePeers    string
+	PersistentPeers string
+	HiddenPeers     string
+	Dandelion       bool
+	BroadcastDelay  int
+	UploadRate      int
+	PeerUploadRate  int
+	TxRelayRate     int
+	TxRelayBurst    int
+	LightServer     bool
 
 	// RPC
 	RPC        bool
@@ -34,8 +52,9 @@ type Flags struct {
 	RPCCORS    string
 
 	// Wallet
-	Wallet     bool
-	WalletFile string
+	Wallet            bool
+	WalletFile        string
+	WalletRebroadcast int
 
 	// Mining (operational only)
 	Mine         bool
@@ -43,6 +62,14 @@ type Flags struct {
 	ValidatorKey string
 	MineThreads  int
 
+	// Sync
+	AssumeValid string
+
+	// Mempool
+	MempoolPersist bool
+	MempoolMaxSize int
+	MempoolExpiry  int
+
 	// Sub-chain sync
 	SyncSubChains string
 	MineSubChains string
@@ -66,6 +93,24 @@ type Flags struct {
 	SetWallet     bool
 	SetMine       bool
 	SetLogJSON    bool
+
+	SetMempoolPersist bool
+	SetMempoolMaxSize bool
+	SetMempoolExpiry  bool
+
+	SetNAT               bool
+	SetAutoNAT           bool
+	SetRelay             bool
+	SetRelayService      bool
+	SetHolePunch         bool
+	SetDandelion         bool
+	SetBroadcastDelay    bool
+	SetUploadRate        bool
+	SetPeerUploadRate    bool
+	SetTxRelayRate       bool
+	SetTxRelayBurst      bool
+	SetLightServer       bool
+	SetWalletRebroadcast bool
 }
 
 // ParseFlags parses command-line flags.
@@ -89,10 +134,28 @@ func ParseFlags() *Flags {
 	// P2P
 	fs.BoolVar(&f.P2P, "p2p", true, "Enable P2P networking")
 	fs.IntVar(&f.P2PPort, "p2p-port", 0, "P2P listen port")
+	fs.StringVar(&f.P2PListen, "p2p-listen", "", "P2P listen multiaddrs (comma-separated, overrides --p2p-port)")
+	fs.StringVar(&f.P2PAnnounce, "p2p-announce", "", "Multiaddrs advertised instead of the listen addresses")
+	fs.StringVar(&f.P2PExternal, "p2p-external", "", "Multiaddrs advertised in addition to the listen addresses")
 	fs.StringVar(&f.Seeds, "seeds", "", "Seed nodes as comma-separated libp2p multiaddrs")
 	fs.IntVar(&f.MaxPeers, "maxpeers", 0, "Maximum number of peers")
 	fs.BoolVar(&f.NoDiscover, "nodiscover", false, "Disable peer discovery")
 	fs.BoolVar(&f.DHTServer, "dht-server", false, "Run DHT in server mode (for seeds/validators)")
+	fs.BoolVar(&f.NAT, "nat", true, "Map the P2P port on the router with UPnP/NAT-PMP")
+	fs.BoolVar(&f.AutoNAT, "autonat", true, "Detect reachability with AutoNAT")
+	fs.BoolVar(&f.Relay, "relay", true, "Reserve circuit relay slots on seeds while unreachable")
+	fs.BoolVar(&f.RelayService, "relay-service", false, "Act as a circuit relay for peers behind NAT")
+	fs.BoolVar(&f.HolePunch, "holepunch", true, "Upgrade relayed connections by hole punching (DCUtR)")
+	fs.StringVar(&f.PrivatePeers, "private-peers", "", "Connect only to these peers (sentries), as comma-separated multiaddrs")
+	fs.StringVar(&f.PersistentPeers, "persistent-peers", "", "Peers always reconnected and exempt from --maxpeers, as comma-separated multiaddrs")
+	fs.StringVar(&f.HiddenPeers, "hidden-peers", "", "Peer IDs never advertised to other peers (comma-separated)")
+	fs.BoolVar(&f.Dandelion, "dandelion", false, "Relay own transactions through a random stem peer before announcing")
+	fs.IntVar(&f.BroadcastDelay, "broadcast-delay", 0, "Max random delay in seconds before announcing own transactions")
+	fs.IntVar(&f.UploadRate, "upload-rate", 0, "Max KiB/s spent serving blocks to syncing peers (0 = unlimited)")
+	fs.IntVar(&f.PeerUploadRate, "peer-upload-rate", 0, "Max KiB/s spent serving blocks to one peer (0 = unlimited)")
+	fs.IntVar(&f.TxRelayRate, "tx-relay-rate", 0, "Transactions per second each peer may relay (0 = default 20)")
+	fs.IntVar(&f.TxRelayBurst, "tx-relay-burst", 0, "Transactions each peer may relay in a burst (0 = default 200)")
+	fs.BoolVar(&f.LightServer, "light-server", false, "Serve headers and proofs to light clients")
 
 	// RPC
 	fs.BoolVar(&f.RPC, "rpc", true, "Enable RPC server")
@@ -104,6 +167,7 @@ func ParseFlags() *Flags {
 	// Wallet
 	fs.BoolVar(&f.Wallet, "wallet", false, "Enable integrated wallet")
 	fs.StringVar(&f.WalletFile, "wallet-file", "", "Wallet file path")
+	fs.IntVar(&f.WalletRebroadcast, "wallet-rebroadcast", 0, "Minutes between rebroadcasts of unconfirmed wallet transactions (default: 30, 0 = never)")
 
 	// Mining (operational - consensus type is in genesis)
 	fs.BoolVar(&f.Mine, "mine", false, "Enable block production")
@@ -111,6 +175,14 @@ func ParseFlags() *Flags {
 	fs.StringVar(&f.ValidatorKey, "validator-key", "", "Path to validator private key (PoA)")
 	fs.IntVar(&f.MineThreads, "mine-threads", 0, "Number of PoW mining threads (default: 1)")
 
+	// Sync
+	fs.StringVar(&f.AssumeValid, "assumevalid", "", "Skip tx signature checks for this height:hash checkpoint and its ancestors (\"none\" to disable)")
+
+	// Mempool
+	fs.BoolVar(&f.MempoolPersist, "mempool-persist", true, "Save the mempool on shutdown and reload it at startup")
+	fs.IntVar(&f.MempoolMaxSize, "mempool-maxsize", 0, "Mempool budget in MB (default: 300, 0 = unlimited)")
+	fs.IntVar(&f.MempoolExpiry, "mempool-expiry", 0, "Hours before unconfirmed transactions are dropped (default: 336, 0 = never)")
+
 	// Sub-chain sync
 	fs.StringVar(&f.SyncSubChains, "sync-subchains", "", "Which sub-chains to sync: all, none (default), or comma-separated chain IDs")
 	fs.StringVar(&f.MineSubChains, "mine-subchains", "", "Comma-separated PoW sub-chain IDs to mine (max 8)")
@@ -147,6 +219,22 @@ func ParseFlags() *Flags {
 	f.SetWallet = isFlagSet(fs, "wallet")
 	f.SetMine = isFlagSet(fs, "mine")
 	f.SetLogJSON = isFlagSet(fs, "log-json")
+	f.SetMempoolPersist = isFlagSet(fs, "mempool-persist")
+	f.SetMempoolMaxSize = isFlagSet(fs, "mempool-maxsize")
+	f.SetMempoolExpiry = isFlagSet(fs, "mempool-expiry")
+	f.SetNAT = isFlagSet(fs, "nat")
+	f.SetAutoNAT = isFlagSet(fs, "autonat")
+	f.SetRelay = isFlagSet(fs, "relay")
+	f.SetRelayService = isFlagSet(fs, "relay-service")
+	f.SetHolePunch = isFlagSet(fs, "holepunch")
+	f.SetDandelion = isFlagSet(fs, "dandelion")
+	f.SetBroadcastDelay = isFlagSet(fs, "broadcast-delay")
+	f.SetUploadRate = isFlagSet(fs, "upload-rate")
+	f.SetPeerUploadRate = isFlagSet(fs, "peer-upload-rate")
+	f.SetTxRelayRate = isFlagSet(fs, "tx-relay-rate")
+	f.SetTxRelayBurst = isFlagSet(fs, "tx-relay-burst")
+	f.SetLightServer = isFlagSet(fs, "light-server")
+	f.SetWalletRebroadcast = isFlagSet(fs, "wallet-rebroadcast")
 
 	f.Args = fs.Args()
 
@@ -181,6 +269,15 @@ func ApplyFlags(cfg *Config, f *Flags) {
 	if f.P2PPort != 0 {
 		cfg.P2P.Port = f.P2PPort
 	}
+	if f.P2PListen != "" {
+		cfg.P2P.ListenAddrs = parseStringList(f.P2PListen)
+	}
+	if f.P2PAnnounce != "" {
+		cfg.P2P.AnnounceAddrs = parseStringList(f.P2PAnnounce)
+	}
+	if f.P2PExternal != "" {
+		cfg.P2P.ExternalAddrs = parseStringList(f.P2PExternal)
+	}
 	if f.Seeds != "" {
 		cfg.P2P.Seeds = parseStringList(f.Seeds)
 	}
@@ -193,6 +290,51 @@ func ApplyFlags(cfg *Config, f *Flags) {
 	if f.DHTServer {
 		cfg.P2P.DHTServer = true
 	}
+	if f.SetNAT {
+		cfg.P2P.NATPortMap = f.NAT
+	}
+	if f.SetAutoNAT {
+		cfg.P2P.AutoNAT = f.AutoNAT
+	}
+	if f.SetRelay {
+		cfg.P2P.Relay = f.Relay
+	}
+	if f.SetRelayService {
+		cfg.P2P.RelayService = f.RelayService
+	}
+	if f.SetHolePunch {
+		cfg.P2P.HolePunch = f.HolePunch
+	}
+	if f.PrivatePeers != "" {
+		cfg.P2P.PrivatePeers = parseStringList(f.PrivatePeers)
+	}
+	if f.PersistentPeers != "" {
+		cfg.P2P.PersistentPeers = parseStringList(f.PersistentPeers)
+	}
+	if f.HiddenPeers != "" {
+		cfg.P2P.HiddenPeers = parseStringList(f.HiddenPeers)
+	}
+	if f.SetDandelion {
+		cfg.P2P.Dandelion = f.Dandelion
+	}
+	if f.SetBroadcastDelay {
+		cfg.P2P.BroadcastDelay = f.BroadcastDelay
+	}
+	if f.SetUploadRate {
+		cfg.P2P.UploadRate = f.UploadRate
+	}
+	if f.SetPeerUploadRate {
+		cfg.P2P.PeerUploadRate = f.PeerUploadRate
+	}
+	if f.SetTxRelayRate {
+		cfg.P2P.TxRelayRate = f.TxRelayRate
+	}
+	if f.SetTxRelayBurst {
+		cfg.P2P.TxRelayBurst = f.TxRelayBurst
+	}
+	if f.SetLightServer {
+		cfg.P2P.LightServer = f.LightServer
+	}
 	if f.ClearBans {
 		cfg.P2P.ClearBans = true
 	}
@@ -224,6 +366,9 @@ func ApplyFlags(cfg *Config, f *Flags) {
 	if f.WalletFile != "" {
 		cfg.Wallet.FilePath = f.WalletFile
 	}
+	if f.SetWalletRebroadcast {
+		cfg.Wallet.Rebroadcast = f.WalletRebroadcast
+	}
 
 	// Mining
 	if f.SetMine {
@@ -239,6 +384,22 @@ func ApplyFlags(cfg *Config, f *Flags) {
 		cfg.Mining.Threads = f.MineThreads
 	}
 
+	// Sync
+	if f.AssumeValid != "" {
+		cfg.Sync.AssumeValid = strings.ToLower(f.AssumeValid)
+	}
+
+	// Mempool
+	if f.SetMempoolPersist {
+		cfg.Mempool.Persist = f.MempoolPersist
+	}
+	if f.SetMempoolMaxSize {
+		cfg.Mempool.MaxSize = f.MempoolMaxSize
+	}
+	if f.SetMempoolExpiry {
+		cfg.Mempool.Expiry = f.MempoolExpiry
+	}
+
 	// Sub-chain sync
 	if f.SyncSubChains != "" {
 		switch f.SyncSubChains {
@@ -287,6 +448,8 @@ func printUsage() {
 
 Usage:
   klingnetd [options]
+  klingnetd db migrate --from <engine> --to <engine>
+  klingnetd db verify [--repair] [--json]
   klingnetd --help
 
 Commands:
@@ -302,10 +465,45 @@ Core Options:
 P2P Options:
   --p2p           Enable P2P networking (default: true)
   --p2p-port      P2P listen port (mainnet: 30303, testnet: 30304)
+  --p2p-listen    P2P listen multiaddrs, comma-separated; TCP and QUIC
+                  (quic-v1) over IPv4 and IPv6 (overrides --p2p-port)
+  --p2p-announce  Multiaddrs advertised instead of the listen addresses
+  --p2p-external  Multiaddrs advertised in addition to the listen addresses
   --seeds         Seed nodes as comma-separated libp2p multiaddrs
   --maxpeers      Maximum number of peers (default: 50)
   --nodiscover    Disable peer discovery
   --dht-server    Run DHT in server mode (for seed nodes/validators)
+  --nat           Map the P2P port with UPnP/NAT-PMP (default: true)
+  --autonat       Detect reachability with AutoNAT (default: true)
+  --relay         Reserve circuit relay slots on seeds while behind NAT
+                  (default: true)
+  --relay-service Relay connections for peers behind NAT, for seed
+                  nodes (default: false)
+  --holepunch     Upgrade relayed connections by hole punching (DCUtR)
+                  (default: true)
+  --private-peers Connect only to these peers, e.g. a validator's
+                  sentries (comma-separated multiaddrs with /p2p/ IDs)
+  --persistent-peers
+                  Peers always reconnected and exempt from --maxpeers
+  --hidden-peers  Peer IDs never advertised to other peers, e.g. the
+                  validator behind a sentry
+  --dandelion     Relay own transactions through a random stem peer
+                  before they are announced (default: false)
+  --broadcast-delay
+                  Max random delay in seconds before announcing own
+                  transactions (default: 0)
+  --upload-rate   Max KiB/s spent serving blocks to syncing peers
+                  (default: 0, unlimited)
+  --peer-upload-rate
+                  Max KiB/s spent serving blocks to a single peer
+                  (default: 0, unlimited)
+  --tx-relay-rate Transactions per second each peer may relay
+                  (default: 20)
+  --tx-relay-burst
+                  Transactions each peer may relay in a burst
+                  (default: 200)
+  --light-server  Serve headers and proofs to light clients
+                  (default: false)
 
 RPC Options:
   --rpc           Enable RPC server (default: true)
@@ -317,6 +515,9 @@ RPC Options:
 Wallet Options:
   --wallet        Enable integrated wallet
   --wallet-file   Wallet file path
+  --wallet-rebroadcast
+                  Minutes between rebroadcasts of unconfirmed wallet
+                  transactions (default: 30, 0 = never)
 
 Mining Options:
   --mine            Enable block production
@@ -324,6 +525,19 @@ Mining Options:
   --validator-key   Path to validator private key (for PoA chains)
   --mine-threads    Number of PoW mining threads (default: 1)
 
+Sync Options:
+  --assumevalid     Skip tx script/signature checks for this height:hash
+                    checkpoint and its ancestors (default: highest
+                    checkpoint, "none" to verify everything)
+
+Mempool Options:
+  --mempool-persist Save the mempool on shutdown and reload it at startup
+                    (default: true; --mempool-persist=false to disable)
+  --mempool-maxsize Mempool budget in MB, lowest fee rate evicted first
+                    (default: 300, 0 = unlimited)
+  --mempool-expiry  Hours before unconfirmed transactions are dropped
+                    (default: 336, 0 = never)
+
 Sub-chain Options:
   --sync-subchains  Which sub-chains to sync: all, none (default), or
                     comma-separated chain ID hex strings
@@ -333,6 +547,14 @@ Maintenance Options:
   --clear-bans        Clear all peer bans on startup
   --rebuild-indexes   Rebuild height and tx indexes from block data
 
+Database Commands:
+  db migrate --from badger --to pebble
+                      Copy the database to another storage engine
+                      (badger, pebble, bolt) and verify key counts
+  db verify [--repair] [--json]
+                      Replay all chains from genesis and check indexes,
+                      undo data and the UTXO set; optionally repair
+
 Logging Options:
   --log-level     Log level: debug, info, warn, error (default: info)
   --log-file      Log file path (default: stdout)
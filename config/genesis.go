@@ -74,13 +74,23 @@ type Genesis struct {
 
 	// Protocol rules
 	Protocol ProtocolConfig `json:"protocol"`
+
+	// Known-good blocks of the chain (not part of the genesis hash).
+	Checkpoints []Checkpoint `json:"checkpoints,omitempty"`
 }
 
 // ForkSchedule defines block heights at which protocol upgrades activate.
 // A zero value means the fork is not scheduled.
 type ForkSchedule struct {
-	// Future forks are added here as fields. Example:
-	// ScriptEngineHeight uint64 `json:"script_engine_height,omitempty"`
+	// InBlockSpendHeight lets a transaction spend an output created by
+	// another transaction in the same block.
+	InBlockSpendHeight uint64 `json:"in_block_spend_height,omitempty"`
+}
+
+// InBlockSpends reports whether a block at height may spend outputs
+// created in the same block.
+func (f *ForkSchedule) InBlockSpends(height uint64) bool {
+	return f.IsActive(f.InBlockSpendHeight, height)
 }
 
 // IsActive returns true if a fork at forkHeight has activated at currentHeight.
@@ -251,6 +261,7 @@ func MainnetGenesis() *Genesis {
 				AllowMinting:     true,
 			},
 		},
+		Checkpoints: append([]Checkpoint(nil), mainnetCheckpoints...),
 	}
 }
 
@@ -275,6 +286,8 @@ func TestnetGenesis() *Genesis {
 	// Testnet validator: derived from the well-known mnemonic.
 	g.Protocol.Consensus.Validators = []string{TestnetValidatorPubKey}
 
+	g.Checkpoints = append([]Checkpoint(nil), testnetCheckpoints...)
+
 	return g
 }
 
@@ -383,13 +396,27 @@ func (g *Genesis) Validate() error {
 		}
 	}
 
+	seen := make(map[uint64]bool, len(g.Checkpoints))
+	for _, cp := range g.Checkpoints {
+		if err := cp.Validate(); err != nil {
+			return err
+		}
+		if seen[cp.Height] {
+			return fmt.Errorf("duplicate checkpoint at height %d", cp.Height)
+		}
+		seen[cp.Height] = true
+	}
+
 	return nil
 }
 
 // Hash returns a BLAKE3 hash of the genesis configuration.
-// Used to identify the chain and detect genesis mismatches.
+// Used to identify the chain and detect genesis mismatches. Checkpoints
+// are excluded so that adding them does not change the chain identity.
 func (g *Genesis) Hash() (types.Hash, error) {
-	data, err := json.Marshal(g)
+	id := *g
+	id.Checkpoints = nil
+	data, err := json.Marshal(&id)
 	if err != nil {
 		return types.Hash{}, err
 	}
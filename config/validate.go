@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
 )
 
 // Validate checks runtime node config for obvious operator mistakes.
@@ -17,6 +19,13 @@ func Validate(cfg *Config) error {
 	if cfg.Network != Mainnet && cfg.Network != Testnet {
 		return fmt.Errorf("network must be %q or %q", Mainnet, Testnet)
 	}
+	switch cfg.Storage.Engine {
+	case "":
+		cfg.Storage.Engine = StorageBadger
+	case StorageBadger, StoragePebble, StorageBolt:
+	default:
+		return fmt.Errorf("storage.engine must be %q, %q, or %q", StorageBadger, StoragePebble, StorageBolt)
+	}
 	if cfg.P2P.Port < 0 || cfg.P2P.Port > 65535 {
 		return fmt.Errorf("p2p.port must be in range [0, 65535]")
 	}
@@ -26,6 +35,75 @@ func Validate(cfg *Config) error {
 	if err := validateRPCAllowedIPs(cfg.RPC.AllowedIPs); err != nil {
 		return err
 	}
+	if err := validateMultiaddrs("p2p.listenaddrs", cfg.P2P.ListenAddrs); err != nil {
+		return err
+	}
+	if err := validateMultiaddrs("p2p.announce", cfg.P2P.AnnounceAddrs); err != nil {
+		return err
+	}
+	if err := validateMultiaddrs("p2p.external", cfg.P2P.ExternalAddrs); err != nil {
+		return err
+	}
+	if err := validatePeerAddrs("p2p.privatepeers", cfg.P2P.PrivatePeers); err != nil {
+		return err
+	}
+	if err := validatePeerAddrs("p2p.persistentpeers", cfg.P2P.PersistentPeers); err != nil {
+		return err
+	}
+	for i, s := range cfg.P2P.HiddenPeers {
+		if _, err := peer.Decode(s); err != nil {
+			return fmt.Errorf("p2p.hiddenpeers[%d] must be a peer ID, got %q: %w", i, s, err)
+		}
+	}
+	if cfg.P2P.BroadcastDelay < 0 {
+		return fmt.Errorf("p2p.broadcastdelay must not be negative")
+	}
+	if cfg.P2P.UploadRate < 0 {
+		return fmt.Errorf("p2p.uploadrate must not be negative")
+	}
+	if cfg.P2P.PeerUploadRate < 0 {
+		return fmt.Errorf("p2p.peeruploadrate must not be negative")
+	}
+	if cfg.P2P.TxRelayRate < 0 {
+		return fmt.Errorf("p2p.txrelayrate must not be negative")
+	}
+	if cfg.P2P.TxRelayBurst < 0 {
+		return fmt.Errorf("p2p.txrelayburst must not be negative")
+	}
+	if cfg.Wallet.Rebroadcast < 0 {
+		return fmt.Errorf("wallet.rebroadcast must not be negative")
+	}
+	if cfg.Mempool.MaxSize < 0 {
+		return fmt.Errorf("mempool.maxsize must not be negative")
+	}
+	if cfg.Mempool.Expiry < 0 {
+		return fmt.Errorf("mempool.expiry must not be negative")
+	}
+	if cfg.Policy.MaxScriptData < 0 || cfg.Policy.MaxScriptData > MaxScriptData {
+		return fmt.Errorf("policy.maxscriptdata must be in range [0, %d]", MaxScriptData)
+	}
+	if cfg.Policy.MaxTokenOutputs < 0 {
+		return fmt.Errorf("policy.maxtokenoutputs must not be negative")
+	}
+	if cfg.Policy.AddressRate < 0 {
+		return fmt.Errorf("policy.addressrate must not be negative")
+	}
+	for _, name := range cfg.Policy.ScriptTypes {
+		if _, err := types.ParseScriptType(name); err != nil {
+			return fmt.Errorf("policy.scripttypes: %w", err)
+		}
+	}
+
+	for _, s := range cfg.Sync.Checkpoints {
+		if _, err := ParseCheckpoint(s); err != nil {
+			return fmt.Errorf("sync.checkpoints: %w", err)
+		}
+	}
+	if cfg.Sync.AssumeValid != "" && cfg.Sync.AssumeValid != AssumeValidNone {
+		if _, err := ParseCheckpoint(cfg.Sync.AssumeValid); err != nil {
+			return fmt.Errorf("sync.assumevalid: %w", err)
+		}
+	}
 
 	if cfg.SubChainSync.Mode == "" {
 		cfg.SubChainSync.Mode = SubChainSyncNone
@@ -74,6 +152,25 @@ func validateChainIDs(ids []string, field string) error {
 	return nil
 }
 
+func validateMultiaddrs(key string, entries []string) error {
+	for i, entry := range entries {
+		if _, err := ma.NewMultiaddr(strings.TrimSpace(entry)); err != nil {
+			return fmt.Errorf("%s[%d] must be a multiaddr, got %q: %w", key, i, entry, err)
+		}
+	}
+	return nil
+}
+
+// validatePeerAddrs checks that entries are multiaddrs ending in a peer ID.
+func validatePeerAddrs(key string, entries []string) error {
+	for i, entry := range entries {
+		if _, err := peer.AddrInfoFromString(strings.TrimSpace(entry)); err != nil {
+			return fmt.Errorf("%s[%d] must be a multiaddr with /p2p/<peer ID>, got %q: %w", key, i, entry, err)
+		}
+	}
+	return nil
+}
+
 func validateRPCAllowedIPs(entries []string) error {
 	for i, entry := range entries {
 		e := strings.TrimSpace(entry)
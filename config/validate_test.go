@@ -19,3 +19,67 @@ func TestValidate_RPCAllowedIPs_Valid(t *testing.T) {
 		t.Fatalf("Validate() error: %v", err)
 	}
 }
+
+func TestValidate_StorageEngine(t *testing.T) {
+	cfg := DefaultMainnet()
+	cfg.Storage.Engine = "leveldb"
+	if err := Validate(cfg); err == nil {
+		t.Fatal("Validate() should fail for unknown storage.engine")
+	}
+
+	cfg.Storage.Engine = ""
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+	if cfg.Storage.Engine != StorageBadger {
+		t.Errorf("empty storage.engine should default to badger, got %q", cfg.Storage.Engine)
+	}
+}
+
+func TestValidate_Policy(t *testing.T) {
+	cfg := DefaultMainnet()
+	cfg.Policy.ScriptTypes = []string{"p2pkh", "Stake"}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+
+	cfg.Policy.ScriptTypes = []string{"p2wpkh"}
+	if err := Validate(cfg); err == nil {
+		t.Fatal("Validate() should fail for unknown policy.scripttypes entry")
+	}
+
+	cfg = DefaultMainnet()
+	cfg.Policy.MaxScriptData = MaxScriptData + 1
+	if err := Validate(cfg); err == nil {
+		t.Fatal("Validate() should fail for policy.maxscriptdata above the consensus limit")
+	}
+}
+
+func TestValidate_P2PMultiaddrs(t *testing.T) {
+	cfg := DefaultMainnet()
+	cfg.P2P.ListenAddrs = []string{"/ip4/0.0.0.0/tcp/30303", "/ip6/::/udp/30303/quic-v1"}
+	cfg.P2P.AnnounceAddrs = []string{"/dns4/node.example.com/tcp/30303"}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+
+	cfg.P2P.ExternalAddrs = []string{"203.0.113.7:30303"}
+	if err := Validate(cfg); err == nil {
+		t.Fatal("Validate() should fail for a p2p.external entry that is not a multiaddr")
+	}
+}
+
+func TestValidate_SentryPeers(t *testing.T) {
+	const id = "12D3KooWSU83DfT1QXgji1XiC2d9RtkGFKt5zwG4sQQVN2R3nNGG"
+	cfg := DefaultMainnet()
+	cfg.P2P.PrivatePeers = []string{"/ip4/10.0.0.2/tcp/30303/p2p/" + id}
+	cfg.P2P.HiddenPeers = []string{id}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+
+	cfg.P2P.PersistentPeers = []string{"/ip4/10.0.0.3/tcp/30303"}
+	if err := Validate(cfg); err == nil {
+		t.Fatal("Validate() should fail for a persistent peer without a peer ID")
+	}
+}
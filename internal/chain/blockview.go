@@ -0,0 +1,111 @@
+package chain
+
+import (
+	"github.com/Klingon-tech/klingnet-chain/config"
+	"github.com/Klingon-tech/klingnet-chain/internal/utxo"
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// blockView overlays the outputs created by a block on the UTXO set.
+//
+// From the InBlockSpendHeight fork, a transaction may spend an output
+// created anywhere in the same block: transactions are ordered by hash
+// rather than by dependency, so validation and application treat a block
+// as creating all of its outputs first and spending all of its inputs
+// second. Double spends within a block are rejected by block.Validate.
+// Before the fork, inputs resolve against the UTXO set only.
+type blockView struct {
+	set       utxo.Set
+	created   map[types.Outpoint]*utxo.UTXO
+	order     []types.Outpoint // Created outpoints in block order.
+	spendable bool             // Inputs may spend created outputs.
+}
+
+// newBlockView returns a view of the UTXO set for blk. Its outputs are
+// visible to its inputs only if forks allow in-block spends at its height.
+// Outputs of a transaction that spends a stake are locked for the unstake
+// cooldown, as they will be once the block is applied.
+func newBlockView(set utxo.Set, blk *block.Block, forks *config.ForkSchedule) *blockView {
+	v := &blockView{
+		set:       set,
+		created:   make(map[types.Outpoint]*utxo.UTXO),
+		spendable: forks.InBlockSpends(blk.Header.Height),
+	}
+
+	for txIdx, transaction := range blk.Transactions {
+		txHash := transaction.Hash()
+		isCoinbase := txIdx == 0 && blk.Header.Height > 0
+		for i, out := range transaction.Outputs {
+			op := types.Outpoint{TxID: txHash, Index: uint32(i)}
+			v.created[op] = &utxo.UTXO{
+				Outpoint: op,
+				Value:    out.Value,
+				Script:   out.Script,
+				Token:    out.Token,
+				Height:   blk.Header.Height,
+				Coinbase: isCoinbase,
+			}
+			v.order = append(v.order, op)
+		}
+	}
+
+	// Detect transactions that spend stake UTXOs → lock their outputs.
+	for _, transaction := range blk.Transactions {
+		spendsStake := false
+		for _, in := range transaction.Inputs {
+			if in.PrevOut.IsZero() {
+				continue
+			}
+			u, err := v.Get(in.PrevOut)
+			if err == nil && u.Script.Type == types.ScriptTypeStake {
+				spendsStake = true
+				break
+			}
+		}
+		if !spendsStake {
+			continue
+		}
+		txHash := transaction.Hash()
+		for i := range transaction.Outputs {
+			v.created[types.Outpoint{TxID: txHash, Index: uint32(i)}].LockedUntil = blk.Header.Height + config.UnstakeCooldown
+		}
+	}
+
+	return v
+}
+
+// Get returns the UTXO an input spends, from the block or the UTXO set.
+func (v *blockView) Get(outpoint types.Outpoint) (*utxo.UTXO, error) {
+	if u, ok := v.created[outpoint]; ok && v.spendable {
+		return u, nil
+	}
+	return v.set.Get(outpoint)
+}
+
+// GetUTXO implements tx.UTXOProvider.
+func (v *blockView) GetUTXO(outpoint types.Outpoint) (uint64, types.Script, error) {
+	u, err := v.Get(outpoint)
+	if err != nil {
+		return 0, types.Script{}, err
+	}
+	return u.Value, u.Script, nil
+}
+
+// HasUTXO implements tx.UTXOProvider.
+func (v *blockView) HasUTXO(outpoint types.Outpoint) bool {
+	if _, ok := v.created[outpoint]; ok && v.spendable {
+		return true
+	}
+	has, err := v.set.Has(outpoint)
+	return err == nil && has
+}
+
+// GetTokenData implements token.InputTokens.
+func (v *blockView) GetTokenData(outpoint types.Outpoint) *types.TokenData {
+	u, err := v.Get(outpoint)
+	if err != nil {
+		return nil
+	}
+	return u.Token
+}
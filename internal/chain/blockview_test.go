@@ -0,0 +1,108 @@
+package chain
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/Klingon-tech/klingnet-chain/config"
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/crypto"
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// inBlockSpendBlock builds a block at height 1 in which a child spends
+// the output of a parent that spends the genesis allocation.
+func inBlockSpendBlock(t *testing.T, ch *Chain, key *crypto.PrivateKey, addr types.Address) (blk *block.Block, allocOut, parentOut, childOut types.Outpoint) {
+	t.Helper()
+
+	genesisBlock, _ := ch.GetBlockByHeight(0)
+	allocOut = types.Outpoint{TxID: genesisBlock.Transactions[0].Hash(), Index: 0}
+	p2pkh := types.Script{Type: types.ScriptTypeP2PKH, Data: addr.Bytes()}
+
+	b := tx.NewBuilder().AddInput(allocOut).AddOutput(99_000, p2pkh)
+	b.Sign(key)
+	parent := b.Build()
+	parentOut = types.Outpoint{TxID: parent.Hash(), Index: 0}
+
+	b = tx.NewBuilder().AddInput(parentOut).AddOutput(98_000, p2pkh)
+	b.Sign(key)
+	child := b.Build()
+	childOut = types.Outpoint{TxID: child.Hash(), Index: 0}
+
+	// Coinbase: subsidy plus both fees. Non-coinbase txs in hash order.
+	coinbase := &tx.Transaction{
+		Version: 1,
+		Inputs:  []tx.Input{{PrevOut: types.Outpoint{}}},
+		Outputs: []tx.Output{{Value: 4000, Script: p2pkh}},
+	}
+	userTxs := []*tx.Transaction{parent, child}
+	sort.Slice(userTxs, func(i, j int) bool {
+		hi, hj := userTxs[i].Hash(), userTxs[j].Hash()
+		return bytes.Compare(hi[:], hj[:]) < 0
+	})
+	blk = buildCustomBlock(t, ch, append([]*tx.Transaction{coinbase}, userTxs...))
+	return blk, allocOut, parentOut, childOut
+}
+
+func TestChain_ProcessBlock_InBlockSpendBeforeFork(t *testing.T) {
+	ch, key, addr, utxoStore := reorgTestChain(t)
+	ch.SetForkSchedule(config.ForkSchedule{InBlockSpendHeight: 2})
+	blk, allocOut, _, _ := inBlockSpendBlock(t, ch, key, addr)
+
+	if err := ch.ProcessBlock(blk); err == nil {
+		t.Fatal("in-block spend accepted below the fork height")
+	}
+	if ch.Height() != 0 {
+		t.Errorf("height = %d, want 0", ch.Height())
+	}
+	if has, _ := utxoStore.Has(allocOut); !has {
+		t.Error("rejected block spent the allocation")
+	}
+}
+
+func TestChain_ProcessBlock_InBlockSpend(t *testing.T) {
+	ch, key, addr, utxoStore := reorgTestChain(t)
+	ch.SetForkSchedule(config.ForkSchedule{InBlockSpendHeight: 1})
+	genesisHash := ch.TipHash()
+	supply := ch.Supply()
+	blk, allocOut, parentOut, childOut := inBlockSpendBlock(t, ch, key, addr)
+
+	if err := ch.ProcessBlock(blk); err != nil {
+		t.Fatalf("ProcessBlock: %v", err)
+	}
+	if got := ch.Supply() - supply; got != 2000 {
+		t.Errorf("supply increase = %d, want 2000 (fees must not count as minted)", got)
+	}
+	for _, c := range []struct {
+		op   types.Outpoint
+		want bool
+	}{{allocOut, false}, {parentOut, false}, {childOut, true}} {
+		if has, _ := utxoStore.Has(c.op); has != c.want {
+			t.Errorf("after apply: has %s = %v, want %v", c.op, has, c.want)
+		}
+	}
+
+	// Reorg the block out: the allocation returns and neither in-block
+	// output survives.
+	blkB1 := buildCoinbaseBlock(t, ch, genesisHash, 1, addr, 100)
+	blkB2 := buildCoinbaseBlock(t, ch, blkB1.Hash(), 2, addr, 100)
+	if err := ch.ProcessBlock(blkB1); err != nil {
+		t.Fatalf("process B1: %v", err)
+	}
+	if err := ch.ProcessBlock(blkB2); err != nil {
+		t.Fatalf("process B2: %v", err)
+	}
+	if ch.TipHash() != blkB2.Hash() {
+		t.Fatal("expected reorg to B2")
+	}
+	for _, c := range []struct {
+		op   types.Outpoint
+		want bool
+	}{{allocOut, true}, {parentOut, false}, {childOut, false}} {
+		if has, _ := utxoStore.Has(c.op); has != c.want {
+			t.Errorf("after revert: has %s = %v, want %v", c.op, has, c.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/Klingon-tech/klingnet-chain/config"
 	"github.com/Klingon-tech/klingnet-chain/internal/consensus"
@@ -48,18 +49,22 @@ type Chain struct {
 	mu        sync.Mutex // Protects all state mutations (ProcessBlock, Reorg).
 	ID        types.ChainID
 	state     *State
+	db        storage.DB
 	blocks    *BlockStore
 	utxos     utxo.Set
 	engine    consensus.Engine
 	validator *consensus.Validator
 
-	maxSupply           uint64     // Max coin supply (0 = unlimited).
-	blockReward         uint64     // Base block subsidy in base units.
-	halvingInterval     uint64     // Blocks between reward halvings (0 = disabled).
-	validatorStake      uint64     // Exact stake amount required (0 = disabled).
-	allowMinting        bool       // Whether new token issuance is allowed.
-	registeredSubChains uint64     // Active ScriptTypeRegister outputs on the current chain.
-	genesisHash         types.Hash // Hash of the genesis block (immutable).
+	maxSupply           uint64              // Max coin supply (0 = unlimited).
+	blockReward         uint64              // Base block subsidy in base units.
+	halvingInterval     uint64              // Blocks between reward halvings (0 = disabled).
+	validatorStake      uint64              // Exact stake amount required (0 = disabled).
+	allowMinting        bool                // Whether new token issuance is allowed.
+	forks               config.ForkSchedule // Protocol upgrade activation heights.
+	registeredSubChains uint64              // Active ScriptTypeRegister outputs on the current chain.
+	genesisHash         types.Hash          // Hash of the genesis block (immutable).
+	checkpoints         checkpoints
+	now                 func() time.Time // Wall clock for timestamp bounds.
 
 	registrationValidator RegistrationValidator
 	registrationHandler   RegistrationHandler
@@ -68,9 +73,14 @@ type Chain struct {
 	unstakeHandler        UnstakeHandler
 	revertedTxHandler     RevertedTxHandler
 	reorgHandler          ReorgHandler
+
+	viewMu sync.RWMutex // Guards view.
+	view   *View        // Latest published read snapshot (see AcquireView).
 }
 
 // New creates a new chain with the given components.
+// The UTXO set is expected to live in db so that published views
+// capture blocks and UTXOs from a single snapshot.
 func New(id types.ChainID, db storage.DB, utxoSet utxo.Set, engine consensus.Engine) (*Chain, error) {
 	if db == nil {
 		return nil, fmt.Errorf("storage db is nil")
@@ -110,12 +120,14 @@ func New(id types.ChainID, db storage.DB, utxoSet utxo.Set, engine consensus.Eng
 	ch := &Chain{
 		ID:                  id,
 		state:               &State{TipHash: tipHash, Height: height, Supply: supply, CumulativeDifficulty: cumDiff, TipTimestamp: tipTimestamp},
+		db:                  db,
 		blocks:              blocks,
 		utxos:               utxoSet,
 		engine:              engine,
 		validator:           consensus.NewValidator(engine),
 		registeredSubChains: registeredSubChains,
 		genesisHash:         genesisHash,
+		now:                 time.Now,
 	}
 
 	// Check for incomplete reorg — if the node crashed mid-reorg, the UTXO
@@ -126,6 +138,8 @@ func New(id types.ChainID, db storage.DB, utxoSet utxo.Set, engine consensus.Eng
 		}
 	}
 
+	ch.publishView()
+
 	return ch, nil
 }
 
@@ -173,12 +187,15 @@ func (c *Chain) InitFromGenesis(gen *config.Genesis) error {
 	c.halvingInterval = gen.Protocol.Consensus.HalvingInterval
 	c.validatorStake = gen.Protocol.Consensus.ValidatorStake
 	c.allowMinting = gen.Protocol.Token.AllowMinting
+	c.forks = gen.Protocol.Forks
 	c.registeredSubChains = 0
 
 	if err := c.blocks.SetTip(hash, 0, supply); err != nil {
 		return fmt.Errorf("set genesis tip: %w", err)
 	}
 
+	c.publishView()
+
 	return nil
 }
 
@@ -196,6 +213,18 @@ func (c *Chain) SetTokenRules(r config.TokenRules) {
 	c.allowMinting = r.AllowMinting
 }
 
+// SetForkSchedule configures the protocol upgrade activation heights.
+// Call this on startup for both fresh and resumed chains.
+func (c *Chain) SetForkSchedule(f config.ForkSchedule) {
+	c.forks = f
+}
+
+// SetClock replaces the wall clock used to reject blocks from the future.
+// In-process simulations run on virtual time.
+func (c *Chain) SetClock(now func() time.Time) {
+	c.now = now
+}
+
 // SetRegistrationValidator configures consensus validation for registration outputs.
 func (c *Chain) SetRegistrationValidator(fn RegistrationValidator) {
 	c.registrationValidator = fn
@@ -239,7 +268,12 @@ func (c *Chain) Supply() uint64 {
 // RebuildIndexes delegates to BlockStore.RebuildIndexes to rebuild
 // all height and transaction indexes by walking the chain from tip.
 func (c *Chain) RebuildIndexes() (int, error) {
-	return c.blocks.RebuildIndexes()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n, err := c.blocks.RebuildIndexes()
+	c.publishView()
+	return n, err
 }
 
 // SetRegistrationHandler sets the callback for ScriptTypeRegister outputs in confirmed blocks.
@@ -418,18 +452,15 @@ func (c *Chain) isPoWEngine() bool {
 
 // GetTransaction looks up a confirmed transaction by hash via the tx index.
 func (c *Chain) GetTransaction(hash types.Hash) (*tx.Transaction, error) {
+	return c.blocks.GetTransaction(hash)
+}
+
+// GetTxBlock returns the active-chain block containing a confirmed
+// transaction.
+func (c *Chain) GetTxBlock(hash types.Hash) (*block.Block, error) {
 	_, blockHash, err := c.blocks.GetTxLocation(hash)
 	if err != nil {
 		return nil, err
 	}
-	blk, err := c.blocks.GetBlock(blockHash)
-	if err != nil {
-		return nil, fmt.Errorf("load block for tx: %w", err)
-	}
-	for _, t := range blk.Transactions {
-		if t.Hash() == hash {
-			return t, nil
-		}
-	}
-	return nil, fmt.Errorf("tx %s not found in block %s (index corrupt)", hash, blockHash)
+	return c.blocks.GetBlock(blockHash)
 }
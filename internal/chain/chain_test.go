@@ -1364,3 +1364,24 @@ func TestProcessBlock_CoinbaseWithMintScript_Rejected(t *testing.T) {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
+
+func TestBlockUTXOChanges_SpendWithinBlock(t *testing.T) {
+	stored := types.Outpoint{TxID: types.Hash{0x01}}
+	parent := &tx.Transaction{
+		Inputs:  []tx.Input{{PrevOut: stored}},
+		Outputs: []tx.Output{{Value: 10}, {Value: 20}},
+	}
+	child := &tx.Transaction{
+		Inputs:  []tx.Input{{PrevOut: types.Outpoint{TxID: parent.Hash(), Index: 0}}},
+		Outputs: []tx.Output{{Value: 9}},
+	}
+	blk := &block.Block{Header: &block.Header{Height: 5}, Transactions: []*tx.Transaction{parent, child}}
+
+	spent, created := blockUTXOChanges(blk)
+	if len(spent) != 1 || spent[0] != stored {
+		t.Errorf("spent = %v, want only the stored outpoint", spent)
+	}
+	if len(created) != 2 || created[0].Value != 20 || created[1].Value != 9 {
+		t.Fatalf("created = %v, want the unspent parent output and the child output", created)
+	}
+}
@@ -0,0 +1,204 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// ErrCheckpointMismatch indicates a block or fork that conflicts with a checkpoint.
+var ErrCheckpointMismatch = errors.New("block conflicts with checkpoint")
+
+// checkpoints holds the chain's checkpoint and assume-valid settings.
+// They are configured once at startup and read-only afterwards.
+type checkpoints struct {
+	hashes  map[uint64]types.Hash
+	heights []uint64 // Sorted ascending.
+
+	assumeValidHeight uint64 // 0 = disabled.
+	assumeValidHash   types.Hash
+
+	// ancestors holds the hashes of the header chain collected towards the
+	// assume-valid block, starting at ancestorsFrom. It is guarded by
+	// Chain.mu, unlike the settings above.
+	ancestors     []types.Hash
+	ancestorsFrom uint64
+}
+
+// SetCheckpoints configures the blocks the active chain must contain.
+// Blocks that conflict with a checkpoint are rejected, as are forks from
+// below the highest checkpoint the chain has already passed. Call before
+// processing blocks.
+func (c *Chain) SetCheckpoints(cps map[uint64]types.Hash) {
+	c.checkpoints.hashes = make(map[uint64]types.Hash, len(cps))
+	c.checkpoints.heights = c.checkpoints.heights[:0]
+	for h, hash := range cps {
+		c.checkpoints.hashes[h] = hash
+		c.checkpoints.heights = append(c.checkpoints.heights, h)
+	}
+	sort.Slice(c.checkpoints.heights, func(i, j int) bool {
+		return c.checkpoints.heights[i] < c.checkpoints.heights[j]
+	})
+}
+
+// SetAssumeValid skips transaction script and signature checks for the
+// block hash at height and, once AddAssumeValidHeaders has linked them to
+// it, its ancestors; the UTXO set is still built and amounts, maturity,
+// tokens and block headers are still checked. Any other block is fully
+// verified. hash is also enforced as a checkpoint. A height of 0 disables
+// assume-valid.
+func (c *Chain) SetAssumeValid(height uint64, hash types.Hash) {
+	c.checkpoints.assumeValidHeight = height
+	c.checkpoints.assumeValidHash = hash
+	c.checkpoints.ancestors = nil
+	if height == 0 {
+		return
+	}
+	if _, ok := c.checkpoints.hashes[height]; !ok {
+		cps := make(map[uint64]types.Hash, len(c.checkpoints.hashes)+1)
+		for h, v := range c.checkpoints.hashes {
+			cps[h] = v
+		}
+		cps[height] = hash
+		c.SetCheckpoints(cps)
+	}
+}
+
+// CheckpointInfo describes the configured checkpoints.
+type CheckpointInfo struct {
+	Count             int        // Number of checkpoints.
+	LastHeight        uint64     // Highest checkpoint height (0 if none).
+	AssumeValidHeight uint64     // 0 = assume-valid disabled.
+	AssumeValidHash   types.Hash // Block assumed valid.
+}
+
+// Checkpoints returns the configured checkpoint settings.
+func (c *Chain) Checkpoints() CheckpointInfo {
+	info := CheckpointInfo{
+		Count:             len(c.checkpoints.heights),
+		AssumeValidHeight: c.checkpoints.assumeValidHeight,
+		AssumeValidHash:   c.checkpoints.assumeValidHash,
+	}
+	if n := len(c.checkpoints.heights); n > 0 {
+		info.LastHeight = c.checkpoints.heights[n-1]
+	}
+	return info
+}
+
+// checkCheckpoint rejects a block whose hash differs from the checkpoint
+// at its height.
+func (c *Chain) checkCheckpoint(blk *block.Block) error {
+	want, ok := c.checkpoints.hashes[blk.Header.Height]
+	if !ok {
+		return nil
+	}
+	if got := blk.Hash(); got != want {
+		return fmt.Errorf("%w: block %s at height %d, checkpoint %s", ErrCheckpointMismatch, got, blk.Header.Height, want)
+	}
+	return nil
+}
+
+// checkForkPoint rejects a fork from forkHeight if the active chain has
+// already passed a checkpoint above it: the new branch would have to
+// replace the checkpointed block.
+func (c *Chain) checkForkPoint(forkHeight uint64) error {
+	hs := c.checkpoints.heights
+	// Highest checkpoint at or below the current tip.
+	i := sort.Search(len(hs), func(i int) bool { return hs[i] > c.state.Height })
+	if i == 0 {
+		return nil
+	}
+	if cp := hs[i-1]; forkHeight < cp {
+		return fmt.Errorf("%w: fork from height %d is below checkpoint at height %d", ErrCheckpointMismatch, forkHeight, cp)
+	}
+	return nil
+}
+
+// AssumeValidHeadersFrom returns the height of the next header
+// AddAssumeValidHeaders needs. ok is false when assume-valid is disabled,
+// the header chain is complete or the chain is already past the
+// assume-valid block.
+func (c *Chain) AssumeValidHeadersFrom() (from uint64, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cp := &c.checkpoints
+	if cp.assumeValidHeight == 0 || c.state.Height >= cp.assumeValidHeight {
+		return 0, false
+	}
+	if len(cp.ancestors) == 0 {
+		return c.state.Height + 1, true
+	}
+	next := cp.ancestorsFrom + uint64(len(cp.ancestors))
+	if next > cp.assumeValidHeight {
+		return 0, false
+	}
+	return next, true
+}
+
+// AddAssumeValidHeaders extends the header chain collected towards the
+// assume-valid block. headers must be ascending, each linked to the one
+// before by PrevHash, and continue the previous call. Headers are not
+// verified otherwise: a chain linked by hash to the assumed block is its
+// ancestry whoever served it. Until the chain reaches that block, blocks
+// are fully verified. A header that does not fit discards the collected
+// chain so it can be fetched again from another peer.
+func (c *Chain) AddAssumeValidHeaders(headers []*block.Header) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cp := &c.checkpoints
+	if cp.assumeValidHeight == 0 {
+		return nil
+	}
+	for _, h := range headers {
+		if h == nil {
+			cp.ancestors = nil
+			return fmt.Errorf("assume-valid headers: nil header")
+		}
+		if n := len(cp.ancestors); n > 0 {
+			next := cp.ancestorsFrom + uint64(n)
+			if next > cp.assumeValidHeight {
+				return nil // Complete; the rest is beyond the assumed block.
+			}
+			if h.Height != next || h.PrevHash != cp.ancestors[n-1] {
+				cp.ancestors = nil
+				return fmt.Errorf("assume-valid headers: header at height %d does not link to %d", h.Height, next-1)
+			}
+		} else {
+			if h.Height > cp.assumeValidHeight {
+				return nil
+			}
+			cp.ancestorsFrom = h.Height
+		}
+		cp.ancestors = append(cp.ancestors, h.Hash())
+		if h.Height == cp.assumeValidHeight && cp.ancestors[len(cp.ancestors)-1] != cp.assumeValidHash {
+			cp.ancestors = nil
+			return fmt.Errorf("%w: header chain ends at %s, assumed %s", ErrCheckpointMismatch, h.Hash(), cp.assumeValidHash)
+		}
+	}
+	return nil
+}
+
+// assumeValid reports whether transaction scripts and signatures may be
+// skipped for blk: it is the assume-valid block, or an ancestor of it in
+// a complete header chain.
+func (c *Chain) assumeValid(blk *block.Block) bool {
+	cp := &c.checkpoints
+	height := blk.Header.Height
+	if cp.assumeValidHeight == 0 || height > cp.assumeValidHeight {
+		return false
+	}
+	hash := blk.Hash()
+	if hash == cp.assumeValidHash {
+		return true
+	}
+	end := cp.ancestorsFrom + uint64(len(cp.ancestors))
+	if len(cp.ancestors) == 0 || end <= cp.assumeValidHeight || height < cp.ancestorsFrom {
+		return false // Incomplete chain, or below where it starts.
+	}
+	return cp.ancestors[height-cp.ancestorsFrom] == hash
+}
@@ -0,0 +1,143 @@
+package chain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/crypto"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+func TestCheckpoint_RejectsMismatch(t *testing.T) {
+	ch, _, addr, _ := reorgTestChain(t)
+	genesisHash := ch.TipHash()
+
+	blkA1 := buildCoinbaseBlock(t, ch, genesisHash, 1, addr, 0)
+	blkB1 := buildCoinbaseBlock(t, ch, genesisHash, 1, addr, 100)
+	ch.SetCheckpoints(map[uint64]types.Hash{1: blkA1.Hash()})
+
+	if err := ch.ProcessBlock(blkB1); !errors.Is(err, ErrCheckpointMismatch) {
+		t.Fatalf("process B1: expected ErrCheckpointMismatch, got %v", err)
+	}
+	if err := ch.ProcessBlock(blkA1); err != nil {
+		t.Fatalf("process A1: %v", err)
+	}
+	if ch.TipHash() != blkA1.Hash() {
+		t.Error("tip should be the checkpointed block")
+	}
+	if info := ch.Checkpoints(); info.Count != 1 || info.LastHeight != 1 {
+		t.Errorf("Checkpoints() = %+v, want 1 checkpoint at height 1", info)
+	}
+}
+
+func TestCheckpoint_RejectsForkBelowCheckpoint(t *testing.T) {
+	ch, _, addr, _ := reorgTestChain(t)
+	genesisHash := ch.TipHash()
+
+	blkA1 := buildCoinbaseBlock(t, ch, genesisHash, 1, addr, 0)
+	if err := ch.ProcessBlock(blkA1); err != nil {
+		t.Fatalf("process A1: %v", err)
+	}
+	blkA2 := buildCoinbaseBlock(t, ch, blkA1.Hash(), 2, addr, 0)
+	if err := ch.ProcessBlock(blkA2); err != nil {
+		t.Fatalf("process A2: %v", err)
+	}
+	ch.SetCheckpoints(map[uint64]types.Hash{2: blkA2.Hash()})
+
+	// B1 forks from genesis, below the checkpoint the chain has passed.
+	blkB1 := buildCoinbaseBlock(t, ch, genesisHash, 1, addr, 100)
+	if err := ch.ProcessBlock(blkB1); !errors.Is(err, ErrCheckpointMismatch) {
+		t.Fatalf("process B1: expected ErrCheckpointMismatch, got %v", err)
+	}
+	if _, err := ch.GetBlock(blkB1.Hash()); err == nil {
+		t.Error("fork block below checkpoint should not be stored")
+	}
+
+	// Forks above the checkpoint are still allowed.
+	blkA3 := buildCoinbaseBlock(t, ch, blkA2.Hash(), 3, addr, 0)
+	if err := ch.ProcessBlock(blkA3); err != nil {
+		t.Fatalf("process A3: %v", err)
+	}
+	blkC3 := buildCoinbaseBlock(t, ch, blkA2.Hash(), 3, addr, 200)
+	if err := ch.ProcessBlock(blkC3); err != nil {
+		t.Fatalf("process C3: %v", err)
+	}
+}
+
+func TestAssumeValid_SkipsScriptChecks(t *testing.T) {
+	ch, validatorKey, _ := testChain(t)
+
+	genesisBlock, _ := ch.GetBlockByHeight(0)
+	prevOut := types.Outpoint{TxID: genesisBlock.Transactions[0].Hash(), Index: 0}
+
+	// Spend the genesis allocation with a key that does not own it.
+	wrongKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	blk := buildSignedBlock(t, ch, wrongKey, validatorKey, prevOut, 4000)
+
+	if err := ch.ProcessBlock(blk); err == nil {
+		t.Fatal("expected rejection without assume-valid")
+	}
+
+	// A different assumed block at the same height acts as a checkpoint.
+	ch.SetAssumeValid(1, types.Hash{0x01})
+	if err := ch.ProcessBlock(blk); !errors.Is(err, ErrCheckpointMismatch) {
+		t.Fatalf("expected ErrCheckpointMismatch, got %v", err)
+	}
+
+	ch.SetCheckpoints(nil)
+	ch.SetAssumeValid(1, blk.Hash())
+	if err := ch.ProcessBlock(blk); err != nil {
+		t.Fatalf("ProcessBlock with assume-valid: %v", err)
+	}
+	if ch.Height() != 1 {
+		t.Errorf("height = %d, want 1", ch.Height())
+	}
+	if info := ch.Checkpoints(); info.AssumeValidHeight != 1 || info.AssumeValidHash != blk.Hash() {
+		t.Errorf("Checkpoints() = %+v", info)
+	}
+}
+
+func TestAssumeValid_OnlyAncestors(t *testing.T) {
+	ch, validatorKey, _ := testChain(t)
+
+	genesisBlock, _ := ch.GetBlockByHeight(0)
+	prevOut := types.Outpoint{TxID: genesisBlock.Transactions[0].Hash(), Index: 0}
+	wrongKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	blk1 := buildSignedBlock(t, ch, wrongKey, validatorKey, prevOut, 4000)
+	blk2 := buildCoinbaseBlock(t, ch, blk1.Hash(), 2, types.Address{}, 0)
+	ch.SetAssumeValid(2, blk2.Hash())
+
+	// Below the assumed height but not yet known to lead to it.
+	if err := ch.ProcessBlock(blk1); err == nil {
+		t.Fatal("expected rejection before the header chain is known")
+	}
+	if from, ok := ch.AssumeValidHeadersFrom(); !ok || from != 1 {
+		t.Fatalf("AssumeValidHeadersFrom() = %d, %v; want 1, true", from, ok)
+	}
+
+	// A header chain ending elsewhere is discarded.
+	other := buildCoinbaseBlock(t, ch, blk1.Hash(), 2, types.Address{}, 7)
+	if err := ch.AddAssumeValidHeaders([]*block.Header{blk1.Header, other.Header}); !errors.Is(err, ErrCheckpointMismatch) {
+		t.Fatalf("expected ErrCheckpointMismatch, got %v", err)
+	}
+	if from, ok := ch.AssumeValidHeadersFrom(); !ok || from != 1 {
+		t.Fatalf("after mismatch: AssumeValidHeadersFrom() = %d, %v; want 1, true", from, ok)
+	}
+
+	if err := ch.AddAssumeValidHeaders([]*block.Header{blk1.Header, blk2.Header}); err != nil {
+		t.Fatalf("AddAssumeValidHeaders: %v", err)
+	}
+	if _, ok := ch.AssumeValidHeadersFrom(); ok {
+		t.Error("complete header chain still requested")
+	}
+	if err := ch.ProcessBlock(blk1); err != nil {
+		t.Fatalf("ProcessBlock of an assumed ancestor: %v", err)
+	}
+}
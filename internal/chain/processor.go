@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"slices"
 	"time"
 
 	"github.com/Klingon-tech/klingnet-chain/config"
@@ -43,6 +44,14 @@ func (c *Chain) ProcessBlock(blk *block.Block) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	// Publish a fresh read view whenever the tip moves (fast path or reorg).
+	prevTip := c.state.TipHash
+	defer func() {
+		if c.state.TipHash != prevTip {
+			c.publishView()
+		}
+	}()
+
 	if blk == nil || blk.Header == nil {
 		return fmt.Errorf("nil block or header")
 	}
@@ -64,6 +73,9 @@ func (c *Chain) ProcessBlock(blk *block.Block) error {
 	if parentErr != nil && !errors.Is(parentErr, ErrForkDetected) {
 		return parentErr
 	}
+	if err := c.checkCheckpoint(blk); err != nil {
+		return err
+	}
 
 	// Verify PoW difficulty matches expected (from chain history).
 	// Only on fast path — fork blocks are verified during reorg replay.
@@ -79,7 +91,7 @@ func (c *Chain) ProcessBlock(blk *block.Block) error {
 	}
 
 	// Block timestamp bounds: reject blocks too far in the future.
-	maxTime := uint64(time.Now().Add(2 * time.Minute).Unix())
+	maxTime := uint64(c.now().Add(2 * time.Minute).Unix())
 	if blk.Header.Timestamp > maxTime {
 		return fmt.Errorf("%w: block timestamp %d exceeds max %d", ErrTimestampTooFuture, blk.Header.Timestamp, maxTime)
 	}
@@ -101,6 +113,10 @@ func (c *Chain) ProcessBlock(blk *block.Block) error {
 
 	// Fork detected: store the block and decide whether to reorg.
 	if errors.Is(parentErr, ErrForkDetected) {
+		if err := c.checkForkPoint(blk.Header.Height - 1); err != nil {
+			return err
+		}
+
 		// Store block data only (no height/tx indexes yet).
 		if err := c.blocks.StoreBlock(blk); err != nil {
 			return fmt.Errorf("store fork block: %w", err)
@@ -246,14 +262,22 @@ func (c *Chain) validateBlockState(blk *block.Block) (uint64, error) {
 
 	// Full UTXO-aware transaction validation (skip coinbase):
 	// ownership checks, input existence/unspent checks, signatures, and fee sanity.
-	utxoProvider := &chainUTXOProvider{set: c.utxos}
+	// For the assume-valid block and its ancestors only existence and
+	// amounts are checked.
+	// Once the fork is active, inputs may spend outputs created elsewhere
+	// in the block.
+	view := newBlockView(c.utxos, blk, &c.forks)
+	validate := (*tx.Transaction).ValidateWithUTXOs
+	if c.assumeValid(blk) {
+		validate = (*tx.Transaction).ValidateAmountsWithUTXOs
+	}
 	fees := make([]uint64, len(blk.Transactions))
 	var totalFees uint64
 	for i, transaction := range blk.Transactions {
 		if i == 0 {
 			continue // Coinbase.
 		}
-		fee, err := transaction.ValidateWithUTXOs(utxoProvider)
+		fee, err := validate(transaction, view)
 		if err != nil {
 			return 0, fmt.Errorf("tx %d validation: %w", i, err)
 		}
@@ -296,18 +320,17 @@ func (c *Chain) validateBlockState(blk *block.Block) (uint64, error) {
 	}
 
 	// Coinbase maturity: reject blocks that spend immature coinbase outputs.
-	if err := c.checkCoinbaseMaturity(blk); err != nil {
+	if err := c.checkCoinbaseMaturity(blk, view); err != nil {
 		return 0, err
 	}
 
 	// Token validation: verify token conservation, minting, and burning rules.
-	tokenInputs := &token.UTXOTokenAdapter{Set: c.utxos}
 	for i, transaction := range blk.Transactions[1:] {
 		hasMint := token.HasMintOutput(transaction)
 		if hasMint && !c.allowMinting {
 			return 0, ErrMintingDisabled
 		}
-		if err := token.ValidateTokens(transaction, tokenInputs); err != nil {
+		if err := token.ValidateTokens(transaction, view); err != nil {
 			return 0, fmt.Errorf("token validation: %w", err)
 		}
 		if config.TokenCreationFee > 0 && hasMint {
@@ -394,6 +417,7 @@ func (c *Chain) computeBlockReward(blk *block.Block) uint64 {
 	}
 
 	// Sum fees from non-coinbase transactions.
+	view := newBlockView(c.utxos, blk, &c.forks)
 	var totalFees uint64
 	for _, transaction := range blk.Transactions[1:] {
 		var inputSum, outputSum uint64
@@ -401,7 +425,7 @@ func (c *Chain) computeBlockReward(blk *block.Block) uint64 {
 			if in.PrevOut.IsZero() {
 				continue
 			}
-			u, err := c.utxos.Get(in.PrevOut)
+			u, err := view.Get(in.PrevOut)
 			if err != nil {
 				continue // Input not found (shouldn't happen after validation).
 			}
@@ -462,26 +486,20 @@ func (c *Chain) computeTxFee(transaction *tx.Transaction) uint64 {
 	return 0
 }
 
-type chainUTXOProvider struct {
-	set utxo.Set
-}
-
-func (p *chainUTXOProvider) GetUTXO(outpoint types.Outpoint) (uint64, types.Script, error) {
-	u, err := p.set.Get(outpoint)
-	if err != nil {
-		return 0, types.Script{}, err
-	}
-	return u.Value, u.Script, nil
-}
-
-func (p *chainUTXOProvider) HasUTXO(outpoint types.Outpoint) bool {
-	has, err := p.set.Has(outpoint)
-	return err == nil && has
+// utxoApplier is implemented by UTXO sets that can apply a block's
+// changes in a single write.
+type utxoApplier interface {
+	Apply(spent []types.Outpoint, created []*utxo.UTXO) error
 }
 
 // applyBlock updates the UTXO set: spends inputs and creates outputs.
 // Coinbase inputs (zero outpoint) are skipped during spending.
 func (c *Chain) applyBlock(blk *block.Block) error {
+	if applier, ok := c.utxos.(utxoApplier); ok {
+		spent, created := blockUTXOChanges(blk)
+		return applier.Apply(spent, created)
+	}
+
 	for txIdx, transaction := range blk.Transactions {
 		txHash := transaction.Hash()
 		isCoinbase := txIdx == 0 && blk.Header.Height > 0
@@ -498,15 +516,7 @@ func (c *Chain) applyBlock(blk *block.Block) error {
 
 		// Create outputs.
 		for i, out := range transaction.Outputs {
-			u := &utxo.UTXO{
-				Outpoint: types.Outpoint{TxID: txHash, Index: uint32(i)},
-				Value:    out.Value,
-				Script:   out.Script,
-				Token:    out.Token,
-				Height:   blk.Header.Height,
-				Coinbase: isCoinbase,
-			}
-			if err := c.utxos.Put(u); err != nil {
+			if err := c.utxos.Put(blockUTXO(blk, txHash, isCoinbase, i, out)); err != nil {
 				return fmt.Errorf("create output %s:%d: %w", txHash, i, err)
 			}
 		}
@@ -514,15 +524,57 @@ func (c *Chain) applyBlock(blk *block.Block) error {
 	return nil
 }
 
+// blockUTXOChanges returns the stored outputs a block spends and the
+// outputs it leaves unspent. Outputs created and spent within the block
+// appear in neither.
+func blockUTXOChanges(blk *block.Block) (spent []types.Outpoint, created []*utxo.UTXO) {
+	index := make(map[types.Outpoint]int)
+	for txIdx, transaction := range blk.Transactions {
+		txHash := transaction.Hash()
+		isCoinbase := txIdx == 0 && blk.Header.Height > 0
+
+		for _, in := range transaction.Inputs {
+			if in.PrevOut.IsZero() {
+				continue // Coinbase input.
+			}
+			if i, ok := index[in.PrevOut]; ok {
+				created[i] = nil
+				delete(index, in.PrevOut)
+				continue
+			}
+			spent = append(spent, in.PrevOut)
+		}
+		for i, out := range transaction.Outputs {
+			u := blockUTXO(blk, txHash, isCoinbase, i, out)
+			index[u.Outpoint] = len(created)
+			created = append(created, u)
+		}
+	}
+	created = slices.DeleteFunc(created, func(u *utxo.UTXO) bool { return u == nil })
+	return spent, created
+}
+
+// blockUTXO builds the UTXO for output i of a transaction in blk.
+func blockUTXO(blk *block.Block, txHash types.Hash, isCoinbase bool, i int, out tx.Output) *utxo.UTXO {
+	return &utxo.UTXO{
+		Outpoint: types.Outpoint{TxID: txHash, Index: uint32(i)},
+		Value:    out.Value,
+		Script:   out.Script,
+		Token:    out.Token,
+		Height:   blk.Header.Height,
+		Coinbase: isCoinbase,
+	}
+}
+
 // checkCoinbaseMaturity verifies that no transaction in the block spends
-// an immature coinbase output.
-func (c *Chain) checkCoinbaseMaturity(blk *block.Block) error {
+// an immature coinbase output or a locked output.
+func (c *Chain) checkCoinbaseMaturity(blk *block.Block, view *blockView) error {
 	for _, transaction := range blk.Transactions {
 		for _, in := range transaction.Inputs {
 			if in.PrevOut.IsZero() {
 				continue
 			}
-			u, err := c.utxos.Get(in.PrevOut)
+			u, err := view.Get(in.PrevOut)
 			if err != nil {
 				continue // Will be caught by UTXO validation.
 			}
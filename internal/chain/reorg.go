@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/Klingon-tech/klingnet-chain/config"
 	"github.com/Klingon-tech/klingnet-chain/internal/utxo"
 	"github.com/Klingon-tech/klingnet-chain/pkg/block"
 	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
@@ -33,28 +32,27 @@ var ErrGenesisReorg = fmt.Errorf("reorg would replace genesis block")
 const MaxReorgDepth = 1000
 
 // applyBlockWithUndo applies a block to the UTXO set and returns undo data.
+// All outputs are created before any input is spent, so once in-block
+// spends are active a transaction may spend an output created elsewhere in
+// the same block.
 func (c *Chain) applyBlockWithUndo(blk *block.Block) (*UndoData, error) {
 	undo := &UndoData{}
+	view := newBlockView(c.utxos, blk, &c.forks)
 
-	for txIdx, transaction := range blk.Transactions {
-		txHash := transaction.Hash()
-		undo.TxHashes = append(undo.TxHashes, txHash)
-		isCoinbase := txIdx == 0 && blk.Header.Height > 0
+	for _, transaction := range blk.Transactions {
+		undo.TxHashes = append(undo.TxHashes, transaction.Hash())
+	}
 
-		// Detect if this tx spends any stake UTXOs → lock return outputs.
-		var lockedUntil uint64
-		for _, in := range transaction.Inputs {
-			if in.PrevOut.IsZero() {
-				continue
-			}
-			u, err := c.utxos.Get(in.PrevOut)
-			if err == nil && u.Script.Type == types.ScriptTypeStake {
-				lockedUntil = blk.Header.Height + config.UnstakeCooldown
-				break
-			}
+	// Create outputs.
+	for _, op := range view.order {
+		undo.CreatedOutpoints = append(undo.CreatedOutpoints, op)
+		if err := c.utxos.Put(view.created[op]); err != nil {
+			return nil, fmt.Errorf("create output %s: %w", op, err)
 		}
+	}
 
-		// Spend inputs — save UTXO before deleting for undo.
+	// Spend inputs — save UTXO before deleting for undo.
+	for _, transaction := range blk.Transactions {
 		for _, in := range transaction.Inputs {
 			if in.PrevOut.IsZero() {
 				continue
@@ -69,25 +67,6 @@ func (c *Chain) applyBlockWithUndo(blk *block.Block) (*UndoData, error) {
 				return nil, fmt.Errorf("spend %s: %w", in.PrevOut, err)
 			}
 		}
-
-		// Create outputs.
-		for i, out := range transaction.Outputs {
-			op := types.Outpoint{TxID: txHash, Index: uint32(i)}
-			undo.CreatedOutpoints = append(undo.CreatedOutpoints, op)
-
-			u := &utxo.UTXO{
-				Outpoint:    op,
-				Value:       out.Value,
-				Script:      out.Script,
-				Token:       out.Token,
-				Height:      blk.Header.Height,
-				Coinbase:    isCoinbase,
-				LockedUntil: lockedUntil,
-			}
-			if err := c.utxos.Put(u); err != nil {
-				return nil, fmt.Errorf("create output %s:%d: %w", txHash, i, err)
-			}
-		}
 	}
 
 	return undo, nil
@@ -95,6 +74,14 @@ func (c *Chain) applyBlockWithUndo(blk *block.Block) (*UndoData, error) {
 
 // revertBlock undoes a block's UTXO changes using stored undo data.
 func (c *Chain) revertBlock(undo *UndoData) error {
+	// Restore spent UTXOs first: outputs both created and spent by the
+	// block are then removed again with the other created outputs.
+	for i := range undo.SpentUTXOs {
+		if err := c.utxos.Put(&undo.SpentUTXOs[i]); err != nil {
+			return fmt.Errorf("restore utxo %s: %w", undo.SpentUTXOs[i].Outpoint, err)
+		}
+	}
+
 	// Delete created outputs (reverse order for safety).
 	for i := len(undo.CreatedOutpoints) - 1; i >= 0; i-- {
 		if err := c.utxos.Delete(undo.CreatedOutpoints[i]); err != nil {
@@ -102,13 +89,6 @@ func (c *Chain) revertBlock(undo *UndoData) error {
 		}
 	}
 
-	// Restore spent UTXOs.
-	for i := range undo.SpentUTXOs {
-		if err := c.utxos.Put(&undo.SpentUTXOs[i]); err != nil {
-			return fmt.Errorf("restore utxo %s: %w", undo.SpentUTXOs[i].Outpoint, err)
-		}
-	}
-
 	// Remove tx index entries.
 	for _, txHash := range undo.TxHashes {
 		if err := c.blocks.DeleteTxIndex(txHash); err != nil {
@@ -137,6 +117,16 @@ func (c *Chain) Reorg(newTipHash types.Hash) error {
 	forkHeight := newBranch[0].Header.Height - 1
 	oldHeight := c.state.Height
 
+	// Never reorg across a checkpoint.
+	if err := c.checkForkPoint(forkHeight); err != nil {
+		return err
+	}
+	for _, blk := range newBranch {
+		if err := c.checkCheckpoint(blk); err != nil {
+			return err
+		}
+	}
+
 	// Compare cumulative work (applies to both PoA and PoW).
 	// For PoA: in-turn blocks have Difficulty=2, out-of-turn have Difficulty=1,
 	// so the in-turn chain always wins. Equal work → keep current (no flip-flopping).
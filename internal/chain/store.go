@@ -7,6 +7,7 @@ import (
 
 	"github.com/Klingon-tech/klingnet-chain/internal/storage"
 	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
 	"github.com/Klingon-tech/klingnet-chain/pkg/types"
 )
 
@@ -104,6 +105,46 @@ func (bs *BlockStore) GetBlockByHeight(height uint64) (*block.Block, error) {
 	return bs.GetBlock(hash)
 }
 
+// HeightHash pairs a height with the hash of the block indexed at it.
+type HeightHash struct {
+	Height uint64     `json:"height"`
+	Hash   types.Hash `json:"hash"`
+}
+
+// HeightRange returns up to limit entries of the height index with
+// lo <= height <= hi, ascending, or descending from hi when reverse is set.
+// A limit of 0 returns the whole range. Only the index is read; blocks are
+// not loaded.
+func (bs *BlockStore) HeightRange(lo, hi uint64, limit int, reverse bool) ([]HeightHash, error) {
+	if lo > hi {
+		return nil, nil
+	}
+	opts := storage.IterOptions{Prefix: prefixHeight, Start: heightKey(lo), Reverse: reverse}
+	if hi < ^uint64(0) {
+		opts.End = heightKey(hi + 1)
+	}
+	it := bs.db.NewIterator(opts)
+	defer it.Close()
+
+	var out []HeightHash
+	for it.Next() {
+		key, val := it.Key(), it.Value()
+		if len(key) != len(prefixHeight)+8 || len(val) != types.HashSize {
+			return nil, fmt.Errorf("corrupt height index entry %x", key)
+		}
+		hh := HeightHash{Height: binary.BigEndian.Uint64(key[len(prefixHeight):])}
+		copy(hh.Hash[:], val)
+		out = append(out, hh)
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("scan height index: %w", err)
+	}
+	return out, nil
+}
+
 // HasBlock checks if a block exists by hash.
 func (bs *BlockStore) HasBlock(hash types.Hash) (bool, error) {
 	return bs.db.Has(blockKey(hash))
@@ -173,6 +214,24 @@ func (bs *BlockStore) GetTxLocation(txHash types.Hash) (uint64, types.Hash, erro
 	return height, blockHash, nil
 }
 
+// GetTransaction looks up a transaction on the active chain via the tx index.
+func (bs *BlockStore) GetTransaction(hash types.Hash) (*tx.Transaction, error) {
+	_, blockHash, err := bs.GetTxLocation(hash)
+	if err != nil {
+		return nil, err
+	}
+	blk, err := bs.GetBlock(blockHash)
+	if err != nil {
+		return nil, fmt.Errorf("load block for tx: %w", err)
+	}
+	for _, t := range blk.Transactions {
+		if t.Hash() == hash {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("tx %s not found in block %s (index corrupt)", hash, blockHash)
+}
+
 // DeleteTxIndex removes the transaction index entry for the given hash.
 func (bs *BlockStore) DeleteTxIndex(txHash types.Hash) error {
 	return bs.db.Delete(txKey(txHash))
@@ -0,0 +1,354 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingnet-chain/internal/storage"
+	"github.com/Klingon-tech/klingnet-chain/internal/utxo"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// Issue kinds reported by Verify.
+const (
+	IssueTip             = "tip"                   // Tip metadata missing or corrupt.
+	IssueMissingBlock    = "missing_block"         // Block on the tip's ancestry not stored.
+	IssueBlockHeight     = "block_height"          // Block header height disagrees with its position.
+	IssueHeightIndex     = "height_index"          // h/ entry missing or pointing at the wrong block.
+	IssueHeightExtra     = "height_index_extra"    // h/ entry above the tip.
+	IssueTxIndex         = "tx_index"              // x/ entry missing or pointing at the wrong block.
+	IssueTxIndexStale    = "tx_index_stale"        // x/ entry for a block not on the active chain.
+	IssueUndo            = "undo"                  // d/ record missing or disagreeing with the replay.
+	IssueReplay          = "replay"                // Block could not be applied to the replayed set.
+	IssueUTXO            = "utxo"                  // u/ entry missing, extra or different.
+	IssueAddrIndex       = "addr_index"            // a/ entry missing or extra.
+	IssueStakeIndex      = "stake_index"           // k/ entry missing or extra.
+	IssueCumDifficulty   = "cumulative_difficulty" // s/cumdiff disagrees with the block headers.
+	IssueReorgCheckpoint = "reorg_checkpoint"      // Interrupted reorg; the node rebuilds UTXOs on start.
+)
+
+// VerifyIssue describes a single inconsistency found by Verify.
+type VerifyIssue struct {
+	Kind     string `json:"kind"`
+	Key      string `json:"key,omitempty"` // Hex-encoded key within the chain's namespace.
+	Detail   string `json:"detail"`
+	Repaired bool   `json:"repaired"`
+}
+
+// VerifyReport is the result of verifying one chain's database.
+type VerifyReport struct {
+	TipHeight    uint64        `json:"tip_height"`
+	TipHash      types.Hash    `json:"tip_hash"`
+	Blocks       uint64        `json:"blocks"`
+	Transactions int           `json:"transactions"`
+	UTXOs        int           `json:"utxos"`
+	Issues       []VerifyIssue `json:"issues"`
+}
+
+// Unrepaired returns the number of issues that are still present.
+func (r *VerifyReport) Unrepaired() int {
+	n := 0
+	for _, is := range r.Issues {
+		if !is.Repaired {
+			n++
+		}
+	}
+	return n
+}
+
+// verifier accumulates issues and the writes that would repair them.
+type verifier struct {
+	db     storage.DB
+	report *VerifyReport
+	fixes  []verifyFix
+}
+
+type verifyFix struct {
+	key   []byte
+	value []byte // nil means delete
+	issue int    // index into report.Issues
+}
+
+// problem records an issue with no repair.
+func (v *verifier) problem(kind string, key []byte, format string, args ...interface{}) {
+	is := VerifyIssue{Kind: kind, Detail: fmt.Sprintf(format, args...)}
+	if key != nil {
+		is.Key = hex.EncodeToString(key)
+	}
+	v.report.Issues = append(v.report.Issues, is)
+}
+
+// fixable records an issue repaired by writing value at key (nil deletes).
+func (v *verifier) fixable(kind string, key, value []byte, format string, args ...interface{}) {
+	v.problem(kind, key, format, args...)
+	v.fixes = append(v.fixes, verifyFix{
+		key:   append([]byte(nil), key...),
+		value: value,
+		issue: len(v.report.Issues) - 1,
+	})
+}
+
+// Verify audits a chain database: it walks the active chain back from the
+// tip, replays every block from genesis into an in-memory UTXO set, and
+// checks the height and tx indexes, undo records, UTXO set and its address
+// and stake indexes against the replay. db is the chain's own namespace
+// (a PrefixDB for sub-chains).
+//
+// With repair set, every fixable issue is corrected in a single batch
+// where the engine supports it. Missing or misordered blocks cannot be
+// repaired and stop the audit early. The replayed UTXO set is held in
+// memory, so the node must not be running.
+func Verify(db storage.DB, repair bool) (*VerifyReport, error) {
+	v := &verifier{db: db, report: &VerifyReport{Issues: []VerifyIssue{}}}
+	bs := NewBlockStore(db)
+
+	tipHash, height, _, err := bs.GetTip()
+	if err != nil {
+		v.problem(IssueTip, keyTipHash, "%v", err)
+		return v.report, nil
+	}
+	if tipHash.IsZero() {
+		return v.report, nil // Empty chain.
+	}
+	v.report.TipHash, v.report.TipHeight = tipHash, height
+
+	// Walk the active chain back from the tip via PrevHash links.
+	hashes := make([]types.Hash, height+1)
+	hash := tipHash
+	for h := int64(height); h >= 0; h-- {
+		blk, err := bs.GetBlock(hash)
+		if err != nil {
+			v.problem(IssueMissingBlock, blockKey(hash), "block %s at height %d: %v", hash, h, err)
+			return v.report, nil
+		}
+		if blk.Header.Height != uint64(h) {
+			v.problem(IssueBlockHeight, blockKey(hash), "block %s has height %d, expected %d", hash, blk.Header.Height, h)
+			return v.report, nil
+		}
+		hashes[h] = hash
+		hash = blk.Header.PrevHash
+	}
+
+	if fork, ok := bs.GetReorgCheckpoint(); ok {
+		v.problem(IssueReorgCheckpoint, keyReorgCheckpoint,
+			"reorg from height %d was interrupted; the node rebuilds the UTXO set on start", fork)
+	}
+
+	// Replay from genesis, checking indexes and undo records per block.
+	mem := storage.NewMemory()
+	replay := &Chain{utxos: utxo.NewStore(mem)}
+	replayOK := true
+	var cumDiff uint64
+	for h := uint64(0); h <= height; h++ {
+		blk, err := bs.GetBlock(hashes[h])
+		if err != nil {
+			return nil, fmt.Errorf("reload block at height %d: %w", h, err)
+		}
+		blkHash := hashes[h]
+		v.report.Blocks++
+		v.report.Transactions += len(blk.Transactions)
+		cumDiff += blk.Header.Difficulty
+
+		if got, err := db.Get(heightKey(h)); err != nil || !bytes.Equal(got, blkHash[:]) {
+			v.fixable(IssueHeightIndex, heightKey(h), blkHash[:], "height %d should index block %s", h, blkHash)
+		}
+
+		loc := make([]byte, 8+types.HashSize)
+		binary.BigEndian.PutUint64(loc[:8], h)
+		copy(loc[8:], blkHash[:])
+		for _, t := range blk.Transactions {
+			txHash := t.Hash()
+			if got, err := db.Get(txKey(txHash)); err != nil || !bytes.Equal(got, loc) {
+				v.fixable(IssueTxIndex, txKey(txHash), loc, "tx %s should index block %s at height %d", txHash, blkHash, h)
+			}
+		}
+
+		if !replayOK {
+			continue
+		}
+		reward := replay.computeBlockReward(blk)
+		undo, err := replay.applyBlockWithUndo(blk)
+		if err != nil {
+			v.problem(IssueReplay, blockKey(blkHash), "replay block %s at height %d: %v", blkHash, h, err)
+			replayOK = false
+			continue
+		}
+		v.checkUndo(bs, h, blkHash, undo, reward)
+	}
+
+	// Height index entries above the tip.
+	if height < ^uint64(0) {
+		it := db.NewIterator(storage.IterOptions{Prefix: prefixHeight, Start: heightKey(height + 1)})
+		for it.Next() {
+			v.fixable(IssueHeightExtra, it.Key(), nil, "height index entry above tip %d", height)
+		}
+		it.Close()
+		if err := it.Err(); err != nil {
+			return nil, fmt.Errorf("scan height index: %w", err)
+		}
+	}
+
+	// Tx index entries for blocks off the active chain. The wallet history
+	// index shares the root "x/" prefix, so only entries shaped like a tx
+	// index record (32-byte hash key, height+hash value) are considered.
+	it := db.NewIterator(storage.IterOptions{Prefix: prefixTx})
+	for it.Next() {
+		key, val := it.Key(), it.Value()
+		if len(key) != len(prefixTx)+types.HashSize || len(val) != 8+types.HashSize || val[0] == '{' {
+			continue
+		}
+		h := binary.BigEndian.Uint64(val[:8])
+		if h <= height && bytes.Equal(val[8:], hashes[h][:]) {
+			continue
+		}
+		v.fixable(IssueTxIndexStale, key, nil, "tx index points at block %x (height %d) not on the active chain", val[8:], h)
+	}
+	it.Close()
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("scan tx index: %w", err)
+	}
+
+	if stored := bs.GetCumulativeDifficulty(); stored != cumDiff {
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], cumDiff)
+		v.fixable(IssueCumDifficulty, keyCumDifficulty, buf[:], "stored %d, headers sum to %d", stored, cumDiff)
+	}
+
+	// Compare the UTXO set and its secondary indexes with the replay. They
+	// are only meaningful if every block replayed.
+	if replayOK {
+		for _, p := range []struct {
+			kind   string
+			prefix string
+		}{{IssueUTXO, "u/"}, {IssueAddrIndex, "a/"}, {IssueStakeIndex, "k/"}} {
+			if err := v.diffPrefix(p.kind, []byte(p.prefix), mem); err != nil {
+				return nil, err
+			}
+		}
+		if err := mem.ForEach([]byte("u/"), func(_, _ []byte) error {
+			v.report.UTXOs++
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if repair && len(v.fixes) > 0 {
+		if err := v.apply(); err != nil {
+			return v.report, fmt.Errorf("repair: %w", err)
+		}
+	}
+	return v.report, nil
+}
+
+// checkUndo compares the stored undo record for a block with the replay.
+// The block reward is not compared: it depends on the max-supply cap,
+// which the verifier does not know, so repairs keep the stored value.
+func (v *verifier) checkUndo(bs *BlockStore, h uint64, blkHash types.Hash, replayed *UndoData, reward uint64) {
+	data, err := bs.GetUndo(blkHash)
+	if err != nil {
+		if h == 0 {
+			return // Genesis is applied without undo data.
+		}
+		replayed.BlockReward = reward
+		v.fixableUndo(blkHash, replayed, "missing undo record for block %s at height %d", blkHash, h)
+		return
+	}
+	var stored UndoData
+	if err := json.Unmarshal(data, &stored); err != nil {
+		replayed.BlockReward = reward
+		v.fixableUndo(blkHash, replayed, "corrupt undo record for block %s at height %d: %v", blkHash, h, err)
+		return
+	}
+	replayed.BlockReward = stored.BlockReward
+	want, _ := json.Marshal(replayed)
+	got, _ := json.Marshal(&stored)
+	if !bytes.Equal(want, got) {
+		v.fixableUndo(blkHash, replayed, "undo record for block %s at height %d disagrees with replay", blkHash, h)
+	}
+}
+
+func (v *verifier) fixableUndo(blkHash types.Hash, undo *UndoData, format string, args ...interface{}) {
+	data, err := json.Marshal(undo)
+	if err != nil {
+		v.problem(IssueUndo, undoKey(blkHash), format, args...)
+		return
+	}
+	v.fixable(IssueUndo, undoKey(blkHash), data, format, args...)
+}
+
+// diffPrefix merge-walks prefix in the database and in the replayed set,
+// recording every missing, extra or differing key.
+func (v *verifier) diffPrefix(kind string, prefix []byte, want storage.DB) error {
+	got := v.db.NewIterator(storage.IterOptions{Prefix: prefix})
+	defer got.Close()
+	exp := want.NewIterator(storage.IterOptions{Prefix: prefix})
+	defer exp.Close()
+
+	gotOK, expOK := got.Next(), exp.Next()
+	for gotOK || expOK {
+		var c int
+		switch {
+		case !gotOK:
+			c = 1
+		case !expOK:
+			c = -1
+		default:
+			c = bytes.Compare(got.Key(), exp.Key())
+		}
+		switch {
+		case c < 0:
+			v.fixable(kind, got.Key(), nil, "entry not in replayed set")
+			gotOK = got.Next()
+		case c > 0:
+			v.fixable(kind, exp.Key(), append([]byte{}, exp.Value()...), "entry missing")
+			expOK = exp.Next()
+		default:
+			if !bytes.Equal(got.Value(), exp.Value()) {
+				v.fixable(kind, exp.Key(), append([]byte{}, exp.Value()...), "entry differs from replayed set")
+			}
+			gotOK, expOK = got.Next(), exp.Next()
+		}
+	}
+	if err := got.Err(); err != nil {
+		return fmt.Errorf("scan %s: %w", prefix, err)
+	}
+	return exp.Err()
+}
+
+// apply writes all repairs, atomically when the engine supports batches.
+func (v *verifier) apply() error {
+	write := func(put func(k, val []byte) error, del func(k []byte) error) error {
+		for _, f := range v.fixes {
+			var err error
+			if f.value == nil {
+				err = del(f.key)
+			} else {
+				err = put(f.key, f.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if batcher, ok := v.db.(storage.Batcher); ok {
+		batch := batcher.NewBatch()
+		if err := write(batch.Put, batch.Delete); err != nil {
+			return err
+		}
+		if err := batch.Commit(); err != nil {
+			return err
+		}
+	} else if err := write(v.db.Put, v.db.Delete); err != nil {
+		return err
+	}
+	for _, f := range v.fixes {
+		v.report.Issues[f.issue].Repaired = true
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/Klingon-tech/klingnet-chain/internal/utxo"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+func TestVerify_Clean(t *testing.T) {
+	ch, validatorKey, _ := testChain(t)
+
+	genesisBlock, _ := ch.GetBlockByHeight(0)
+	prevOut := types.Outpoint{TxID: genesisBlock.Transactions[0].Hash(), Index: 0}
+	blk := buildSignedBlock(t, ch, validatorKey, validatorKey, prevOut, 4000)
+	if err := ch.ProcessBlock(blk); err != nil {
+		t.Fatalf("ProcessBlock: %v", err)
+	}
+
+	report, err := Verify(ch.db, false)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if len(report.Issues) != 0 {
+		t.Fatalf("issues on a clean chain: %+v", report.Issues)
+	}
+	if report.TipHeight != 1 || report.Blocks != 2 {
+		t.Errorf("tip height %d, blocks %d; want 1, 2", report.TipHeight, report.Blocks)
+	}
+	if report.UTXOs == 0 {
+		t.Error("expected a non-empty replayed UTXO set")
+	}
+}
+
+func TestVerify_ReportsAndRepairs(t *testing.T) {
+	ch, validatorKey, _ := testChain(t)
+
+	genesisBlock, _ := ch.GetBlockByHeight(0)
+	prevOut := types.Outpoint{TxID: genesisBlock.Transactions[0].Hash(), Index: 0}
+	blk := buildSignedBlock(t, ch, validatorKey, validatorKey, prevOut, 4000)
+	if err := ch.ProcessBlock(blk); err != nil {
+		t.Fatalf("ProcessBlock: %v", err)
+	}
+	db := ch.db
+
+	// Break one thing of every repairable kind.
+	db.Delete(heightKey(1))
+	db.Put(heightKey(7), genesisBlock.Hash().Bytes())
+	db.Delete(txKey(blk.Transactions[0].Hash()))
+	stale := make([]byte, 8+types.HashSize)
+	stale[8] = 0xff
+	db.Put(txKey(types.Hash{0xaa}), stale)
+	db.Delete(undoKey(blk.Hash()))
+	ch.blocks.SetCumulativeDifficulty(999)
+
+	var addrKeys [][]byte
+	db.ForEach([]byte("a/"), func(key, _ []byte) error {
+		addrKeys = append(addrKeys, append([]byte(nil), key...))
+		return nil
+	})
+	if len(addrKeys) == 0 {
+		t.Fatal("expected address index entries")
+	}
+	db.Delete(addrKeys[0])
+	utxo.NewStore(db).Put(&utxo.UTXO{
+		Outpoint: types.Outpoint{TxID: types.Hash{0xbb}},
+		Value:    1,
+		Script:   types.Script{Type: types.ScriptTypeP2PKH, Data: make([]byte, types.AddressSize)},
+	})
+
+	report, err := Verify(db, false)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	kinds := make(map[string]int)
+	for _, is := range report.Issues {
+		if is.Repaired {
+			t.Errorf("issue %+v marked repaired without --repair", is)
+		}
+		kinds[is.Kind]++
+	}
+	for _, k := range []string{
+		IssueHeightIndex, IssueHeightExtra, IssueTxIndex, IssueTxIndexStale,
+		IssueUndo, IssueCumDifficulty, IssueUTXO, IssueAddrIndex,
+	} {
+		if kinds[k] == 0 {
+			t.Errorf("no %s issue reported; got %v", k, kinds)
+		}
+	}
+
+	report, err = Verify(db, true)
+	if err != nil {
+		t.Fatalf("Verify repair: %v", err)
+	}
+	if n := report.Unrepaired(); n != 0 {
+		t.Fatalf("%d issues left unrepaired: %+v", n, report.Issues)
+	}
+
+	report, err = Verify(db, false)
+	if err != nil {
+		t.Fatalf("Verify after repair: %v", err)
+	}
+	if len(report.Issues) != 0 {
+		t.Errorf("issues after repair: %+v", report.Issues)
+	}
+	if _, err := ch.blocks.GetUndo(blk.Hash()); err != nil {
+		t.Errorf("undo record not restored: %v", err)
+	}
+}
+
+func TestVerify_MissingBlock(t *testing.T) {
+	ch, validatorKey, _ := testChain(t)
+
+	genesisBlock, _ := ch.GetBlockByHeight(0)
+	prevOut := types.Outpoint{TxID: genesisBlock.Transactions[0].Hash(), Index: 0}
+	blk := buildSignedBlock(t, ch, validatorKey, validatorKey, prevOut, 4000)
+	if err := ch.ProcessBlock(blk); err != nil {
+		t.Fatalf("ProcessBlock: %v", err)
+	}
+	ch.db.Delete(blockKey(genesisBlock.Hash()))
+
+	report, err := Verify(ch.db, true)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if len(report.Issues) != 1 || report.Issues[0].Kind != IssueMissingBlock || report.Issues[0].Repaired {
+		t.Errorf("issues = %+v, want one unrepaired %s", report.Issues, IssueMissingBlock)
+	}
+}
@@ -0,0 +1,126 @@
+package chain
+
+import (
+	"sync/atomic"
+
+	"github.com/Klingon-tech/klingnet-chain/internal/storage"
+	"github.com/Klingon-tech/klingnet-chain/internal/utxo"
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// View is a consistent, read-only snapshot of the chain at a single tip.
+// The tip state, block store and UTXO set all reflect the same block, so
+// queries never observe a half-applied block. Views are published by the
+// chain after every tip change and must be released by the reader.
+type View struct {
+	state  State
+	snap   storage.Snapshot
+	blocks *BlockStore
+	utxos  *utxo.Store
+	refs   atomic.Int64
+}
+
+// newView snapshots db and pairs it with the given tip state.
+// The returned view holds one reference owned by the chain.
+func newView(db storage.DB, state State) *View {
+	snap := storage.NewSnapshot(db)
+	ro := storage.NewReadOnly(snap)
+	v := &View{
+		state:  state,
+		snap:   snap,
+		blocks: NewBlockStore(ro),
+		utxos:  utxo.NewStore(ro),
+	}
+	v.refs.Store(1)
+	return v
+}
+
+// State returns the tip state captured by this view.
+func (v *View) State() State {
+	return v.state
+}
+
+// Height returns the tip height captured by this view.
+func (v *View) Height() uint64 {
+	return v.state.Height
+}
+
+// TipHash returns the tip hash captured by this view.
+func (v *View) TipHash() types.Hash {
+	return v.state.TipHash
+}
+
+// UTXOs returns a read-only UTXO store backed by the view's snapshot.
+func (v *View) UTXOs() *utxo.Store {
+	return v.utxos
+}
+
+// GetBlock retrieves a block by its hash.
+func (v *View) GetBlock(hash types.Hash) (*block.Block, error) {
+	return v.blocks.GetBlock(hash)
+}
+
+// GetBlockByHeight retrieves a block by its height on the view's chain.
+func (v *View) GetBlockByHeight(height uint64) (*block.Block, error) {
+	return v.blocks.GetBlockByHeight(height)
+}
+
+// HeightRange returns up to limit (height, hash) pairs of the view's chain
+// with lo <= height <= hi, clamped to the view's tip. See
+// BlockStore.HeightRange.
+func (v *View) HeightRange(lo, hi uint64, limit int, reverse bool) ([]HeightHash, error) {
+	if hi > v.state.Height {
+		hi = v.state.Height
+	}
+	return v.blocks.HeightRange(lo, hi, limit, reverse)
+}
+
+// GetTransaction looks up a confirmed transaction by hash.
+func (v *View) GetTransaction(hash types.Hash) (*tx.Transaction, error) {
+	return v.blocks.GetTransaction(hash)
+}
+
+// Release drops a reference to the view. The underlying snapshot is
+// released once the chain has published a newer view and every reader
+// has released theirs.
+func (v *View) Release() {
+	if v.refs.Add(-1) == 0 {
+		v.snap.Release()
+	}
+}
+
+// AcquireView returns the most recently published view with an extra
+// reference held for the caller, who must call Release when done.
+func (c *Chain) AcquireView() *View {
+	c.viewMu.RLock()
+	defer c.viewMu.RUnlock()
+	v := c.view
+	v.refs.Add(1)
+	return v
+}
+
+// RefreshView publishes a new view that includes writes made to the
+// database outside block processing (maintenance tools, test fixtures).
+func (c *Chain) RefreshView() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.publishView()
+}
+
+// publishView snapshots the current state and makes it the view returned
+// by AcquireView. Must be called with c.mu held, after all writes for the
+// new tip have been committed.
+func (c *Chain) publishView() {
+	v := newView(c.db, *c.state)
+
+	c.viewMu.Lock()
+	old := c.view
+	c.view = v
+	c.viewMu.Unlock()
+
+	if old != nil {
+		old.Release()
+	}
+}
@@ -0,0 +1,110 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+func TestChain_AcquireView_Genesis(t *testing.T) {
+	ch, _, _ := testChain(t)
+
+	v := ch.AcquireView()
+	defer v.Release()
+
+	if v.Height() != 0 {
+		t.Errorf("view height = %d, want 0", v.Height())
+	}
+	if v.TipHash() != ch.TipHash() {
+		t.Error("view tip should match chain tip after genesis")
+	}
+	if _, err := v.GetBlockByHeight(0); err != nil {
+		t.Errorf("view GetBlockByHeight(0): %v", err)
+	}
+}
+
+func TestChain_AcquireView_IsolatedFromNewBlocks(t *testing.T) {
+	ch, validatorKey, _ := testChain(t)
+
+	genesisBlock, _ := ch.GetBlockByHeight(0)
+	prevOut := types.Outpoint{TxID: genesisBlock.Transactions[0].Hash(), Index: 0}
+
+	// Hold a view across block processing.
+	old := ch.AcquireView()
+	defer old.Release()
+
+	blk := buildSignedBlock(t, ch, validatorKey, validatorKey, prevOut, 4000)
+	if err := ch.ProcessBlock(blk); err != nil {
+		t.Fatalf("ProcessBlock: %v", err)
+	}
+
+	// The old view still sees the genesis state: tip, blocks and UTXOs.
+	if old.Height() != 0 {
+		t.Errorf("old view height = %d, want 0", old.Height())
+	}
+	if _, err := old.GetBlockByHeight(1); err == nil {
+		t.Error("old view should not see block 1")
+	}
+	if ok, _ := old.UTXOs().Has(prevOut); !ok {
+		t.Error("old view should still see the genesis UTXO as unspent")
+	}
+
+	// A fresh view sees the new tip consistently.
+	cur := ch.AcquireView()
+	defer cur.Release()
+	if cur.Height() != 1 || cur.TipHash() != blk.Hash() {
+		t.Errorf("new view tip = %d/%s, want 1/%s", cur.Height(), cur.TipHash(), blk.Hash())
+	}
+	if ok, _ := cur.UTXOs().Has(prevOut); ok {
+		t.Error("new view should see the genesis UTXO as spent")
+	}
+	userTx := blk.Transactions[1]
+	if _, err := cur.GetTransaction(userTx.Hash()); err != nil {
+		t.Errorf("new view GetTransaction: %v", err)
+	}
+}
+
+func TestView_UTXOsReadOnly(t *testing.T) {
+	ch, _, _ := testChain(t)
+
+	v := ch.AcquireView()
+	defer v.Release()
+
+	if err := v.UTXOs().Delete(types.Outpoint{}); err == nil {
+		t.Error("view UTXO store should reject writes")
+	}
+}
+
+func TestView_HeightRange(t *testing.T) {
+	ch, validatorKey, _ := testChain(t)
+
+	genesisBlock, _ := ch.GetBlockByHeight(0)
+	prevOut := types.Outpoint{TxID: genesisBlock.Transactions[0].Hash(), Index: 0}
+	blk := buildSignedBlock(t, ch, validatorKey, validatorKey, prevOut, 4000)
+	if err := ch.ProcessBlock(blk); err != nil {
+		t.Fatalf("ProcessBlock: %v", err)
+	}
+
+	v := ch.AcquireView()
+	defer v.Release()
+
+	// hi is clamped to the tip.
+	got, err := v.HeightRange(0, 100, 0, false)
+	if err != nil {
+		t.Fatalf("HeightRange: %v", err)
+	}
+	if len(got) != 2 || got[0].Height != 0 || got[1].Height != 1 {
+		t.Fatalf("HeightRange(0, 100) = %v, want heights 0,1", got)
+	}
+	if got[0].Hash != genesisBlock.Hash() || got[1].Hash != blk.Hash() {
+		t.Error("HeightRange hashes do not match the stored blocks")
+	}
+
+	got, err = v.HeightRange(0, 1, 1, true)
+	if err != nil {
+		t.Fatalf("HeightRange reverse: %v", err)
+	}
+	if len(got) != 1 || got[0].Height != 1 {
+		t.Errorf("reverse HeightRange limit 1 = %v, want height 1", got)
+	}
+}
@@ -0,0 +1,198 @@
+package mempool
+
+import (
+	"fmt"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// Default limits on chains of unconfirmed transactions.
+const (
+	DefaultMaxAncestors      = 25
+	DefaultMaxAncestorSize   = 101_000
+	DefaultMaxDescendants    = 25
+	DefaultMaxDescendantSize = 101_000
+)
+
+// PackageLimits bounds chains of unconfirmed transactions. Counts and sizes
+// include the transaction itself; sizes are in signing bytes. Zero means no
+// limit.
+type PackageLimits struct {
+	MaxAncestors      int // In-pool ancestors of a transaction.
+	MaxAncestorSize   int
+	MaxDescendants    int // In-pool descendants of any ancestor.
+	MaxDescendantSize int
+}
+
+// DefaultPackageLimits returns the default ancestor/descendant limits.
+func DefaultPackageLimits() PackageLimits {
+	return PackageLimits{
+		MaxAncestors:      DefaultMaxAncestors,
+		MaxAncestorSize:   DefaultMaxAncestorSize,
+		MaxDescendants:    DefaultMaxDescendants,
+		MaxDescendantSize: DefaultMaxDescendantSize,
+	}
+}
+
+// poolView overlays the outputs of pool transactions on the chain UTXO set,
+// so a transaction can spend an unconfirmed output. Outputs already spent by
+// another pool transaction are caught by the conflict index, not here.
+// Must be used with p.mu held.
+type poolView struct {
+	p *Pool
+}
+
+// output returns the pool output for an outpoint, if any.
+func (v poolView) output(op types.Outpoint) (*tx.Output, bool) {
+	e, ok := v.p.txs[op.TxID]
+	if !ok || int(op.Index) >= len(e.tx.Outputs) {
+		return nil, false
+	}
+	return &e.tx.Outputs[op.Index], true
+}
+
+// GetUTXO implements tx.UTXOProvider.
+func (v poolView) GetUTXO(op types.Outpoint) (uint64, types.Script, error) {
+	if out, ok := v.output(op); ok {
+		return out.Value, out.Script, nil
+	}
+	return v.p.utxos.GetUTXO(op)
+}
+
+// HasUTXO implements tx.UTXOProvider.
+func (v poolView) HasUTXO(op types.Outpoint) bool {
+	if _, ok := v.output(op); ok {
+		return true
+	}
+	return v.p.utxos.HasUTXO(op)
+}
+
+// GetTokenData implements token.InputTokens.
+func (v poolView) GetTokenData(op types.Outpoint) *types.TokenData {
+	if out, ok := v.output(op); ok {
+		return out.Token
+	}
+	return v.p.tokenInputs.GetTokenData(op)
+}
+
+// ancestorsLocked returns all in-pool ancestors reachable from parents,
+// including the parents themselves. Must be called with p.mu held.
+func (p *Pool) ancestorsLocked(parents map[types.Hash]struct{}) map[types.Hash]*entry {
+	result := make(map[types.Hash]*entry)
+	stack := make([]types.Hash, 0, len(parents))
+	for h := range parents {
+		stack = append(stack, h)
+	}
+	for len(stack) > 0 {
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, seen := result[h]; seen {
+			continue
+		}
+		e, ok := p.txs[h]
+		if !ok {
+			continue
+		}
+		result[h] = e
+		for parent := range e.parents {
+			stack = append(stack, parent)
+		}
+	}
+	return result
+}
+
+// descendantsLocked returns all in-pool descendants of txHash, excluding
+// txHash itself. Must be called with p.mu held.
+func (p *Pool) descendantsLocked(txHash types.Hash) map[types.Hash]*entry {
+	result := make(map[types.Hash]*entry)
+	e, ok := p.txs[txHash]
+	if !ok {
+		return result
+	}
+	stack := make([]types.Hash, 0, len(e.children))
+	for h := range e.children {
+		stack = append(stack, h)
+	}
+	for len(stack) > 0 {
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, seen := result[h]; seen {
+			continue
+		}
+		child, ok := p.txs[h]
+		if !ok {
+			continue
+		}
+		result[h] = child
+		for grandchild := range child.children {
+			stack = append(stack, grandchild)
+		}
+	}
+	return result
+}
+
+// checkPackageLimitsLocked checks that adding a transaction of the given
+// size with the given in-pool ancestors stays within the pool's limits.
+// Entries in replaced are about to be evicted and are not counted.
+// Must be called with p.mu held.
+func (p *Pool) checkPackageLimitsLocked(size int, ancestors, replaced map[types.Hash]*entry) error {
+	l := p.limits
+	if l.MaxAncestors > 0 && len(ancestors)+1 > l.MaxAncestors {
+		return fmt.Errorf("%w: %d ancestors, max %d", ErrPackageLimit, len(ancestors)+1, l.MaxAncestors)
+	}
+	if l.MaxAncestorSize > 0 {
+		total := size
+		for _, a := range ancestors {
+			total += a.size
+		}
+		if total > l.MaxAncestorSize {
+			return fmt.Errorf("%w: ancestor size %d, max %d", ErrPackageLimit, total, l.MaxAncestorSize)
+		}
+	}
+	if l.MaxDescendants == 0 && l.MaxDescendantSize == 0 {
+		return nil
+	}
+	for h, a := range ancestors {
+		desc := p.descendantsLocked(h)
+		for r := range replaced {
+			delete(desc, r)
+		}
+		// The ancestor itself, its existing descendants and the new tx.
+		if l.MaxDescendants > 0 && len(desc)+2 > l.MaxDescendants {
+			return fmt.Errorf("%w: ancestor %s would have %d descendants, max %d", ErrPackageLimit, h, len(desc)+2, l.MaxDescendants)
+		}
+		if l.MaxDescendantSize > 0 {
+			total := a.size + size
+			for _, d := range desc {
+				total += d.size
+			}
+			if total > l.MaxDescendantSize {
+				return fmt.Errorf("%w: ancestor %s descendant size %d, max %d", ErrPackageLimit, h, total, l.MaxDescendantSize)
+			}
+		}
+	}
+	return nil
+}
+
+// packageLocked returns e and its ancestors not yet in selected, parents
+// before children. Must be called with p.mu held.
+func (p *Pool) packageLocked(e *entry, selected map[types.Hash]bool) []*entry {
+	var result []*entry
+	visited := make(map[types.Hash]bool)
+	var visit func(*entry)
+	visit = func(x *entry) {
+		if selected[x.txHash] || visited[x.txHash] {
+			return
+		}
+		visited[x.txHash] = true
+		for parent := range x.parents {
+			if pe, ok := p.txs[parent]; ok {
+				visit(pe)
+			}
+		}
+		result = append(result, x)
+	}
+	visit(e)
+	return result
+}
@@ -0,0 +1,302 @@
+package mempool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"sync"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// Fee estimation parameters.
+const (
+	// MaxEstimateTarget is the furthest confirmation target, in blocks.
+	MaxEstimateTarget = 48
+
+	// DefaultEstimateTarget is the confirmation target used when a caller
+	// does not ask for one.
+	DefaultEstimateTarget = 6
+
+	estimateDecay     = 0.998 // Per-block weight decay of past observations.
+	estimateSuccess   = 0.85  // Share that must confirm within the target.
+	estimateMinWeight = 2.0   // Observations needed before a bucket range counts.
+
+	bucketMinRate = 1.0
+	bucketMaxRate = 1e9
+	bucketSpacing = 1.25
+)
+
+// feeEstimatesVersion is the format version written by FeeEstimator.Save.
+const feeEstimatesVersion = 1
+
+// FeeEstimator estimates the fee rate needed to confirm within a number of
+// blocks, from how long past pool transactions took to be included.
+//
+// Transactions are grouped into exponentially spaced fee-rate buckets. For
+// every bucket it keeps a decaying count of confirmed transactions and, for
+// each target, how many of them confirmed within that many blocks.
+// Transactions still waiting past a target count against it.
+type FeeEstimator struct {
+	mu      sync.Mutex
+	bounds  []float64   // Upper fee rate of each bucket.
+	total   []float64   // Confirmed transactions per bucket.
+	feeSum  []float64   // Sum of their fee rates.
+	within  [][]float64 // [target-1][bucket]: confirmed within target blocks.
+	tracked map[types.Hash]trackedTx
+	height  uint64 // Last block processed.
+}
+
+// trackedTx is a pool transaction waiting for confirmation.
+type trackedTx struct {
+	bucket  int
+	feeRate float64
+	height  uint64 // Chain height when it entered the pool.
+}
+
+// NewFeeEstimator creates an empty fee estimator.
+func NewFeeEstimator() *FeeEstimator {
+	var bounds []float64
+	for r := bucketMinRate; r < bucketMaxRate; r *= bucketSpacing {
+		bounds = append(bounds, r)
+	}
+	bounds = append(bounds, math.Inf(1))
+
+	e := &FeeEstimator{
+		bounds:  bounds,
+		total:   make([]float64, len(bounds)),
+		feeSum:  make([]float64, len(bounds)),
+		within:  make([][]float64, MaxEstimateTarget),
+		tracked: make(map[types.Hash]trackedTx),
+	}
+	for i := range e.within {
+		e.within[i] = make([]float64, len(bounds))
+	}
+	return e
+}
+
+// SetFeeEstimator makes the pool feed e with the transactions it admits and
+// the blocks that confirm them. It needs the chain height set by
+// SetCoinbaseMaturity.
+func (p *Pool) SetFeeEstimator(e *FeeEstimator) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.estimator = e
+}
+
+// FeeEstimator returns the pool's fee estimator, or nil.
+func (p *Pool) FeeEstimator() *FeeEstimator {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.estimator
+}
+
+// EstimateFeeRate returns the fee rate to confirm within target blocks, never
+// below the pool minimum. The second result is false when the estimator has
+// too little data and the minimum is returned instead.
+func (p *Pool) EstimateFeeRate(target int) (uint64, bool) {
+	p.mu.RLock()
+	est, minRate := p.estimator, p.minFeeRate
+	p.mu.RUnlock()
+	if est == nil {
+		return minRate, false
+	}
+	rate, ok := est.Estimate(target)
+	if !ok {
+		return minRate, false
+	}
+	return max(rate, minRate), true
+}
+
+// bucketFor returns the bucket of a fee rate.
+func (e *FeeEstimator) bucketFor(feeRate float64) int {
+	lo, hi := 0, len(e.bounds)-1
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if feeRate <= e.bounds[mid] {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo
+}
+
+// track starts timing a transaction that entered the pool at height.
+// A transaction already tracked keeps its original height.
+func (e *FeeEstimator) track(txHash types.Hash, feeRate float64, height uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.tracked[txHash]; ok {
+		return
+	}
+	e.tracked[txHash] = trackedTx{bucket: e.bucketFor(feeRate), feeRate: feeRate, height: height}
+}
+
+// untrack stops timing a transaction that left the pool unconfirmed.
+func (e *FeeEstimator) untrack(txHash types.Hash) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.tracked, txHash)
+}
+
+// processBlock records the tracked transactions confirmed in the block at
+// height. Blocks at or below the last processed height are ignored.
+func (e *FeeEstimator) processBlock(height uint64, confirmed []types.Hash) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if height <= e.height {
+		return
+	}
+	e.height = height
+
+	for b := range e.total {
+		e.total[b] *= estimateDecay
+		e.feeSum[b] *= estimateDecay
+		for t := range e.within {
+			e.within[t][b] *= estimateDecay
+		}
+	}
+
+	for _, h := range confirmed {
+		t, ok := e.tracked[h]
+		if !ok {
+			continue
+		}
+		delete(e.tracked, h)
+		if t.height >= height {
+			continue // Entered the pool after the tip moved past it.
+		}
+		blocks := int(height - t.height)
+		e.total[t.bucket]++
+		e.feeSum[t.bucket] += t.feeRate
+		for target := blocks; target <= MaxEstimateTarget; target++ {
+			e.within[target-1][t.bucket]++
+		}
+	}
+}
+
+// Estimate returns the fee rate (base units per byte) at which at least
+// 85% of past transactions confirmed within target blocks, or false if
+// there is not enough data. target is clamped to [1, MaxEstimateTarget].
+func (e *FeeEstimator) Estimate(target int) (uint64, bool) {
+	if target < 1 {
+		target = 1
+	}
+	if target > MaxEstimateTarget {
+		target = MaxEstimateTarget
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// Transactions already waiting longer than target have missed it.
+	waiting := make([]float64, len(e.bounds))
+	for _, t := range e.tracked {
+		if e.height >= t.height+uint64(target) {
+			waiting[t.bucket]++
+		}
+	}
+
+	// Scan from the highest fee rate down, grouping buckets until they
+	// hold enough data; stop at the first group that fails.
+	var best float64
+	var within, attempts, confirmed, fees float64
+	for b := len(e.bounds) - 1; b >= 0; b-- {
+		within += e.within[target-1][b]
+		attempts += e.total[b] + waiting[b]
+		confirmed += e.total[b]
+		fees += e.feeSum[b]
+		if attempts < estimateMinWeight {
+			continue
+		}
+		if within/attempts < estimateSuccess {
+			break
+		}
+		if confirmed > 0 {
+			best = fees / confirmed
+		}
+		within, attempts, confirmed, fees = 0, 0, 0, 0
+	}
+	if best == 0 {
+		return 0, false
+	}
+	return uint64(math.Ceil(best)), true
+}
+
+// savedEstimates is the on-disk form of a FeeEstimator.
+type savedEstimates struct {
+	Version int         `json:"version"`
+	Buckets int         `json:"buckets"`
+	Total   []float64   `json:"total"`
+	FeeSum  []float64   `json:"fee_sum"`
+	Within  [][]float64 `json:"within"`
+}
+
+// Save writes the estimator's statistics to path, replacing it atomically.
+// Transactions still waiting are not saved; the pool tracks them again
+// when it is reloaded.
+func (e *FeeEstimator) Save(path string) error {
+	e.mu.Lock()
+	saved := savedEstimates{
+		Version: feeEstimatesVersion,
+		Buckets: len(e.bounds),
+		Total:   e.total,
+		FeeSum:  e.feeSum,
+		Within:  e.within,
+	}
+	data, err := json.Marshal(saved)
+	e.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("marshal fee estimates: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write fee estimates: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write fee estimates: %w", err)
+	}
+	return nil
+}
+
+// Load restores statistics written by Save. A missing file is not an
+// error; a file from a different bucket layout is rejected.
+func (e *FeeEstimator) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read fee estimates: %w", err)
+	}
+	var saved savedEstimates
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("parse fee estimates: %w", err)
+	}
+	if saved.Version != feeEstimatesVersion {
+		return fmt.Errorf("unsupported fee estimates version %d", saved.Version)
+	}
+
+	n := len(e.bounds)
+	if saved.Buckets != n || len(saved.Total) != n || len(saved.FeeSum) != n || len(saved.Within) != MaxEstimateTarget {
+		return fmt.Errorf("fee estimates have a different bucket layout")
+	}
+	for _, row := range saved.Within {
+		if len(row) != n {
+			return fmt.Errorf("fee estimates have a different bucket layout")
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.total = saved.Total
+	e.feeSum = saved.FeeSum
+	e.within = saved.Within
+	return nil
+}
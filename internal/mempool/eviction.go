@@ -1,13 +1,112 @@
 package mempool
 
-import "sort"
+import (
+	"fmt"
+	"sort"
 
-// Evict removes the lowest fee-rate transactions until the pool is at or below maxSize.
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// DefaultMaxBytes is the default pool budget in signing bytes (300 MB).
+const DefaultMaxBytes = 300_000_000
+
+// SetMaxBytes sets the pool budget in signing bytes (0 = unlimited).
+// Entries over the new budget are evicted by the next Add or Evict.
+func (p *Pool) SetMaxBytes(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.maxBytes = n
+}
+
+// Bytes returns the total signing bytes of the pool's transactions.
+func (p *Pool) Bytes() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.totalBytes
+}
+
+// MaxBytes returns the pool budget in signing bytes (0 = unlimited).
+func (p *Pool) MaxBytes() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.maxBytes
+}
+
+// fullLocked reports whether count transactions totalling size bytes
+// exceed the pool's limits. Must be called with p.mu held.
+func (p *Pool) fullLocked(count, size int) bool {
+	return count > p.maxSize || (p.maxBytes > 0 && size > p.maxBytes)
+}
+
+// planEvictionLocked returns the entries to evict, lowest fee rate first,
+// so that n new transactions of the given total size fit. Each evicted
+// entry takes its descendants with it. Entries in keep (the new
+// transactions' ancestors) and replaced are never chosen. Returns
+// ErrPoolFull if room could only be made by evicting something paying at
+// least feeRate. Must be called with p.mu held.
+func (p *Pool) planEvictionLocked(n, size int, feeRate float64, keep, replaced map[types.Hash]*entry) ([]types.Hash, error) {
+	count := len(p.txs) - len(replaced) + n
+	total := p.totalBytes + size
+	for _, r := range replaced {
+		total -= r.size
+	}
+	if !p.fullLocked(count, total) {
+		return nil, nil
+	}
+	if p.maxBytes > 0 && size > p.maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes exceeds pool budget", ErrPoolFull, size)
+	}
+
+	candidates := make([]*entry, 0, len(p.txs))
+	for h, e := range p.txs {
+		_, k := keep[h]
+		_, r := replaced[h]
+		if !k && !r {
+			candidates = append(candidates, e)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].feeRate < candidates[j].feeRate
+	})
+
+	var evict []types.Hash
+	gone := make(map[types.Hash]bool)
+	for _, c := range candidates {
+		if !p.fullLocked(count, total) {
+			break
+		}
+		if gone[c.txHash] {
+			continue
+		}
+		if c.feeRate >= feeRate {
+			return nil, ErrPoolFull
+		}
+		evict = append(evict, c.txHash)
+		group := p.descendantsLocked(c.txHash)
+		group[c.txHash] = c
+		for h, e := range group {
+			if _, r := replaced[h]; r || gone[h] {
+				continue
+			}
+			gone[h] = true
+			count--
+			total -= e.size
+		}
+	}
+	if p.fullLocked(count, total) {
+		return nil, ErrPoolFull
+	}
+	return evict, nil
+}
+
+// Evict removes the lowest fee-rate transactions until the pool is within
+// its count and byte limits. Descendants of an evicted transaction are
+// evicted with it.
 func (p *Pool) Evict() int {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if len(p.txs) <= p.maxSize {
+	if !p.fullLocked(len(p.txs), p.totalBytes) {
 		return 0
 	}
 
@@ -21,9 +120,9 @@ func (p *Pool) Evict() int {
 	})
 
 	evicted := 0
-	for len(p.txs) > p.maxSize && evicted < len(entries) {
-		p.removeLocked(entries[evicted].txHash)
-		evicted++
+	for i := 0; p.fullLocked(len(p.txs), p.totalBytes) && i < len(entries); i++ {
+		evicted += p.removeLocked(entries[i].txHash)
 	}
+	p.removed.Evicted += uint64(evicted)
 	return evicted
 }
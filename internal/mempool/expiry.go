@@ -0,0 +1,98 @@
+package mempool
+
+import (
+	"time"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// DefaultMaxAge is how long a transaction may stay in the pool (two weeks).
+const DefaultMaxAge = 14 * 24 * time.Hour
+
+// RemovalStats counts transactions removed from the pool, by reason.
+// Descendants removed along with a transaction count under the same reason.
+type RemovalStats struct {
+	Confirmed uint64 // Included in a connected block.
+	Conflict  uint64 // Double-spent an input of a connected block.
+	Expired   uint64 // Older than the maximum age.
+	Evicted   uint64 // Pushed out by the count or byte limit.
+	Replaced  uint64 // Replaced by fee.
+	Invalid   uint64 // No longer valid after a reorg.
+}
+
+// Removals returns the removal counters since the pool was created.
+func (p *Pool) Removals() RemovalStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.removed
+}
+
+// SetMaxAge sets how long transactions may stay in the pool (0 = forever).
+func (p *Pool) SetMaxAge(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.maxAge = d
+}
+
+// MaxAge returns the maximum transaction age (0 = forever).
+func (p *Pool) MaxAge() time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.maxAge
+}
+
+// Expire removes transactions first seen more than the maximum age before
+// now, with their descendants. RemoveConfirmed does this on every block.
+func (p *Pool) Expire(now time.Time) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.expireLocked(now)
+}
+
+// expireLocked implements Expire. Must be called with p.mu held.
+func (p *Pool) expireLocked(now time.Time) int {
+	if p.maxAge <= 0 {
+		return 0
+	}
+	cutoff := now.Add(-p.maxAge)
+	var old []*entry
+	for _, e := range p.txs {
+		if e.added.Before(cutoff) {
+			old = append(old, e)
+		}
+	}
+	removed := 0
+	for _, e := range old {
+		removed += p.removeLocked(e.txHash)
+	}
+	p.removed.Expired += uint64(removed)
+	return removed
+}
+
+// Revalidate re-checks every pool transaction against the current chain,
+// parents first, and drops those that no longer pass Add — for example
+// after a reorg removed the outputs they spend. First-seen times are kept.
+// The indexes are rebuilt from scratch with the lock held throughout, so
+// readers and concurrent Adds see either the old pool or the new one.
+// Returns the number of transactions dropped.
+func (p *Pool) Revalidate() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	saved := p.snapshotLocked()
+	p.txs = make(map[types.Hash]*entry, len(saved))
+	p.spends = make(map[types.Outpoint]types.Hash)
+	p.totalBytes = 0
+
+	_, dropped := p.reinsertLocked(saved)
+
+	p.removed.Invalid += uint64(dropped)
+	if p.estimator != nil {
+		for _, s := range saved {
+			if h := s.Tx.Hash(); p.txs[h] == nil {
+				p.estimator.untrack(h)
+			}
+		}
+	}
+	return dropped
+}
@@ -0,0 +1,208 @@
+package mempool
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// Orphan pool limits.
+const (
+	DefaultMaxOrphans = 100
+	MaxOrphanTxSize   = 10_000 // Signing bytes; larger orphans are not kept.
+	OrphanTTL         = 20 * time.Minute
+)
+
+// orphan is a relayed transaction waiting for a parent.
+type orphan struct {
+	tx    *tx.Transaction
+	from  string // Relaying peer.
+	added time.Time
+}
+
+// OrphanRejected is an orphan that Resolve found invalid once its parents
+// arrived.
+type OrphanRejected struct {
+	Tx   *tx.Transaction
+	From string // Relaying peer.
+	Err  error
+}
+
+// OrphanPool holds relayed transactions whose inputs are unknown
+// (ErrMissingInputs) until their parents arrive. It is bounded in count,
+// transaction size and age; the oldest orphan is evicted when full.
+// Orphans are keyed by FullHash, so copies of a transaction with
+// different signatures are held apart.
+type OrphanPool struct {
+	mu       sync.Mutex
+	max      int
+	orphans  map[types.Hash]*orphan
+	byParent map[types.Hash]map[types.Hash]struct{} // Parent txid -> FullHash of orphans spending it.
+}
+
+// NewOrphanPool creates an orphan pool holding at most max transactions.
+func NewOrphanPool(max int) *OrphanPool {
+	if max <= 0 {
+		max = DefaultMaxOrphans
+	}
+	return &OrphanPool{
+		max:      max,
+		orphans:  make(map[types.Hash]*orphan),
+		byParent: make(map[types.Hash]map[types.Hash]struct{}),
+	}
+}
+
+// Add stores an orphan relayed by from. Returns false if it is already
+// held or too large.
+func (o *OrphanPool) Add(t *tx.Transaction, from string) bool {
+	if len(t.SigningBytes()) > MaxOrphanTxSize {
+		return false
+	}
+	h := t.FullHash()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.orphans[h]; ok {
+		return false
+	}
+	now := time.Now()
+	o.expireLocked(now)
+	for len(o.orphans) >= o.max {
+		o.removeLocked(o.oldestLocked())
+	}
+
+	o.orphans[h] = &orphan{tx: t, from: from, added: now}
+	for _, in := range t.Inputs {
+		if in.PrevOut.IsZero() {
+			continue
+		}
+		children := o.byParent[in.PrevOut.TxID]
+		if children == nil {
+			children = make(map[types.Hash]struct{})
+			o.byParent[in.PrevOut.TxID] = children
+		}
+		children[h] = struct{}{}
+	}
+	return true
+}
+
+// Has reports whether a transaction is held as an orphan, by FullHash.
+func (o *OrphanPool) Has(fullHash types.Hash) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, ok := o.orphans[fullHash]
+	return ok
+}
+
+// Count returns the number of orphans held.
+func (o *OrphanPool) Count() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.orphans)
+}
+
+// RemoveFrom drops every orphan relayed by from, returning how many.
+func (o *OrphanPool) RemoveFrom(from string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	removed := 0
+	for h, orph := range o.orphans {
+		if orph.from == from {
+			o.removeLocked(h)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Expire drops orphans older than OrphanTTL, returning how many.
+func (o *OrphanPool) Expire(now time.Time) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.expireLocked(now)
+}
+
+func (o *OrphanPool) expireLocked(now time.Time) int {
+	removed := 0
+	for h, orph := range o.orphans {
+		if now.Sub(orph.added) > OrphanTTL {
+			o.removeLocked(h)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Resolve retries the orphans spending any of parents, which have just
+// entered the pool or been confirmed. Accepted orphans are retried as
+// parents in turn. Orphans still missing inputs stay; any other rejection
+// drops them. Returns the accepted and the rejected orphans.
+func (o *OrphanPool) Resolve(pool *Pool, parents []types.Hash) ([]*tx.Transaction, []OrphanRejected) {
+	var accepted []*tx.Transaction
+	var rejected []OrphanRejected
+	queue := append([]types.Hash(nil), parents...)
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+
+		o.mu.Lock()
+		var candidates []*orphan
+		for h := range o.byParent[parent] {
+			candidates = append(candidates, o.orphans[h])
+		}
+		o.mu.Unlock()
+
+		for _, orph := range candidates {
+			h := orph.tx.FullHash()
+			_, err := pool.Add(orph.tx)
+			if errors.Is(err, ErrMissingInputs) {
+				continue
+			}
+			o.mu.Lock()
+			_, held := o.orphans[h]
+			o.removeLocked(h)
+			o.mu.Unlock()
+			if !held {
+				continue // Already resolved through another parent.
+			}
+			if err != nil {
+				rejected = append(rejected, OrphanRejected{Tx: orph.tx, From: orph.from, Err: err})
+				continue
+			}
+			accepted = append(accepted, orph.tx)
+			queue = append(queue, orph.tx.Hash())
+		}
+	}
+	return accepted, rejected
+}
+
+// oldestLocked returns the hash of the longest-held orphan.
+func (o *OrphanPool) oldestLocked() types.Hash {
+	var oldest types.Hash
+	var oldestAt time.Time
+	for h, orph := range o.orphans {
+		if oldestAt.IsZero() || orph.added.Before(oldestAt) {
+			oldest, oldestAt = h, orph.added
+		}
+	}
+	return oldest
+}
+
+// removeLocked drops an orphan and its parent index entries.
+func (o *OrphanPool) removeLocked(txHash types.Hash) {
+	orph, ok := o.orphans[txHash]
+	if !ok {
+		return
+	}
+	delete(o.orphans, txHash)
+	for _, in := range orph.tx.Inputs {
+		children := o.byParent[in.PrevOut.TxID]
+		delete(children, txHash)
+		if len(children) == 0 {
+			delete(o.byParent, in.PrevOut.TxID)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package mempool
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// MaxPackageTxs is the maximum number of transactions in a submitted package.
+const MaxPackageTxs = DefaultMaxAncestors
+
+// ErrInvalidPackage is returned for a malformed transaction package.
+var ErrInvalidPackage = errors.New("invalid package")
+
+// AddPackage atomically adds a package of related transactions, parents
+// before children. The minimum fee rate applies to the package as a whole
+// rather than to each transaction, so a child can pay for a parent that
+// would be rejected on its own. Transactions already in the pool are
+// skipped and do not count towards the package fee rate. Packages never
+// replace existing entries; like Add, they evict lower fee-rate entries
+// when the pool is full, compared against the package fee rate. Returns
+// the fee of each transaction.
+func (p *Pool) AddPackage(txs []*tx.Transaction) ([]uint64, error) {
+	return p.addPackage(txs, false)
+}
+
+// addPackage is AddPackage; restored packages are exempt from the
+// per-address rate limit.
+func (p *Pool) addPackage(txs []*tx.Transaction, restored bool) ([]uint64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.addPackageLocked(txs, restored)
+}
+
+// addPackageLocked implements addPackage. Must be called with p.mu held.
+func (p *Pool) addPackageLocked(txs []*tx.Transaction, restored bool) ([]uint64, error) {
+	if len(txs) == 0 {
+		return nil, fmt.Errorf("%w: empty", ErrInvalidPackage)
+	}
+	if len(txs) > MaxPackageTxs {
+		return nil, fmt.Errorf("%w: %d transactions, max %d", ErrInvalidPackage, len(txs), MaxPackageTxs)
+	}
+
+	// Parents must come before the children spending them.
+	index := make(map[types.Hash]int, len(txs))
+	for i, t := range txs {
+		h := t.Hash()
+		if _, dup := index[h]; dup {
+			return nil, fmt.Errorf("%w: duplicate transaction %s", ErrInvalidPackage, h)
+		}
+		index[h] = i
+	}
+	for i, t := range txs {
+		for _, in := range t.Inputs {
+			if j, ok := index[in.PrevOut.TxID]; ok && j >= i {
+				return nil, fmt.Errorf("%w: transaction %d spends transaction %d, parents must come first", ErrInvalidPackage, i, j)
+			}
+		}
+	}
+
+	fees := make([]uint64, len(txs))
+	added := make([]types.Hash, 0, len(txs))
+	rollback := func() {
+		for i := len(added) - 1; i >= 0; i-- {
+			p.removeLocked(added[i])
+		}
+	}
+
+	var pkgFee uint64
+	var pkgSize int
+	for i, t := range txs {
+		h := t.Hash()
+		if e, exists := p.txs[h]; exists {
+			fees[i] = e.fee
+			continue
+		}
+		fee, err := p.addLocked(t, true, restored)
+		if err != nil {
+			rollback()
+			return nil, fmt.Errorf("transaction %d (%s): %w", i, h, err)
+		}
+		added = append(added, h)
+		fees[i] = fee
+		pkgFee += fee
+		pkgSize += p.txs[h].size
+	}
+
+	if p.minFeeRate > 0 {
+		requiredFee := p.minFeeRate * uint64(pkgSize)
+		if pkgFee < requiredFee {
+			rollback()
+			return nil, fmt.Errorf("%w: package pays %d, need %d (%d bytes × %d rate)", ErrFeeTooLow, pkgFee, requiredFee, pkgSize, p.minFeeRate)
+		}
+	}
+
+	// Check pool capacity for the package as a whole. Its members are
+	// already in the pool, so plan for no further additions; neither the
+	// members nor their ancestors are evicted for it.
+	if len(added) > 0 {
+		keep := make(map[types.Hash]*entry)
+		for _, h := range added {
+			keep[h] = p.txs[h]
+			for ah, a := range p.ancestorsLocked(p.txs[h].parents) {
+				keep[ah] = a
+			}
+		}
+		if p.maxBytes > 0 && pkgSize > p.maxBytes {
+			rollback()
+			return nil, fmt.Errorf("%w: package of %d bytes exceeds pool budget", ErrPoolFull, pkgSize)
+		}
+		evict, err := p.planEvictionLocked(0, 0, float64(pkgFee)/float64(pkgSize), keep, nil)
+		if err != nil {
+			rollback()
+			return nil, err
+		}
+		for _, h := range evict {
+			p.removed.Evicted += uint64(p.removeLocked(h))
+		}
+	}
+	return fees, nil
+}
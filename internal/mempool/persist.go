@@ -0,0 +1,199 @@
+package mempool
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// savedPoolVersion is the format version written by Save.
+const savedPoolVersion = 1
+
+// savedPool is the on-disk form of the mempool.
+type savedPool struct {
+	Version      int       `json:"version"`
+	Transactions []savedTx `json:"transactions"` // Parents before children.
+}
+
+// savedTx is a saved mempool transaction.
+type savedTx struct {
+	Tx        *tx.Transaction `json:"tx"`
+	Fee       uint64          `json:"fee"`
+	FirstSeen time.Time       `json:"first_seen"`
+}
+
+// Save writes the pool's transactions to path, parents before children.
+// The file is replaced atomically. Returns the number of transactions saved.
+func (p *Pool) Save(path string) (int, error) {
+	p.mu.RLock()
+	saved := savedPool{
+		Version:      savedPoolVersion,
+		Transactions: p.snapshotLocked(),
+	}
+	p.mu.RUnlock()
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return 0, fmt.Errorf("marshal mempool: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return 0, fmt.Errorf("write mempool: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("write mempool: %w", err)
+	}
+	return len(saved.Transactions), nil
+}
+
+// snapshotLocked returns the pool's transactions oldest first, each
+// preceded by its ancestors. Must be called with p.mu held.
+func (p *Pool) snapshotLocked() []savedTx {
+	entries := make([]*entry, 0, len(p.txs))
+	for _, e := range p.txs {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].added.Equal(entries[j].added) {
+			return entries[i].added.Before(entries[j].added)
+		}
+		return bytes.Compare(entries[i].txHash[:], entries[j].txHash[:]) < 0
+	})
+	result := make([]savedTx, 0, len(entries))
+	done := make(map[types.Hash]bool, len(entries))
+	for _, e := range entries {
+		for _, pe := range p.packageLocked(e, done) {
+			done[pe.txHash] = true
+			result = append(result, savedTx{
+				Tx:        pe.tx,
+				Fee:       pe.fee,
+				FirstSeen: pe.added,
+			})
+		}
+	}
+	return result
+}
+
+// Load re-adds the transactions saved at path, validating each one against
+// the current chain as Add does. Transactions that are now confirmed,
+// invalid or conflicting are dropped. A missing file is not an error.
+// Returns the number of transactions loaded and dropped.
+func (p *Pool) Load(path string) (loaded, dropped int, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("read mempool: %w", err)
+	}
+	var saved savedPool
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return 0, 0, fmt.Errorf("parse mempool: %w", err)
+	}
+	if saved.Version != savedPoolVersion {
+		return 0, 0, fmt.Errorf("unsupported mempool file version %d", saved.Version)
+	}
+
+	loaded, dropped = p.reinsert(saved.Transactions)
+	return loaded, dropped, nil
+}
+
+// reinsert adds saved transactions through Add, restoring their first-seen
+// times and skipping the per-address rate limit. A transaction rejected
+// only for its fee is retried as a package with the first descendant that
+// spends it. Returns the number added and dropped.
+func (p *Pool) reinsert(saved []savedTx) (loaded, dropped int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.reinsertLocked(saved)
+}
+
+// reinsertLocked implements reinsert. Transactions already in the pool
+// count as neither loaded nor dropped. Must be called with p.mu held.
+func (p *Pool) reinsertLocked(saved []savedTx) (loaded, dropped int) {
+	order := make(map[types.Hash]int, len(saved))
+	firstSeen := make(map[types.Hash]time.Time, len(saved))
+	for i, s := range saved {
+		if s.Tx != nil {
+			h := s.Tx.Hash()
+			order[h] = i
+			firstSeen[h] = s.FirstSeen
+		}
+	}
+
+	// Rejected for their fee alone; they may still get in with a child.
+	lowFee := make(map[types.Hash]*tx.Transaction)
+	for _, s := range saved {
+		if s.Tx == nil {
+			dropped++
+			continue
+		}
+		h := s.Tx.Hash()
+		pkg := lowFeeAncestors(s.Tx, lowFee, order)
+		var err error
+		if len(pkg) == 0 {
+			_, err = p.addLocked(s.Tx, false, true)
+		} else {
+			_, err = p.addPackageLocked(append(pkg, s.Tx), true)
+		}
+		switch {
+		case errors.Is(err, ErrAlreadyExists):
+		case err == nil:
+			for _, a := range pkg {
+				delete(lowFee, a.Hash())
+			}
+			loaded += len(pkg) + 1
+		case errors.Is(err, ErrFeeTooLow):
+			lowFee[h] = s.Tx
+		default:
+			dropped++
+		}
+	}
+	dropped += len(lowFee)
+
+	for h, t := range firstSeen {
+		if e, ok := p.txs[h]; ok && !t.IsZero() {
+			e.added = t
+		}
+	}
+	return loaded, dropped
+}
+
+// lowFeeAncestors returns the transactions in lowFee that t spends,
+// directly or through each other, in saved order.
+func lowFeeAncestors(t *tx.Transaction, lowFee map[types.Hash]*tx.Transaction, order map[types.Hash]int) []*tx.Transaction {
+	found := make(map[types.Hash]*tx.Transaction)
+	stack := []*tx.Transaction{t}
+	for len(stack) > 0 {
+		cur := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, in := range cur.Inputs {
+			parent, ok := lowFee[in.PrevOut.TxID]
+			if !ok {
+				continue
+			}
+			if _, seen := found[in.PrevOut.TxID]; seen {
+				continue
+			}
+			found[in.PrevOut.TxID] = parent
+			stack = append(stack, parent)
+		}
+	}
+	result := make([]*tx.Transaction, 0, len(found))
+	for _, a := range found {
+		result = append(result, a)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return order[result[i].Hash()] < order[result[j].Hash()]
+	})
+	return result
+}
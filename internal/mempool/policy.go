@@ -1,18 +1,40 @@
 package mempool
 
 import (
+	"container/list"
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/Klingon-tech/klingnet-chain/config"
 	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
 )
 
 // DefaultMaxTxSize is the maximum transaction size in bytes (signing bytes).
 const DefaultMaxTxSize = 100_000
 
+// Policy errors. Transactions rejected with these are valid in blocks but
+// not standard on this node.
+var (
+	ErrNonStandard  = errors.New("non-standard transaction")
+	ErrDust         = errors.New("output below dust threshold")
+	ErrScriptData   = errors.New("script data exceeds standard size")
+	ErrScriptType   = errors.New("script type not allowed")
+	ErrTokenOutputs = errors.New("too many token outputs")
+	ErrAddressRate  = errors.New("address transaction rate exceeded")
+	ErrPolicyRule   = errors.New("rejected by policy rule")
+)
+
 // Policy defines transaction acceptance rules.
 type Policy struct {
-	MaxTxSize int // Maximum transaction size in signing bytes.
+	MaxTxSize       int                // Maximum transaction size in signing bytes.
+	DustThreshold   uint64             // Minimum value of a native P2PKH/P2SH output (0 = none).
+	MaxScriptData   int                // Maximum script data bytes per output (0 = consensus limit).
+	MaxTokenOutputs int                // Maximum token-carrying outputs (0 = unlimited).
+	AllowedScripts  []types.ScriptType // Output script types accepted (empty = all).
+	AddressRate     int                // Transactions per spending address per minute (0 = unlimited).
 }
 
 // DefaultPolicy returns a policy with sensible defaults.
@@ -28,18 +50,199 @@ func DefaultPolicy() *Policy {
 func (p *Policy) Check(transaction *tx.Transaction) error {
 	size := len(transaction.SigningBytes())
 	if p.MaxTxSize > 0 && size > p.MaxTxSize {
-		return fmt.Errorf("transaction too large: %d bytes, max %d", size, p.MaxTxSize)
+		return fmt.Errorf("%w: transaction too large: %d bytes, max %d", ErrNonStandard, size, p.MaxTxSize)
 	}
 	if len(transaction.Inputs) > config.MaxTxInputs {
-		return fmt.Errorf("too many inputs: %d, max %d", len(transaction.Inputs), config.MaxTxInputs)
+		return fmt.Errorf("%w: too many inputs: %d, max %d", ErrValidation, len(transaction.Inputs), config.MaxTxInputs)
 	}
 	if len(transaction.Outputs) > config.MaxTxOutputs {
-		return fmt.Errorf("too many outputs: %d, max %d", len(transaction.Outputs), config.MaxTxOutputs)
+		return fmt.Errorf("%w: too many outputs: %d, max %d", ErrValidation, len(transaction.Outputs), config.MaxTxOutputs)
 	}
+
+	tokenOutputs := 0
 	for i, out := range transaction.Outputs {
 		if len(out.Script.Data) > config.MaxScriptData {
-			return fmt.Errorf("output %d script data too large: %d bytes, max %d", i, len(out.Script.Data), config.MaxScriptData)
+			return fmt.Errorf("%w: output %d script data too large: %d bytes, max %d", ErrValidation, i, len(out.Script.Data), config.MaxScriptData)
+		}
+		if p.MaxScriptData > 0 && len(out.Script.Data) > p.MaxScriptData {
+			return fmt.Errorf("%w: output %d has %d bytes, max %d", ErrScriptData, i, len(out.Script.Data), p.MaxScriptData)
+		}
+		if len(p.AllowedScripts) > 0 && !slices.Contains(p.AllowedScripts, out.Script.Type) {
+			return fmt.Errorf("%w: output %d is %s", ErrScriptType, i, out.Script.Type)
+		}
+		if out.Token != nil {
+			tokenOutputs++
+			continue
+		}
+		spendable := out.Script.Type == types.ScriptTypeP2PKH || out.Script.Type == types.ScriptTypeP2SH
+		if spendable && out.Value < p.DustThreshold {
+			return fmt.Errorf("%w: output %d pays %d, min %d", ErrDust, i, out.Value, p.DustThreshold)
 		}
 	}
+	if p.MaxTokenOutputs > 0 && tokenOutputs > p.MaxTokenOutputs {
+		return fmt.Errorf("%w: %d, max %d", ErrTokenOutputs, tokenOutputs, p.MaxTokenOutputs)
+	}
 	return nil
 }
+
+// PolicyRule is an extra acceptance rule registered by a node embedder
+// with Pool.AddPolicyRule. Check runs after consensus validation, with
+// inputs resolvable through utxos (chain outputs plus pool outputs); a
+// non-nil error rejects the transaction with ErrPolicyRule.
+type PolicyRule interface {
+	Name() string
+	Check(transaction *tx.Transaction, utxos tx.UTXOProvider) error
+}
+
+// SetPolicy sets the standardness rules applied in Add. A nil policy
+// restores DefaultPolicy.
+func (p *Pool) SetPolicy(policy *Policy) {
+	if policy == nil {
+		policy = DefaultPolicy()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.policy = policy
+	p.addrLimiter = nil
+	if policy.AddressRate > 0 {
+		p.addrLimiter = newAddressLimiter(policy.AddressRate)
+	}
+}
+
+// Policy returns the standardness rules applied in Add.
+func (p *Pool) Policy() *Policy {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.policy
+}
+
+// AddPolicyRule registers an extra acceptance rule, checked in Add after
+// the built-in policy in registration order.
+func (p *Pool) AddPolicyRule(rule PolicyRule) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rules = append(p.rules, rule)
+}
+
+// checkRulesLocked runs the registered policy rules.
+// Must be called with p.mu held.
+func (p *Pool) checkRulesLocked(transaction *tx.Transaction, view tx.UTXOProvider) error {
+	for _, rule := range p.rules {
+		if err := rule.Check(transaction, view); err != nil {
+			return fmt.Errorf("%w %s: %v", ErrPolicyRule, rule.Name(), err)
+		}
+	}
+	return nil
+}
+
+// spenders returns the addresses whose outputs a transaction spends.
+func spenders(transaction *tx.Transaction, view tx.UTXOProvider) []types.Address {
+	var addrs []types.Address
+	for _, in := range transaction.Inputs {
+		if in.PrevOut.IsZero() {
+			continue
+		}
+		_, script, err := view.GetUTXO(in.PrevOut)
+		if err != nil || len(script.Data) != types.AddressSize {
+			continue
+		}
+		addr := types.Address(script.Data)
+		if !slices.Contains(addrs, addr) {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// addressLimiter limits how many transactions spending each address are
+// accepted. Every address gets a token bucket holding rate tokens that
+// refills at rate per minute. It is guarded by the pool's mutex.
+type addressLimiter struct {
+	rate    float64
+	buckets map[types.Address]*list.Element // Values are *addressBucket.
+	order   *list.List                      // Least recently used at the back.
+}
+
+type addressBucket struct {
+	addr   types.Address
+	tokens float64
+	last   time.Time
+}
+
+// maxAddressBuckets bounds the limiter's memory; the least recently used
+// buckets are dropped past it.
+const maxAddressBuckets = 100_000
+
+func newAddressLimiter(perMinute int) *addressLimiter {
+	return &addressLimiter{
+		rate:    float64(perMinute),
+		buckets: make(map[types.Address]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// refill tops up addr's bucket to now and returns it.
+func (l *addressLimiter) refill(addr types.Address, now time.Time) *addressBucket {
+	e, ok := l.buckets[addr]
+	if !ok {
+		return &addressBucket{addr: addr, tokens: l.rate, last: now}
+	}
+	b := e.Value.(*addressBucket)
+	if elapsed := now.Sub(b.last).Minutes(); elapsed > 0 {
+		b.tokens = min(l.rate, b.tokens+elapsed*l.rate)
+		b.last = now
+	}
+	return b
+}
+
+// check returns the first address without a token left.
+func (l *addressLimiter) check(addrs []types.Address, now time.Time) (types.Address, bool) {
+	for _, addr := range addrs {
+		if l.refill(addr, now).tokens < 1 {
+			return addr, false
+		}
+	}
+	return types.Address{}, true
+}
+
+// take uses one token of each address.
+func (l *addressLimiter) take(addrs []types.Address, now time.Time) {
+	for _, addr := range addrs {
+		b := l.refill(addr, now)
+		b.tokens--
+		if e, ok := l.buckets[addr]; ok {
+			l.order.MoveToFront(e)
+			continue
+		}
+		l.buckets[addr] = l.order.PushFront(b)
+		if l.order.Len() > maxAddressBuckets {
+			oldest := l.order.Back()
+			l.order.Remove(oldest)
+			delete(l.buckets, oldest.Value.(*addressBucket).addr)
+		}
+	}
+}
+
+// IsPolicyError reports whether err is a rejection under local policy, such
+// as a fee below this node's minimum, rather than an invalid transaction.
+// Peers relaying such transactions should not be penalised.
+func IsPolicyError(err error) bool {
+	return errors.Is(err, ErrReplacement) ||
+		errors.Is(err, ErrFeeTooLow) ||
+		errors.Is(err, ErrPoolFull) ||
+		errors.Is(err, ErrPackageLimit) ||
+		errors.Is(err, ErrAlreadyExists) ||
+		IsStandardnessError(err)
+}
+
+// IsStandardnessError reports whether err is a rejection by the node's
+// Policy or a registered PolicyRule.
+func IsStandardnessError(err error) bool {
+	return errors.Is(err, ErrNonStandard) ||
+		errors.Is(err, ErrDust) ||
+		errors.Is(err, ErrScriptData) ||
+		errors.Is(err, ErrScriptType) ||
+		errors.Is(err, ErrTokenOutputs) ||
+		errors.Is(err, ErrAddressRate) ||
+		errors.Is(err, ErrPolicyRule)
+}
@@ -4,9 +4,8 @@ package mempool
 import (
 	"errors"
 	"fmt"
-	"math"
-	"sort"
 	"sync"
+	"time"
 
 	"github.com/Klingon-tech/klingnet-chain/internal/token"
 	"github.com/Klingon-tech/klingnet-chain/internal/utxo"
@@ -23,6 +22,10 @@ var (
 	ErrFeeTooLow         = errors.New("transaction fee below minimum")
 	ErrCoinbaseNotMature = errors.New("coinbase output not mature")
 	ErrMintingDisabled   = errors.New("token minting is disabled")
+	ErrPackageLimit      = errors.New("too many unconfirmed ancestors or descendants")
+	ErrReplacement       = errors.New("replacement rejected")
+	ErrMissingInputs     = errors.New("transaction spends unknown outputs")
+	ErrOutputLocked      = errors.New("output is locked")
 )
 
 // entry wraps a transaction with its fee and metadata.
@@ -31,6 +34,17 @@ type entry struct {
 	txHash  types.Hash
 	fee     uint64
 	feeRate float64 // fee per byte of SigningBytes.
+	size    int     // len(SigningBytes).
+	added   time.Time
+
+	// In-pool transactions whose outputs this one spends, and those
+	// spending its outputs.
+	parents  map[types.Hash]struct{}
+	children map[types.Hash]struct{}
+
+	// spendsStake marks an unstake: its outputs are locked on confirmation
+	// and cannot be spent by other pool transactions.
+	spendsStake bool
 }
 
 // Pool holds unconfirmed transactions.
@@ -38,9 +52,17 @@ type Pool struct {
 	mu         sync.RWMutex
 	txs        map[types.Hash]*entry         // txHash -> entry
 	spends     map[types.Outpoint]types.Hash // outpoint -> txHash (conflict index)
-	maxSize    int
+	maxSize    int                           // Maximum number of transactions.
+	maxBytes   int                           // Maximum total signing bytes (0 = unlimited).
+	totalBytes int                           // Current total signing bytes.
+	maxAge     time.Duration                 // Entries older than this expire (0 = never).
+	removed    RemovalStats
 	minFeeRate uint64 // Minimum fee rate in base units per byte (0 = no minimum).
 	utxos      tx.UTXOProvider
+	limits     PackageLimits
+
+	// Replace-by-fee.
+	incrementalFeeRate uint64 // Extra fee rate a replacement must pay.
 
 	// Coinbase maturity checking.
 	utxoSet          utxo.Set      // For maturity checks (nil = disabled).
@@ -54,6 +76,14 @@ type Pool struct {
 
 	// Stake validation.
 	stakeAmount uint64 // Exact amount required for stake outputs (0 = disabled).
+
+	// Fee estimation.
+	estimator *FeeEstimator // Times confirmations (nil = disabled).
+
+	// Standardness.
+	policy      *Policy
+	rules       []PolicyRule    // Registered by embedders.
+	addrLimiter *addressLimiter // Per-address rate limit (nil = disabled).
 }
 
 // New creates a new mempool with the given UTXO provider and max size.
@@ -65,11 +95,24 @@ func New(utxos tx.UTXOProvider, maxSize int) *Pool {
 		txs:          make(map[types.Hash]*entry),
 		spends:       make(map[types.Outpoint]types.Hash),
 		maxSize:      maxSize,
+		maxBytes:     DefaultMaxBytes,
+		maxAge:       DefaultMaxAge,
 		utxos:        utxos,
+		limits:       DefaultPackageLimits(),
 		allowMinting: true,
+		policy:       DefaultPolicy(),
+
+		incrementalFeeRate: DefaultIncrementalFeeRate,
 	}
 }
 
+// SetPackageLimits sets the limits on chains of unconfirmed transactions.
+func (p *Pool) SetPackageLimits(limits PackageLimits) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.limits = limits
+}
+
 // SetMinFeeRate sets the minimum fee rate (base units per byte) for transaction acceptance.
 func (p *Pool) SetMinFeeRate(rate uint64) {
 	p.mu.Lock()
@@ -123,11 +166,29 @@ func (p *Pool) SetCoinbaseMaturity(maturity uint64, heightFn func() uint64, set
 }
 
 // Add validates and adds a transaction to the mempool.
-// Returns the computed fee. Rejects duplicates and double-spend conflicts.
+// Returns the computed fee. Rejects duplicates and double-spend conflicts,
+// unless every conflicting transaction signals replace-by-fee and the new
+// one pays enough to replace them (see checkReplacementLocked); the
+// conflicts and their descendants are then swapped out atomically.
+// Inputs may spend outputs of other pool transactions, within the pool's
+// ancestor and descendant limits.
 func (p *Pool) Add(transaction *tx.Transaction) (uint64, error) {
+	return p.add(transaction, false)
+}
+
+// add is Add; restored transactions were accepted before and are exempt
+// from the per-address rate limit.
+func (p *Pool) add(transaction *tx.Transaction, restored bool) (uint64, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	return p.addLocked(transaction, false, restored)
+}
 
+// addLocked validates and adds a transaction. Package members skip the
+// per-transaction minimum fee rate and pool capacity, which AddPackage
+// enforces on the whole package, and never replace other entries.
+// Must be called with p.mu held.
+func (p *Pool) addLocked(transaction *tx.Transaction, inPackage, restored bool) (uint64, error) {
 	txHash := transaction.Hash()
 
 	// Reject duplicates.
@@ -135,13 +196,45 @@ func (p *Pool) Add(transaction *tx.Transaction) (uint64, error) {
 		return 0, ErrAlreadyExists
 	}
 
-	// Check for double-spend conflicts.
+	// Standardness, before the costlier checks below.
+	if err := p.policy.Check(transaction); err != nil {
+		return 0, err
+	}
+
+	// Check for double-spend conflicts. Conflicts that signal
+	// replace-by-fee are checked against the fee rules below.
+	conflicts := make(map[types.Hash]struct{})
 	for _, in := range transaction.Inputs {
 		if in.PrevOut.IsZero() {
 			continue
 		}
 		if conflictHash, exists := p.spends[in.PrevOut]; exists {
-			return 0, fmt.Errorf("%w: input %s already spent by %s", ErrConflict, in.PrevOut, conflictHash)
+			if inPackage || !p.txs[conflictHash].tx.Replaceable() {
+				return 0, fmt.Errorf("%w: input %s already spent by %s", ErrConflict, in.PrevOut, conflictHash)
+			}
+			conflicts[conflictHash] = struct{}{}
+		}
+	}
+
+	// In-pool parents. Outputs of an unstake are locked once confirmed.
+	parents := make(map[types.Hash]struct{})
+	for _, in := range transaction.Inputs {
+		parent, ok := p.txs[in.PrevOut.TxID]
+		if !ok || in.PrevOut.IsZero() {
+			continue
+		}
+		if parent.spendsStake {
+			return 0, fmt.Errorf("%w: input %s is an unstake output", ErrOutputLocked, in.PrevOut)
+		}
+		parents[parent.txHash] = struct{}{}
+	}
+
+	// Inputs that are neither confirmed nor in the pool: the parent may
+	// not have arrived yet, or the output was already spent.
+	view := poolView{p: p}
+	for _, in := range transaction.Inputs {
+		if !in.PrevOut.IsZero() && !view.HasUTXO(in.PrevOut) {
+			return 0, fmt.Errorf("%w: %w: %s", ErrValidation, ErrMissingInputs, in.PrevOut)
 		}
 	}
 
@@ -158,13 +251,13 @@ func (p *Pool) Add(transaction *tx.Transaction) (uint64, error) {
 					ErrCoinbaseNotMature, p.coinbaseMaturity, currentHeight-u.Height)
 			}
 			if uErr == nil && u.LockedUntil > 0 && currentHeight < u.LockedUntil {
-				return 0, fmt.Errorf("output locked until block %d, current %d", u.LockedUntil, currentHeight)
+				return 0, fmt.Errorf("%w: until block %d, current %d", ErrOutputLocked, u.LockedUntil, currentHeight)
 			}
 		}
 	}
 
-	// UTXO-aware validation.
-	fee, err := transaction.ValidateWithUTXOs(p.utxos)
+	// UTXO-aware validation against the chain plus pool outputs.
+	fee, err := transaction.ValidateWithUTXOs(view)
 	if err != nil {
 		return 0, fmt.Errorf("%w: %v", ErrValidation, err)
 	}
@@ -176,7 +269,7 @@ func (p *Pool) Add(transaction *tx.Transaction) (uint64, error) {
 
 	// Token validation.
 	if p.tokenInputs != nil {
-		if err := token.ValidateTokens(transaction, p.tokenInputs); err != nil {
+		if err := token.ValidateTokens(transaction, view); err != nil {
 			return 0, fmt.Errorf("%w: %v", ErrValidation, err)
 		}
 	}
@@ -197,6 +290,18 @@ func (p *Pool) Add(transaction *tx.Transaction) (uint64, error) {
 		}
 	}
 
+	// Embedder rules and the per-address rate limit.
+	if err := p.checkRulesLocked(transaction, view); err != nil {
+		return 0, err
+	}
+	var senders []types.Address
+	if p.addrLimiter != nil && !restored {
+		senders = spenders(transaction, view)
+		if addr, ok := p.addrLimiter.check(senders, time.Now()); !ok {
+			return 0, fmt.Errorf("%w: %s", ErrAddressRate, addr)
+		}
+	}
+
 	// Compute fee rate for minimum check and eviction comparison.
 	sigBytes := len(transaction.SigningBytes())
 	var feeRate float64
@@ -205,48 +310,118 @@ func (p *Pool) Add(transaction *tx.Transaction) (uint64, error) {
 	}
 
 	// Enforce minimum fee rate (fee per byte of SigningBytes).
-	if p.minFeeRate > 0 {
+	if p.minFeeRate > 0 && !inPackage {
 		requiredFee := p.minFeeRate * uint64(sigBytes)
 		if fee < requiredFee {
 			return 0, fmt.Errorf("%w: got %d, need %d (%d bytes × %d rate)", ErrFeeTooLow, fee, requiredFee, sigBytes, p.minFeeRate)
 		}
 	}
 
-	// Check pool capacity — evict lowest fee-rate if new tx pays more.
-	if len(p.txs) >= p.maxSize {
-		lowestHash, lowestRate := p.findLowestFeeRate()
-		if feeRate <= lowestRate {
-			return 0, ErrPoolFull
+	// Replace-by-fee.
+	var replaced map[types.Hash]*entry
+	if len(conflicts) > 0 {
+		replaced, err = p.checkReplacementLocked(sigBytes, fee, feeRate, conflicts, parents)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Ancestor/descendant limits.
+	ancestors := p.ancestorsLocked(parents)
+	if err := p.checkPackageLimitsLocked(sigBytes, ancestors, replaced); err != nil {
+		return 0, err
+	}
+
+	// Check pool capacity — evict lower fee-rate entries if the new tx
+	// pays more. Its own ancestors are never evicted for it.
+	var evict []types.Hash
+	if !inPackage {
+		evict, err = p.planEvictionLocked(1, sigBytes, feeRate, ancestors, replaced)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// All checks passed: swap out replaced entries and make room.
+	for h := range conflicts {
+		p.removed.Replaced += uint64(p.removeLocked(h))
+	}
+	for _, h := range evict {
+		p.removed.Evicted += uint64(p.removeLocked(h))
+	}
+
+	spendsStake := false
+	for _, in := range transaction.Inputs {
+		if in.PrevOut.IsZero() {
+			continue
+		}
+		if _, script, err := view.GetUTXO(in.PrevOut); err == nil && script.Type == types.ScriptTypeStake {
+			spendsStake = true
+			break
 		}
-		p.removeLocked(lowestHash)
 	}
 
 	e := &entry{
-		tx:      transaction,
-		txHash:  txHash,
-		fee:     fee,
-		feeRate: feeRate,
+		tx:          transaction,
+		txHash:      txHash,
+		fee:         fee,
+		feeRate:     feeRate,
+		size:        sigBytes,
+		added:       time.Now(),
+		parents:     parents,
+		children:    make(map[types.Hash]struct{}),
+		spendsStake: spendsStake,
 	}
 
-	// Add to pool and conflict index.
+	// Add to pool, conflict index and parents.
+	if len(senders) > 0 {
+		p.addrLimiter.take(senders, e.added)
+	}
 	p.txs[txHash] = e
+	p.totalBytes += e.size
 	for _, in := range transaction.Inputs {
 		if !in.PrevOut.IsZero() {
 			p.spends[in.PrevOut] = txHash
 		}
 	}
+	for parent := range parents {
+		p.txs[parent].children[txHash] = struct{}{}
+	}
+
+	// A transaction with pool parents confirms when they do, so only
+	// independent ones say anything about the fee rate needed.
+	if p.estimator != nil && p.heightFn != nil && len(parents) == 0 {
+		p.estimator.track(txHash, feeRate, p.heightFn())
+	}
 
 	return fee, nil
 }
 
-// Remove removes a transaction from the mempool by hash.
+// Remove removes a transaction and its in-pool descendants, which spend
+// outputs that no longer exist.
 func (p *Pool) Remove(txHash types.Hash) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	p.removeLocked(txHash)
 }
 
-func (p *Pool) removeLocked(txHash types.Hash) {
+// removeLocked removes a transaction and its descendants, returning the
+// number of transactions removed.
+func (p *Pool) removeLocked(txHash types.Hash) int {
+	if _, exists := p.txs[txHash]; !exists {
+		return 0
+	}
+	desc := p.descendantsLocked(txHash)
+	for h := range desc {
+		p.unlinkLocked(h)
+	}
+	p.unlinkLocked(txHash)
+	return len(desc) + 1
+}
+
+// unlinkLocked removes a single transaction, its spend index entries and its
+// parent/child links. Children stay in the pool.
+func (p *Pool) unlinkLocked(txHash types.Hash) {
 	e, exists := p.txs[txHash]
 	if !exists {
 		return
@@ -257,16 +432,54 @@ func (p *Pool) removeLocked(txHash types.Hash) {
 			delete(p.spends, in.PrevOut)
 		}
 	}
+	for parent := range e.parents {
+		if pe, ok := p.txs[parent]; ok {
+			delete(pe.children, txHash)
+		}
+	}
+	for child := range e.children {
+		if ce, ok := p.txs[child]; ok {
+			delete(ce.parents, txHash)
+		}
+	}
 	delete(p.txs, txHash)
+	p.totalBytes -= e.size
+	if p.estimator != nil {
+		p.estimator.untrack(txHash)
+	}
 }
 
 // RemoveConfirmed removes all transactions that were included in a block.
+// Their in-pool children stay, now spending confirmed outputs. Pool
+// transactions that conflict with the block are removed with their
+// descendants, and expired entries are dropped. With a fee estimator set,
+// the block is recorded at the current chain height.
 func (p *Pool) RemoveConfirmed(transactions []*tx.Transaction) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	if p.estimator != nil && p.heightFn != nil {
+		hashes := make([]types.Hash, len(transactions))
+		for i, t := range transactions {
+			hashes[i] = t.Hash()
+		}
+		p.estimator.processBlock(p.heightFn(), hashes)
+	}
 	for _, t := range transactions {
-		p.removeLocked(t.Hash())
+		h := t.Hash()
+		if _, ok := p.txs[h]; ok {
+			p.unlinkLocked(h)
+			p.removed.Confirmed++
+		}
+		for _, in := range t.Inputs {
+			if in.PrevOut.IsZero() {
+				continue
+			}
+			if conflict, exists := p.spends[in.PrevOut]; exists {
+				p.removed.Conflict += uint64(p.removeLocked(conflict))
+			}
+		}
 	}
+	p.expireLocked(time.Now())
 }
 
 // Has checks if a transaction exists in the mempool.
@@ -317,43 +530,19 @@ func (p *Pool) Hashes() []types.Hash {
 	return hashes
 }
 
-// findLowestFeeRate returns the hash and fee rate of the lowest fee-rate entry.
-// Must be called with p.mu held.
-func (p *Pool) findLowestFeeRate() (types.Hash, float64) {
-	var lowestHash types.Hash
-	lowestRate := math.MaxFloat64
-	for h, e := range p.txs {
-		if e.feeRate < lowestRate {
-			lowestRate = e.feeRate
-			lowestHash = h
-		}
-	}
-	return lowestHash, lowestRate
-}
-
-// SelectForBlock returns transactions ordered by fee rate (highest first),
-// up to the given limit.
-func (p *Pool) SelectForBlock(limit int) []*tx.Transaction {
+// Transactions returns all transactions in the mempool, in no order.
+func (p *Pool) Transactions() []*tx.Transaction {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-
-	entries := make([]*entry, 0, len(p.txs))
+	txs := make([]*tx.Transaction, 0, len(p.txs))
 	for _, e := range p.txs {
-		entries = append(entries, e)
-	}
-
-	// Sort by fee rate descending.
-	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].feeRate > entries[j].feeRate
-	})
-
-	if limit > len(entries) {
-		limit = len(entries)
+		txs = append(txs, e.tx)
 	}
+	return txs
+}
 
-	result := make([]*tx.Transaction, limit)
-	for i := 0; i < limit; i++ {
-		result[i] = entries[i].tx
-	}
-	return result
+// SelectForBlock returns up to limit transactions by package fee rate,
+// with no byte budget. See SelectPackages.
+func (p *Pool) SelectForBlock(limit int) []*tx.Transaction {
+	return p.SelectPackages(limit, 0)
 }
@@ -1,12 +1,18 @@
 package mempool
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Klingon-tech/klingnet-chain/config"
+	"github.com/Klingon-tech/klingnet-chain/internal/storage"
+	"github.com/Klingon-tech/klingnet-chain/internal/utxo"
 	"github.com/Klingon-tech/klingnet-chain/pkg/crypto"
 	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
 	"github.com/Klingon-tech/klingnet-chain/pkg/types"
@@ -161,6 +167,59 @@ func TestPool_Add_ValidationFailure(t *testing.T) {
 	}
 }
 
+func TestPool_Add_Overspend(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 500, addressFromKey(key))
+	pool := New(utxos, 100)
+
+	// Spends more than the input holds.
+	transaction := buildTx(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 1000)
+
+	_, err := pool.Add(transaction)
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("expected ErrValidation, got: %v", err)
+	}
+}
+
+func TestPool_Add_MissingInputs(t *testing.T) {
+	utxos := newMockUTXOs() // Empty — no UTXOs.
+	pool := New(utxos, 100)
+
+	key, _ := crypto.GenerateKey()
+	transaction := buildTx(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 1000)
+
+	_, err := pool.Add(transaction)
+	if !errors.Is(err, ErrMissingInputs) {
+		t.Errorf("expected ErrMissingInputs, got: %v", err)
+	}
+}
+
+func TestPool_Add_LockedOutput(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+	op := types.Outpoint{TxID: types.Hash{0x01}, Index: 0}
+
+	utxos := newMockUTXOs()
+	utxos.add(op, 5000, addr)
+	set := utxo.NewStore(storage.NewMemory())
+	if err := set.Put(&utxo.UTXO{
+		Outpoint:    op,
+		Value:       5000,
+		Script:      types.Script{Type: types.ScriptTypeP2PKH, Data: addr.Bytes()},
+		LockedUntil: 40,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	pool := New(utxos, 100)
+	pool.SetCoinbaseMaturity(20, func() uint64 { return 30 }, set)
+
+	_, err := pool.Add(buildTxTo(t, key, op, addr, 4900))
+	if !errors.Is(err, ErrOutputLocked) {
+		t.Errorf("expected ErrOutputLocked, got: %v", err)
+	}
+}
+
 func TestPool_Remove(t *testing.T) {
 	key, _ := crypto.GenerateKey()
 	addr := addressFromKey(key)
@@ -569,3 +628,1261 @@ func TestPolicy_Check_ScriptDataTooLarge(t *testing.T) {
 		t.Errorf("expected script data too large error, got: %v", err)
 	}
 }
+
+// buildTxTo creates a signed transaction spending prevOut with one output
+// per value, all paying addr.
+func buildTxTo(t *testing.T, key *crypto.PrivateKey, prevOut types.Outpoint, addr types.Address, values ...uint64) *tx.Transaction {
+	t.Helper()
+	b := tx.NewBuilder().AddInput(prevOut)
+	for _, v := range values {
+		b.AddOutput(v, types.Script{Type: types.ScriptTypeP2PKH, Data: addr[:]})
+	}
+	b.Sign(key)
+	return b.Build()
+}
+
+func TestPool_Add_SpendsUnconfirmed(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4000)
+	if _, err := pool.Add(parent); err != nil {
+		t.Fatalf("Add parent: %v", err)
+	}
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 3500)
+	fee, err := pool.Add(child)
+	if err != nil {
+		t.Fatalf("Add child: %v", err)
+	}
+	if fee != 500 {
+		t.Errorf("child fee = %d, want 500", fee)
+	}
+
+	// A second spend of the parent output conflicts.
+	other := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 3000)
+	if _, err := pool.Add(other); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict, got: %v", err)
+	}
+}
+
+func TestPool_Add_AncestorLimit(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 10000, addr)
+	pool := New(utxos, 100)
+	pool.SetPackageLimits(PackageLimits{MaxAncestors: 2})
+
+	tx1 := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 9000)
+	tx2 := buildTxTo(t, key, types.Outpoint{TxID: tx1.Hash(), Index: 0}, addr, 8000)
+	tx3 := buildTxTo(t, key, types.Outpoint{TxID: tx2.Hash(), Index: 0}, addr, 7000)
+	for _, tr := range []*tx.Transaction{tx1, tx2} {
+		if _, err := pool.Add(tr); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if _, err := pool.Add(tx3); !errors.Is(err, ErrPackageLimit) {
+		t.Errorf("expected ErrPackageLimit, got: %v", err)
+	}
+}
+
+func TestPool_Add_DescendantLimit(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 10000, addr)
+	pool := New(utxos, 100)
+	pool.SetPackageLimits(PackageLimits{MaxDescendants: 2})
+
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4000, 4000)
+	child1 := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 3000)
+	child2 := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 1}, addr, 3000)
+	for _, tr := range []*tx.Transaction{parent, child1} {
+		if _, err := pool.Add(tr); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if _, err := pool.Add(child2); !errors.Is(err, ErrPackageLimit) {
+		t.Errorf("expected ErrPackageLimit, got: %v", err)
+	}
+}
+
+func TestPool_Remove_RemovesDescendants(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4000)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 3000)
+	pool.Add(parent)
+	pool.Add(child)
+
+	pool.Remove(parent.Hash())
+	if pool.Count() != 0 {
+		t.Errorf("count = %d, want 0", pool.Count())
+	}
+	// The parent's input is free again.
+	if _, err := pool.Add(parent); err != nil {
+		t.Errorf("re-add parent: %v", err)
+	}
+}
+
+func TestPool_RemoveConfirmed_KeepsChildren(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4000)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 3000)
+	pool.Add(parent)
+	pool.Add(child)
+
+	// Parent confirmed: its output is now on chain.
+	delete(utxos.utxos, types.Outpoint{TxID: types.Hash{0x01}, Index: 0})
+	utxos.add(types.Outpoint{TxID: parent.Hash(), Index: 0}, 4000, addr)
+	pool.RemoveConfirmed([]*tx.Transaction{parent})
+
+	if pool.Has(parent.Hash()) || !pool.Has(child.Hash()) {
+		t.Fatal("expected only the child to remain")
+	}
+	if got := pool.SelectForBlock(10); len(got) != 1 || got[0].Hash() != child.Hash() {
+		t.Errorf("SelectForBlock = %d txs, want the child", len(got))
+	}
+}
+
+func TestPool_RemoveConfirmed_Conflict(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4000)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 3000)
+	pool.Add(parent)
+	pool.Add(child)
+
+	// A different spend of the same input is confirmed.
+	confirmed := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4500)
+	pool.RemoveConfirmed([]*tx.Transaction{confirmed})
+
+	if pool.Count() != 0 {
+		t.Errorf("count = %d, want 0 (conflict and descendant removed)", pool.Count())
+	}
+}
+
+func TestPool_SelectForBlock_Topological(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+
+	// Low-fee parent, high-fee child.
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4990)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 2000)
+	pool.Add(parent)
+	pool.Add(child)
+
+	selected := pool.SelectForBlock(2)
+	if len(selected) != 2 {
+		t.Fatalf("selected %d, want 2", len(selected))
+	}
+	if selected[0].Hash() != parent.Hash() || selected[1].Hash() != child.Hash() {
+		t.Error("parent must be selected before child")
+	}
+
+	// The child does not fit without its parent.
+	selected = pool.SelectForBlock(1)
+	if len(selected) != 1 || selected[0].Hash() != parent.Hash() {
+		t.Error("limit 1 should select only the parent")
+	}
+}
+
+func TestPool_Evict_RemovesDescendants(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	utxos.add(types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+
+	// Lowest fee rate parent with a child; an unrelated higher-fee tx.
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4990)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 4000)
+	other := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, addr, 4500)
+	for _, tr := range []*tx.Transaction{parent, child, other} {
+		if _, err := pool.Add(tr); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	pool.maxSize = 2
+	if evicted := pool.Evict(); evicted != 2 {
+		t.Errorf("evicted = %d, want 2", evicted)
+	}
+	if !pool.Has(other.Hash()) || pool.Count() != 1 {
+		t.Error("expected only the unrelated tx to remain")
+	}
+}
+
+// buildReplaceableTx creates a signed, RBF-signalling transaction spending
+// prevOut with one output per value, all paying addr.
+func buildReplaceableTx(t *testing.T, key *crypto.PrivateKey, prevOut types.Outpoint, addr types.Address, values ...uint64) *tx.Transaction {
+	t.Helper()
+	b := tx.NewBuilder().SetReplaceable().AddInput(prevOut)
+	for _, v := range values {
+		b.AddOutput(v, types.Script{Type: types.ScriptTypeP2PKH, Data: addr[:]})
+	}
+	b.Sign(key)
+	return b.Build()
+}
+
+func TestPool_Replace(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+	op := types.Outpoint{TxID: types.Hash{0x01}, Index: 0}
+
+	utxos := newMockUTXOs()
+	utxos.add(op, 10000, addr)
+	pool := New(utxos, 100)
+
+	original := buildReplaceableTx(t, key, op, addr, 9900) // fee 100
+	if _, err := pool.Add(original); err != nil {
+		t.Fatalf("Add original: %v", err)
+	}
+	child := buildTxTo(t, key, types.Outpoint{TxID: original.Hash(), Index: 0}, addr, 9800) // fee 100
+	if _, err := pool.Add(child); err != nil {
+		t.Fatalf("Add child: %v", err)
+	}
+
+	size := len(original.SigningBytes())
+	minFee, err := pool.MinReplacementFee(original.Hash(), size)
+	if err != nil {
+		t.Fatalf("MinReplacementFee: %v", err)
+	}
+	if want := uint64(200 + size*DefaultIncrementalFeeRate); minFee != want {
+		t.Errorf("MinReplacementFee = %d, want %d", minFee, want)
+	}
+
+	// Must cover the fees of the original and its child plus the increment.
+	tooLow := buildReplaceableTx(t, key, op, addr, 10000-minFee+1)
+	if _, err := pool.Add(tooLow); !errors.Is(err, ErrReplacement) {
+		t.Fatalf("expected ErrReplacement, got: %v", err)
+	}
+	if !pool.Has(original.Hash()) || !pool.Has(child.Hash()) {
+		t.Fatal("rejected replacement must leave the pool unchanged")
+	}
+
+	replacement := buildReplaceableTx(t, key, op, addr, 10000-minFee)
+	fee, err := pool.Add(replacement)
+	if err != nil {
+		t.Fatalf("Add replacement: %v", err)
+	}
+	if fee != minFee {
+		t.Errorf("fee = %d, want %d", fee, minFee)
+	}
+	if pool.Has(original.Hash()) || pool.Has(child.Hash()) || pool.Count() != 1 {
+		t.Error("original and its descendant should be evicted")
+	}
+}
+
+func TestPool_Replace_NotSignalled(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+	op := types.Outpoint{TxID: types.Hash{0x01}, Index: 0}
+
+	utxos := newMockUTXOs()
+	utxos.add(op, 10000, addr)
+	pool := New(utxos, 100)
+
+	if _, err := pool.Add(buildTxTo(t, key, op, addr, 9900)); err != nil {
+		t.Fatalf("Add original: %v", err)
+	}
+	if _, err := pool.Add(buildReplaceableTx(t, key, op, addr, 5000)); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict, got: %v", err)
+	}
+}
+
+func TestPool_Replace_FeeRateMustIncrease(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+	op := types.Outpoint{TxID: types.Hash{0x01}, Index: 0}
+
+	utxos := newMockUTXOs()
+	utxos.add(op, 100000, addr)
+	pool := New(utxos, 100)
+	pool.SetIncrementalFeeRate(0)
+
+	// Small original with a high fee rate.
+	if _, err := pool.Add(buildReplaceableTx(t, key, op, addr, 90000)); err != nil {
+		t.Fatalf("Add original: %v", err)
+	}
+	// Larger replacement: higher absolute fee, lower fee rate.
+	outputs := make([]uint64, 40)
+	for i := range outputs {
+		outputs[i] = 2200
+	}
+	if _, err := pool.Add(buildReplaceableTx(t, key, op, addr, outputs...)); !errors.Is(err, ErrReplacement) {
+		t.Errorf("expected ErrReplacement, got: %v", err)
+	}
+}
+
+func TestPool_Replace_EvictionLimit(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+	op := types.Outpoint{TxID: types.Hash{0x01}, Index: 0}
+
+	utxos := newMockUTXOs()
+	utxos.add(op, 1_000_000, addr)
+	pool := New(utxos, 1000)
+	pool.SetPackageLimits(PackageLimits{})
+
+	// Original fans out to more descendants than a replacement may evict.
+	n := MaxReplacementEvictions
+	values := make([]uint64, n)
+	for i := range values {
+		values[i] = 9000
+	}
+	original := buildReplaceableTx(t, key, op, addr, values...)
+	if _, err := pool.Add(original); err != nil {
+		t.Fatalf("Add original: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		if _, err := pool.Add(buildTxTo(t, key, types.Outpoint{TxID: original.Hash(), Index: uint32(i)}, addr, 8000)); err != nil {
+			t.Fatalf("Add child %d: %v", i, err)
+		}
+	}
+
+	if _, err := pool.Add(buildReplaceableTx(t, key, op, addr, 1000)); !errors.Is(err, ErrReplacement) {
+		t.Errorf("expected ErrReplacement, got: %v", err)
+	}
+}
+
+func TestPool_SelectPackages_ChildPaysForParent(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	utxos.add(types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+
+	// Parent pays 10, child 2990: the package outbids other's 400.
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4990)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 2000)
+	other := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, addr, 4600)
+	for _, tr := range []*tx.Transaction{parent, child, other} {
+		if _, err := pool.Add(tr); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	selected := pool.SelectPackages(2, 0)
+	if len(selected) != 2 || selected[0].Hash() != parent.Hash() || selected[1].Hash() != child.Hash() {
+		t.Error("child should pull its parent in ahead of other")
+	}
+}
+
+func TestPool_SelectPackages_PackageRate(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	utxos.add(types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+
+	// The child alone pays more than other, but not with its parent.
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4990)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 3990)
+	other := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, addr, 4200)
+	for _, tr := range []*tx.Transaction{parent, child, other} {
+		if _, err := pool.Add(tr); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	// other first; the child's package no longer fits, the parent does.
+	selected := pool.SelectPackages(2, 0)
+	if len(selected) != 2 || selected[0].Hash() != other.Hash() || selected[1].Hash() != parent.Hash() {
+		t.Error("other should be selected before the parent/child package")
+	}
+}
+
+func TestPool_SelectConfirmed(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	utxos.add(types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4990)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 2000)
+	other := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, addr, 4600)
+	for _, tr := range []*tx.Transaction{parent, child, other} {
+		if _, err := pool.Add(tr); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	// The child waits for its parent to confirm, however much it pays.
+	selected := pool.SelectConfirmed(10, 0)
+	if len(selected) != 2 || selected[0].Hash() != other.Hash() || selected[1].Hash() != parent.Hash() {
+		t.Error("expected other and parent only, by fee rate")
+	}
+}
+
+func TestPool_SelectPackages_ByteBudget(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	var txs []*tx.Transaction
+	for i := byte(1); i <= 3; i++ {
+		op := types.Outpoint{TxID: types.Hash{i}, Index: 0}
+		utxos.add(op, 5000, addr)
+		txs = append(txs, buildTxTo(t, key, op, addr, 5000-uint64(i)*100))
+	}
+	pool := New(utxos, 100)
+	size := 0
+	for _, tr := range txs {
+		if _, err := pool.Add(tr); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		size = max(size, len(tr.SigningBytes()))
+	}
+
+	// Room for two transactions: the two highest fees.
+	selected := pool.SelectPackages(10, 2*size)
+	if len(selected) != 2 {
+		t.Fatalf("selected %d, want 2", len(selected))
+	}
+	if selected[0].Hash() != txs[2].Hash() || selected[1].Hash() != txs[1].Hash() {
+		t.Error("expected the two highest fee-rate transactions")
+	}
+}
+
+func TestPool_AddPackage(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+	pool.SetMinFeeRate(1)
+
+	// The parent alone is below the minimum fee rate.
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4990)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 2000)
+	if _, err := pool.Add(parent); !errors.Is(err, ErrFeeTooLow) {
+		t.Fatalf("Add(parent) = %v, want ErrFeeTooLow", err)
+	}
+
+	fees, err := pool.AddPackage([]*tx.Transaction{parent, child})
+	if err != nil {
+		t.Fatalf("AddPackage: %v", err)
+	}
+	if fees[0] != 10 || fees[1] != 2990 {
+		t.Errorf("fees = %v, want [10 2990]", fees)
+	}
+	if !pool.Has(parent.Hash()) || !pool.Has(child.Hash()) {
+		t.Error("package should be in the pool")
+	}
+}
+
+func TestPool_AddPackage_FeeTooLow(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+	pool.SetMinFeeRate(1)
+
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4990)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 4980)
+	if _, err := pool.AddPackage([]*tx.Transaction{parent, child}); !errors.Is(err, ErrFeeTooLow) {
+		t.Fatalf("AddPackage = %v, want ErrFeeTooLow", err)
+	}
+	if pool.Count() != 0 {
+		t.Errorf("Count = %d, want 0 after rollback", pool.Count())
+	}
+}
+
+func TestPool_AddPackage_Order(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4990)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 2000)
+	if _, err := pool.AddPackage([]*tx.Transaction{child, parent}); !errors.Is(err, ErrInvalidPackage) {
+		t.Fatalf("AddPackage = %v, want ErrInvalidPackage", err)
+	}
+	if pool.Count() != 0 {
+		t.Errorf("Count = %d, want 0", pool.Count())
+	}
+}
+
+func TestPool_SaveLoad(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	utxos.add(types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4900)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 4800)
+	other := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, addr, 4900)
+	for _, tr := range []*tx.Transaction{parent, child, other} {
+		if _, err := pool.Add(tr); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	// The child was seen first; it must still be saved after its parent.
+	firstSeen := time.Unix(1_700_000_000, 0)
+	pool.txs[child.Hash()].added = firstSeen
+
+	path := filepath.Join(t.TempDir(), "mempool.json")
+	saved, err := pool.Save(path)
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if saved != 3 {
+		t.Errorf("saved = %d, want 3", saved)
+	}
+
+	// Other was confirmed while the node was down.
+	delete(utxos.utxos, types.Outpoint{TxID: types.Hash{0x02}, Index: 0})
+
+	reloaded := New(utxos, 100)
+	loaded, dropped, err := reloaded.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded != 2 || dropped != 1 {
+		t.Errorf("loaded %d dropped %d, want 2 and 1", loaded, dropped)
+	}
+	if !reloaded.Has(parent.Hash()) || !reloaded.Has(child.Hash()) {
+		t.Error("parent and child should be reloaded")
+	}
+	if reloaded.GetFee(child.Hash()) != 100 {
+		t.Errorf("child fee = %d, want 100", reloaded.GetFee(child.Hash()))
+	}
+	if got := reloaded.txs[child.Hash()].added; !got.Equal(firstSeen) {
+		t.Errorf("first seen = %v, want %v", got, firstSeen)
+	}
+}
+
+func TestPool_Load_AlreadyPresent(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+	if _, err := pool.Add(buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4900)); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "mempool.json")
+	if _, err := pool.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Transactions already in the pool are neither loaded nor dropped.
+	loaded, dropped, err := pool.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded != 0 || dropped != 0 {
+		t.Errorf("loaded %d dropped %d, want 0 and 0", loaded, dropped)
+	}
+}
+
+func TestPool_Load_Package(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+	pool.SetMinFeeRate(1)
+
+	// The parent only got in with its child.
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4990)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 2000)
+	if _, err := pool.AddPackage([]*tx.Transaction{parent, child}); err != nil {
+		t.Fatalf("AddPackage: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "mempool.json")
+	if _, err := pool.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reloaded := New(utxos, 100)
+	reloaded.SetMinFeeRate(1)
+	loaded, dropped, err := reloaded.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded != 2 || dropped != 0 {
+		t.Errorf("loaded %d dropped %d, want 2 and 0", loaded, dropped)
+	}
+}
+
+func TestPool_Load_MissingFile(t *testing.T) {
+	pool := New(newMockUTXOs(), 100)
+	loaded, dropped, err := pool.Load(filepath.Join(t.TempDir(), "mempool.json"))
+	if err != nil || loaded != 0 || dropped != 0 {
+		t.Errorf("Load = %d, %d, %v; want 0, 0, nil", loaded, dropped, err)
+	}
+}
+
+func TestPool_Add_ByteBudget(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	var txs []*tx.Transaction
+	for i := byte(1); i <= 4; i++ {
+		op := types.Outpoint{TxID: types.Hash{i}, Index: 0}
+		utxos.add(op, 5000, addr)
+		txs = append(txs, buildTxTo(t, key, op, addr, 5000-uint64(i)*100))
+	}
+	// Fees: 100, 200, 300, 400.
+	pool := New(utxos, 100)
+	size := len(txs[0].SigningBytes())
+	pool.SetMaxBytes(2 * size)
+
+	for _, tr := range txs[1:3] {
+		if _, err := pool.Add(tr); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if pool.Bytes() != 2*size {
+		t.Errorf("Bytes = %d, want %d", pool.Bytes(), 2*size)
+	}
+
+	// Lower fee rate than everything in the pool.
+	if _, err := pool.Add(txs[0]); !errors.Is(err, ErrPoolFull) {
+		t.Fatalf("Add(low) = %v, want ErrPoolFull", err)
+	}
+	// Higher fee rate evicts the lowest.
+	if _, err := pool.Add(txs[3]); err != nil {
+		t.Fatalf("Add(high): %v", err)
+	}
+	if pool.Has(txs[1].Hash()) || !pool.Has(txs[3].Hash()) {
+		t.Error("lowest fee-rate tx should be evicted")
+	}
+	if got := pool.Removals().Evicted; got != 1 {
+		t.Errorf("Evicted = %d, want 1", got)
+	}
+}
+
+func TestPool_AddPackage_ByteBudget(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	var txs []*tx.Transaction
+	for i := byte(1); i <= 3; i++ {
+		op := types.Outpoint{TxID: types.Hash{i}, Index: 0}
+		utxos.add(op, 5000, addr)
+		txs = append(txs, buildTxTo(t, key, op, addr, 5000-uint64(i)*100))
+	}
+	// Fees: 100, 200, 300.
+	pool := New(utxos, 100)
+	size := len(txs[0].SigningBytes())
+	pool.SetMaxBytes(3 * size)
+	for _, tr := range txs {
+		if _, err := pool.Add(tr); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	// A package paying less than everything in the pool does not fit and
+	// leaves no trace.
+	utxos.add(types.Outpoint{TxID: types.Hash{0x10}, Index: 0}, 5000, addr)
+	lowParent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x10}, Index: 0}, addr, 4990)
+	lowChild := buildTxTo(t, key, types.Outpoint{TxID: lowParent.Hash(), Index: 0}, addr, 4980)
+	if _, err := pool.AddPackage([]*tx.Transaction{lowParent, lowChild}); !errors.Is(err, ErrPoolFull) {
+		t.Fatalf("AddPackage(low) = %v, want ErrPoolFull", err)
+	}
+	if pool.Has(lowParent.Hash()) || pool.Count() != 3 {
+		t.Error("rejected package should be rolled back")
+	}
+
+	// A package out-paying the two cheapest entries evicts them.
+	utxos.add(types.Outpoint{TxID: types.Hash{0x11}, Index: 0}, 5000, addr)
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x11}, Index: 0}, addr, 4990)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 3990)
+	if _, err := pool.AddPackage([]*tx.Transaction{parent, child}); err != nil {
+		t.Fatalf("AddPackage(high): %v", err)
+	}
+	if pool.Has(txs[0].Hash()) || pool.Has(txs[1].Hash()) || !pool.Has(txs[2].Hash()) {
+		t.Error("the two lowest fee-rate txs should be evicted")
+	}
+	if pool.Bytes() > pool.MaxBytes() {
+		t.Errorf("Bytes = %d over budget %d", pool.Bytes(), pool.MaxBytes())
+	}
+	if got := pool.Removals().Evicted; got != 2 {
+		t.Errorf("Evicted = %d, want 2", got)
+	}
+}
+
+func TestPool_AddPackage_CountLimit(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	utxos.add(types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, 5000, addr)
+	pool := New(utxos, 2)
+	low := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4900)
+	if _, err := pool.Add(low); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, addr, 4900)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 3900)
+	if _, err := pool.AddPackage([]*tx.Transaction{parent, child}); err != nil {
+		t.Fatalf("AddPackage: %v", err)
+	}
+	if pool.Has(low.Hash()) || pool.Count() != 2 {
+		t.Error("full pool should evict the lower fee-rate entry for the package")
+	}
+}
+
+func TestPool_Expire(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	utxos.add(types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+	pool.SetMaxAge(time.Hour)
+
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4900)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 4800)
+	fresh := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, addr, 4900)
+	for _, tr := range []*tx.Transaction{parent, child, fresh} {
+		if _, err := pool.Add(tr); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	pool.txs[parent.Hash()].added = time.Now().Add(-2 * time.Hour)
+
+	if n := pool.Expire(time.Now()); n != 2 {
+		t.Errorf("Expire = %d, want 2 (parent and child)", n)
+	}
+	if !pool.Has(fresh.Hash()) || pool.Count() != 1 {
+		t.Error("only the fresh tx should remain")
+	}
+	if got := pool.Removals().Expired; got != 2 {
+		t.Errorf("Expired = %d, want 2", got)
+	}
+}
+
+func TestPool_RemoveConfirmed_Stats(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	utxos.add(types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+
+	confirmed := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4900)
+	pooled := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, addr, 4900)
+	// The block spends the same input as pooled.
+	rival := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, addr, 4800)
+	pool.Add(confirmed)
+	pool.Add(pooled)
+
+	pool.RemoveConfirmed([]*tx.Transaction{confirmed, rival})
+	if pool.Count() != 0 {
+		t.Errorf("Count = %d, want 0", pool.Count())
+	}
+	r := pool.Removals()
+	if r.Confirmed != 1 || r.Conflict != 1 {
+		t.Errorf("removals = %+v, want 1 confirmed and 1 conflict", r)
+	}
+}
+
+func TestPool_Revalidate(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	utxos.add(types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4900)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 4800)
+	other := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x02}, Index: 0}, addr, 4900)
+	for _, tr := range []*tx.Transaction{parent, child, other} {
+		if _, err := pool.Add(tr); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	firstSeen := pool.txs[other.Hash()].added
+
+	// A reorg removed the output the parent spends.
+	delete(utxos.utxos, types.Outpoint{TxID: types.Hash{0x01}, Index: 0})
+
+	if dropped := pool.Revalidate(); dropped != 2 {
+		t.Errorf("Revalidate = %d, want 2", dropped)
+	}
+	if !pool.Has(other.Hash()) || pool.Count() != 1 {
+		t.Error("only other should remain")
+	}
+	if !pool.txs[other.Hash()].added.Equal(firstSeen) {
+		t.Error("first-seen time should be kept")
+	}
+	if got := pool.Removals().Invalid; got != 2 {
+		t.Errorf("Invalid = %d, want 2", got)
+	}
+}
+
+func TestPool_Revalidate_Atomic(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	pool := New(utxos, 100)
+	for i := byte(1); i <= 20; i++ {
+		op := types.Outpoint{TxID: types.Hash{i}, Index: 0}
+		utxos.add(op, 5000, addr)
+		if _, err := pool.Add(buildTxTo(t, key, op, addr, 4900)); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	// Readers never see the pool partly refilled.
+	done := make(chan struct{})
+	seen := make(chan int, 1)
+	go func() {
+		defer close(seen)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if n := pool.Count(); n != 20 {
+				seen <- n
+				return
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if dropped := pool.Revalidate(); dropped != 0 {
+			t.Fatalf("Revalidate dropped %d, want 0", dropped)
+		}
+	}
+	close(done)
+	if n, ok := <-seen; ok {
+		t.Errorf("reader saw %d transactions during Revalidate, want 20", n)
+	}
+	if got := pool.Removals().Invalid; got != 0 {
+		t.Errorf("Invalid = %d, want 0", got)
+	}
+}
+
+func TestPool_FeeEstimator(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	pool := New(utxos, 100)
+	height := uint64(100)
+	pool.SetCoinbaseMaturity(0, func() uint64 { return height }, nil)
+	est := NewFeeEstimator()
+	pool.SetFeeEstimator(est)
+
+	if _, ok := est.Estimate(1); ok {
+		t.Fatal("empty estimator should have no estimate")
+	}
+	if rate, ok := pool.EstimateFeeRate(1); ok || rate != 0 {
+		t.Fatalf("EstimateFeeRate = %d, %v; want pool minimum", rate, ok)
+	}
+
+	// High-fee transactions confirm in the next block, low-fee ones wait
+	// ten blocks.
+	var high, low []*tx.Transaction
+	for i := byte(0); i < 10; i++ {
+		hi := types.Outpoint{TxID: types.Hash{0x01, i}}
+		lo := types.Outpoint{TxID: types.Hash{0x02, i}}
+		utxos.add(hi, 50_000, addr)
+		utxos.add(lo, 50_000, addr)
+		high = append(high, buildTxTo(t, key, hi, addr, 30_000))
+		low = append(low, buildTxTo(t, key, lo, addr, 49_900))
+	}
+	for _, tr := range append(append([]*tx.Transaction{}, high...), low...) {
+		if _, err := pool.Add(tr); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	highRate := uint64(20_000 / len(high[0].SigningBytes()))
+
+	height++
+	pool.RemoveConfirmed(high)
+	for height < 109 {
+		height++
+		pool.RemoveConfirmed(nil)
+	}
+	height++
+	pool.RemoveConfirmed(low)
+
+	fast, ok := est.Estimate(1)
+	if !ok {
+		t.Fatal("expected an estimate for 1 block")
+	}
+	if fast < highRate || fast > highRate+highRate/2 {
+		t.Errorf("Estimate(1) = %d, want about %d", fast, highRate)
+	}
+	slow, ok := est.Estimate(12)
+	if !ok {
+		t.Fatal("expected an estimate for 12 blocks")
+	}
+	if slow >= fast {
+		t.Errorf("Estimate(12) = %d, should be below Estimate(1) = %d", slow, fast)
+	}
+
+	pool.SetMinFeeRate(fast * 2)
+	if rate, _ := pool.EstimateFeeRate(1); rate != fast*2 {
+		t.Errorf("EstimateFeeRate = %d, want pool minimum %d", rate, fast*2)
+	}
+
+	path := filepath.Join(t.TempDir(), "fee_estimates.json")
+	if err := est.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	reloaded := NewFeeEstimator()
+	if err := reloaded.Load(path); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got, _ := reloaded.Estimate(1); got != fast {
+		t.Errorf("reloaded Estimate(1) = %d, want %d", got, fast)
+	}
+}
+
+func TestFeeEstimator_Waiting(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	pool := New(utxos, 100)
+	height := uint64(100)
+	pool.SetCoinbaseMaturity(0, func() uint64 { return height }, nil)
+	est := NewFeeEstimator()
+	pool.SetFeeEstimator(est)
+
+	var confirmed []*tx.Transaction
+	for i := byte(0); i < 4; i++ {
+		op := types.Outpoint{TxID: types.Hash{0x01, i}}
+		utxos.add(op, 50_000, addr)
+		tr := buildTxTo(t, key, op, addr, 40_000)
+		if _, err := pool.Add(tr); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		if i < 2 {
+			confirmed = append(confirmed, tr)
+		}
+	}
+	height++
+	pool.RemoveConfirmed(confirmed)
+	if _, ok := est.Estimate(1); ok {
+		t.Error("half of the transactions missed the target; expected no estimate")
+	}
+}
+
+func TestOrphanPool_Resolve(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+	orphans := NewOrphanPool(10)
+
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4900)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 4800)
+	grandchild := buildTxTo(t, key, types.Outpoint{TxID: child.Hash(), Index: 0}, addr, 4700)
+	// Spends more than the child output holds.
+	bad := buildTxTo(t, key, types.Outpoint{TxID: child.Hash(), Index: 0}, addr, 9000)
+
+	for _, tr := range []*tx.Transaction{grandchild, child} {
+		if _, err := pool.Add(tr); !errors.Is(err, ErrMissingInputs) {
+			t.Fatalf("expected ErrMissingInputs, got: %v", err)
+		}
+		if !orphans.Add(tr, "peer-a") {
+			t.Fatal("orphan not added")
+		}
+	}
+	orphans.Add(bad, "peer-b")
+	if orphans.Add(child, "peer-a") {
+		t.Error("duplicate orphan should not be added")
+	}
+
+	if _, err := pool.Add(parent); err != nil {
+		t.Fatalf("Add parent: %v", err)
+	}
+	accepted, rejected := orphans.Resolve(pool, []types.Hash{parent.Hash()})
+	if len(accepted) != 2 || !pool.Has(child.Hash()) || !pool.Has(grandchild.Hash()) {
+		t.Errorf("accepted %d, want child and grandchild", len(accepted))
+	}
+	// The bad orphan conflicts with the grandchild or fails validation;
+	// either way it is dropped and reported with its relaying peer.
+	if len(rejected) != 1 || rejected[0].From != "peer-b" {
+		t.Errorf("rejected = %+v, want bad from peer-b", rejected)
+	}
+	if orphans.Count() != 0 {
+		t.Errorf("Count = %d, want 0", orphans.Count())
+	}
+}
+
+func TestOrphanPool_SignatureCopies(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+
+	utxos := newMockUTXOs()
+	utxos.add(types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, 5000, addr)
+	pool := New(utxos, 100)
+	orphans := NewOrphanPool(10)
+
+	parent := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, addr, 4900)
+	child := buildTxTo(t, key, types.Outpoint{TxID: parent.Hash(), Index: 0}, addr, 4800)
+	forged := *child
+	forged.Inputs = []tx.Input{child.Inputs[0]}
+	forged.Inputs[0].Signature = bytes.Repeat([]byte{0x01}, len(child.Inputs[0].Signature))
+
+	// A copy with a bad signature does not stop the genuine one being held.
+	if !orphans.Add(&forged, "peer-b") || !orphans.Add(child, "peer-a") {
+		t.Fatal("copies with different signatures should both be held")
+	}
+	if !orphans.Has(child.FullHash()) || orphans.Has(child.Hash()) {
+		t.Error("orphans should be keyed by FullHash")
+	}
+
+	if _, err := pool.Add(parent); err != nil {
+		t.Fatalf("Add parent: %v", err)
+	}
+	accepted, rejected := orphans.Resolve(pool, []types.Hash{parent.Hash()})
+	if len(accepted) != 1 || !pool.Has(child.Hash()) {
+		t.Errorf("accepted %d, want the genuine child", len(accepted))
+	}
+	if len(rejected) != 1 || rejected[0].From != "peer-b" {
+		t.Errorf("rejected = %+v, want the forged copy from peer-b", rejected)
+	}
+}
+
+func TestOrphanPool_Limits(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+	orphans := NewOrphanPool(2)
+
+	var txs []*tx.Transaction
+	for i := byte(0); i < 3; i++ {
+		tr := buildTxTo(t, key, types.Outpoint{TxID: types.Hash{0x01, i}}, addr, 1000)
+		txs = append(txs, tr)
+		orphans.Add(tr, "peer")
+		time.Sleep(time.Millisecond)
+	}
+	// Full: the oldest was evicted.
+	if orphans.Count() != 2 || orphans.Has(txs[0].FullHash()) {
+		t.Error("oldest orphan should be evicted when full")
+	}
+
+	if n := orphans.RemoveFrom("peer"); n != 2 || orphans.Count() != 0 {
+		t.Errorf("RemoveFrom = %d, want 2", n)
+	}
+
+	orphans.Add(txs[0], "peer")
+	if n := orphans.Expire(time.Now().Add(OrphanTTL + time.Second)); n != 1 {
+		t.Errorf("Expire = %d, want 1", n)
+	}
+}
+
+func TestRejectFilter(t *testing.T) {
+	f := NewRejectFilter(2)
+	a, b, c := types.Hash{0x01}, types.Hash{0x02}, types.Hash{0x03}
+
+	f.Add(a)
+	f.Add(b)
+	if !f.Has(a) || !f.Has(b) {
+		t.Fatal("added hashes should be remembered")
+	}
+	f.Add(c)
+	if f.Has(a) || !f.Has(b) || !f.Has(c) {
+		t.Error("oldest hash should be forgotten first")
+	}
+	f.Reset()
+	if f.Has(b) || f.Has(c) {
+		t.Error("Reset should forget every hash")
+	}
+}
+
+func TestPolicy_Check_Standardness(t *testing.T) {
+	p2pkh := types.Script{Type: types.ScriptTypeP2PKH, Data: make([]byte, 20)}
+	token := &types.TokenData{ID: types.TokenID{0x01}, Amount: 5}
+	transaction := &tx.Transaction{
+		Inputs: []tx.Input{{PrevOut: types.Outpoint{TxID: types.Hash{0x01}}, Signature: []byte("s"), PubKey: []byte("k")}},
+		Outputs: []tx.Output{
+			{Value: 500, Script: p2pkh},
+			{Value: 0, Script: p2pkh, Token: token},
+			{Value: 0, Script: p2pkh, Token: token},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		want   error
+	}{
+		{"dust", Policy{DustThreshold: 501}, ErrDust},
+		{"token outputs exempt from dust", Policy{DustThreshold: 500}, nil},
+		{"script data", Policy{MaxScriptData: 19}, ErrScriptData},
+		{"script type", Policy{AllowedScripts: []types.ScriptType{types.ScriptTypeP2SH}}, ErrScriptType},
+		{"allowed script type", Policy{AllowedScripts: []types.ScriptType{types.ScriptTypeP2PKH}}, nil},
+		{"token outputs", Policy{MaxTokenOutputs: 1}, ErrTokenOutputs},
+		{"size", Policy{MaxTxSize: 1}, ErrNonStandard},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(transaction)
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Errorf("Check = %v, want %v", err, tt.want)
+			}
+			if err != nil && !IsPolicyError(err) {
+				t.Errorf("%v should be a policy error", err)
+			}
+		})
+	}
+}
+
+func TestPool_Add_Policy(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+	utxos := newMockUTXOs()
+	prevOut := types.Outpoint{TxID: types.Hash{0x01}, Index: 0}
+	utxos.add(prevOut, 5000, addr)
+
+	pool := New(utxos, 100)
+	pool.SetPolicy(&Policy{DustThreshold: 1000})
+	if _, err := pool.Add(buildTxTo(t, key, prevOut, addr, 999)); !errors.Is(err, ErrDust) {
+		t.Fatalf("Add dust = %v, want ErrDust", err)
+	}
+	if _, err := pool.Add(buildTxTo(t, key, prevOut, addr, 1000)); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+}
+
+// denyRule rejects transactions with more than max outputs.
+type denyRule struct{ max int }
+
+func (r denyRule) Name() string { return "max-outputs" }
+
+func (r denyRule) Check(transaction *tx.Transaction, _ tx.UTXOProvider) error {
+	if len(transaction.Outputs) > r.max {
+		return fmt.Errorf("%d outputs", len(transaction.Outputs))
+	}
+	return nil
+}
+
+func TestPool_AddPolicyRule(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+	utxos := newMockUTXOs()
+	prevOut := types.Outpoint{TxID: types.Hash{0x01}, Index: 0}
+	utxos.add(prevOut, 5000, addr)
+
+	pool := New(utxos, 100)
+	pool.AddPolicyRule(denyRule{max: 1})
+	_, err := pool.Add(buildTxTo(t, key, prevOut, addr, 1000, 1000))
+	if !errors.Is(err, ErrPolicyRule) || !strings.Contains(err.Error(), "max-outputs") {
+		t.Fatalf("Add = %v, want ErrPolicyRule from max-outputs", err)
+	}
+	if _, err := pool.Add(buildTxTo(t, key, prevOut, addr, 1000)); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+}
+
+func TestPool_AddressRate(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := addressFromKey(key)
+	utxos := newMockUTXOs()
+	for i := byte(1); i <= 3; i++ {
+		utxos.add(types.Outpoint{TxID: types.Hash{i}}, 5000, addr)
+	}
+
+	pool := New(utxos, 100)
+	pool.SetPolicy(&Policy{AddressRate: 2})
+	for i := byte(1); i <= 2; i++ {
+		if _, err := pool.Add(buildTx(t, key, types.Outpoint{TxID: types.Hash{i}}, 4000)); err != nil {
+			t.Fatalf("Add %d: %v", i, err)
+		}
+	}
+	third := buildTx(t, key, types.Outpoint{TxID: types.Hash{0x03}}, 4000)
+	if _, err := pool.Add(third); !errors.Is(err, ErrAddressRate) {
+		t.Fatalf("Add third = %v, want ErrAddressRate", err)
+	}
+
+	// Restored transactions were accepted before and are not limited.
+	if dropped := pool.Revalidate(); dropped != 0 {
+		t.Errorf("Revalidate dropped %d, want 0", dropped)
+	}
+}
+
+func TestAddressLimiter_EvictsLeastRecentlyUsed(t *testing.T) {
+	l := newAddressLimiter(1)
+	now := time.Now()
+	addrAt := func(i int) types.Address {
+		var a types.Address
+		binary.BigEndian.PutUint32(a[:], uint32(i))
+		return a
+	}
+
+	for i := 0; i < maxAddressBuckets; i++ {
+		l.take([]types.Address{addrAt(i)}, now)
+	}
+	// Address 0 is used again, so address 1 is now the least recent.
+	l.take([]types.Address{addrAt(0)}, now)
+	l.take([]types.Address{addrAt(maxAddressBuckets)}, now)
+
+	if len(l.buckets) != maxAddressBuckets {
+		t.Fatalf("buckets = %d, want %d", len(l.buckets), maxAddressBuckets)
+	}
+	if _, ok := l.buckets[addrAt(1)]; ok {
+		t.Error("least recently used bucket should be evicted")
+	}
+	if _, ok := l.check([]types.Address{addrAt(0)}, now); ok {
+		t.Error("recently used address should keep its limit")
+	}
+}
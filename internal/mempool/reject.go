@@ -0,0 +1,68 @@
+package mempool
+
+import (
+	"sync"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// DefaultRejectFilterSize is the number of rejected hashes remembered.
+const DefaultRejectFilterSize = 10_000
+
+// RejectFilter remembers recently rejected transactions by FullHash so the
+// same relayed transaction is not validated again. Keying on the txid
+// would let a peer relaying a copy with a corrupted signature block the
+// genuine transaction. It holds at most size hashes, forgetting the
+// oldest first. Reset it when the chain tip moves, since a rejected
+// transaction may become valid.
+type RejectFilter struct {
+	mu    sync.Mutex
+	set   map[types.Hash]struct{}
+	order []types.Hash // Ring buffer of hashes in insertion order.
+	next  int
+}
+
+// NewRejectFilter creates a filter remembering at most size hashes.
+func NewRejectFilter(size int) *RejectFilter {
+	if size <= 0 {
+		size = DefaultRejectFilterSize
+	}
+	return &RejectFilter{
+		set:   make(map[types.Hash]struct{}, size),
+		order: make([]types.Hash, 0, size),
+	}
+}
+
+// Add remembers a rejected hash.
+func (f *RejectFilter) Add(txHash types.Hash) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.set[txHash]; ok {
+		return
+	}
+	if len(f.order) < cap(f.order) {
+		f.order = append(f.order, txHash)
+	} else {
+		delete(f.set, f.order[f.next])
+		f.order[f.next] = txHash
+		f.next = (f.next + 1) % len(f.order)
+	}
+	f.set[txHash] = struct{}{}
+}
+
+// Has reports whether a hash was recently rejected.
+func (f *RejectFilter) Has(txHash types.Hash) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.set[txHash]
+	return ok
+}
+
+// Reset forgets every hash.
+func (f *RejectFilter) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	clear(f.set)
+	f.order = f.order[:0]
+	f.next = 0
+}
@@ -0,0 +1,125 @@
+package mempool
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// Replace-by-fee defaults.
+const (
+	// DefaultIncrementalFeeRate is the fee rate (base units per byte) a
+	// replacement must add on top of the fees of everything it evicts.
+	DefaultIncrementalFeeRate = 1
+
+	// MaxReplacementEvictions bounds how many pool transactions (conflicts
+	// plus their descendants) a single replacement may evict.
+	MaxReplacementEvictions = 100
+)
+
+// SetIncrementalFeeRate sets the extra fee rate (base units per byte) a
+// replacement must pay over the transactions it evicts.
+func (p *Pool) SetIncrementalFeeRate(rate uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.incrementalFeeRate = rate
+}
+
+// IncrementalFeeRate returns the replacement incremental fee rate.
+func (p *Pool) IncrementalFeeRate() uint64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.incrementalFeeRate
+}
+
+// MinReplacementFee returns the lowest fee a replacement of txHash with the
+// given size must pay: the fees of txHash and its descendants plus the
+// incremental fee for size. Replacing several conflicts needs more.
+func (p *Pool) MinReplacementFee(txHash types.Hash, size int) (uint64, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	e, ok := p.txs[txHash]
+	if !ok {
+		return 0, fmt.Errorf("transaction %s not in mempool", txHash)
+	}
+	evict := p.descendantsLocked(txHash)
+	evict[txHash] = e
+	fees, err := sumFees(evict)
+	if err != nil {
+		return 0, err
+	}
+	// Strictly higher than the fees replaced.
+	incremental := p.incrementalFeeRate * uint64(size)
+	if incremental == 0 {
+		incremental = 1
+	}
+	if fees > math.MaxUint64-incremental {
+		return 0, fmt.Errorf("%w: fee overflow", ErrReplacement)
+	}
+	return fees + incremental, nil
+}
+
+// checkReplacementLocked applies the replace-by-fee rules to a transaction
+// that conflicts with the given pool entries, all of which signal
+// replaceability. It returns every entry the replacement would evict: the
+// conflicts and their descendants. Must be called with p.mu held.
+//
+// The replacement must pay a higher fee rate than each conflict, a higher
+// absolute fee than all evicted entries together plus the incremental fee
+// for its own size, evict at most MaxReplacementEvictions entries, and not
+// spend outputs of an entry it evicts.
+func (p *Pool) checkReplacementLocked(
+	size int,
+	fee uint64,
+	feeRate float64,
+	conflicts map[types.Hash]struct{},
+	parents map[types.Hash]struct{},
+) (map[types.Hash]*entry, error) {
+	evict := make(map[types.Hash]*entry)
+	for h := range conflicts {
+		e := p.txs[h]
+		if feeRate <= e.feeRate {
+			return nil, fmt.Errorf("%w: fee rate %.2f not above %.2f of %s", ErrReplacement, feeRate, e.feeRate, h)
+		}
+		evict[h] = e
+		for dh, d := range p.descendantsLocked(h) {
+			evict[dh] = d
+		}
+	}
+	if len(evict) > MaxReplacementEvictions {
+		return nil, fmt.Errorf("%w: would evict %d transactions, max %d", ErrReplacement, len(evict), MaxReplacementEvictions)
+	}
+	for h := range parents {
+		if _, ok := evict[h]; ok {
+			return nil, fmt.Errorf("%w: spends output of replaced transaction %s", ErrReplacement, h)
+		}
+	}
+
+	evictedFees, err := sumFees(evict)
+	if err != nil {
+		return nil, err
+	}
+	if fee <= evictedFees {
+		return nil, fmt.Errorf("%w: fee %d not above replaced fees %d", ErrReplacement, fee, evictedFees)
+	}
+	incremental := p.incrementalFeeRate * uint64(size)
+	if fee-evictedFees < incremental {
+		return nil, fmt.Errorf("%w: fee %d below %d (replaced fees %d + %d bytes × %d incremental rate)",
+			ErrReplacement, fee, evictedFees+incremental, evictedFees, size, p.incrementalFeeRate)
+	}
+	return evict, nil
+}
+
+// sumFees returns the total fee of the given entries.
+func sumFees(entries map[types.Hash]*entry) (uint64, error) {
+	var total uint64
+	for _, e := range entries {
+		if total > math.MaxUint64-e.fee {
+			return 0, fmt.Errorf("%w: fee overflow", ErrReplacement)
+		}
+		total += e.fee
+	}
+	return total, nil
+}
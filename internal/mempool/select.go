@@ -0,0 +1,152 @@
+package mempool
+
+import (
+	"bytes"
+	"container/heap"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// SelectPackages returns transactions for a block, at most maxTxs of them
+// totalling at most maxBytes signing bytes (0 = no byte limit).
+//
+// Transactions are scored by ancestor package: the fee rate of the
+// transaction together with its unselected in-pool ancestors. The best
+// package is taken whole, so a high-fee child pays for its low-fee parent
+// (child-pays-for-parent). Packages that no longer fit are skipped in
+// favour of smaller ones. The result is in topological order: parents
+// before children.
+func (p *Pool) SelectPackages(maxTxs, maxBytes int) []*tx.Transaction {
+	return p.selectPackages(maxTxs, maxBytes, true)
+}
+
+// SelectConfirmed is SelectPackages restricted to transactions whose
+// inputs are all confirmed, for blocks that may not spend outputs created
+// in the same block. Children are left in the pool until their parents
+// confirm.
+func (p *Pool) SelectConfirmed(maxTxs, maxBytes int) []*tx.Transaction {
+	return p.selectPackages(maxTxs, maxBytes, false)
+}
+
+func (p *Pool) selectPackages(maxTxs, maxBytes int, withChildren bool) []*tx.Transaction {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if maxTxs <= 0 {
+		return nil
+	}
+
+	selected := make(map[types.Hash]bool)
+	h := make(packageHeap, 0, len(p.txs))
+	for _, e := range p.txs {
+		if !withChildren && p.hasPoolParentLocked(e) {
+			continue
+		}
+		h = append(h, p.scoreLocked(e, selected))
+	}
+	heap.Init(&h)
+
+	var result []*tx.Transaction
+	usedBytes := 0
+	for h.Len() > 0 && len(result) < maxTxs {
+		item := heap.Pop(&h).(*packageScore)
+		if selected[item.e.txHash] {
+			continue
+		}
+		// Stale: an ancestor was selected since this score was pushed.
+		// Its descendants were re-scored then, so drop it.
+		cur := p.scoreLocked(item.e, selected)
+		if cur.fee != item.fee || cur.size != item.size {
+			continue
+		}
+		if len(result)+len(cur.pkg) > maxTxs {
+			continue
+		}
+		if maxBytes > 0 && usedBytes+cur.size > maxBytes {
+			continue
+		}
+
+		for _, pe := range cur.pkg {
+			selected[pe.txHash] = true
+			result = append(result, pe.tx)
+			usedBytes += pe.size
+		}
+		if !withChildren {
+			continue
+		}
+		// Selecting a package changes the package of every descendant.
+		for _, pe := range cur.pkg {
+			for dh, d := range p.descendantsLocked(pe.txHash) {
+				if !selected[dh] {
+					heap.Push(&h, p.scoreLocked(d, selected))
+				}
+			}
+		}
+	}
+	return result
+}
+
+// hasPoolParentLocked reports whether e spends an output of another pool
+// transaction. Must be called with p.mu held.
+func (p *Pool) hasPoolParentLocked(e *entry) bool {
+	for parent := range e.parents {
+		if _, ok := p.txs[parent]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// packageScore is an entry with its unselected ancestor package.
+type packageScore struct {
+	e    *entry
+	pkg  []*entry // Topological order, e last.
+	fee  uint64
+	size int
+}
+
+// rate returns the package fee rate.
+func (s *packageScore) rate() float64 {
+	if s.size == 0 {
+		return 0
+	}
+	return float64(s.fee) / float64(s.size)
+}
+
+// scoreLocked computes the ancestor package of e excluding selected
+// entries. Must be called with p.mu held.
+func (p *Pool) scoreLocked(e *entry, selected map[types.Hash]bool) *packageScore {
+	s := &packageScore{e: e, pkg: p.packageLocked(e, selected)}
+	for _, pe := range s.pkg {
+		s.fee += pe.fee
+		s.size += pe.size
+	}
+	return s
+}
+
+// packageHeap is a max-heap of package scores, ties broken by hash for a
+// deterministic selection.
+type packageHeap []*packageScore
+
+func (h packageHeap) Len() int { return len(h) }
+
+func (h packageHeap) Less(i, j int) bool {
+	ri, rj := h[i].rate(), h[j].rate()
+	if ri != rj {
+		return ri > rj
+	}
+	return bytes.Compare(h[i].e.txHash[:], h[j].e.txHash[:]) < 0
+}
+
+func (h packageHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *packageHeap) Push(x any) { *h = append(*h, x.(*packageScore)) }
+
+func (h *packageHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
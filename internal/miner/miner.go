@@ -25,7 +25,12 @@ type ChainState interface {
 
 // MempoolSelector selects transactions for block inclusion.
 type MempoolSelector interface {
-	SelectForBlock(limit int) []*tx.Transaction
+	// SelectPackages returns at most maxTxs transactions totalling at most
+	// maxBytes signing bytes, parents before children.
+	SelectPackages(maxTxs, maxBytes int) []*tx.Transaction
+	// SelectConfirmed is SelectPackages without transactions that spend
+	// outputs of other pool transactions.
+	SelectConfirmed(maxTxs, maxBytes int) []*tx.Transaction
 	GetFee(txHash types.Hash) uint64
 }
 
@@ -43,6 +48,7 @@ type Miner struct {
 	maxSupply       uint64     // 0 = unlimited
 	supplyFn        SupplyFunc // nil = no cap check
 	maxBlockTxs     int
+	forks           config.ForkSchedule
 }
 
 // New creates a new block producer.
@@ -65,6 +71,11 @@ func (m *Miner) SetHalvingInterval(interval uint64) {
 	m.halvingInterval = interval
 }
 
+// SetForkSchedule configures the protocol upgrades new blocks follow.
+func (m *Miner) SetForkSchedule(f config.ForkSchedule) {
+	m.forks = f
+}
+
 // ProduceBlock builds, seals, and returns a new block using the current time.
 // The coinbase output value = block reward + sum of all tx fees.
 // The block is NOT applied to the chain — the caller must call ProcessBlock.
@@ -95,7 +106,15 @@ func (m *Miner) produceBlock(ctx context.Context, timestamp uint64) (*block.Bloc
 	var selected []*tx.Transaction
 	var totalFees uint64
 	if m.pool != nil {
-		selected = m.pool.SelectForBlock(m.maxBlockTxs - 1) // Reserve slot for coinbase.
+		// Reserve a slot and room for the coinbase.
+		height := m.chain.Height() + 1
+		budget := TxByteBudget(m.coinbaseAddr, height)
+		if m.forks.InBlockSpends(height) {
+			selected = m.pool.SelectPackages(m.maxBlockTxs-1, budget)
+		} else {
+			// Until the fork, children wait for their parents to confirm.
+			selected = m.pool.SelectConfirmed(m.maxBlockTxs-1, budget)
+		}
 		for _, t := range selected {
 			totalFees += m.pool.GetFee(t.Hash())
 		}
@@ -165,6 +184,15 @@ func (m *Miner) blockRewardAtHeight(height uint64) uint64 {
 	}.BlockSubsidy(height)
 }
 
+// TxByteBudget returns the signing bytes left for non-coinbase transactions
+// in a block at height whose coinbase pays addr, under config.MaxBlockSize.
+// The coinbase size does not depend on the reward.
+func TxByteBudget(addr types.Address, height uint64) int {
+	header := len((&block.Header{}).SigningBytes())
+	coinbase := len(BuildCoinbase(addr, 0, height).SigningBytes())
+	return config.MaxBlockSize - header - coinbase
+}
+
 // BuildCoinbase creates a coinbase transaction with the given reward.
 // The block height is encoded in the coinbase input's signature field
 // to ensure each coinbase tx has a unique hash (similar to Bitcoin's BIP34).
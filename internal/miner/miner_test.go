@@ -3,6 +3,7 @@ package miner
 import (
 	"testing"
 
+	"github.com/Klingon-tech/klingnet-chain/config"
 	"github.com/Klingon-tech/klingnet-chain/internal/consensus"
 	"github.com/Klingon-tech/klingnet-chain/internal/storage"
 	"github.com/Klingon-tech/klingnet-chain/internal/utxo"
@@ -76,17 +77,29 @@ func (m *mockChainState) TipTimestamp() uint64 { return m.tipTimestamp }
 type mockMempool struct {
 	txs  []*tx.Transaction
 	fees map[types.Hash]uint64
+
+	// Limits passed to the last selection, and whether it was
+	// SelectPackages rather than SelectConfirmed.
+	maxTxs, maxBytes int
+	withChildren     bool
 }
 
 func newMockMempool(txs []*tx.Transaction, fees map[types.Hash]uint64) *mockMempool {
 	return &mockMempool{txs: txs, fees: fees}
 }
 
-func (m *mockMempool) SelectForBlock(limit int) []*tx.Transaction {
-	if limit >= len(m.txs) {
+func (m *mockMempool) SelectPackages(maxTxs, maxBytes int) []*tx.Transaction {
+	txs := m.SelectConfirmed(maxTxs, maxBytes)
+	m.withChildren = true
+	return txs
+}
+
+func (m *mockMempool) SelectConfirmed(maxTxs, maxBytes int) []*tx.Transaction {
+	m.maxTxs, m.maxBytes, m.withChildren = maxTxs, maxBytes, false
+	if maxTxs >= len(m.txs) {
 		return m.txs
 	}
-	return m.txs[:limit]
+	return m.txs[:maxTxs]
 }
 
 func (m *mockMempool) GetFee(txHash types.Hash) uint64 {
@@ -230,6 +243,49 @@ func TestMiner_ProduceBlock_WithMempool(t *testing.T) {
 
 // --- Supply Cap ---
 
+func TestMiner_ProduceBlock_BlockBudget(t *testing.T) {
+	m, _ := testMiner(t)
+	pool := newMockMempool(nil, nil)
+	m.pool = pool
+
+	blk, err := m.ProduceBlock()
+	if err != nil {
+		t.Fatalf("ProduceBlock: %v", err)
+	}
+	if pool.maxTxs != config.MaxBlockTxs-1 {
+		t.Errorf("maxTxs = %d, want %d", pool.maxTxs, config.MaxBlockTxs-1)
+	}
+
+	// Budget plus header and coinbase fills the block exactly.
+	used := len(blk.Header.SigningBytes()) + len(blk.Transactions[0].SigningBytes())
+	if pool.maxBytes+used != config.MaxBlockSize {
+		t.Errorf("maxBytes = %d, want %d", pool.maxBytes, config.MaxBlockSize-used)
+	}
+}
+
+func TestMiner_ProduceBlock_InBlockSpendFork(t *testing.T) {
+	m, _ := testMiner(t)
+	pool := newMockMempool(nil, nil)
+	m.pool = pool
+
+	// The next block is at height 1.
+	m.SetForkSchedule(config.ForkSchedule{InBlockSpendHeight: 2})
+	if _, err := m.ProduceBlock(); err != nil {
+		t.Fatalf("ProduceBlock: %v", err)
+	}
+	if pool.withChildren {
+		t.Error("unconfirmed children selected before the fork")
+	}
+
+	m.SetForkSchedule(config.ForkSchedule{InBlockSpendHeight: 1})
+	if _, err := m.ProduceBlock(); err != nil {
+		t.Fatalf("ProduceBlock: %v", err)
+	}
+	if !pool.withChildren {
+		t.Error("unconfirmed children not selected after the fork")
+	}
+}
+
 func TestMiner_ProduceBlock_SupplyCapReduced(t *testing.T) {
 	key, _ := crypto.GenerateKey()
 	poa, _ := consensus.NewPoA([][]byte{key.PublicKey()}, 3)
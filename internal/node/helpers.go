@@ -9,6 +9,9 @@ import (
 
 	"github.com/Klingon-tech/klingnet-chain/config"
 	"github.com/Klingon-tech/klingnet-chain/internal/consensus"
+	"github.com/Klingon-tech/klingnet-chain/internal/mempool"
+	"github.com/Klingon-tech/klingnet-chain/internal/p2p"
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
 	"github.com/Klingon-tech/klingnet-chain/pkg/crypto"
 	"github.com/Klingon-tech/klingnet-chain/pkg/types"
 )
@@ -56,6 +59,24 @@ func loadValidatorKey(path string) (*crypto.PrivateKey, error) {
 	return pk, nil
 }
 
+// mempoolPolicy builds the mempool standardness rules from the policy
+// config section.
+func mempoolPolicy(cfg config.PolicyConfig) (*mempool.Policy, error) {
+	policy := mempool.DefaultPolicy()
+	policy.DustThreshold = cfg.Dust
+	policy.MaxScriptData = cfg.MaxScriptData
+	policy.MaxTokenOutputs = cfg.MaxTokenOutputs
+	policy.AddressRate = cfg.AddressRate
+	for _, name := range cfg.ScriptTypes {
+		st, err := types.ParseScriptType(name)
+		if err != nil {
+			return nil, fmt.Errorf("policy.scripttypes: %w", err)
+		}
+		policy.AllowedScripts = append(policy.AllowedScripts, st)
+	}
+	return policy, nil
+}
+
 // resolveCoinbase determines the coinbase address from a string or validator key.
 func resolveCoinbase(coinbaseStr string, validatorKey *crypto.PrivateKey) (types.Address, error) {
 	if coinbaseStr != "" {
@@ -104,6 +125,39 @@ func isPoW(engine consensus.Engine) bool {
 	return ok
 }
 
+// nextHeaderVerifier returns a gossip block check that verifies headers
+// for the next height with engine. Other headers pass: blocks further
+// ahead may be signed by validators staked in blocks we have not seen,
+// and older ones by validators since removed.
+func nextHeaderVerifier(height func() uint64, engine consensus.Engine) func(*block.Header) error {
+	return func(h *block.Header) error {
+		if h.Height != height()+1 {
+			return nil
+		}
+		return engine.VerifyHeader(h)
+	}
+}
+
+// badBatchOutcome classifies a sync batch that failed the contiguity
+// check. A peer serving nothing at or below the height it claimed has
+// proven the claim false. Request errors are recorded by the syncer, so
+// ok is false for them.
+func badBatchOutcome(err error, blocks []*block.Block, from, claimed uint64) (outcome p2p.SyncOutcome, ok bool) {
+	if err != nil {
+		return 0, false
+	}
+	if len(blocks) == 0 && from <= claimed {
+		return p2p.SyncFalseHeight, true
+	}
+	return p2p.SyncEmpty, true
+}
+
+// contradictsTip reports whether blk sits at the height sp claimed as its
+// tip but is not the tip it reported.
+func contradictsTip(blk *block.Block, sp syncPeer) bool {
+	return sp.tipHash != "" && blk.Header.Height == sp.height && blk.Hash().String() != sp.tipHash
+}
+
 // formatDifficulty returns a human-readable difficulty string (e.g. "1.05M").
 func formatDifficulty(d uint64) string {
 	switch {
@@ -0,0 +1,53 @@
+package node
+
+import (
+	"github.com/Klingon-tech/klingnet-chain/internal/chain"
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/lightclient"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// chainLightProvider serves light client requests from the chain.
+type chainLightProvider struct {
+	ch *chain.Chain
+}
+
+// Headers returns up to max active-chain headers from height from.
+func (p chainLightProvider) Headers(from uint64, max uint32) []*block.Header {
+	var headers []*block.Header
+	for h := from; h < from+uint64(max); h++ {
+		blk, err := p.ch.GetBlockByHeight(h)
+		if err != nil {
+			break
+		}
+		headers = append(headers, blk.Header)
+	}
+	return headers
+}
+
+// StakeChanges returns the proven validator set changes in up to max
+// blocks from height from.
+func (p chainLightProvider) StakeChanges(from uint64, max uint32) ([]*lightclient.StakeChange, error) {
+	var changes []*lightclient.StakeChange
+	for h := from; h < from+uint64(max); h++ {
+		blk, err := p.ch.GetBlockByHeight(h)
+		if err != nil {
+			break
+		}
+		sc, err := lightclient.BlockStakeChanges(blk, p.ch.GetTxBlock)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, sc...)
+	}
+	return changes, nil
+}
+
+// TxProof returns the inclusion proof for a confirmed transaction.
+func (p chainLightProvider) TxProof(hash types.Hash) (*lightclient.TxProof, error) {
+	blk, err := p.ch.GetTxBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	return lightclient.NewTxProof(blk, hash)
+}
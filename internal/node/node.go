@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand/v2"
 	"os"
 	"sort"
 	"sync"
@@ -32,6 +33,8 @@ import (
 	"github.com/Klingon-tech/klingnet-chain/pkg/crypto"
 	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
 	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/rs/zerolog"
 )
@@ -56,7 +59,8 @@ type Node struct {
 	syncer  *p2p.Syncer
 
 	// RPC
-	rpcServer *rpc.Server
+	rpcServer     *rpc.Server
+	rebroadcaster *rpc.Rebroadcaster // Wallet tx rebroadcast (nil = disabled).
 
 	// Mining
 	validatorKey *crypto.PrivateKey
@@ -73,16 +77,43 @@ type Node struct {
 	rootSyncing     atomic.Bool
 	initialSyncDone chan struct{} // closed after first startup sync completes
 
+	now   func() time.Time // Wall clock (virtual in simulations).
+	miner *miner.Miner     // Root-chain block producer (nil until needed).
+
 	// Lifecycle
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 }
 
+// Options replaces parts of a node's normal setup. Zero fields keep the
+// default; in-process networks use them to run many nodes side by side.
+type Options struct {
+	Genesis      *config.Genesis    // Instead of the network's built-in genesis.
+	DB           storage.DB         // Instead of opening cfg.DatabaseDir().
+	ValidatorKey *crypto.PrivateKey // Instead of loading cfg.Mining.ValidatorKey; zeroed by Stop.
+	Clock        func() time.Time   // Instead of time.Now.
+
+	// With cfg.P2P.Enabled: a host instead of one listening on cfg.P2P,
+	// e.g. an in-memory mocknet peer, and extra GossipSub options.
+	Host          host.Host
+	PubSubOptions []pubsub.Option
+}
+
 // New creates and initializes a new Node. It performs all setup steps
 // (logger, genesis, storage, consensus, chain, mempool, P2P, RPC) but
 // does NOT start background goroutines (mining, sync). Call Start() for that.
 func New(cfg *config.Config) (*Node, error) {
+	return NewWithOptions(cfg, Options{})
+}
+
+// NewWithOptions is New with parts of the setup replaced by opts.
+func NewWithOptions(cfg *config.Config, opts Options) (*Node, error) {
+	now := opts.Clock
+	if now == nil {
+		now = time.Now
+	}
+
 	// ── 1. Set address HRP ──────────────────────────────────────────
 	if cfg.Network == config.Testnet {
 		types.SetAddressHRP(types.TestnetHRP)
@@ -105,7 +136,10 @@ func New(cfg *config.Config) (*Node, error) {
 	logger := klog.WithComponent("node")
 
 	// ── 3. Genesis ──────────────────────────────────────────────────
-	genesis := config.GenesisFor(cfg.Network)
+	genesis := opts.Genesis
+	if genesis == nil {
+		genesis = config.GenesisFor(cfg.Network)
+	}
 
 	logger.Info().
 		Str("chain_id", genesis.ChainID).
@@ -115,18 +149,26 @@ func New(cfg *config.Config) (*Node, error) {
 		Msg("Starting Klingnet Chain Node")
 
 	// ── 4. Open storage ─────────────────────────────────────────────
-	db, err := storage.NewBadger(cfg.ChainDataDir())
-	if err != nil {
-		return nil, fmt.Errorf("open database at %s: %w", cfg.ChainDataDir(), err)
+	db := opts.DB
+	if db == nil {
+		var err error
+		db, err = storage.Open(storage.Engine(cfg.Storage.Engine), cfg.DatabaseDir())
+		if err != nil {
+			return nil, fmt.Errorf("open database at %s: %w", cfg.DatabaseDir(), err)
+		}
 	}
 
 	utxoStore := utxo.NewStore(db)
 	tokenStore := token.NewStore(db)
-	logger.Info().Str("path", cfg.ChainDataDir()).Msg("Database opened")
+	logger.Info().
+		Str("path", cfg.DatabaseDir()).
+		Str("engine", cfg.Storage.Engine).
+		Msg("Database opened")
 
 	// ── 5. Validator key ────────────────────────────────────────────
-	var validatorKey *crypto.PrivateKey
-	if cfg.Mining.ValidatorKey != "" {
+	var err error
+	validatorKey := opts.ValidatorKey
+	if validatorKey == nil && cfg.Mining.ValidatorKey != "" {
 		validatorKey, err = loadValidatorKey(cfg.Mining.ValidatorKey)
 		if err != nil {
 			db.Close()
@@ -173,7 +215,36 @@ func New(cfg *config.Config) (*Node, error) {
 	}
 	ch.SetConsensusRules(genesis.Protocol.Consensus)
 	ch.SetTokenRules(genesis.Protocol.Token)
+	ch.SetForkSchedule(genesis.Protocol.Forks)
 	ch.SetRegistrationValidator(subchain.NewRegistrationValidator(&genesis.Protocol.SubChain))
+	ch.SetClock(now)
+
+	checkpoints, assumeValid, err := config.ResolveCheckpoints(genesis, cfg.Sync)
+	if err != nil {
+		db.Close()
+		if validatorKey != nil {
+			validatorKey.Zero()
+		}
+		return nil, fmt.Errorf("checkpoints: %w", err)
+	}
+	if len(checkpoints) > 0 {
+		cps := make(map[uint64]types.Hash, len(checkpoints))
+		for _, cp := range checkpoints {
+			cps[cp.Height] = cp.BlockHash()
+		}
+		ch.SetCheckpoints(cps)
+		logger.Info().
+			Int("count", len(checkpoints)).
+			Uint64("last_height", checkpoints[len(checkpoints)-1].Height).
+			Msg("Checkpoints loaded")
+	}
+	if assumeValid != nil {
+		ch.SetAssumeValid(assumeValid.Height, assumeValid.BlockHash())
+		logger.Info().
+			Uint64("height", assumeValid.Height).
+			Str("hash", assumeValid.Hash).
+			Msg("Assume-valid enabled: skipping script checks below this block")
+	}
 
 	state := ch.State()
 	if state.IsGenesis() {
@@ -210,17 +281,51 @@ func New(cfg *config.Config) (*Node, error) {
 	adapter := miner.NewUTXOAdapter(utxoStore)
 	pool := mempool.New(adapter, 5000)
 	pool.SetMinFeeRate(genesis.Protocol.Consensus.MinFeeRate)
+	if rate := genesis.Protocol.Consensus.MinFeeRate; rate > 0 {
+		pool.SetIncrementalFeeRate(rate)
+	}
 	pool.SetCoinbaseMaturity(config.CoinbaseMaturity, ch.Height, utxoStore)
 	pool.SetTokenValidator(&token.UTXOTokenAdapter{Set: utxoStore})
 	pool.SetMintingAllowed(genesis.Protocol.Token.AllowMinting)
 	pool.SetMintFee(config.TokenCreationFee)
 	pool.SetStakeAmount(genesis.Protocol.Consensus.ValidatorStake)
+	pool.SetMaxBytes(cfg.Mempool.MaxSize * 1_000_000)
+	pool.SetMaxAge(time.Duration(cfg.Mempool.Expiry) * time.Hour)
+	policy, err := mempoolPolicy(cfg.Policy)
+	if err != nil {
+		db.Close()
+		if validatorKey != nil {
+			validatorKey.Zero()
+		}
+		return nil, fmt.Errorf("mempool policy: %w", err)
+	}
+	pool.SetPolicy(policy)
+
+	feeEstimator := mempool.NewFeeEstimator()
+	if err := feeEstimator.Load(cfg.FeeEstimatesFile()); err != nil {
+		logger.Warn().Err(err).Msg("Failed to load fee estimates")
+	}
+	pool.SetFeeEstimator(feeEstimator)
 
 	logger.Info().
 		Uint64("min_fee_rate", genesis.Protocol.Consensus.MinFeeRate).
 		Uint64("mint_fee", config.TokenCreationFee).
+		Int("max_mb", cfg.Mempool.MaxSize).
+		Int("expiry_hours", cfg.Mempool.Expiry).
 		Msg("Mempool ready")
 
+	if cfg.Mempool.Persist {
+		loaded, dropped, err := pool.Load(cfg.MempoolFile())
+		if err != nil {
+			logger.Warn().Err(err).Msg("Failed to load saved mempool")
+		} else if loaded+dropped > 0 {
+			logger.Info().
+				Int("loaded", loaded).
+				Int("dropped", dropped).
+				Msg("Saved mempool reloaded")
+		}
+	}
+
 	// ── 9. Validator tracker ────────────────────────────────────────
 	tracker := consensus.NewValidatorTracker(60 * time.Second)
 
@@ -245,11 +350,44 @@ func New(cfg *config.Config) (*Node, error) {
 			NetworkID:  genesis.ChainID,
 			DataDir:    cfg.ChainDataDir(),
 			ClearBans:  cfg.P2P.ClearBans,
+
+			ListenAddrs:   cfg.P2P.ListenAddrs,
+			AnnounceAddrs: cfg.P2P.AnnounceAddrs,
+			ExternalAddrs: cfg.P2P.ExternalAddrs,
+
+			NATPortMap:   cfg.P2P.NATPortMap,
+			AutoNAT:      cfg.P2P.AutoNAT,
+			Relay:        cfg.P2P.Relay,
+			RelayService: cfg.P2P.RelayService,
+			HolePunch:    cfg.P2P.HolePunch,
+
+			PrivatePeers:    cfg.P2P.PrivatePeers,
+			PersistentPeers: cfg.P2P.PersistentPeers,
+			HiddenPeers:     cfg.P2P.HiddenPeers,
+
+			Dandelion:      cfg.P2P.Dandelion,
+			BroadcastDelay: time.Duration(cfg.P2P.BroadcastDelay) * time.Second,
+
+			UploadRate:     cfg.P2P.UploadRate * 1024,
+			PeerUploadRate: cfg.P2P.PeerUploadRate * 1024,
+
+			TxRelayRate:  float64(cfg.P2P.TxRelayRate),
+			TxRelayBurst: cfg.P2P.TxRelayBurst,
+
+			Host:          opts.Host,
+			PubSubOptions: opts.PubSubOptions,
 		})
 
+		relay := newTxRelay(pool, p2pNode, logger)
+		relay.announce = p2pNode.BroadcastTx
+		p2pNode.SetTxSource(relay)
+		p2pNode.SetStemTxHandler(relay.handleStemTx)
+
 		genesisHash, _ := genesis.Hash()
 		p2pNode.SetGenesisHash(genesisHash)
 		p2pNode.SetHeightFn(func() uint64 { return ch.Height() })
+		p2pNode.SetBlockVerifier(nextHeaderVerifier(ch.Height, engine))
+		p2pNode.SetCapability(p2p.CapValidator, cfg.Mining.Enabled && validatorKey != nil)
 
 		// Block handler with sync trigger for unknown parents.
 		p2pNode.SetBlockHandler(func(from peer.ID, data []byte) {
@@ -281,6 +419,7 @@ func New(cfg *config.Config) (*Node, error) {
 				return
 			}
 			pool.RemoveConfirmed(blk.Transactions)
+			relay.blockConnected(blk.Transactions)
 			token.ExtractAndStoreMetadata(tokenStore, &blk)
 
 			if poaEngine != nil {
@@ -298,24 +437,7 @@ func New(cfg *config.Config) (*Node, error) {
 		})
 
 		// Tx handler.
-		p2pNode.SetTxHandler(func(from peer.ID, data []byte) {
-			var t tx.Transaction
-			if err := json.Unmarshal(data, &t); err != nil {
-				logger.Debug().Err(err).Msg("Failed to unmarshal transaction")
-				p2pNode.BanManager.RecordOffense(from, p2p.PenaltyInvalidTx, "unmarshal: "+err.Error())
-				return
-			}
-			fee, err := pool.Add(&t)
-			if err != nil {
-				logger.Debug().Err(err).Msg("Rejected transaction")
-				p2pNode.BanManager.RecordOffense(from, p2p.PenaltyInvalidTx, err.Error())
-				return
-			}
-			logger.Info().
-				Str("tx", t.Hash().String()[:16]+"...").
-				Uint64("fee", fee).
-				Msg("Transaction added to mempool")
-		})
+		p2pNode.SetTxHandler(relay.handleTx)
 
 		if err := p2pNode.Start(); err != nil {
 			db.Close()
@@ -327,7 +449,7 @@ func New(cfg *config.Config) (*Node, error) {
 
 		logger.Info().
 			Str("id", p2pNode.ID().String()).
-			Int("port", cfg.P2P.Port).
+			Strs("listen", p2pNode.ListenAddrs()).
 			Bool("discovery", !cfg.P2P.NoDiscover).
 			Msg("P2P node started")
 
@@ -360,6 +482,9 @@ func New(cfg *config.Config) (*Node, error) {
 		syncer.RegisterHeightHandler(func() (uint64, string) {
 			return ch.Height(), ch.TipHash().String()
 		})
+		if cfg.P2P.LightServer {
+			syncer.RegisterLightHandler(chainLightProvider{ch: ch})
+		}
 		logger.Info().Msg("Chain sync protocol registered")
 	} else {
 		logger.Warn().Msg("P2P disabled by config; node will run offline")
@@ -436,6 +561,7 @@ func New(cfg *config.Config) (*Node, error) {
 
 	// ── 11. RPC server ──────────────────────────────────────────────
 	var rpcServer *rpc.Server
+	var rebroadcaster *rpc.Rebroadcaster
 	if cfg.RPC.Enabled {
 		rpcAddr := fmt.Sprintf("%s:%d", cfg.RPC.Addr, cfg.RPC.Port)
 		rpcServer = rpc.New(rpcAddr, ch, utxoStore, pool, p2pNode, genesis, engine, cfg.RPC)
@@ -461,6 +587,10 @@ func New(cfg *config.Config) (*Node, error) {
 			rpcServer.SetBanManager(p2pNode.BanManager)
 		}
 
+		if cfg.Mempool.Persist {
+			rpcServer.SetMempoolFile(cfg.MempoolFile())
+		}
+
 		logger.Info().Str("addr", rpcServer.Addr()).Msg("RPC server started")
 
 		// Wallet RPC.
@@ -479,6 +609,10 @@ func New(cfg *config.Config) (*Node, error) {
 			}
 			rpcServer.SetKeystore(ks)
 			rpcServer.SetWalletTxIndex(rpc.NewWalletTxIndex(db))
+			if p2pNode != nil && cfg.Wallet.Rebroadcast > 0 {
+				rebroadcaster = rpc.NewRebroadcaster(pool, p2pNode.SubmitTx)
+				rpcServer.SetRebroadcaster(rebroadcaster)
+			}
 			logger.Info().Str("path", cfg.KeystoreDir()).Msg("Wallet RPC enabled")
 		}
 	} else {
@@ -504,11 +638,13 @@ func New(cfg *config.Config) (*Node, error) {
 		p2pNode:         p2pNode,
 		syncer:          syncer,
 		rpcServer:       rpcServer,
+		rebroadcaster:   rebroadcaster,
 		validatorKey:    validatorKey,
 		poaEngine:       poaEngine,
 		scMiners:        make(map[types.ChainID]context.CancelFunc),
 		scHBs:           make(map[types.ChainID]context.CancelFunc),
 		initialSyncDone: make(chan struct{}),
+		now:             now,
 		ctx:             ctx,
 		cancel:          cancel,
 	}
@@ -532,6 +668,9 @@ func (n *Node) Start() error {
 	// Wire reorg handler to re-reconstruct suspensions after chain reorganization.
 	n.ch.SetReorgHandler(func() {
 		n.reconstructSuspensions()
+		if dropped := n.pool.Revalidate(); dropped > 0 {
+			n.logger.Info().Int("dropped", dropped).Msg("Mempool revalidated after reorg")
+		}
 	})
 
 	// Startup sync (reconstructSuspensions runs at the end of each sync path).
@@ -550,22 +689,29 @@ func (n *Node) Start() error {
 	// (sync is a no-op) and ensures state is fresh before mining starts.
 	n.reconstructSuspensions()
 
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.runMempoolSaver(mempoolSaveInterval)
+	}()
+
+	if n.rebroadcaster != nil {
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			n.runWalletRebroadcast(time.Duration(n.cfg.Wallet.Rebroadcast) * time.Minute)
+		}()
+	}
+
 	// Mining.
 	if n.cfg.Mining.Enabled {
-		coinbaseAddr, err := resolveCoinbase(n.cfg.Mining.Coinbase, n.validatorKey)
+		m, err := n.blockMiner()
 		if err != nil {
-			return fmt.Errorf("resolve coinbase: %w", err)
+			return err
 		}
-
-		m := miner.New(n.ch, n.engine, n.pool, coinbaseAddr,
-			n.genesis.Protocol.Consensus.BlockReward,
-			n.genesis.Protocol.Consensus.MaxSupply,
-			n.ch.Supply)
-		m.SetHalvingInterval(n.genesis.Protocol.Consensus.HalvingInterval)
 		blockTime := time.Duration(n.genesis.Protocol.Consensus.BlockTime) * time.Second
 
 		n.logger.Info().
-			Str("coinbase", hex.EncodeToString(coinbaseAddr[:])[:16]+"...").
 			Uint64("reward", n.genesis.Protocol.Consensus.BlockReward).
 			Dur("interval", blockTime).
 			Msg("Block production enabled")
@@ -614,6 +760,7 @@ func (n *Node) Stop() {
 	if n.p2pNode != nil {
 		n.p2pNode.Stop()
 	}
+	n.saveMempool()
 	if n.validatorKey != nil {
 		n.validatorKey.Zero()
 	}
@@ -637,6 +784,24 @@ func (n *Node) Height() uint64 {
 	return n.ch.Height()
 }
 
+// TipHash returns the hash of the current chain tip.
+func (n *Node) TipHash() types.Hash {
+	return n.ch.TipHash()
+}
+
+// Sync runs one sync round against the connected peers now, as the
+// periodic sync loop does.
+func (n *Node) Sync() {
+	n.runStartupSync()
+}
+
+// AddPolicyRule registers an extra acceptance rule on the root-chain
+// mempool. Transactions restored from mempool.json at startup were
+// checked before the rule existed.
+func (n *Node) AddPolicyRule(rule mempool.PolicyRule) {
+	n.pool.AddPolicyRule(rule)
+}
+
 // ── Sync ────────────────────────────────────────────────────────────
 
 func (n *Node) runSyncLoop() {
@@ -684,7 +849,7 @@ func (n *Node) runStartupSync() {
 
 		roundStartHeight := n.ch.Height()
 
-		peers := n.p2pNode.PeerList()
+		peers := n.p2pNode.SyncPeers()
 		if len(peers) == 0 {
 			if round == 0 {
 				n.logger.Info().Msg("No peers for startup sync")
@@ -712,7 +877,9 @@ func (n *Node) runStartupSync() {
 		if len(candidates) == 0 {
 			return
 		}
-		sort.Slice(candidates, func(i, j int) bool {
+		// Peers were queried best reputation first; keep that order among
+		// equal heights.
+		sort.SliceStable(candidates, func(i, j int) bool {
 			return candidates[i].height > candidates[j].height
 		})
 
@@ -733,6 +900,7 @@ func (n *Node) runStartupSync() {
 					Str("peer_tip", best.tipHash[:16]+"...").
 					Msg("Same-height fork detected, resolving")
 				n.resolveFork(candidates, localHeight, best.height)
+				n.recordForkOutcome(n.ch, best)
 				if n.ch.Height() != roundStartHeight {
 					n.reconstructSuspensions()
 				}
@@ -755,6 +923,8 @@ func (n *Node) runStartupSync() {
 			Uint64("blocks", total).
 			Msg("Syncing chain")
 
+		n.fetchAssumeValidHeaders(candidates)
+
 		syncStart := time.Now()
 		peerIdx := 0
 		currentPeer := candidates[peerIdx].id
@@ -772,6 +942,9 @@ func (n *Node) runStartupSync() {
 
 			// On failure or bad batch, try the next peer.
 			if err != nil || len(blocks) == 0 || blocks[0].Header.Height != from {
+				if outcome, ok := badBatchOutcome(err, blocks, from, candidates[peerIdx].height); ok {
+					n.p2pNode.RecordSyncOutcome(currentPeer, outcome)
+				}
 				reason := "empty batch"
 				if err != nil {
 					reason = err.Error()
@@ -800,6 +973,18 @@ func (n *Node) runStartupSync() {
 						Uint64("expected", nextFrom).
 						Uint64("got", blk.Header.Height).
 						Msg("Peer batch has height gap, aborting sync")
+					n.p2pNode.RecordSyncOutcome(currentPeer, p2p.SyncEmpty)
+					abortBatch = true
+					break
+				}
+				if contradictsTip(blk, candidates[peerIdx]) && n.stillClaimsTip(&candidates[peerIdx], func(ctx context.Context) (*p2p.HeightResponse, error) {
+					return n.syncer.RequestHeight(ctx, currentPeer)
+				}) {
+					n.logger.Warn().
+						Uint64("height", blk.Header.Height).
+						Str("peer", currentPeer.String()[:16]+"...").
+						Msg("Peer served a block contradicting its claimed tip, aborting sync")
+					n.p2pNode.RecordSyncOutcome(currentPeer, p2p.SyncFalseHeight)
 					abortBatch = true
 					break
 				}
@@ -817,6 +1002,7 @@ func (n *Node) runStartupSync() {
 						break
 					}
 					n.logger.Warn().Err(err).Uint64("height", blk.Header.Height).Msg("Sync block failed")
+					n.p2pNode.RecordSyncOutcome(currentPeer, p2p.SyncInvalid)
 					abortBatch = true
 					break
 				}
@@ -824,6 +1010,9 @@ func (n *Node) runStartupSync() {
 				token.ExtractAndStoreMetadata(n.tokenStore, blk)
 				nextFrom++
 			}
+			if nextFrom > from {
+				n.p2pNode.RecordSyncOutcome(currentPeer, p2p.SyncDelivered)
+			}
 			if forkResolved || abortBatch {
 				break
 			}
@@ -982,6 +1171,9 @@ func (n *Node) resolveFork(candidates []syncPeer, failedHeight, peerTip uint64)
 		cancel()
 
 		if err != nil || len(blocks) == 0 || blocks[0].Header.Height != from {
+			if outcome, ok := badBatchOutcome(err, blocks, from, candidates[peerIdx].height); ok {
+				n.p2pNode.RecordSyncOutcome(currentPeer, outcome)
+			}
 			reason := "empty batch"
 			if err != nil {
 				reason = err.Error()
@@ -1009,6 +1201,7 @@ func (n *Node) resolveFork(candidates []syncPeer, failedHeight, peerTip uint64)
 					Uint64("expected", nextFrom).
 					Uint64("got", blk.Header.Height).
 					Msg("Fork sync batch has height gap, aborting")
+				n.p2pNode.RecordSyncOutcome(currentPeer, p2p.SyncEmpty)
 				return
 			}
 			if err := n.ch.ProcessBlock(blk); err != nil {
@@ -1019,12 +1212,16 @@ func (n *Node) resolveFork(candidates []syncPeer, failedHeight, peerTip uint64)
 				n.logger.Warn().Err(err).
 					Uint64("height", blk.Header.Height).
 					Msg("Fork sync block failed")
+				n.p2pNode.RecordSyncOutcome(currentPeer, p2p.SyncInvalid)
 				return
 			}
 			n.pool.RemoveConfirmed(blk.Transactions)
 			token.ExtractAndStoreMetadata(n.tokenStore, blk)
 			nextFrom++
 		}
+		if nextFrom > from {
+			n.p2pNode.RecordSyncOutcome(currentPeer, p2p.SyncDelivered)
+		}
 		from = nextFrom
 	}
 
@@ -1034,6 +1231,68 @@ func (n *Node) resolveFork(candidates []syncPeer, failedHeight, peerTip uint64)
 		Msg("Fork resolved")
 }
 
+// recordForkOutcome isolates a peer whose tip we hold but did not adopt
+// after fork resolution: its fork lost to our validated chain, and
+// syncing from it again would only repeat the resolution.
+func (n *Node) recordForkOutcome(ch *chain.Chain, sp syncPeer) {
+	if sp.tipHash == "" || ch.TipHash().String() == sp.tipHash {
+		return
+	}
+	hash, err := types.HexToHash(sp.tipHash)
+	if err != nil {
+		return
+	}
+	if _, err := ch.GetBlock(hash); err == nil {
+		n.p2pNode.RecordSyncOutcome(sp.id, p2p.SyncForkMismatch)
+	}
+}
+
+// fetchAssumeValidHeaders collects the header chain up to the assume-valid
+// block, so that its ancestors can skip script and signature checks.
+// Peers are tried in order; if none serves a chain that reaches the
+// block, sync verifies every block in full.
+func (n *Node) fetchAssumeValidHeaders(candidates []syncPeer) {
+	for _, sp := range candidates {
+		for {
+			from, ok := n.ch.AssumeValidHeadersFrom()
+			if !ok {
+				return
+			}
+			reqCtx, cancel := context.WithTimeout(n.ctx, 30*time.Second)
+			headers, err := n.syncer.RequestHeaders(reqCtx, sp.id, from, 500)
+			cancel()
+			if err != nil || len(headers) == 0 || headers[0] == nil || headers[0].Height != from {
+				break
+			}
+			if err := n.ch.AddAssumeValidHeaders(headers); err != nil {
+				n.logger.Debug().Err(err).Str("peer", sp.id.String()[:16]+"...").Msg("Assume-valid headers rejected")
+				break
+			}
+		}
+		if n.ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// stillClaimsTip re-queries a peer that served a block contradicting the
+// tip it claimed. A peer that reorganised since the first query reports a
+// new tip and sp is updated to it. Returns true only if the peer still
+// claims the contradicted tip; a failed query proves nothing.
+func (n *Node) stillClaimsTip(sp *syncPeer, query func(context.Context) (*p2p.HeightResponse, error)) bool {
+	reqCtx, cancel := context.WithTimeout(n.ctx, 5*time.Second)
+	resp, err := query(reqCtx)
+	cancel()
+	if err != nil {
+		return false
+	}
+	if resp.Height == sp.height && resp.TipHash == sp.tipHash {
+		return true
+	}
+	sp.height, sp.tipHash = resp.Height, resp.TipHash
+	return false
+}
+
 // ── Suspension reconstruction ────────────────────────────────────────
 
 // reconstructSuspensions rebuilds the PoA suspension state from on-chain blocks.
@@ -1111,9 +1370,9 @@ func (n *Node) runMiner(m *miner.Miner, blockTime time.Duration) {
 	for {
 		// Align to next slot boundary: (now/blockTime + 1) * blockTime.
 		// All nodes with synced clocks wake at the same instant.
-		nowUnix := time.Now().Unix()
+		nowUnix := n.now().Unix()
 		nextSlot := ((nowUnix / blockTimeSec) + 1) * blockTimeSec
-		sleepDur := time.Until(time.Unix(nextSlot, 0))
+		sleepDur := time.Unix(nextSlot, 0).Sub(n.now())
 		if sleepDur < 0 {
 			sleepDur = 0
 		}
@@ -1131,31 +1390,16 @@ func (n *Node) runMiner(m *miner.Miner, blockTime time.Duration) {
 		}
 
 		nextHeight := n.ch.Height() + 1
-		now := uint64(time.Now().Unix())
-
-		// NOTE: Suspended validators are NOT blocked from in-turn production.
-		// This is intentional — producing an in-turn block (DiffInTurn=2) is
-		// the reactivation mechanism. The block beats any backup's DiffNoTurn=1,
-		// and RecordBlockProduction clears the suspension automatically.
+		now := uint64(n.now().Unix())
 
-		// Time-slot-based election: check if we're in-turn.
+		delay, ok := n.SlotDelay(now)
+		if !ok {
+			continue
+		}
 		if n.poaEngine != nil && !n.poaEngine.IsInTurn(now) {
-			// Not in-turn. Identify the expected in-turn validator.
-			expectedPub := n.poaEngine.SlotValidator(now)
-
-			// Only defer to the in-turn validator if they are online AND not suspended.
-			isSuspended := n.poaEngine.IsSuspended(expectedPub)
-			if !isSuspended && n.tracker != nil && expectedPub != nil && n.tracker.IsOnline(expectedPub) {
-				continue
-			}
-
-			// In-turn validator appears offline or suspended. Wait staggered
-			// backup delay using the effective (non-suspended) validator set.
-			delay := n.poaEngine.BackupDelayEffective(now)
 			n.logger.Debug().
 				Uint64("height", nextHeight).
 				Dur("backup_delay", delay).
-				Bool("in_turn_suspended", isSuspended).
 				Msg("Not in-turn, waiting backup delay")
 
 			select {
@@ -1168,10 +1412,7 @@ func (n *Node) runMiner(m *miner.Miner, blockTime time.Duration) {
 			if n.ch.Height() >= nextHeight {
 				continue
 			}
-
-			if expectedPub != nil && n.tracker != nil {
-				n.tracker.RecordMiss(expectedPub)
-			}
+			n.recordMiss(now)
 		}
 
 		// Re-check: a block may have arrived via gossip since we read the tip.
@@ -1182,48 +1423,196 @@ func (n *Node) runMiner(m *miner.Miner, blockTime time.Duration) {
 		// Refresh now: the original may be stale after backup delay
 		// or re-checks. ProduceBlockAt also enforces monotonicity
 		// (>= parent timestamp + 1).
-		now = uint64(time.Now().Unix())
-
-		blk, err := m.ProduceBlockAt(now)
+		blk, err := n.produceBlock(m, uint64(n.now().Unix()))
 		if err != nil {
-			n.logger.Error().Err(err).Msg("Failed to produce block")
 			continue
 		}
+		n.relayBlock(blk)
+	}
+}
 
-		if err := n.ch.ProcessBlock(blk); err != nil {
-			n.logger.Error().Err(err).Msg("Failed to process own block")
-			if errors.Is(err, chain.ErrCoinbaseNotMature) {
-				for _, t := range blk.Transactions[1:] {
-					n.pool.Remove(t.Hash())
-				}
-				n.logger.Info().Msg("Evicted mempool transactions due to coinbase maturity")
+// SlotDelay reports whether this validator should produce a block in the
+// slot containing the Unix timestamp now, and how long after now: zero
+// when in turn, a staggered backup delay when the in-turn validator
+// looks offline or suspended.
+func (n *Node) SlotDelay(now uint64) (time.Duration, bool) {
+	// NOTE: Suspended validators are NOT blocked from in-turn production.
+	// This is intentional — producing an in-turn block (DiffInTurn=2) is
+	// the reactivation mechanism. The block beats any backup's DiffNoTurn=1,
+	// and RecordBlockProduction clears the suspension automatically.
+	if n.poaEngine == nil || n.poaEngine.IsInTurn(now) {
+		return 0, true
+	}
+
+	// Not in-turn. Only defer to the in-turn validator if they are online
+	// AND not suspended.
+	expectedPub := n.poaEngine.SlotValidator(now)
+	isSuspended := n.poaEngine.IsSuspended(expectedPub)
+	if !isSuspended && n.tracker != nil && expectedPub != nil && n.tracker.IsOnline(expectedPub) {
+		return 0, false
+	}
+
+	// In-turn validator appears offline or suspended. Wait staggered
+	// backup delay using the effective (non-suspended) validator set.
+	return n.poaEngine.BackupDelayEffective(now), true
+}
+
+// ProduceBlock produces the block for the slot starting at slot with
+// timestamp now, applies it and relays it, as the miner loop does once any
+// backup delay has passed.
+func (n *Node) ProduceBlock(slot, now uint64) (*block.Block, error) {
+	m, err := n.blockMiner()
+	if err != nil {
+		return nil, err
+	}
+	n.recordMiss(slot)
+	blk, err := n.produceBlock(m, now)
+	if err != nil {
+		return nil, err
+	}
+	n.relayBlock(blk)
+	return blk, nil
+}
+
+// recordMiss records a missed slot for the in-turn validator when this
+// node produces the slot's block as a backup.
+func (n *Node) recordMiss(slot uint64) {
+	if n.poaEngine == nil || n.tracker == nil || n.poaEngine.IsInTurn(slot) {
+		return
+	}
+	if expectedPub := n.poaEngine.SlotValidator(slot); expectedPub != nil {
+		n.tracker.RecordMiss(expectedPub)
+	}
+}
+
+// relayBlock broadcasts a produced block to peers.
+func (n *Node) relayBlock(blk *block.Block) {
+	if n.p2pNode == nil {
+		return
+	}
+	if err := n.p2pNode.BroadcastBlock(blk); err != nil {
+		n.logger.Error().Err(err).Msg("Failed to broadcast block")
+	}
+}
+
+// blockMiner returns the root-chain block producer, creating it on first
+// use.
+func (n *Node) blockMiner() (*miner.Miner, error) {
+	if n.miner != nil {
+		return n.miner, nil
+	}
+	coinbaseAddr, err := resolveCoinbase(n.cfg.Mining.Coinbase, n.validatorKey)
+	if err != nil {
+		return nil, fmt.Errorf("resolve coinbase: %w", err)
+	}
+
+	m := miner.New(n.ch, n.engine, n.pool, coinbaseAddr,
+		n.genesis.Protocol.Consensus.BlockReward,
+		n.genesis.Protocol.Consensus.MaxSupply,
+		n.ch.Supply)
+	m.SetHalvingInterval(n.genesis.Protocol.Consensus.HalvingInterval)
+	m.SetForkSchedule(n.genesis.Protocol.Forks)
+	n.logger.Info().
+		Str("coinbase", hex.EncodeToString(coinbaseAddr[:])[:16]+"...").
+		Msg("Block producer ready")
+	n.miner = m
+	return m, nil
+}
+
+// produceBlock produces and applies a block with timestamp now.
+func (n *Node) produceBlock(m *miner.Miner, now uint64) (*block.Block, error) {
+	blk, err := m.ProduceBlockAt(now)
+	if err != nil {
+		n.logger.Error().Err(err).Msg("Failed to produce block")
+		return nil, err
+	}
+
+	if err := n.ch.ProcessBlock(blk); err != nil {
+		n.logger.Error().Err(err).Msg("Failed to process own block")
+		if errors.Is(err, chain.ErrCoinbaseNotMature) {
+			for _, t := range blk.Transactions[1:] {
+				n.pool.Remove(t.Hash())
 			}
-			continue
+			n.logger.Info().Msg("Evicted mempool transactions due to coinbase maturity")
 		}
-		n.pool.RemoveConfirmed(blk.Transactions)
+		return nil, err
+	}
+	n.pool.RemoveConfirmed(blk.Transactions)
 
-		if n.poaEngine != nil {
-			if signer := n.poaEngine.IdentifySigner(blk.Header); signer != nil {
-				if n.tracker != nil {
-					n.tracker.RecordBlock(signer)
-				}
-				n.poaEngine.RecordBlockProduction(signer, blk.Header.Height)
+	if n.poaEngine != nil {
+		if signer := n.poaEngine.IdentifySigner(blk.Header); signer != nil {
+			if n.tracker != nil {
+				n.tracker.RecordBlock(signer)
 			}
+			n.poaEngine.RecordBlockProduction(signer, blk.Header.Height)
 		}
+	}
 
-		if n.p2pNode != nil {
-			if err := n.p2pNode.BroadcastBlock(blk); err != nil {
-				n.logger.Error().Err(err).Msg("Failed to broadcast block")
-			}
+	n.logger.Info().
+		Uint64("height", blk.Header.Height).
+		Str("hash", blk.Hash().String()[:16]+"...").
+		Int("txs", len(blk.Transactions)).
+		Uint64("reward", blk.Transactions[0].Outputs[0].Value).
+		Msg("Block produced")
+	return blk, nil
+}
+
+// ── Mempool persistence ─────────────────────────────────────────────
+
+// mempoolSaveInterval is how often the mempool and fee estimates are saved
+// while running.
+const mempoolSaveInterval = 10 * time.Minute
+
+func (n *Node) runMempoolSaver(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-ticker.C:
+			n.saveMempool()
 		}
+	}
+}
 
-		n.logger.Info().
-			Uint64("height", blk.Header.Height).
-			Str("hash", blk.Hash().String()[:16]+"...").
-			Int("txs", len(blk.Transactions)).
-			Uint64("reward", blk.Transactions[0].Outputs[0].Value).
-			Msg("Block produced")
+// runWalletRebroadcast re-announces unconfirmed wallet transactions at
+// random times averaging interval, so the rounds are not a fingerprint.
+func (n *Node) runWalletRebroadcast(interval time.Duration) {
+	for {
+		timer := time.NewTimer(interval/2 + rand.N(interval))
+		select {
+		case <-n.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		announced, dropped := n.rebroadcaster.Rebroadcast()
+		if announced > 0 || dropped > 0 {
+			n.logger.Debug().
+				Int("announced", announced).
+				Int("dropped", dropped).
+				Msg("Rebroadcast wallet transactions")
+		}
+	}
+}
+
+func (n *Node) saveMempool() {
+	if est := n.pool.FeeEstimator(); est != nil {
+		if err := est.Save(n.cfg.FeeEstimatesFile()); err != nil {
+			n.logger.Warn().Err(err).Msg("Failed to save fee estimates")
+		}
 	}
+	if !n.cfg.Mempool.Persist {
+		return
+	}
+	count, err := n.pool.Save(n.cfg.MempoolFile())
+	if err != nil {
+		n.logger.Warn().Err(err).Msg("Failed to save mempool")
+		return
+	}
+	n.logger.Debug().Int("txs", count).Msg("Mempool saved")
 }
 
 // ── Heartbeat ───────────────────────────────────────────────────────
@@ -1468,11 +1857,15 @@ func (n *Node) handleSubChainSpawn(chainID types.ChainID, sr *subchain.SpawnResu
 		scPoALocal := scPoA
 		sr.Chain.SetReorgHandler(func() {
 			n.reconstructSubChainSuspensions(sr.Chain, scPoALocal)
+			if dropped := sr.Pool.Revalidate(); dropped > 0 {
+				scLog.Info().Int("dropped", dropped).Msg("Mempool revalidated after reorg")
+			}
 		})
 	}
 
 	if n.p2pNode != nil && n.syncer != nil {
 		// Join P2P topics.
+		n.p2pNode.SetSubChainBlockVerifier(idHex, nextHeaderVerifier(sr.Chain.Height, sr.Engine))
 		if err := n.p2pNode.JoinSubChain(idHex); err != nil {
 			scLog.Warn().Err(err).Msg("Failed to join sub-chain P2P topics")
 		} else {
@@ -1495,6 +1888,8 @@ func (n *Node) handleSubChainSpawn(chainID types.ChainID, sr *subchain.SpawnResu
 			return sr.Chain.Height(), sr.Chain.TipHash().String()
 		})
 
+		scRelay := newTxRelay(sr.Pool, n.p2pNode, scLog)
+
 		// Block handler with sync trigger.
 		var syncing atomic.Bool
 		n.p2pNode.SetSubChainBlockHandler(idHex, func(from peer.ID, data []byte) {
@@ -1517,6 +1912,7 @@ func (n *Node) handleSubChainSpawn(chainID types.ChainID, sr *subchain.SpawnResu
 				return
 			}
 			sr.Pool.RemoveConfirmed(blk.Transactions)
+			scRelay.blockConnected(blk.Transactions)
 
 			if scPoA != nil {
 				if signer := scPoA.IdentifySigner(blk.Header); signer != nil {
@@ -1534,17 +1930,7 @@ func (n *Node) handleSubChainSpawn(chainID types.ChainID, sr *subchain.SpawnResu
 		})
 
 		// Tx handler.
-		n.p2pNode.SetSubChainTxHandler(idHex, func(from peer.ID, data []byte) {
-			var t tx.Transaction
-			if err := json.Unmarshal(data, &t); err != nil {
-				n.p2pNode.BanManager.RecordOffense(from, p2p.PenaltyInvalidTx, "sc tx unmarshal: "+err.Error())
-				return
-			}
-			if _, err := sr.Pool.Add(&t); err != nil {
-				n.p2pNode.BanManager.RecordOffense(from, p2p.PenaltyInvalidTx, err.Error())
-				return
-			}
-		})
+		n.p2pNode.SetSubChainTxHandler(idHex, scRelay.handleTx)
 
 		// Wait for root chain sync, then sub-chain sync, then start miners.
 		// This prevents mining on a stale fork while the root or sub-chain
@@ -1615,7 +2001,8 @@ func (n *Node) runSubChainSync(ch *chain.Chain, pool *mempool.Pool, chainIDHex s
 	if n.p2pNode == nil || n.syncer == nil {
 		return
 	}
-	peers := n.p2pNode.PeerList()
+	// Only peers that advertise the sub-chain can answer for it.
+	peers := n.p2pNode.PeersWithSubChain(chainIDHex)
 	if len(peers) == 0 {
 		return
 	}
@@ -1648,7 +2035,9 @@ func (n *Node) runSubChainSync(ch *chain.Chain, pool *mempool.Pool, chainIDHex s
 	if len(candidates) == 0 {
 		return
 	}
-	sort.Slice(candidates, func(i, j int) bool {
+	// Peers were queried best reputation first; keep that order among
+	// equal heights.
+	sort.SliceStable(candidates, func(i, j int) bool {
 		return candidates[i].height > candidates[j].height
 	})
 
@@ -1665,6 +2054,7 @@ func (n *Node) runSubChainSync(ch *chain.Chain, pool *mempool.Pool, chainIDHex s
 				Uint64("height", localHeight).
 				Msg("Same-height sub-chain fork detected, resolving")
 			n.resolveSubChainFork(ch, pool, candidates, chainIDHex, localHeight, best.height, logger, poaEng...)
+			n.recordForkOutcome(ch, best)
 		}
 		return
 	}
@@ -1696,6 +2086,9 @@ func (n *Node) runSubChainSync(ch *chain.Chain, pool *mempool.Pool, chainIDHex s
 
 		// On failure or bad batch, try the next peer.
 		if err != nil || len(blocks) == 0 || blocks[0].Header.Height != from {
+			if outcome, ok := badBatchOutcome(err, blocks, from, candidates[peerIdx].height); ok {
+				n.p2pNode.RecordSyncOutcome(currentPeer, outcome)
+			}
 			reason := "empty batch"
 			if err != nil {
 				reason = err.Error()
@@ -1723,6 +2116,17 @@ func (n *Node) runSubChainSync(ch *chain.Chain, pool *mempool.Pool, chainIDHex s
 					Uint64("expected", nextFrom).
 					Uint64("got", blk.Header.Height).
 					Msg("Peer sub-chain batch has height gap, aborting sync")
+				n.p2pNode.RecordSyncOutcome(currentPeer, p2p.SyncEmpty)
+				return
+			}
+			if contradictsTip(blk, candidates[peerIdx]) && n.stillClaimsTip(&candidates[peerIdx], func(ctx context.Context) (*p2p.HeightResponse, error) {
+				return n.syncer.RequestSubChainHeight(ctx, currentPeer, chainIDHex)
+			}) {
+				logger.Warn().
+					Uint64("height", blk.Header.Height).
+					Str("peer", currentPeer.String()[:16]+"...").
+					Msg("Peer served a sub-chain block contradicting its claimed tip, aborting sync")
+				n.p2pNode.RecordSyncOutcome(currentPeer, p2p.SyncFalseHeight)
 				return
 			}
 			if err := ch.ProcessBlock(blk); err != nil {
@@ -1738,11 +2142,15 @@ func (n *Node) runSubChainSync(ch *chain.Chain, pool *mempool.Pool, chainIDHex s
 					return
 				}
 				logger.Warn().Err(err).Uint64("height", blk.Header.Height).Msg("Sub-chain sync block failed")
+				n.p2pNode.RecordSyncOutcome(currentPeer, p2p.SyncInvalid)
 				return
 			}
 			pool.RemoveConfirmed(blk.Transactions)
 			nextFrom++
 		}
+		if nextFrom > from {
+			n.p2pNode.RecordSyncOutcome(currentPeer, p2p.SyncDelivered)
+		}
 		from = nextFrom
 
 		synced := ch.Height() - localHeight
@@ -1864,6 +2272,9 @@ func (n *Node) resolveSubChainFork(ch *chain.Chain, pool *mempool.Pool,
 		cancel()
 
 		if err != nil || len(blocks) == 0 || blocks[0].Header.Height != from {
+			if outcome, ok := badBatchOutcome(err, blocks, from, candidates[peerIdx].height); ok {
+				n.p2pNode.RecordSyncOutcome(currentPeer, outcome)
+			}
 			reason := "empty batch"
 			if err != nil {
 				reason = err.Error()
@@ -1891,6 +2302,7 @@ func (n *Node) resolveSubChainFork(ch *chain.Chain, pool *mempool.Pool,
 					Uint64("expected", nextFrom).
 					Uint64("got", blk.Header.Height).
 					Msg("Sub-chain fork sync batch has height gap, aborting")
+				n.p2pNode.RecordSyncOutcome(currentPeer, p2p.SyncEmpty)
 				return
 			}
 			if err := ch.ProcessBlock(blk); err != nil {
@@ -1901,11 +2313,15 @@ func (n *Node) resolveSubChainFork(ch *chain.Chain, pool *mempool.Pool,
 				logger.Warn().Err(err).
 					Uint64("height", blk.Header.Height).
 					Msg("Sub-chain fork sync block failed")
+				n.p2pNode.RecordSyncOutcome(currentPeer, p2p.SyncInvalid)
 				return
 			}
 			pool.RemoveConfirmed(blk.Transactions)
 			nextFrom++
 		}
+		if nextFrom > from {
+			n.p2pNode.RecordSyncOutcome(currentPeer, p2p.SyncDelivered)
+		}
 		from = nextFrom
 	}
 
@@ -1934,6 +2350,7 @@ func (n *Node) startSubChainMiner(chainID types.ChainID,
 		sr.Genesis.Protocol.Consensus.MaxSupply,
 		sr.Chain.Supply)
 	m.SetHalvingInterval(sr.Genesis.Protocol.Consensus.HalvingInterval)
+	m.SetForkSchedule(sr.Genesis.Protocol.Forks)
 
 	idHex := hex.EncodeToString(chainID[:])
 	blockTime := time.Duration(sr.Genesis.Protocol.Consensus.BlockTime) * time.Second
@@ -1970,6 +2387,7 @@ func (n *Node) startSubChainPoAMiner(chainID types.ChainID,
 		sr.Genesis.Protocol.Consensus.MaxSupply,
 		sr.Chain.Supply)
 	m.SetHalvingInterval(sr.Genesis.Protocol.Consensus.HalvingInterval)
+	m.SetForkSchedule(sr.Genesis.Protocol.Forks)
 
 	idHex := hex.EncodeToString(chainID[:])
 	blockTime := time.Duration(sr.Genesis.Protocol.Consensus.BlockTime) * time.Second
@@ -1,13 +1,25 @@
 package node
 
 import (
+	"bytes"
+	"context"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/Klingon-tech/klingnet-chain/config"
+	"github.com/Klingon-tech/klingnet-chain/internal/mempool"
+	"github.com/Klingon-tech/klingnet-chain/internal/p2p"
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
 	"github.com/Klingon-tech/klingnet-chain/pkg/crypto"
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+	"github.com/rs/zerolog"
 )
 
 func TestExpandHome(t *testing.T) {
@@ -144,6 +156,161 @@ func TestIsPoW(t *testing.T) {
 	}
 }
 
+func TestNextHeaderVerifier(t *testing.T) {
+	genesis := config.GenesisFor(config.Testnet)
+	engine, _ := createEngine(genesis)
+	verify := nextHeaderVerifier(func() uint64 { return 10 }, engine)
+
+	// Unsigned headers only pass away from the next height.
+	for _, height := range []uint64{5, 10, 12} {
+		if err := verify(&block.Header{Height: height}); err != nil {
+			t.Errorf("height %d: unexpected error %v", height, err)
+		}
+	}
+	if err := verify(&block.Header{Height: 11}); err == nil {
+		t.Error("height 11: expected error for unsigned header")
+	}
+}
+
+func TestBadBatchOutcome(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		blocks  []*block.Block
+		from    uint64
+		claimed uint64
+		want    p2p.SyncOutcome
+		ok      bool
+	}{
+		{"request error", fmt.Errorf("read sync response: timeout"), nil, 5, 10, 0, false},
+		{"empty below claimed height", nil, nil, 5, 10, p2p.SyncFalseHeight, true},
+		{"empty at claimed height", nil, nil, 10, 10, p2p.SyncFalseHeight, true},
+		{"empty above claimed height", nil, nil, 11, 10, p2p.SyncEmpty, true},
+		{"non-contiguous", nil, []*block.Block{{Header: &block.Header{Height: 7}}}, 5, 10, p2p.SyncEmpty, true},
+	}
+	for _, tt := range tests {
+		got, ok := badBatchOutcome(tt.err, tt.blocks, tt.from, tt.claimed)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("%s: got (%s, %v), want (%s, %v)", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestContradictsTip(t *testing.T) {
+	blk := &block.Block{Header: &block.Header{Height: 10}}
+	tip := blk.Hash().String()
+
+	if contradictsTip(blk, syncPeer{height: 10, tipHash: tip}) {
+		t.Error("claimed tip flagged as contradiction")
+	}
+	if !contradictsTip(blk, syncPeer{height: 10, tipHash: strings.Repeat("ab", 32)}) {
+		t.Error("different block at claimed height not flagged")
+	}
+	if contradictsTip(blk, syncPeer{height: 11, tipHash: strings.Repeat("ab", 32)}) {
+		t.Error("block below claimed height flagged")
+	}
+	if contradictsTip(blk, syncPeer{height: 10}) {
+		t.Error("peer without tip hash flagged")
+	}
+}
+
+func TestStillClaimsTip(t *testing.T) {
+	n := &Node{ctx: context.Background()}
+	claimed := strings.Repeat("ab", 32)
+	reply := func(resp *p2p.HeightResponse, err error) func(context.Context) (*p2p.HeightResponse, error) {
+		return func(context.Context) (*p2p.HeightResponse, error) { return resp, err }
+	}
+
+	sp := syncPeer{height: 10, tipHash: claimed}
+	if !n.stillClaimsTip(&sp, reply(&p2p.HeightResponse{Height: 10, TipHash: claimed}, nil)) {
+		t.Error("repeated claim not confirmed")
+	}
+	if n.stillClaimsTip(&sp, reply(nil, errors.New("timeout"))) {
+		t.Error("failed query confirmed the claim")
+	}
+
+	// A peer that reorganised reports its new tip.
+	moved := strings.Repeat("cd", 32)
+	if n.stillClaimsTip(&sp, reply(&p2p.HeightResponse{Height: 11, TipHash: moved}, nil)) {
+		t.Error("new tip confirmed the old claim")
+	}
+	if sp.height != 11 || sp.tipHash != moved {
+		t.Errorf("sync peer not updated: %+v", sp)
+	}
+}
+
+// relayTestUTXOs is a tx.UTXOProvider holding P2PKH outputs of 5000.
+type relayTestUTXOs map[types.Outpoint]types.Address
+
+func (u relayTestUTXOs) GetUTXO(op types.Outpoint) (uint64, types.Script, error) {
+	addr, ok := u[op]
+	if !ok {
+		return 0, types.Script{}, errors.New("not found")
+	}
+	return 5000, types.Script{Type: types.ScriptTypeP2PKH, Data: addr.Bytes()}, nil
+}
+
+func (u relayTestUTXOs) HasUTXO(op types.Outpoint) bool {
+	_, ok := u[op]
+	return ok
+}
+
+func TestTxRelay_ForgedCopyDoesNotBlockGenuine(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	addr := crypto.AddressFromPubKey(key.PublicKey())
+	prevOut := types.Outpoint{TxID: types.Hash{0x01}}
+	relay := newTxRelay(mempool.New(relayTestUTXOs{prevOut: addr}, 100), nil, zerolog.Nop())
+
+	b := tx.NewBuilder().
+		AddInput(prevOut).
+		AddOutput(4000, types.Script{Type: types.ScriptTypeP2PKH, Data: addr.Bytes()})
+	b.Sign(key)
+	genuine := b.Build()
+	genuineData, _ := json.Marshal(genuine)
+
+	var forged tx.Transaction
+	json.Unmarshal(genuineData, &forged)
+	forged.Inputs[0].Signature = bytes.Repeat([]byte{0x01}, len(forged.Inputs[0].Signature))
+	forgedData, _ := json.Marshal(&forged)
+
+	if got := relay.admit("peer-a", forgedData); got != nil {
+		t.Fatal("forged copy admitted")
+	}
+	if relay.Has(genuine.Hash()) {
+		t.Error("forged copy marked the txid as known")
+	}
+	if got := relay.admit("peer-b", genuineData); got == nil {
+		t.Fatal("genuine transaction dropped after a forged copy was rejected")
+	}
+	if !relay.Has(genuine.Hash()) {
+		t.Error("admitted transaction not reported as known")
+	}
+}
+
+func TestTxRejectPenalty(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("%w: bad signature", mempool.ErrValidation), p2p.PenaltyInvalidTx},
+		{fmt.Errorf("%w: need 100, got 1", mempool.ErrFeeTooLow), 0},
+		{mempool.ErrPoolFull, 0},
+		{mempool.ErrAlreadyExists, 0},
+		{fmt.Errorf("%w: spent", mempool.ErrConflict), 0},
+		{fmt.Errorf("%w: %w: parent", mempool.ErrValidation, mempool.ErrMissingInputs), 0},
+		{fmt.Errorf("%w: 3 of 20", mempool.ErrCoinbaseNotMature), p2p.PenaltyNonStandardTx},
+		{fmt.Errorf("%w: until block 40", mempool.ErrOutputLocked), p2p.PenaltyNonStandardTx},
+	}
+	for _, tt := range tests {
+		if got := txRejectPenalty(tt.err); got != tt.want {
+			t.Errorf("txRejectPenalty(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
 func TestNodeLifecycle(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test in short mode")
@@ -0,0 +1,194 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Klingon-tech/klingnet-chain/internal/mempool"
+	"github.com/Klingon-tech/klingnet-chain/internal/p2p"
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog"
+)
+
+// txRelay admits transactions gossiped by peers into one chain's mempool.
+// It skips hashes it recently rejected, holds transactions with unknown
+// parents as orphans until the parents arrive, and penalises the relaying
+// peer according to why a transaction was rejected. It is also the
+// p2p.TxSource for the chain's inventory relay.
+type txRelay struct {
+	pool     *mempool.Pool
+	orphans  *mempool.OrphanPool
+	rejects  *mempool.RejectFilter
+	net      *p2p.Node                   // For ban penalties (nil = none).
+	announce func(*tx.Transaction) error // Announces accepted transactions (nil = gossip relays them).
+	logger   zerolog.Logger
+}
+
+func newTxRelay(pool *mempool.Pool, net *p2p.Node, logger zerolog.Logger) *txRelay {
+	return &txRelay{
+		pool:    pool,
+		orphans: mempool.NewOrphanPool(mempool.DefaultMaxOrphans),
+		rejects: mempool.NewRejectFilter(mempool.DefaultRejectFilterSize),
+		net:     net,
+		logger:  logger,
+	}
+}
+
+// handleTx is the gossip handler for a transaction topic.
+func (r *txRelay) handleTx(from peer.ID, data []byte) {
+	t := r.admit(from, data)
+	if t == nil {
+		return
+	}
+	r.accepted(t)
+	r.resolveOrphans([]types.Hash{t.Hash()})
+}
+
+// handleStemTx is the handler for Dandelion stem-phase transactions. They
+// are admitted like gossiped ones but passed along the stem, not announced.
+func (r *txRelay) handleStemTx(from peer.ID, data []byte) {
+	t := r.admit(from, data)
+	if t == nil {
+		return
+	}
+	if err := r.net.RelayStemTx(t, from); err != nil {
+		r.logger.Debug().Err(err).Msg("Failed to relay stem transaction")
+	}
+	r.resolveOrphans([]types.Hash{t.Hash()})
+}
+
+// admit decodes a relayed transaction and adds it to the pool, returning
+// it if it was accepted.
+func (r *txRelay) admit(from peer.ID, data []byte) *tx.Transaction {
+	var t tx.Transaction
+	if err := json.Unmarshal(data, &t); err != nil {
+		r.logger.Debug().Err(err).Msg("Failed to unmarshal transaction")
+		r.penalize(from, p2p.PenaltyMalformedTx, "unmarshal: "+err.Error())
+		return nil
+	}
+	txHash := t.Hash()
+	full := t.FullHash()
+	if r.pool.Has(txHash) || r.orphans.Has(full) || r.rejects.Has(full) {
+		return nil
+	}
+
+	fee, err := r.pool.Add(&t)
+	if err != nil {
+		r.reject(from, &t, err)
+		return nil
+	}
+	r.logger.Info().
+		Str("tx", txHash.String()[:16]+"...").
+		Uint64("fee", fee).
+		Msg("Transaction added to mempool")
+	return &t
+}
+
+// Has reports whether a transaction is pooled, so announcements of it
+// need not be fetched. Orphans and rejections are keyed by FullHash, which
+// an announced txid does not give, so those are fetched again: skipping
+// them by txid would let a copy with a bad signature hide the genuine one.
+func (r *txRelay) Has(txHash types.Hash) bool {
+	return r.pool.Has(txHash)
+}
+
+// Get returns a mempool transaction, or nil.
+func (r *txRelay) Get(txHash types.Hash) *tx.Transaction {
+	return r.pool.Get(txHash)
+}
+
+// Transactions returns every mempool transaction.
+func (r *txRelay) Transactions() []*tx.Transaction {
+	return r.pool.Transactions()
+}
+
+// accepted announces a transaction admitted to the pool.
+func (r *txRelay) accepted(t *tx.Transaction) {
+	if r.announce == nil {
+		return
+	}
+	if err := r.announce(t); err != nil {
+		r.logger.Debug().Err(err).Msg("Failed to announce transaction")
+	}
+}
+
+// blockConnected retries orphans spending the block's transactions and
+// forgets past rejections, which the new tip may have made valid.
+func (r *txRelay) blockConnected(txs []*tx.Transaction) {
+	r.rejects.Reset()
+	r.orphans.Expire(time.Now())
+	hashes := make([]types.Hash, len(txs))
+	for i, t := range txs {
+		hashes[i] = t.Hash()
+	}
+	r.resolveOrphans(hashes)
+}
+
+// resolveOrphans admits orphans whose parents are now known.
+func (r *txRelay) resolveOrphans(parents []types.Hash) {
+	accepted, rejected := r.orphans.Resolve(r.pool, parents)
+	for _, t := range accepted {
+		r.logger.Debug().Str("tx", t.Hash().String()[:16]+"...").Msg("Orphan transaction added to mempool")
+		r.accepted(t)
+	}
+	for _, o := range rejected {
+		r.reject(peer.ID(o.From), o.Tx, o.Err)
+	}
+}
+
+// reject records a rejected transaction relayed by from.
+func (r *txRelay) reject(from peer.ID, t *tx.Transaction, err error) {
+	if errors.Is(err, mempool.ErrMissingInputs) {
+		if r.orphans.Add(t, string(from)) {
+			r.logger.Debug().Str("tx", t.Hash().String()[:16]+"...").Msg("Holding orphan transaction")
+		}
+		return
+	}
+	r.logger.Debug().Err(err).Msg("Rejected transaction")
+	if rememberReject(err) {
+		r.rejects.Add(t.FullHash())
+	}
+	if penalty := txRejectPenalty(err); penalty > 0 {
+		r.penalize(from, penalty, err.Error())
+	}
+}
+
+func (r *txRelay) penalize(from peer.ID, penalty int, reason string) {
+	// The ban manager is created when the P2P node starts.
+	if r.net != nil && r.net.BanManager != nil {
+		r.net.BanManager.RecordOffense(from, penalty, reason)
+	}
+}
+
+// txRejectPenalty grades a mempool rejection of a relayed transaction.
+// Honest peers can relay transactions rejected under local policy, racing
+// double spends or ones whose parents we have not seen, so those cost
+// nothing; invalid transactions cost the most.
+func txRejectPenalty(err error) int {
+	switch {
+	case mempool.IsPolicyError(err),
+		errors.Is(err, mempool.ErrMissingInputs),
+		errors.Is(err, mempool.ErrConflict):
+		return 0
+	case errors.Is(err, mempool.ErrCoinbaseNotMature),
+		errors.Is(err, mempool.ErrOutputLocked):
+		// Valid soon, not yet.
+		return p2p.PenaltyNonStandardTx
+	case errors.Is(err, mempool.ErrValidation):
+		return p2p.PenaltyInvalidTx
+	default:
+		return p2p.PenaltyNonStandardTx
+	}
+}
+
+// rememberReject reports whether a rejection holds until the chain tip
+// moves, so the same transaction need not be validated again before then.
+func rememberReject(err error) bool {
+	return errors.Is(err, mempool.ErrValidation) ||
+		errors.Is(err, mempool.ErrFeeTooLow) ||
+		errors.Is(err, mempool.ErrCoinbaseNotMature) ||
+		errors.Is(err, mempool.ErrOutputLocked)
+}
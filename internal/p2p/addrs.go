@@ -0,0 +1,72 @@
+package p2p
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// listenMultiaddrs returns the addresses the host listens on: the
+// configured ListenAddrs, or TCP on ListenAddr and Port.
+func (c Config) listenMultiaddrs() ([]ma.Multiaddr, error) {
+	if len(c.ListenAddrs) > 0 {
+		return parseMultiaddrs("listen", c.ListenAddrs)
+	}
+	proto := "ip4"
+	if ip := net.ParseIP(c.ListenAddr); ip != nil && ip.To4() == nil {
+		proto = "ip6"
+	}
+	addr, err := ma.NewMultiaddr(fmt.Sprintf("/%s/%s/tcp/%d", proto, c.ListenAddr, c.Port))
+	if err != nil {
+		return nil, fmt.Errorf("invalid listen address %s:%d: %w", c.ListenAddr, c.Port, err)
+	}
+	return []ma.Multiaddr{addr}, nil
+}
+
+// parseMultiaddrs parses a list of configured multiaddrs; kind names the
+// list in errors.
+func parseMultiaddrs(kind string, addrs []string) ([]ma.Multiaddr, error) {
+	out := make([]ma.Multiaddr, 0, len(addrs))
+	for _, s := range addrs {
+		addr, err := ma.NewMultiaddr(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s address %q: %w", kind, s, err)
+		}
+		out = append(out, addr)
+	}
+	return out, nil
+}
+
+// advertisedAddrs returns the host's address factory. Announce addresses
+// replace the listen addresses; external addresses, such as a public IP
+// that port-forwards to us, are added to them.
+func advertisedAddrs(announce, external []ma.Multiaddr) func([]ma.Multiaddr) []ma.Multiaddr {
+	return func(addrs []ma.Multiaddr) []ma.Multiaddr {
+		if len(announce) > 0 {
+			return slices.Clone(announce)
+		}
+		out := slices.Clone(addrs)
+		for _, ext := range external {
+			if !slices.ContainsFunc(out, ext.Equal) {
+				out = append(out, ext)
+			}
+		}
+		return out
+	}
+}
+
+// ListenAddrs returns the addresses the host is listening on, with
+// wildcard ports resolved. They can differ from the advertised Addrs.
+func (n *Node) ListenAddrs() []string {
+	if n.host == nil {
+		return nil
+	}
+	var addrs []string
+	for _, a := range n.host.Network().ListenAddresses() {
+		addrs = append(addrs, a.String())
+	}
+	return addrs
+}
@@ -0,0 +1,93 @@
+package p2p
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestConfig_ListenMultiaddrs(t *testing.T) {
+	tests := []struct {
+		cfg  Config
+		want []string
+	}{
+		{Config{ListenAddr: "0.0.0.0", Port: 30303}, []string{"/ip4/0.0.0.0/tcp/30303"}},
+		{Config{ListenAddr: "::", Port: 30303}, []string{"/ip6/::/tcp/30303"}},
+		{
+			Config{ListenAddr: "0.0.0.0", Port: 30303, ListenAddrs: []string{"/ip6/::1/tcp/1", " /ip4/127.0.0.1/udp/1/quic-v1"}},
+			[]string{"/ip6/::1/tcp/1", "/ip4/127.0.0.1/udp/1/quic-v1"},
+		},
+	}
+	for _, tt := range tests {
+		addrs, err := tt.cfg.listenMultiaddrs()
+		if err != nil {
+			t.Fatalf("listenMultiaddrs(%+v): %v", tt.cfg, err)
+		}
+		var got []string
+		for _, a := range addrs {
+			got = append(got, a.String())
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("listenMultiaddrs(%+v) = %v, want %v", tt.cfg, got, tt.want)
+		}
+	}
+
+	if _, err := (Config{ListenAddrs: []string{"127.0.0.1:30303"}}).listenMultiaddrs(); err == nil {
+		t.Error("expected error for a listen address that is not a multiaddr")
+	}
+}
+
+func TestAdvertisedAddrs(t *testing.T) {
+	listen := []ma.Multiaddr{ma.StringCast("/ip4/10.0.0.2/tcp/30303")}
+	public := ma.StringCast("/ip4/203.0.113.7/tcp/30303")
+
+	if got := advertisedAddrs(nil, nil)(listen); !slices.EqualFunc(got, listen, ma.Multiaddr.Equal) {
+		t.Errorf("no announce/external: got %v, want %v", got, listen)
+	}
+	if got := advertisedAddrs(nil, []ma.Multiaddr{public, listen[0]})(listen); len(got) != 2 || !got[1].Equal(public) {
+		t.Errorf("external: got %v, want listen address plus %v", got, public)
+	}
+	if got := advertisedAddrs([]ma.Multiaddr{public}, nil)(listen); len(got) != 1 || !got[0].Equal(public) {
+		t.Errorf("announce: got %v, want only %v", got, public)
+	}
+}
+
+func TestNode_ListenTCPAndQUIC(t *testing.T) {
+	n := New(Config{
+		ListenAddrs:   []string{"/ip4/127.0.0.1/tcp/0", "/ip4/127.0.0.1/udp/0/quic-v1"},
+		ExternalAddrs: []string{"/ip4/203.0.113.7/tcp/30303"},
+		NoDiscover:    true,
+	})
+	if err := n.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer n.Stop()
+
+	listen := strings.Join(n.ListenAddrs(), " ")
+	if !strings.Contains(listen, "/tcp/") || !strings.Contains(listen, "/quic-v1") {
+		t.Errorf("ListenAddrs = %v, want TCP and QUIC addresses", n.ListenAddrs())
+	}
+	if !strings.Contains(strings.Join(n.Addrs(), " "), "/ip4/203.0.113.7/tcp/30303/p2p/") {
+		t.Errorf("Addrs = %v, want external address advertised", n.Addrs())
+	}
+}
+
+func TestTwoNodes_ConnectOverQUIC(t *testing.T) {
+	a := New(Config{ListenAddrs: []string{"/ip4/127.0.0.1/udp/0/quic-v1"}, NoDiscover: true})
+	if err := a.Start(); err != nil {
+		t.Fatalf("Start A: %v", err)
+	}
+	defer a.Stop()
+	b := New(Config{ListenAddrs: []string{"/ip4/127.0.0.1/udp/0/quic-v1"}, NoDiscover: true})
+	if err := b.Start(); err != nil {
+		t.Fatalf("Start B: %v", err)
+	}
+	defer b.Stop()
+
+	connectNodes(t, a, b)
+	if b.PeerCount() == 0 {
+		t.Fatal("B should be connected to A over QUIC")
+	}
+}
@@ -0,0 +1,260 @@
+package p2p
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/metrics"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+)
+
+// Traffic is counted three ways: per protocol and per peer by the libp2p
+// bandwidth reporter, and per GossipSub topic by a pubsub tracer, since
+// all topics share one protocol stream. The resource manager bounds how
+// many streams each of our protocols may hold open, in total and per
+// peer, and block serving is throttled to the configured upload rates.
+
+// protocolStreamLimit bounds the concurrent inbound streams of one of our
+// request/response protocols.
+type protocolStreamLimit struct {
+	proto   protocol.ID
+	inbound int // Across all peers.
+	perPeer int // From a single peer.
+}
+
+// protocolStreamLimits lists the stream limits of our protocols. Block
+// serving is the most expensive, so a peer gets one sync stream at a
+// time. Sub-chain protocols use the libp2p defaults.
+var protocolStreamLimits = []protocolStreamLimit{
+	{SyncProtocol, 32, 1},
+	{LightProtocol, 32, 2},
+	{HeightProtocol, 128, 4},
+	{HandshakeProtocol, 128, 2},
+	{TxFetchProtocol, 256, 8},
+	{BlockTxnProtocol, 256, 8},
+	{StemProtocol, 256, 8},
+	{PexProtocol, 64, 1},
+}
+
+// newResourceManager returns a resource manager enforcing resourceLimits,
+// scaled to the machine's memory and file descriptors.
+func newResourceManager() (network.ResourceManager, error) {
+	limits := resourceLimits()
+	return rcmgr.NewResourceManager(rcmgr.NewFixedLimiter(limits.AutoScale()))
+}
+
+// resourceLimits returns the libp2p defaults, including the limits for
+// libp2p's own services, with our per-protocol and per-protocol-peer
+// stream limits added.
+func resourceLimits() rcmgr.ScalingLimitConfig {
+	limits := rcmgr.DefaultLimits
+	libp2p.SetDefaultServiceLimits(&limits)
+	for _, l := range protocolStreamLimits {
+		limits.AddProtocolLimit(l.proto, rcmgr.BaseLimit{
+			StreamsInbound:  l.inbound,
+			StreamsOutbound: limits.ProtocolBaseLimit.StreamsOutbound,
+			Streams:         l.inbound + limits.ProtocolBaseLimit.StreamsOutbound,
+			Memory:          limits.ProtocolBaseLimit.Memory,
+		}, rcmgr.BaseLimitIncrease{})
+		limits.AddProtocolPeerLimit(l.proto, rcmgr.BaseLimit{
+			StreamsInbound:  l.perPeer,
+			StreamsOutbound: limits.ProtocolPeerBaseLimit.StreamsOutbound,
+			Streams:         l.perPeer + limits.ProtocolPeerBaseLimit.StreamsOutbound,
+			Memory:          limits.ProtocolPeerBaseLimit.Memory,
+		}, rcmgr.BaseLimitIncrease{})
+	}
+	return limits
+}
+
+// BandwidthTotals returns the traffic of all protocols.
+func (n *Node) BandwidthTotals() metrics.Stats {
+	return n.bandwidth.GetBandwidthTotals()
+}
+
+// BandwidthByProtocol returns the traffic of each protocol.
+func (n *Node) BandwidthByProtocol() map[protocol.ID]metrics.Stats {
+	return n.bandwidth.GetBandwidthByProtocol()
+}
+
+// BandwidthForPeer returns the traffic exchanged with a peer.
+func (n *Node) BandwidthForPeer(id peer.ID) metrics.Stats {
+	return n.bandwidth.GetBandwidthForPeer(id)
+}
+
+// BandwidthByTopic returns the GossipSub message bytes of each topic,
+// root and sub-chain alike. Control messages are not included.
+func (n *Node) BandwidthByTopic() map[string]metrics.Stats {
+	out := make(map[string]metrics.Stats)
+	for topic, s := range n.topicBandwidth.GetBandwidthByProtocol() {
+		out[string(topic)] = s
+	}
+	return out
+}
+
+// topicTracer counts published message bytes per topic. Topics are
+// recorded as protocol IDs of a separate bandwidth counter.
+type topicTracer struct {
+	counter *metrics.BandwidthCounter
+}
+
+var _ pubsub.RawTracer = (*topicTracer)(nil)
+
+func (t *topicTracer) RecvRPC(rpc *pubsub.RPC) {
+	for _, msg := range rpc.GetPublish() {
+		t.counter.LogRecvMessageStream(int64(msg.Size()), protocol.ID(msg.GetTopic()), "")
+	}
+}
+
+func (t *topicTracer) SendRPC(rpc *pubsub.RPC, p peer.ID) {
+	for _, msg := range rpc.GetPublish() {
+		t.counter.LogSentMessageStream(int64(msg.Size()), protocol.ID(msg.GetTopic()), p)
+	}
+}
+
+func (t *topicTracer) AddPeer(peer.ID, protocol.ID)          {}
+func (t *topicTracer) RemovePeer(peer.ID)                    {}
+func (t *topicTracer) Join(string)                           {}
+func (t *topicTracer) Leave(string)                          {}
+func (t *topicTracer) Graft(peer.ID, string)                 {}
+func (t *topicTracer) Prune(peer.ID, string)                 {}
+func (t *topicTracer) ValidateMessage(*pubsub.Message)       {}
+func (t *topicTracer) DeliverMessage(*pubsub.Message)        {}
+func (t *topicTracer) RejectMessage(*pubsub.Message, string) {}
+func (t *topicTracer) DuplicateMessage(*pubsub.Message)      {}
+func (t *topicTracer) ThrottlePeer(peer.ID)                  {}
+func (t *topicTracer) DropRPC(*pubsub.RPC, peer.ID)          {}
+func (t *topicTracer) UndeliverableMessage(*pubsub.Message)  {}
+
+// uploadChunkSize is the largest write charged to the upload limiter at
+// once.
+const uploadChunkSize = 16 * 1024
+
+// byteBucket is a token bucket of bytes holding up to one second of
+// rate. It may go into debt: a caller that takes more than is left waits
+// for the debt to refill.
+type byteBucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newByteBucket(rate int) *byteBucket {
+	return &byteBucket{rate: float64(rate), tokens: float64(rate)}
+}
+
+// take removes size bytes and returns how long to wait before sending.
+func (b *byteBucket) take(size int, now time.Time) time.Duration {
+	if b.last.IsZero() {
+		b.last = now
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(b.rate, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+	b.tokens -= float64(size)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// uploadLimiter caps the bytes per second spent serving blocks, in total
+// and to each peer. A zero rate means unlimited.
+type uploadLimiter struct {
+	mu       sync.Mutex
+	total    *byteBucket // nil = unlimited
+	peerRate int
+	peers    map[peer.ID]*byteBucket
+}
+
+func newUploadLimiter(totalRate, peerRate int) *uploadLimiter {
+	l := &uploadLimiter{peerRate: peerRate, peers: make(map[peer.ID]*byteBucket)}
+	if totalRate > 0 {
+		l.total = newByteBucket(totalRate)
+	}
+	return l
+}
+
+// enabled reports whether any upload cap is configured.
+func (l *uploadLimiter) enabled() bool {
+	return l.total != nil || l.peerRate > 0
+}
+
+// reserve charges size bytes to id and returns how long to wait before
+// sending them: the longer of the total and the per-peer wait.
+func (l *uploadLimiter) reserve(id peer.ID, size int, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var wait time.Duration
+	if l.total != nil {
+		wait = l.total.take(size, now)
+	}
+	if l.peerRate > 0 {
+		b, ok := l.peers[id]
+		if !ok {
+			b = newByteBucket(l.peerRate)
+			l.peers[id] = b
+		}
+		wait = max(wait, b.take(size, now))
+	}
+	return wait
+}
+
+// forget drops a disconnected peer's bucket.
+func (l *uploadLimiter) forget(id peer.ID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.peers, id)
+}
+
+// throttledWriter writes to w no faster than the limiter allows for peer.
+type throttledWriter struct {
+	ctx     context.Context
+	w       io.Writer
+	limiter *uploadLimiter
+	peer    peer.ID
+}
+
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p[:min(len(p), uploadChunkSize)]
+		if wait := t.limiter.reserve(t.peer, len(chunk), time.Now()); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-t.ctx.Done():
+				timer.Stop()
+				return written, t.ctx.Err()
+			case <-timer.C:
+			}
+		}
+		n, err := t.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
+
+// blockWriter returns the writer for a block-serving response on stream,
+// throttled when upload caps are configured.
+func (n *Node) blockWriter(stream network.Stream) io.Writer {
+	if n.upload == nil || !n.upload.enabled() {
+		return stream
+	}
+	ctx := n.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &throttledWriter{ctx: ctx, w: stream, limiter: n.upload, peer: stream.Conn().RemotePeer()}
+}
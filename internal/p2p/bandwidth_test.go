@@ -0,0 +1,145 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/identify"
+)
+
+func TestUploadLimiter_Reserve(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := newUploadLimiter(1000, 500)
+	a, b := peer.ID("a"), peer.ID("b")
+
+	// Each bucket starts with one second of rate.
+	if wait := l.reserve(a, 100, now); wait != 0 {
+		t.Errorf("first reserve waited %v", wait)
+	}
+	// Peer a goes 100 bytes into debt at 500 B/s.
+	if wait := l.reserve(a, 500, now); wait != 200*time.Millisecond {
+		t.Errorf("per-peer wait = %v, want 200ms", wait)
+	}
+	// Peer b has its own bucket but shares the total, now 400.
+	if wait := l.reserve(b, 100, now); wait != 0 {
+		t.Errorf("other peer waited %v", wait)
+	}
+	if wait := l.reserve(b, 400, now); wait != 100*time.Millisecond {
+		t.Errorf("total wait = %v, want 100ms", wait)
+	}
+
+	// Buckets refill over time.
+	if wait := l.reserve(a, 50, now.Add(3*time.Second)); wait != 0 {
+		t.Errorf("refilled reserve waited %v", wait)
+	}
+
+	l.forget(a)
+	if _, ok := l.peers[a]; ok {
+		t.Error("forget kept the peer bucket")
+	}
+}
+
+func TestUploadLimiter_Unlimited(t *testing.T) {
+	l := newUploadLimiter(0, 0)
+	if l.enabled() {
+		t.Fatal("limiter without rates should be disabled")
+	}
+	if wait := l.reserve("a", 1<<30, time.Now()); wait != 0 {
+		t.Errorf("unlimited reserve waited %v", wait)
+	}
+}
+
+func TestResourceLimits(t *testing.T) {
+	limits := resourceLimits()
+	if _, ok := limits.ServiceLimits[identify.ServiceName]; !ok {
+		t.Error("libp2p service limits missing")
+	}
+	if _, ok := limits.ProtocolLimits[SyncProtocol]; !ok {
+		t.Error("protocol limits missing")
+	}
+}
+
+func TestTwoNodes_SyncUploadCap(t *testing.T) {
+	nodeA := New(Config{ListenAddr: "127.0.0.1", NoDiscover: true, PeerUploadRate: 16 * 1024})
+	if err := nodeA.Start(); err != nil {
+		t.Fatalf("start node: %v", err)
+	}
+	t.Cleanup(func() { nodeA.Stop() })
+	nodeB := startTestNode(t)
+	connectNodes(t, nodeA, nodeB)
+
+	blocks := make([]*block.Block, 300)
+	for i := range blocks {
+		blocks[i] = &block.Block{Header: &block.Header{Height: uint64(i), Version: 1}}
+	}
+	NewSyncer(nodeA).RegisterHandler(func(from uint64, max uint32) []*block.Block {
+		return blocks
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	start := time.Now()
+	got, err := NewSyncer(nodeB).RequestBlocks(ctx, nodeA.host.ID(), 0, 500)
+	if err != nil {
+		t.Fatalf("RequestBlocks: %v", err)
+	}
+	elapsed := time.Since(start)
+	if len(got) != len(blocks) {
+		t.Fatalf("got %d blocks, want %d", len(got), len(blocks))
+	}
+
+	sent := nodeA.BandwidthByProtocol()[SyncProtocol].TotalOut
+	if sent < 32*1024 {
+		t.Fatalf("sync protocol sent %d bytes, test needs over two seconds of cap", sent)
+	}
+	// One second of burst, then 16 KiB/s.
+	if min := time.Duration(float64(sent-16*1024) / (16 * 1024) * float64(time.Second) * 0.8); elapsed < min {
+		t.Errorf("served %d bytes in %v, cap allows no faster than %v", sent, elapsed, min)
+	}
+	if nodeA.BandwidthForPeer(nodeB.host.ID()).TotalOut < sent {
+		t.Error("peer counter missed the sync traffic")
+	}
+	if nodeB.BandwidthTotals().TotalIn < sent {
+		t.Error("receiver totals missed the sync traffic")
+	}
+}
+
+func TestTwoNodes_TopicBandwidth(t *testing.T) {
+	nodeA := startTestNode(t)
+	nodeB := startTestNode(t)
+	for _, n := range []*Node{nodeA, nodeB} {
+		if err := n.JoinHeartbeat(); err != nil {
+			t.Fatalf("JoinHeartbeat: %v", err)
+		}
+	}
+	connectNodes(t, nodeA, nodeB)
+	time.Sleep(300 * time.Millisecond)
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	hb := &HeartbeatMessage{PubKey: key.PublicKey(), Height: 1, Timestamp: time.Now().Unix()}
+	hash := crypto.Hash(HeartbeatSigningBytes(hb.PubKey, hb.Height, hb.Timestamp))
+	if hb.Signature, err = key.Sign(hash[:]); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if err := nodeA.BroadcastHeartbeat(hb); err != nil {
+			t.Fatalf("BroadcastHeartbeat: %v", err)
+		}
+		if nodeA.BandwidthByTopic()[TopicHeartbeat].TotalOut > 0 && nodeB.BandwidthByTopic()[TopicHeartbeat].TotalIn > 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("topic counters: sent %+v, received %+v",
+				nodeA.BandwidthByTopic()[TopicHeartbeat], nodeB.BandwidthByTopic()[TopicHeartbeat])
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
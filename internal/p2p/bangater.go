@@ -8,14 +8,24 @@ import (
 )
 
 // banGater implements the libp2p ConnectionGater interface to reject
-// connections from banned peers at the transport level.
+// connections from banned peers at the transport level. When private is
+// non-empty, only those peers may connect.
 type banGater struct {
-	banMgr *BanManager
+	banMgr  *BanManager
+	private map[peer.ID]bool
+}
+
+// allowed reports whether p may connect at all.
+func (g *banGater) allowed(p peer.ID) bool {
+	if len(g.private) > 0 && !g.private[p] {
+		return false
+	}
+	return !g.banMgr.IsBanned(p)
 }
 
 // InterceptPeerDial rejects outbound dials to banned peers.
 func (g *banGater) InterceptPeerDial(p peer.ID) bool {
-	return !g.banMgr.IsBanned(p)
+	return g.allowed(p)
 }
 
 // InterceptAddrDial allows all address dials (filtering is done per-peer).
@@ -32,7 +42,7 @@ func (g *banGater) InterceptAccept(_ network.ConnMultiaddrs) bool {
 // InterceptSecured rejects connections from banned peers once their
 // identity is authenticated.
 func (g *banGater) InterceptSecured(_ network.Direction, p peer.ID, _ network.ConnMultiaddrs) bool {
-	return !g.banMgr.IsBanned(p)
+	return g.allowed(p)
 }
 
 // InterceptUpgraded allows all fully upgraded connections.
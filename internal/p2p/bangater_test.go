@@ -87,3 +87,16 @@ func TestBanGater_AfterUnban(t *testing.T) {
 		t.Error("should allow after unban")
 	}
 }
+
+func TestBanGater_PrivatePeersOnly(t *testing.T) {
+	bm := NewBanManager(nil, nil)
+	sentry := peer.ID("sentry")
+	g := &banGater{banMgr: bm, private: map[peer.ID]bool{sentry: true}}
+
+	if !g.InterceptPeerDial(sentry) || !g.InterceptSecured(0, sentry, nil) {
+		t.Error("should allow private peer")
+	}
+	if g.InterceptPeerDial(peer.ID("stranger")) || g.InterceptSecured(0, peer.ID("stranger"), nil) {
+		t.Error("should reject peers outside the private group")
+	}
+}
@@ -17,7 +17,11 @@ const (
 // Penalty values for different offenses.
 const (
 	PenaltyInvalidBlock  = 50  // Bad sig, consensus fail.
+	PenaltyMalformedTx   = 50  // Undecodable transaction message.
 	PenaltyInvalidTx     = 20  // Validation failure.
+	PenaltyNonStandardTx = 5   // Spends immature or locked outputs.
+	PenaltyBadHeartbeat  = 20  // Undecodable or badly signed heartbeat.
+	PenaltyGossipScore   = 20  // Graylisted by GossipSub peer scoring.
 	PenaltyHandshakeFail = 100 // Instant ban (genesis mismatch).
 )
 
@@ -140,6 +144,13 @@ func (bm *BanManager) IsBanned(id peer.ID) bool {
 	return true
 }
 
+// Score returns a peer's offense score towards a ban.
+func (bm *BanManager) Score(id peer.ID) int {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+	return bm.scores[id]
+}
+
 // Unban manually removes a ban.
 func (bm *BanManager) Unban(id peer.ID) {
 	bm.mu.Lock()
@@ -0,0 +1,149 @@
+package p2p
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/Klingon-tech/klingnet-chain/config"
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/crypto"
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// CompactBlock relays a block as its header and short transaction IDs.
+// Receivers rebuild the block from transactions they already hold and
+// fetch the rest over BlockTxnProtocol. Transactions peers cannot have,
+// such as the coinbase, are sent in full.
+type CompactBlock struct {
+	Header    *block.Header `json:"header"`
+	ShortIDs  []uint64      `json:"short_ids"` // Non-prefilled transactions, in block order.
+	Prefilled []PrefilledTx `json:"prefilled"` // Ascending by index.
+}
+
+// PrefilledTx is a transaction sent in full inside a compact block.
+type PrefilledTx struct {
+	Index int             `json:"index"`
+	Tx    *tx.Transaction `json:"tx"`
+}
+
+// NewCompactBlock builds the compact form of b, prefilling the coinbase.
+func NewCompactBlock(b *block.Block) *CompactBlock {
+	cb := &CompactBlock{Header: b.Header}
+	blockHash := b.Hash()
+	for i, t := range b.Transactions {
+		if i == 0 {
+			cb.Prefilled = append(cb.Prefilled, PrefilledTx{Index: 0, Tx: t})
+			continue
+		}
+		cb.ShortIDs = append(cb.ShortIDs, shortTxID(blockHash, t.Hash()))
+	}
+	return cb
+}
+
+// shortTxID is the first 8 bytes of H(blockHash || txHash). Salting with
+// the block hash keeps collisions from being reused across blocks.
+func shortTxID(blockHash, txHash types.Hash) uint64 {
+	h := crypto.HashConcat(blockHash, txHash)
+	return binary.BigEndian.Uint64(h[:8])
+}
+
+// TxCount returns the number of transactions in the block.
+func (cb *CompactBlock) TxCount() int {
+	return len(cb.ShortIDs) + len(cb.Prefilled)
+}
+
+// check validates the compact block's shape.
+func (cb *CompactBlock) check() error {
+	if cb.Header == nil {
+		return fmt.Errorf("missing header")
+	}
+	total := cb.TxCount()
+	if total > config.MaxBlockTxs {
+		return fmt.Errorf("too many transactions: %d", total)
+	}
+	last := -1
+	for _, p := range cb.Prefilled {
+		if p.Tx == nil {
+			return fmt.Errorf("prefilled transaction %d is empty", p.Index)
+		}
+		if p.Index <= last || p.Index >= total {
+			return fmt.Errorf("prefilled index %d out of order", p.Index)
+		}
+		last = p.Index
+	}
+	return nil
+}
+
+// reconstruct places the prefilled transactions and matches short IDs
+// against candidates. It returns the block's transactions, nil where no
+// candidate matched, and the indexes still missing.
+func (cb *CompactBlock) reconstruct(candidates []*tx.Transaction) ([]*tx.Transaction, []int) {
+	blockHash := cb.Header.Hash()
+	byID := make(map[uint64]*tx.Transaction, len(candidates))
+	for _, t := range candidates {
+		byID[shortTxID(blockHash, t.Hash())] = t
+	}
+
+	txs := make([]*tx.Transaction, cb.TxCount())
+	for _, p := range cb.Prefilled {
+		txs[p.Index] = p.Tx
+	}
+	var missing []int
+	next := 0
+	for i := range txs {
+		if txs[i] != nil {
+			continue
+		}
+		if t, ok := byID[cb.ShortIDs[next]]; ok {
+			txs[i] = t
+		} else {
+			missing = append(missing, i)
+		}
+		next++
+	}
+	return txs, missing
+}
+
+// shortID returns the short ID of the transaction at index, or false if
+// the index is prefilled or out of range.
+func (cb *CompactBlock) shortID(index int) (uint64, bool) {
+	if index < 0 || index >= cb.TxCount() {
+		return 0, false
+	}
+	next := index
+	for _, p := range cb.Prefilled {
+		if p.Index == index {
+			return 0, false
+		}
+		if p.Index < index {
+			next--
+		}
+	}
+	return cb.ShortIDs[next], true
+}
+
+// matches reports whether txs are the block's transactions at indexes:
+// one non-nil transaction per index, each hashing to its short ID.
+func (cb *CompactBlock) matches(indexes []int, txs []*tx.Transaction) bool {
+	if len(txs) != len(indexes) {
+		return false
+	}
+	blockHash := cb.Header.Hash()
+	for i, idx := range indexes {
+		id, ok := cb.shortID(idx)
+		if !ok || txs[i] == nil || shortTxID(blockHash, txs[i].Hash()) != id {
+			return false
+		}
+	}
+	return true
+}
+
+// merkleMatches reports whether txs hash to the header's merkle root.
+func merkleMatches(h *block.Header, txs []*tx.Transaction) bool {
+	hashes := make([]types.Hash, len(txs))
+	for i, t := range txs {
+		hashes[i] = t.Hash()
+	}
+	return block.ComputeMerkleRoot(hashes) == h.MerkleRoot
+}
@@ -0,0 +1,223 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/Klingon-tech/klingnet-chain/config"
+	klog "github.com/Klingon-tech/klingnet-chain/internal/log"
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// Dandelion relay of locally submitted root-chain transactions. In the
+// stem phase a transaction is passed along a chain of single peers; each
+// hop switches to the fluff phase (a normal announcement) with probability
+// stemFluffProbability. The announcing node is then usually several hops
+// from the one that created the transaction.
+const (
+	// StemProtocol carries one stem-phase transaction to a peer.
+	StemProtocol = protocol.ID("/klingnet/stem/1.0.0")
+
+	stemFluffProbability = 0.1
+	stemEpoch            = 10 * time.Minute // How long a stem peer is kept.
+	stemEmbargo          = 30 * time.Second // Fluff a stem tx ourselves if no peer announced it by then.
+	stemSendTimeout      = 5 * time.Second
+)
+
+// stemRoute is the peer stem transactions are forwarded to this epoch.
+type stemRoute struct {
+	mu     sync.Mutex
+	peer   peer.ID
+	chosen time.Time
+}
+
+// SetStemTxHandler sets the handler for transactions received in the stem
+// phase. Without one they are handed to the transaction handler, which
+// fluffs them.
+func (n *Node) SetStemTxHandler(fn func(from peer.ID, data []byte)) {
+	n.stemHandler = fn
+}
+
+// SubmitTx relays a transaction created or submitted on this node. With
+// Dandelion enabled it enters the stem phase; otherwise, or without a
+// stem peer, it is announced after a random delay of up to
+// Config.BroadcastDelay.
+func (n *Node) SubmitTx(t *tx.Transaction) error {
+	if n.topicTx == nil {
+		return fmt.Errorf("p2p node not started")
+	}
+	if n.config.Dandelion {
+		if err := n.stemTx(t, ""); err == nil {
+			n.embargoTx(t)
+			return nil
+		}
+	}
+	if n.config.BroadcastDelay <= 0 {
+		return n.BroadcastTx(t)
+	}
+	delay := rand.N(n.config.BroadcastDelay)
+	time.AfterFunc(delay, func() {
+		if n.ctx.Err() != nil {
+			return
+		}
+		if err := n.BroadcastTx(t); err != nil {
+			logger := klog.WithComponent("p2p")
+			logger.Debug().Err(err).Msg("Failed to announce delayed transaction")
+		}
+	})
+	return nil
+}
+
+// RelayStemTx continues the stem phase of a transaction received over
+// StemProtocol and admitted to the mempool: it goes to our stem peer, or
+// is announced with probability stemFluffProbability or when no other
+// peer is connected.
+func (n *Node) RelayStemTx(t *tx.Transaction, from peer.ID) error {
+	if rand.Float64() >= stemFluffProbability {
+		if err := n.stemTx(t, from); err == nil {
+			n.embargoTx(t)
+			return nil
+		}
+	}
+	return n.BroadcastTx(t)
+}
+
+// embargoTx announces a stem transaction if no peer has announced it by
+// the end of the embargo, in case a stem hop dropped it.
+func (n *Node) embargoTx(t *tx.Transaction) {
+	h := t.Hash()
+	n.inv.stem(h)
+	delay := stemEmbargo + rand.N(stemEmbargo/2)
+	time.AfterFunc(delay, func() {
+		if n.ctx.Err() != nil || !n.inv.unstem(h) {
+			return
+		}
+		if src := n.inv.txSource(); src != nil && src.Get(h) == nil {
+			return // Mined or dropped meanwhile.
+		}
+		if err := n.BroadcastTx(t); err != nil {
+			logger := klog.WithComponent("p2p")
+			logger.Debug().Err(err).Msg("Failed to fluff embargoed transaction")
+		}
+	})
+}
+
+// stemTx sends t to the stem peer, or to another random peer if the stem
+// peer is exclude.
+func (n *Node) stemTx(t *tx.Transaction, exclude peer.ID) error {
+	pid, ok := n.stemPeer(exclude, time.Now())
+	if !ok {
+		return fmt.Errorf("no stem peer")
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("marshal tx: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(n.ctx, stemSendTimeout)
+	defer cancel()
+	stream, err := n.host.NewStream(ctx, pid, StemProtocol)
+	if err != nil {
+		return fmt.Errorf("open stem stream: %w", err)
+	}
+	defer stream.Close()
+	_ = stream.SetWriteDeadline(time.Now().Add(stemSendTimeout))
+	if _, err := stream.Write(data); err != nil {
+		stream.Reset()
+		return fmt.Errorf("send stem tx: %w", err)
+	}
+	return stream.CloseWrite()
+}
+
+// stemPeer returns the peer to forward stem transactions to. A random
+// connected peer is chosen each epoch; a transaction that came from that
+// peer goes to another random peer instead.
+func (n *Node) stemPeer(exclude peer.ID, now time.Time) (peer.ID, bool) {
+	if n.host == nil {
+		return "", false
+	}
+	var candidates []peer.ID
+	connected := false
+	for _, pid := range n.host.Network().Peers() {
+		if pid == exclude || (n.BanManager != nil && n.BanManager.IsBanned(pid)) {
+			continue
+		}
+		candidates = append(candidates, pid)
+	}
+
+	n.stem.mu.Lock()
+	defer n.stem.mu.Unlock()
+	for _, pid := range candidates {
+		if pid == n.stem.peer {
+			connected = true
+		}
+	}
+	if connected && now.Sub(n.stem.chosen) < stemEpoch {
+		return n.stem.peer, true
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+	pid := candidates[rand.IntN(len(candidates))]
+	if exclude == "" || exclude != n.stem.peer {
+		n.stem.peer, n.stem.chosen = pid, now
+	}
+	return pid, true
+}
+
+func (n *Node) handleStem(stream network.Stream) {
+	defer stream.Close()
+	from := stream.Conn().RemotePeer()
+	if n.BanManager != nil && n.BanManager.IsBanned(from) {
+		return
+	}
+	if !n.txLimiter.allow(from, time.Now()) {
+		return
+	}
+
+	_ = stream.SetReadDeadline(time.Now().Add(stemSendTimeout))
+	data, err := io.ReadAll(io.LimitReader(stream, config.MaxBlockSize))
+	if err != nil || len(data) == 0 {
+		return
+	}
+
+	defer func() { recover() }()
+	n.addPeer(from)
+	switch {
+	case n.stemHandler != nil:
+		n.stemHandler(from, data)
+	case n.txHandler != nil:
+		n.txHandler(from, data)
+	}
+}
+
+// stem marks a transaction as waiting in the stem phase.
+func (inv *inventory) stem(h types.Hash) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.stems[h] = struct{}{}
+}
+
+// unstem clears a stem mark, reporting whether it was still set.
+func (inv *inventory) unstem(h types.Hash) bool {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	_, ok := inv.stems[h]
+	delete(inv.stems, h)
+	return ok
+}
+
+func (inv *inventory) txSource() TxSource {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	return inv.source
+}
@@ -0,0 +1,72 @@
+package p2p
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestNode_SubmitTxStem(t *testing.T) {
+	nodeA := New(Config{ListenAddr: "127.0.0.1", Port: 0, NoDiscover: true, Dandelion: true})
+	if err := nodeA.Start(); err != nil {
+		t.Fatalf("start node: %v", err)
+	}
+	t.Cleanup(func() { nodeA.Stop() })
+	nodeB := startTestNode(t)
+	connectNodes(t, nodeA, nodeB)
+
+	stemmed := make(chan *tx.Transaction, 1)
+	nodeB.SetStemTxHandler(func(from peer.ID, data []byte) {
+		if from != nodeA.ID() {
+			t.Errorf("stem tx from %s, want %s", from, nodeA.ID())
+		}
+		var got tx.Transaction
+		if err := json.Unmarshal(data, &got); err == nil {
+			stemmed <- &got
+		}
+	})
+
+	sent := testInvTx(5)
+	if err := nodeA.SubmitTx(sent); err != nil {
+		t.Fatalf("SubmitTx: %v", err)
+	}
+	select {
+	case got := <-stemmed:
+		if got.Hash() != sent.Hash() {
+			t.Errorf("stem tx = %s, want %s", got.Hash(), sent.Hash())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for stem tx")
+	}
+
+	// In the stem phase: not announced until the embargo ends.
+	if len(nodeA.inv.takePending()) != 0 {
+		t.Error("stem tx should not be announced")
+	}
+	if !nodeA.inv.unstem(sent.Hash()) {
+		t.Error("stem tx should be under embargo")
+	}
+}
+
+func TestNode_StemPeer(t *testing.T) {
+	nodeA := startTestNode(t)
+	nodeB := startTestNode(t)
+	connectNodes(t, nodeA, nodeB)
+
+	now := time.Now()
+	pid, ok := nodeA.stemPeer("", now)
+	if !ok || pid != nodeB.ID() {
+		t.Fatalf("stemPeer = %s, %v; want %s", pid, ok, nodeB.ID())
+	}
+	// A tx from the only peer has nowhere to go but the fluff phase.
+	if _, ok := nodeA.stemPeer(nodeB.ID(), now); ok {
+		t.Error("stemPeer should exclude the sending peer")
+	}
+	// Kept for the epoch.
+	if pid, _ := nodeA.stemPeer("", now.Add(stemEpoch/2)); pid != nodeB.ID() {
+		t.Errorf("stem peer changed within the epoch: %s", pid)
+	}
+}
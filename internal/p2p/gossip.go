@@ -8,7 +8,10 @@ import (
 	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
 )
 
-// BroadcastTx publishes a transaction to the gossip network.
+// BroadcastTx announces a transaction to the gossip network. Its hash goes
+// out in the next announcement batch; peers that lack it fetch it over
+// TxFetchProtocol. Announcing a transaction again refreshes it on peers
+// that dropped it.
 func (n *Node) BroadcastTx(t *tx.Transaction) error {
 	if n.topicTx == nil {
 		return fmt.Errorf("p2p node not started")
@@ -19,19 +22,24 @@ func (n *Node) BroadcastTx(t *tx.Transaction) error {
 		return fmt.Errorf("marshal tx: %w", err)
 	}
 
-	return n.topicTx.Publish(n.ctx, data)
+	if n.inv.announce(t, data) >= maxInvHashes {
+		n.flushAnnouncements()
+	}
+	return nil
 }
 
-// BroadcastBlock publishes a block to the gossip network.
+// BroadcastBlock publishes a block to the gossip network as a compact
+// block. The full block is kept to serve peers missing transactions.
 func (n *Node) BroadcastBlock(b *block.Block) error {
 	if n.topicBlock == nil {
 		return fmt.Errorf("p2p node not started")
 	}
 
-	data, err := json.Marshal(b)
+	data, err := json.Marshal(NewCompactBlock(b))
 	if err != nil {
 		return fmt.Errorf("marshal block: %w", err)
 	}
 
+	n.inv.addBlock(b)
 	return n.topicBlock.Publish(n.ctx, data)
 }
@@ -1,13 +1,17 @@
 package p2p
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"slices"
+	"strings"
 	"time"
 
 	klog "github.com/Klingon-tech/klingnet-chain/internal/log"
 	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+	libp2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 )
@@ -17,15 +21,126 @@ const (
 	handshakeTimeout = 10 * time.Second
 
 	// maxHandshakeBytes limits handshake message size.
-	maxHandshakeBytes = 4096
+	maxHandshakeBytes = 32 * 1024
+
+	// maxHandshakeSubChains caps the sub-chains a peer may advertise.
+	maxHandshakeSubChains = 256
+
+	// maxUserAgentLen caps the advertised user agent.
+	maxUserAgentLen = 128
+
+	// maxHandshakeClockSkew is how far a signed handshake's timestamp may
+	// be from our clock.
+	maxHandshakeClockSkew = time.Hour
+
+	// handshakeSigDomain separates handshake signatures from any other
+	// use of the libp2p identity key.
+	handshakeSigDomain = "klingnet-handshake:"
+
+	// capabilityAnnounceDelay coalesces capability changes made in quick
+	// succession, e.g. at startup, into one round of re-handshakes.
+	capabilityAnnounceDelay = time.Second
 )
 
+// Capability is a bitfield of services a node offers, advertised in its
+// handshake.
+type Capability uint32
+
+const (
+	CapHistory   Capability = 1 << iota // Serves the block history over SyncProtocol.
+	CapLight                            // Serves light clients over LightProtocol.
+	CapValidator                        // Produces blocks.
+	CapRelay                            // Acts as a circuit relay for peers behind NAT.
+)
+
+var capabilityNames = []struct {
+	cap  Capability
+	name string
+}{
+	{CapHistory, "history"},
+	{CapLight, "light"},
+	{CapValidator, "validator"},
+	{CapRelay, "relay"},
+}
+
+// Has reports whether all of the given capabilities are set.
+func (c Capability) Has(want Capability) bool {
+	return c&want == want
+}
+
+// Names returns the names of the set capabilities.
+func (c Capability) Names() []string {
+	names := []string{}
+	for _, cn := range capabilityNames {
+		if c.Has(cn.cap) {
+			names = append(names, cn.name)
+		}
+	}
+	return names
+}
+
+// String returns the set capabilities as a comma-separated list.
+func (c Capability) String() string {
+	return strings.Join(c.Names(), ",")
+}
+
 // HandshakeMessage is exchanged between peers to verify compatibility.
+// From SignedHandshakeVersion on it also advertises what the sender
+// serves and is signed by its libp2p identity key.
 type HandshakeMessage struct {
 	ProtocolVersion uint32     `json:"protocol_version"`
 	GenesisHash     types.Hash `json:"genesis_hash"`
 	NetworkID       string     `json:"network_id"`
 	BestHeight      uint64     `json:"best_height"`
+
+	UserAgent    string     `json:"user_agent,omitempty"`   // e.g. "klingnetd/v1.2.3"
+	Capabilities Capability `json:"capabilities,omitempty"` // Services offered.
+	SubChains    []string   `json:"sub_chains,omitempty"`   // Chain ID hex of spawned sub-chains.
+	Timestamp    int64      `json:"timestamp,omitempty"`    // Unix seconds when signed.
+	Signature    []byte     `json:"signature,omitempty"`    // Over signingBytes, by the libp2p identity.
+}
+
+// signingBytes returns the bytes covered by the signature: the message
+// without its signature, encoded as JSON, after a domain prefix.
+func (m *HandshakeMessage) signingBytes() []byte {
+	unsigned := *m
+	unsigned.Signature = nil
+	data, _ := json.Marshal(&unsigned) // Plain struct, cannot fail.
+	return append([]byte(handshakeSigDomain), data...)
+}
+
+// sign signs the message with key.
+func (m *HandshakeMessage) sign(key libp2pcrypto.PrivKey) error {
+	m.Signature = nil
+	sig, err := key.Sign(m.signingBytes())
+	if err != nil {
+		return fmt.Errorf("sign handshake: %w", err)
+	}
+	m.Signature = sig
+	return nil
+}
+
+// verify checks the signature against the sender's public key.
+func (m *HandshakeMessage) verify(pub libp2pcrypto.PubKey) bool {
+	if pub == nil || len(m.Signature) == 0 {
+		return false
+	}
+	ok, err := pub.Verify(m.signingBytes(), m.Signature)
+	return err == nil && ok
+}
+
+// PeerInfo is what a peer told us about itself in its handshake.
+type PeerInfo struct {
+	ProtocolVersion uint32
+	UserAgent       string
+	Capabilities    Capability
+	SubChains       []string // Chain ID hex; replaced, never modified in place.
+	BestHeight      uint64
+}
+
+// HasSubChain reports whether the peer advertised the sub-chain.
+func (pi *PeerInfo) HasSubChain(chainIDHex string) bool {
+	return slices.Contains(pi.SubChains, chainIDHex)
 }
 
 // registerHandshakeHandler sets up the stream handler for incoming handshakes.
@@ -53,16 +168,11 @@ func (n *Node) registerHandshakeHandler() {
 		}
 
 		// Validate peer's message.
-		if reason := n.validateHandshake(peerMsg); reason != "" {
-			logger.Warn().
-				Str("peer", remotePeer.String()[:16]).
-				Str("reason", reason).
-				Msg("Handshake rejected")
-			if n.BanManager != nil && !n.isSeedPeer(remotePeer) {
-				n.BanManager.RecordOffense(remotePeer, PenaltyHandshakeFail, reason)
-			}
-			n.DisconnectPeer(remotePeer)
+		if reason, penalize := n.checkHandshake(peerMsg, stream.Conn().RemotePublicKey()); reason != "" {
+			n.rejectHandshake(remotePeer, reason, penalize)
+			return
 		}
+		n.recordHandshake(remotePeer, peerMsg)
 	})
 }
 
@@ -76,7 +186,7 @@ func (n *Node) doHandshake(peerID peer.ID) {
 	stream, err := n.host.NewStream(ctx, peerID, HandshakeProtocol)
 	if err != nil {
 		logger.Warn().Str("peer", peerID.String()[:16]).Msg("Peer does not support handshake protocol, disconnecting")
-		if n.BanManager != nil && !n.isSeedPeer(peerID) {
+		if n.BanManager != nil && !n.isExemptPeer(peerID) {
 			n.BanManager.RecordOffense(peerID, PenaltyHandshakeFail, "no handshake support")
 		}
 		n.DisconnectPeer(peerID)
@@ -104,30 +214,87 @@ func (n *Node) doHandshake(peerID peer.ID) {
 	}
 
 	// Validate.
-	if reason := n.validateHandshake(peerMsg); reason != "" {
-		logger.Warn().
-			Str("peer", peerID.String()[:16]).
-			Str("reason", reason).
-			Msg("Handshake rejected")
-		if n.BanManager != nil && !n.isSeedPeer(peerID) {
-			n.BanManager.RecordOffense(peerID, PenaltyHandshakeFail, reason)
-		}
-		n.DisconnectPeer(peerID)
+	if reason, penalize := n.checkHandshake(peerMsg, stream.Conn().RemotePublicKey()); reason != "" {
+		n.rejectHandshake(peerID, reason, penalize)
+		return
 	}
+	n.recordHandshake(peerID, peerMsg)
 }
 
 // validateHandshake checks a peer's handshake message for compatibility.
 // Returns an empty string on success, or a reason string on failure.
-func (n *Node) validateHandshake(msg HandshakeMessage) string {
+// penalize is false for failures an honest peer can cause, such as a
+// drifting clock, which only warrant a disconnect.
+func (n *Node) validateHandshake(msg HandshakeMessage) (reason string, penalize bool) {
 	if msg.GenesisHash != n.genesisHash {
 		return fmt.Sprintf("genesis mismatch: peer=%s local=%s",
-			msg.GenesisHash.String()[:16], n.genesisHash.String()[:16])
+			msg.GenesisHash.String()[:16], n.genesisHash.String()[:16]), true
 	}
 	if msg.ProtocolVersion < MinProtocolVersion {
 		return fmt.Sprintf("protocol version too low: peer=%d min=%d",
-			msg.ProtocolVersion, MinProtocolVersion)
+			msg.ProtocolVersion, MinProtocolVersion), true
+	}
+	if msg.ProtocolVersion < SignedHandshakeVersion {
+		return "", false
+	}
+	if skew := time.Since(time.Unix(msg.Timestamp, 0)); skew > maxHandshakeClockSkew || skew < -maxHandshakeClockSkew {
+		return fmt.Sprintf("handshake timestamp off by %s", skew.Round(time.Second)), false
+	}
+	if len(msg.UserAgent) > maxUserAgentLen {
+		return fmt.Sprintf("user agent too long: %d bytes", len(msg.UserAgent)), false
+	}
+	if len(msg.SubChains) > maxHandshakeSubChains {
+		return fmt.Sprintf("too many sub-chains: %d", len(msg.SubChains)), true
+	}
+	for _, id := range msg.SubChains {
+		if b, err := hex.DecodeString(id); err != nil || len(b) != len(types.ChainID{}) {
+			return fmt.Sprintf("invalid sub-chain ID %q", id), true
+		}
+	}
+	return "", false
+}
+
+// checkHandshake validates a peer's handshake and, from
+// SignedHandshakeVersion on, its signature by the connection's key.
+func (n *Node) checkHandshake(msg HandshakeMessage, pub libp2pcrypto.PubKey) (reason string, penalize bool) {
+	if reason, penalize := n.validateHandshake(msg); reason != "" {
+		return reason, penalize
+	}
+	if msg.ProtocolVersion >= SignedHandshakeVersion && !msg.verify(pub) {
+		return "bad handshake signature", true
+	}
+	return "", false
+}
+
+// rejectHandshake disconnects a peer whose handshake failed, banning it
+// first if the failure warrants a penalty.
+func (n *Node) rejectHandshake(id peer.ID, reason string, penalize bool) {
+	logger := klog.WithComponent("p2p")
+	logger.Warn().
+		Str("peer", id.String()[:16]).
+		Str("reason", reason).
+		Msg("Handshake rejected")
+	if penalize && n.BanManager != nil && !n.isExemptPeer(id) {
+		n.BanManager.RecordOffense(id, PenaltyHandshakeFail, reason)
+	}
+	n.DisconnectPeer(id)
+}
+
+// recordHandshake stores what a peer advertised. Capabilities of peers
+// older than SignedHandshakeVersion stay unknown.
+func (n *Node) recordHandshake(id peer.ID, msg HandshakeMessage) {
+	info := &PeerInfo{ProtocolVersion: msg.ProtocolVersion, BestHeight: msg.BestHeight}
+	if msg.ProtocolVersion >= SignedHandshakeVersion {
+		info.UserAgent = msg.UserAgent
+		info.Capabilities = msg.Capabilities
+		info.SubChains = slices.Clone(msg.SubChains)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if p, ok := n.peers[id]; ok {
+		p.Info = info
 	}
-	return ""
 }
 
 // buildHandshakeMessage constructs our handshake message from node state.
@@ -136,9 +303,99 @@ func (n *Node) buildHandshakeMessage() HandshakeMessage {
 		ProtocolVersion: ProtocolVersion,
 		GenesisHash:     n.genesisHash,
 		NetworkID:       n.config.NetworkID,
+		UserAgent:       n.userAgent(),
+		Timestamp:       time.Now().Unix(),
 	}
 	if n.heightFn != nil {
 		msg.BestHeight = n.heightFn()
 	}
+	msg.Capabilities, msg.SubChains = n.localCapabilities()
+	if n.host != nil {
+		if key := n.host.Peerstore().PrivKey(n.host.ID()); key != nil {
+			msg.sign(key) // Unsigned on failure; the peer then rejects us.
+		}
+	}
 	return msg
 }
+
+// userAgent identifies this software in handshakes.
+func (n *Node) userAgent() string {
+	return "klingnetd/" + Version
+}
+
+// SetCapability sets or clears a capability advertised in handshakes.
+func (n *Node) SetCapability(c Capability, on bool) {
+	n.capMu.Lock()
+	old := n.capabilities
+	if on {
+		n.capabilities |= c
+	} else {
+		n.capabilities &^= c
+	}
+	changed := n.capabilities != old
+	n.capMu.Unlock()
+	if changed {
+		n.capabilitiesChanged()
+	}
+}
+
+// addSubChain advertises a spawned sub-chain in handshakes.
+func (n *Node) addSubChain(chainIDHex string) {
+	n.capMu.Lock()
+	if n.subChains == nil {
+		n.subChains = make(map[string]bool)
+	}
+	changed := !n.subChains[chainIDHex]
+	n.subChains[chainIDHex] = true
+	n.capMu.Unlock()
+	if changed {
+		n.capabilitiesChanged()
+	}
+}
+
+// removeSubChain stops advertising a sub-chain.
+func (n *Node) removeSubChain(chainIDHex string) {
+	n.capMu.Lock()
+	changed := n.subChains[chainIDHex]
+	delete(n.subChains, chainIDHex)
+	n.capMu.Unlock()
+	if changed {
+		n.capabilitiesChanged()
+	}
+}
+
+// localCapabilities returns our capabilities and sorted sub-chain IDs.
+func (n *Node) localCapabilities() (Capability, []string) {
+	n.capMu.Lock()
+	defer n.capMu.Unlock()
+	caps := n.capabilities
+	if n.config.RelayService {
+		caps |= CapRelay
+	}
+	var subChains []string
+	for id := range n.subChains {
+		subChains = append(subChains, id)
+	}
+	slices.Sort(subChains)
+	if len(subChains) > maxHandshakeSubChains {
+		subChains = subChains[:maxHandshakeSubChains]
+	}
+	return caps, subChains
+}
+
+// capabilitiesChanged schedules a fresh handshake with every connected
+// peer, so they learn about new capabilities and sub-chains.
+func (n *Node) capabilitiesChanged() {
+	if !n.handshakeEnabled || n.host == nil || !n.announcePending.CompareAndSwap(false, true) {
+		return
+	}
+	time.AfterFunc(capabilityAnnounceDelay, func() {
+		n.announcePending.Store(false)
+		if n.ctx.Err() != nil {
+			return
+		}
+		for _, id := range n.host.Network().Peers() {
+			go n.doHandshake(id)
+		}
+	})
+}
@@ -1,11 +1,15 @@
 package p2p
 
 import (
+	"crypto/rand"
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
 	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+	libp2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/peer"
 )
 
@@ -50,9 +54,10 @@ func TestNode_ValidateHandshake_Success(t *testing.T) {
 		GenesisHash:     types.Hash{0x01, 0x02, 0x03},
 		NetworkID:       "test",
 		BestHeight:      100,
+		Timestamp:       time.Now().Unix(),
 	}
 
-	reason := n.validateHandshake(msg)
+	reason, _ := n.validateHandshake(msg)
 	if reason != "" {
 		t.Errorf("expected success, got reason: %s", reason)
 	}
@@ -68,7 +73,7 @@ func TestNode_ValidateHandshake_GenesisMismatch(t *testing.T) {
 		NetworkID:       "test",
 	}
 
-	reason := n.validateHandshake(msg)
+	reason, _ := n.validateHandshake(msg)
 	if reason == "" {
 		t.Error("expected genesis mismatch reason, got empty")
 	}
@@ -84,7 +89,7 @@ func TestNode_ValidateHandshake_VersionTooLow(t *testing.T) {
 		NetworkID:       "test",
 	}
 
-	reason := n.validateHandshake(msg)
+	reason, _ := n.validateHandshake(msg)
 	if reason == "" {
 		t.Error("expected version too low reason, got empty")
 	}
@@ -100,7 +105,7 @@ func TestNode_ValidateHandshake_V1Rejected(t *testing.T) {
 		NetworkID:       "test",
 	}
 
-	reason := n.validateHandshake(msg)
+	reason, _ := n.validateHandshake(msg)
 	if reason == "" {
 		t.Error("expected v1 peer to be rejected, got empty reason")
 	}
@@ -252,3 +257,181 @@ func TestTwoNodes_Handshake_GenesisMismatch(t *testing.T) {
 			nodeA.PeerCount(), nodeB.PeerCount())
 	}
 }
+
+func TestCapability_Names(t *testing.T) {
+	caps := CapHistory | CapValidator
+	if !caps.Has(CapHistory) || caps.Has(CapLight) || caps.Has(CapHistory|CapLight) {
+		t.Errorf("Has wrong for %d", caps)
+	}
+	if got := caps.String(); got != "history,validator" {
+		t.Errorf("String: got %q", got)
+	}
+}
+
+func TestHandshakeMessage_Signature(t *testing.T) {
+	key, pub, err := libp2pcrypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPub, _ := libp2pcrypto.GenerateEd25519Key(rand.Reader)
+
+	n := New(Config{ListenAddr: "127.0.0.1", Port: 0})
+	n.genesisHash = types.Hash{0x01}
+	msg := HandshakeMessage{
+		ProtocolVersion: ProtocolVersion,
+		GenesisHash:     types.Hash{0x01},
+		UserAgent:       "klingnetd/test",
+		Capabilities:    CapHistory,
+		SubChains:       []string{strings.Repeat("ab", 32)},
+		Timestamp:       time.Now().Unix(),
+	}
+	if reason, _ := n.checkHandshake(msg, pub); reason == "" {
+		t.Error("unsigned v4 handshake accepted")
+	}
+	if err := msg.sign(key); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if reason, _ := n.checkHandshake(msg, pub); reason != "" {
+		t.Errorf("signed handshake rejected: %s", reason)
+	}
+	if reason, penalize := n.checkHandshake(msg, otherPub); reason == "" || !penalize {
+		t.Errorf("handshake with another peer's key: reason %q, penalize %v", reason, penalize)
+	}
+
+	// Any change to a signed field invalidates the signature.
+	msg.Capabilities |= CapValidator
+	if reason, _ := n.checkHandshake(msg, pub); reason == "" {
+		t.Error("tampered handshake accepted")
+	}
+
+	// Older peers cannot sign; their handshakes are not checked.
+	legacy := HandshakeMessage{ProtocolVersion: SignedHandshakeVersion - 1, GenesisHash: types.Hash{0x01}}
+	if reason, _ := n.checkHandshake(legacy, pub); reason != "" {
+		t.Errorf("legacy handshake rejected: %s", reason)
+	}
+}
+
+func TestNode_ValidateHandshake_Fields(t *testing.T) {
+	n := New(Config{ListenAddr: "127.0.0.1", Port: 0})
+	n.genesisHash = types.Hash{0x01}
+	valid := func() HandshakeMessage {
+		return HandshakeMessage{
+			ProtocolVersion: ProtocolVersion,
+			GenesisHash:     types.Hash{0x01},
+			Timestamp:       time.Now().Unix(),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(*HandshakeMessage)
+		penalize bool
+	}{
+		{"stale timestamp", func(m *HandshakeMessage) { m.Timestamp -= int64(2 * maxHandshakeClockSkew / time.Second) }, false},
+		{"future timestamp", func(m *HandshakeMessage) { m.Timestamp += int64(2 * maxHandshakeClockSkew / time.Second) }, false},
+		{"long user agent", func(m *HandshakeMessage) { m.UserAgent = strings.Repeat("x", maxUserAgentLen+1) }, false},
+		{"bad sub-chain", func(m *HandshakeMessage) { m.SubChains = []string{"not-hex"} }, true},
+		{"short sub-chain", func(m *HandshakeMessage) { m.SubChains = []string{"abcd"} }, true},
+		{"too many sub-chains", func(m *HandshakeMessage) {
+			m.SubChains = make([]string, maxHandshakeSubChains+1)
+			for i := range m.SubChains {
+				m.SubChains[i] = strings.Repeat("ab", 32)
+			}
+		}, true},
+	}
+	for _, tt := range tests {
+		msg := valid()
+		tt.modify(&msg)
+		reason, penalize := n.validateHandshake(msg)
+		if reason == "" {
+			t.Errorf("%s: expected rejection", tt.name)
+		}
+		if penalize != tt.penalize {
+			t.Errorf("%s: penalize = %v, want %v", tt.name, penalize, tt.penalize)
+		}
+	}
+}
+
+func TestNode_RejectHandshake_Penalty(t *testing.T) {
+	n := startTestNode(t)
+
+	skewed := generateTestPeerID(t)
+	n.rejectHandshake(skewed, "handshake timestamp off by 10m0s", false)
+	if n.BanManager.IsBanned(skewed) {
+		t.Error("peer banned for clock skew")
+	}
+
+	forged := generateTestPeerID(t)
+	n.rejectHandshake(forged, "bad handshake signature", true)
+	if !n.BanManager.IsBanned(forged) {
+		t.Error("peer with a bad signature not banned")
+	}
+}
+
+func TestTwoNodes_Handshake_Capabilities(t *testing.T) {
+	genesis := types.Hash{0x01, 0x02, 0x03}
+	chainA := strings.Repeat("aa", 32)
+	chainB := strings.Repeat("bb", 32)
+
+	nodeA := New(Config{ListenAddr: "127.0.0.1", Port: 0, NoDiscover: true, NetworkID: "test"})
+	nodeA.SetGenesisHash(genesis)
+	if err := nodeA.Start(); err != nil {
+		t.Fatalf("start nodeA: %v", err)
+	}
+	t.Cleanup(func() { nodeA.Stop() })
+
+	nodeB := New(Config{ListenAddr: "127.0.0.1", Port: 0, NoDiscover: true, NetworkID: "test"})
+	nodeB.SetGenesisHash(genesis)
+	nodeB.SetHeightFn(func() uint64 { return 7 })
+	if err := nodeB.Start(); err != nil {
+		t.Fatalf("start nodeB: %v", err)
+	}
+	t.Cleanup(func() { nodeB.Stop() })
+	syncerB := NewSyncer(nodeB)
+	syncerB.RegisterHandler(func(uint64, uint32) []*block.Block { return nil })
+	syncerB.RegisterSubChainHandler(chainA, func(uint64, uint32) []*block.Block { return nil })
+
+	connectNodes(t, nodeA, nodeB)
+
+	infoOf := func() *PeerInfo {
+		for _, p := range nodeA.PeerList() {
+			if p.ID == nodeB.ID() {
+				return p.Info
+			}
+		}
+		return nil
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for infoOf() == nil && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	info := infoOf()
+	if info == nil {
+		t.Fatal("nodeA has no handshake info for nodeB")
+	}
+	if info.ProtocolVersion != ProtocolVersion || info.BestHeight != 7 {
+		t.Errorf("info: version=%d height=%d", info.ProtocolVersion, info.BestHeight)
+	}
+	if !strings.HasPrefix(info.UserAgent, "klingnetd/") {
+		t.Errorf("UserAgent: got %q", info.UserAgent)
+	}
+	if !info.Capabilities.Has(CapHistory) {
+		t.Errorf("Capabilities: got %s, want history", info.Capabilities)
+	}
+	if len(nodeA.PeersWithSubChain(chainA)) != 1 {
+		t.Error("nodeB should be listed for the sub-chain it serves")
+	}
+	if len(nodeA.PeersWithSubChain(chainB)) != 0 {
+		t.Error("nodeB should not be listed for a sub-chain it does not serve")
+	}
+
+	// Spawning a sub-chain later is announced with a fresh handshake.
+	syncerB.RegisterSubChainHandler(chainB, func(uint64, uint32) []*block.Block { return nil })
+	deadline = time.Now().Add(5 * time.Second)
+	for len(nodeA.PeersWithSubChain(chainB)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if len(nodeA.PeersWithSubChain(chainB)) != 1 {
+		t.Error("new sub-chain was not announced")
+	}
+}
@@ -51,13 +51,18 @@ func (n *Node) JoinHeartbeat() error {
 		return nil // Already joined.
 	}
 
+	if err := n.pubsub.RegisterTopicValidator(TopicHeartbeat, n.validateHeartbeat); err != nil {
+		return fmt.Errorf("register heartbeat validator: %w", err)
+	}
 	topic, err := n.pubsub.Join(TopicHeartbeat)
 	if err != nil {
+		n.pubsub.UnregisterTopicValidator(TopicHeartbeat)
 		return fmt.Errorf("join heartbeat topic: %w", err)
 	}
 	sub, err := topic.Subscribe()
 	if err != nil {
 		topic.Close()
+		n.pubsub.UnregisterTopicValidator(TopicHeartbeat)
 		return fmt.Errorf("subscribe heartbeat topic: %w", err)
 	}
 	n.topicHeartbeat = topic
@@ -75,6 +80,7 @@ func (n *Node) LeaveHeartbeat() {
 	}
 	if n.topicHeartbeat != nil {
 		n.topicHeartbeat.Close()
+		n.pubsub.UnregisterTopicValidator(TopicHeartbeat)
 		n.topicHeartbeat = nil
 	}
 }
@@ -101,20 +107,16 @@ func (n *Node) heartbeatReadLoop() {
 			continue // Skip own messages.
 		}
 
-		var hb HeartbeatMessage
-		if err := json.Unmarshal(msg.Data, &hb); err != nil {
-			continue // Malformed message.
-		}
-
-		// Verify signature before forwarding.
-		if !VerifyHeartbeat(&hb) {
-			continue // Invalid signature.
+		// Decoded and signature-checked by validateHeartbeat.
+		hb, ok := msg.ValidatorData.(*HeartbeatMessage)
+		if !ok {
+			continue
 		}
 
 		if n.heartbeatHandler != nil {
 			func() {
 				defer func() { recover() }()
-				n.heartbeatHandler(&hb)
+				n.heartbeatHandler(hb)
 			}()
 		}
 	}
@@ -142,13 +144,24 @@ func (n *Node) JoinSubChainHeartbeat(chainIDHex string) error {
 		return nil // Already joined.
 	}
 
-	topic, err := n.pubsub.Join(SubChainHeartbeatTopic(chainIDHex))
+	topicName := SubChainHeartbeatTopic(chainIDHex)
+	if err := n.pubsub.RegisterTopicValidator(topicName, n.validateHeartbeat); err != nil {
+		return fmt.Errorf("register sub-chain heartbeat validator %s: %w", chainIDHex, err)
+	}
+	topic, err := n.pubsub.Join(topicName)
 	if err != nil {
+		n.pubsub.UnregisterTopicValidator(topicName)
 		return fmt.Errorf("join sub-chain heartbeat topic %s: %w", chainIDHex, err)
 	}
+	if err := topic.SetScoreParams(heartbeatTopicScore()); err != nil {
+		topic.Close()
+		n.pubsub.UnregisterTopicValidator(topicName)
+		return fmt.Errorf("score sub-chain heartbeat topic %s: %w", chainIDHex, err)
+	}
 	sub, err := topic.Subscribe()
 	if err != nil {
 		topic.Close()
+		n.pubsub.UnregisterTopicValidator(topicName)
 		return fmt.Errorf("subscribe sub-chain heartbeat topic %s: %w", chainIDHex, err)
 	}
 
@@ -170,6 +183,7 @@ func (n *Node) LeaveSubChainHeartbeat(chainIDHex string) {
 	}
 	if t, ok := n.scHBTopics[chainIDHex]; ok {
 		t.Close()
+		n.pubsub.UnregisterTopicValidator(SubChainHeartbeatTopic(chainIDHex))
 		delete(n.scHBTopics, chainIDHex)
 	}
 	delete(n.scHBHandlers, chainIDHex)
@@ -201,12 +215,8 @@ func (n *Node) scHeartbeatReadLoop(chainIDHex string, sub *pubsub.Subscription)
 			continue
 		}
 
-		var hb HeartbeatMessage
-		if err := json.Unmarshal(msg.Data, &hb); err != nil {
-			continue
-		}
-
-		if !VerifyHeartbeat(&hb) {
+		hb, ok := msg.ValidatorData.(*HeartbeatMessage)
+		if !ok {
 			continue
 		}
 
@@ -217,7 +227,7 @@ func (n *Node) scHeartbeatReadLoop(chainIDHex string, sub *pubsub.Subscription)
 		if handler != nil {
 			func() {
 				defer func() { recover() }()
-				handler(&hb)
+				handler(hb)
 			}()
 		}
 	}
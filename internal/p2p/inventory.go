@@ -0,0 +1,566 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	klog "github.com/Klingon-tech/klingnet-chain/internal/log"
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// Inventory relay on the root chain: transactions are gossiped as batches
+// of hashes and fetched on demand, blocks as compact blocks.
+const (
+	// TxFetchProtocol fetches announced transactions by hash.
+	TxFetchProtocol = protocol.ID("/klingnet/gettx/1.0.0")
+
+	// BlockTxnProtocol fetches the transactions of a compact block that
+	// the receiver could not rebuild from its mempool.
+	BlockTxnProtocol = protocol.ID("/klingnet/blocktxn/1.0.0")
+
+	maxInvHashes       = 1000                   // Hashes per announcement or fetch.
+	txAnnounceInterval = 100 * time.Millisecond // Announcement batching window.
+	relayCacheSize     = 5000                   // Announced transactions kept to serve fetches.
+	recentBlocksSize   = 8                      // Relayed blocks kept to serve BlockTxn requests.
+	invFetchTimeout    = 10 * time.Second       // Per fetch round trip.
+)
+
+// TxSource gives the inventory relay read access to the local mempool.
+type TxSource interface {
+	// Has reports whether the transaction is known and need not be fetched.
+	Has(txHash types.Hash) bool
+	// Get returns a mempool transaction, or nil.
+	Get(txHash types.Hash) *tx.Transaction
+	// Transactions returns every mempool transaction.
+	Transactions() []*tx.Transaction
+}
+
+// TxAnnouncement is gossiped on TopicTransactions.
+type TxAnnouncement struct {
+	Hashes []types.Hash `json:"hashes"`
+}
+
+// TxFetchRequest asks a peer for announced transactions.
+type TxFetchRequest struct {
+	Hashes []types.Hash `json:"hashes"`
+}
+
+// TxFetchResponse carries the requested transactions the peer still has.
+type TxFetchResponse struct {
+	Transactions []json.RawMessage `json:"transactions"`
+}
+
+// BlockTxnRequest asks a peer for transactions of a compact block by index.
+type BlockTxnRequest struct {
+	BlockHash types.Hash `json:"block_hash"`
+	Indexes   []int      `json:"indexes"`
+}
+
+// BlockTxnResponse carries the requested transactions in request order.
+type BlockTxnResponse struct {
+	Transactions []*tx.Transaction `json:"transactions"`
+}
+
+// relayedTx is an announced transaction and its encoding.
+type relayedTx struct {
+	tx   *tx.Transaction
+	data json.RawMessage
+}
+
+// inventory tracks what this node announced, requested and relayed.
+type inventory struct {
+	mu         sync.Mutex
+	source     TxSource
+	pending    []types.Hash // Hashes for the next announcement.
+	relay      map[types.Hash]relayedTx
+	relayOrder []types.Hash
+	requested  map[types.Hash]time.Time // Fetches in flight, by start time.
+	blocks     map[types.Hash]*block.Block
+	blockOrder []types.Hash
+	fetching   map[types.Hash]struct{} // Compact blocks being rebuilt from peers.
+	stems      map[types.Hash]struct{} // Stem-phase txs not yet announced by a peer.
+}
+
+func newInventory() *inventory {
+	return &inventory{
+		relay:     make(map[types.Hash]relayedTx),
+		requested: make(map[types.Hash]time.Time),
+		blocks:    make(map[types.Hash]*block.Block),
+		fetching:  make(map[types.Hash]struct{}),
+		stems:     make(map[types.Hash]struct{}),
+	}
+}
+
+// announce caches t for fetches and queues its hash. Returns the number of
+// queued hashes.
+func (inv *inventory) announce(t *tx.Transaction, data []byte) int {
+	h := t.Hash()
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if _, ok := inv.relay[h]; !ok {
+		inv.relay[h] = relayedTx{tx: t, data: data}
+		inv.relayOrder = append(inv.relayOrder, h)
+		if len(inv.relayOrder) > relayCacheSize {
+			delete(inv.relay, inv.relayOrder[0])
+			inv.relayOrder = inv.relayOrder[1:]
+		}
+	}
+	inv.pending = append(inv.pending, h)
+	return len(inv.pending)
+}
+
+// takePending returns and clears the queued hashes.
+func (inv *inventory) takePending() []types.Hash {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	hashes := inv.pending
+	inv.pending = nil
+	return hashes
+}
+
+// want filters announced hashes down to those worth fetching and marks
+// them requested. Announced stem transactions have reached the fluff phase.
+func (inv *inventory) want(hashes []types.Hash, now time.Time) []types.Hash {
+	inv.mu.Lock()
+	src := inv.source
+	for _, h := range hashes {
+		delete(inv.stems, h)
+	}
+	for h, at := range inv.requested {
+		if now.Sub(at) > 2*invFetchTimeout {
+			delete(inv.requested, h)
+		}
+	}
+	var candidates []types.Hash
+	for _, h := range hashes {
+		if _, ok := inv.relay[h]; ok {
+			continue
+		}
+		if _, ok := inv.requested[h]; ok {
+			continue
+		}
+		inv.requested[h] = now // Also dedupes within the batch.
+		candidates = append(candidates, h)
+	}
+	inv.mu.Unlock()
+
+	// Check the source outside the lock: it may take mempool locks.
+	var wanted []types.Hash
+	for _, h := range candidates {
+		if src != nil && src.Has(h) {
+			inv.done([]types.Hash{h})
+			continue
+		}
+		wanted = append(wanted, h)
+	}
+	return wanted
+}
+
+// done clears finished fetches.
+func (inv *inventory) done(hashes []types.Hash) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	for _, h := range hashes {
+		delete(inv.requested, h)
+	}
+}
+
+// lookup returns an announced or mempool transaction's encoding.
+func (inv *inventory) lookup(h types.Hash) (json.RawMessage, bool) {
+	inv.mu.Lock()
+	r, ok := inv.relay[h]
+	src := inv.source
+	inv.mu.Unlock()
+	if ok {
+		return r.data, true
+	}
+	if src == nil {
+		return nil, false
+	}
+	t := src.Get(h)
+	if t == nil {
+		return nil, false
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// candidates returns the transactions a compact block may reference.
+func (inv *inventory) candidates() []*tx.Transaction {
+	inv.mu.Lock()
+	txs := make([]*tx.Transaction, 0, len(inv.relay))
+	for _, r := range inv.relay {
+		txs = append(txs, r.tx)
+	}
+	src := inv.source
+	inv.mu.Unlock()
+	if src != nil {
+		txs = append(txs, src.Transactions()...)
+	}
+	return txs
+}
+
+// startBlockFetch marks a compact block as being rebuilt. It returns false
+// if a fetch for it is already running.
+func (inv *inventory) startBlockFetch(h types.Hash) bool {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if _, ok := inv.fetching[h]; ok {
+		return false
+	}
+	inv.fetching[h] = struct{}{}
+	return true
+}
+
+// blockFetchDone clears a finished compact block fetch.
+func (inv *inventory) blockFetchDone(h types.Hash) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	delete(inv.fetching, h)
+}
+
+// addBlock keeps a relayed block to serve BlockTxn requests.
+func (inv *inventory) addBlock(b *block.Block) {
+	h := b.Hash()
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if _, ok := inv.blocks[h]; ok {
+		return
+	}
+	inv.blocks[h] = b
+	inv.blockOrder = append(inv.blockOrder, h)
+	if len(inv.blockOrder) > recentBlocksSize {
+		delete(inv.blocks, inv.blockOrder[0])
+		inv.blockOrder = inv.blockOrder[1:]
+	}
+}
+
+// block returns a recently relayed block, or nil.
+func (inv *inventory) block(h types.Hash) *block.Block {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	return inv.blocks[h]
+}
+
+// SetTxSource sets the mempool used to skip known announcements, serve
+// fetches and rebuild compact blocks.
+func (n *Node) SetTxSource(src TxSource) {
+	n.inv.mu.Lock()
+	defer n.inv.mu.Unlock()
+	n.inv.source = src
+}
+
+func (n *Node) registerInventoryHandlers() {
+	n.host.SetStreamHandler(TxFetchProtocol, n.handleTxFetch)
+	n.host.SetStreamHandler(BlockTxnProtocol, n.handleBlockTxn)
+	n.host.SetStreamHandler(StemProtocol, n.handleStem)
+}
+
+// runAnnouncer publishes queued transaction hashes in batches.
+func (n *Node) runAnnouncer() {
+	ticker := time.NewTicker(txAnnounceInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-ticker.C:
+			n.flushAnnouncements()
+		}
+	}
+}
+
+func (n *Node) flushAnnouncements() {
+	hashes := n.inv.takePending()
+	for len(hashes) > 0 {
+		batch := hashes[:min(len(hashes), maxInvHashes)]
+		hashes = hashes[len(batch):]
+		data, err := json.Marshal(&TxAnnouncement{Hashes: batch})
+		if err != nil {
+			return
+		}
+		if err := n.topicTx.Publish(n.ctx, data); err != nil {
+			logger := klog.WithComponent("p2p")
+			logger.Debug().Err(err).Msg("Failed to publish tx announcement")
+			return
+		}
+	}
+}
+
+// validateTxAnnouncement is the GossipSub validator for TopicTransactions.
+// It fetches the unknown announced transactions from the relaying peer and
+// ignores the announcement itself: each node announces only transactions
+// it has accepted, so announcements travel one hop.
+func (n *Node) validateTxAnnouncement(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	if n.host != nil && from == n.host.ID() {
+		return pubsub.ValidationAccept
+	}
+	if res := n.validateTx(ctx, from, msg); res != pubsub.ValidationAccept {
+		return res
+	}
+	var ann TxAnnouncement
+	if err := json.Unmarshal(msg.Data, &ann); err != nil || len(ann.Hashes) > maxInvHashes {
+		if n.BanManager != nil {
+			n.BanManager.RecordOffense(from, PenaltyMalformedTx, "malformed tx announcement")
+		}
+		return pubsub.ValidationReject
+	}
+	if wanted := n.inv.want(ann.Hashes, time.Now()); len(wanted) > 0 {
+		go n.fetchTxs(from, wanted)
+	}
+	return pubsub.ValidationIgnore
+}
+
+// fetchTxs fetches announced transactions from a peer and hands them to
+// the transaction handler.
+func (n *Node) fetchTxs(from peer.ID, hashes []types.Hash) {
+	defer n.inv.done(hashes)
+
+	ctx, cancel := context.WithTimeout(n.ctx, invFetchTimeout)
+	defer cancel()
+	txs, err := n.requestTxs(ctx, from, hashes)
+	if err != nil {
+		logger := klog.WithComponent("p2p")
+		logger.Debug().Err(err).Str("peer", from.String()).Msg("Failed to fetch announced transactions")
+		return
+	}
+
+	wanted := make(map[types.Hash]bool, len(hashes))
+	for _, h := range hashes {
+		wanted[h] = true
+	}
+	for _, data := range txs {
+		var t tx.Transaction
+		if err := json.Unmarshal(data, &t); err != nil {
+			if n.BanManager != nil {
+				n.BanManager.RecordOffense(from, PenaltyMalformedTx, "malformed fetched tx")
+			}
+			return
+		}
+		h := t.Hash()
+		if !wanted[h] {
+			continue // Not requested.
+		}
+		delete(wanted, h)
+		n.deliverTx(from, data)
+	}
+}
+
+func (n *Node) deliverTx(from peer.ID, data []byte) {
+	defer func() { recover() }()
+	n.addPeer(from)
+	if n.txHandler != nil {
+		n.txHandler(from, data)
+	}
+}
+
+func (n *Node) requestTxs(ctx context.Context, pid peer.ID, hashes []types.Hash) ([]json.RawMessage, error) {
+	var resp TxFetchResponse
+	if err := n.request(ctx, pid, TxFetchProtocol, &TxFetchRequest{Hashes: hashes}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Transactions) > len(hashes) {
+		return nil, fmt.Errorf("peer sent %d transactions for %d hashes", len(resp.Transactions), len(hashes))
+	}
+	return resp.Transactions, nil
+}
+
+func (n *Node) handleTxFetch(stream network.Stream) {
+	defer stream.Close()
+	_ = stream.SetReadDeadline(time.Now().Add(invFetchTimeout))
+
+	var req TxFetchRequest
+	if err := json.NewDecoder(io.LimitReader(stream, maxSyncResponseBytes)).Decode(&req); err != nil {
+		return
+	}
+	if len(req.Hashes) > maxInvHashes {
+		req.Hashes = req.Hashes[:maxInvHashes]
+	}
+	resp := TxFetchResponse{Transactions: []json.RawMessage{}}
+	for _, h := range req.Hashes {
+		if data, ok := n.inv.lookup(h); ok {
+			resp.Transactions = append(resp.Transactions, data)
+		}
+	}
+	json.NewEncoder(stream).Encode(&resp)
+}
+
+// validateBlock is the GossipSub validator for TopicBlocks. It checks the
+// header with the block verifier and accepts compact blocks it can rebuild
+// from known transactions, so only blocks this node can serve are relayed
+// further. Blocks with missing transactions are ignored and fetched in the
+// background by fetchCompactBlock, keeping round trips out of the
+// validator.
+func (n *Node) validateBlock(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	if n.host != nil && from == n.host.ID() {
+		return pubsub.ValidationAccept
+	}
+	if n.BanManager != nil && n.BanManager.IsBanned(from) {
+		return pubsub.ValidationReject
+	}
+	var cb CompactBlock
+	if err := json.Unmarshal(msg.Data, &cb); err != nil {
+		return n.rejectMessage(from, PenaltyInvalidBlock, "malformed compact block")
+	}
+	if err := cb.check(); err != nil {
+		return n.rejectMessage(from, PenaltyInvalidBlock, "compact block: "+err.Error())
+	}
+	hash := cb.Header.Hash()
+	if n.inv.block(hash) != nil {
+		return pubsub.ValidationAccept
+	}
+	// Check the header before fetching any transactions for it.
+	if n.blockVerifier != nil {
+		if err := n.blockVerifier(cb.Header); err != nil {
+			return n.rejectMessage(from, PenaltyInvalidBlock, "block header: "+err.Error())
+		}
+	}
+
+	txs, missing := cb.reconstruct(n.inv.candidates())
+	if len(missing) == 0 && merkleMatches(cb.Header, txs) {
+		n.inv.addBlock(block.NewBlock(cb.Header, txs))
+		return pubsub.ValidationAccept
+	}
+	if n.inv.startBlockFetch(hash) {
+		go n.fetchCompactBlock(&cb, []peer.ID{from, msg.GetFrom()}, msg.Data)
+	}
+	return pubsub.ValidationIgnore
+}
+
+// fetchCompactBlock rebuilds a compact block the validator could not,
+// fetching its transactions from peers. The block goes to the block
+// handler and, if it matches its merkle root, is published again since
+// the original message was ignored.
+func (n *Node) fetchCompactBlock(cb *CompactBlock, peers []peer.ID, data []byte) {
+	hash := cb.Header.Hash()
+	defer n.inv.blockFetchDone(hash)
+
+	ctx, cancel := context.WithTimeout(n.ctx, invFetchTimeout)
+	defer cancel()
+	blk, err := n.reconstructBlock(ctx, cb, peers)
+	if err != nil {
+		logger := klog.WithComponent("p2p")
+		logger.Debug().Err(err).Uint64("height", cb.Header.Height).Msg("Failed to rebuild compact block")
+		return
+	}
+	n.inv.addBlock(blk)
+	n.deliverBlock(peers[0], blk)
+	if merkleMatches(blk.Header, blk.Transactions) && n.topicBlock != nil {
+		if err := n.topicBlock.Publish(n.ctx, data); err != nil {
+			logger := klog.WithComponent("p2p")
+			logger.Debug().Err(err).Str("block", hash.String()).Msg("Failed to relay rebuilt block")
+		}
+	}
+}
+
+// reconstructBlock rebuilds a compact block from known transactions,
+// fetching the rest from peers. If the merkle root does not match, a short
+// ID matched the wrong transaction and every transaction is fetched.
+func (n *Node) reconstructBlock(ctx context.Context, cb *CompactBlock, peers []peer.ID) (*block.Block, error) {
+	txs, missing := cb.reconstruct(n.inv.candidates())
+	if len(missing) > 0 {
+		if err := n.fillBlockTxns(ctx, peers, cb, txs, missing); err != nil {
+			return nil, err
+		}
+	}
+	if !merkleMatches(cb.Header, txs) && len(missing) < len(cb.ShortIDs) {
+		var all []int
+		for i := range txs {
+			if _, ok := cb.shortID(i); ok {
+				all = append(all, i)
+			}
+		}
+		if err := n.fillBlockTxns(ctx, peers, cb, txs, all); err != nil {
+			return nil, err
+		}
+	}
+	// A root mismatch after a full fetch is the sender's block; chain
+	// validation rejects it.
+	return block.NewBlock(cb.Header, txs), nil
+}
+
+// fillBlockTxns fetches the transactions at indexes from the first peer
+// that serves all of them. A response with an empty entry or a
+// transaction that does not match its short ID is discarded and the next
+// peer is tried.
+func (n *Node) fillBlockTxns(ctx context.Context, peers []peer.ID, cb *CompactBlock, txs []*tx.Transaction, indexes []int) error {
+	blockHash := cb.Header.Hash()
+	tried := make(map[peer.ID]bool, len(peers))
+	for _, pid := range peers {
+		if pid == "" || tried[pid] || (n.host != nil && pid == n.host.ID()) {
+			continue
+		}
+		tried[pid] = true
+
+		var resp BlockTxnResponse
+		req := &BlockTxnRequest{BlockHash: blockHash, Indexes: indexes}
+		if err := n.request(ctx, pid, BlockTxnProtocol, req, &resp); err != nil {
+			continue
+		}
+		if !cb.matches(indexes, resp.Transactions) {
+			continue
+		}
+		for i, idx := range indexes {
+			txs[idx] = resp.Transactions[i]
+		}
+		return nil
+	}
+	return fmt.Errorf("no peer served %d transactions of block %s", len(indexes), blockHash)
+}
+
+func (n *Node) handleBlockTxn(stream network.Stream) {
+	defer stream.Close()
+	_ = stream.SetReadDeadline(time.Now().Add(invFetchTimeout))
+
+	var req BlockTxnRequest
+	if err := json.NewDecoder(io.LimitReader(stream, maxSyncResponseBytes)).Decode(&req); err != nil {
+		return
+	}
+	resp := BlockTxnResponse{Transactions: []*tx.Transaction{}}
+	if b := n.inv.block(req.BlockHash); b != nil {
+		for _, idx := range req.Indexes {
+			if idx < 0 || idx >= len(b.Transactions) {
+				resp.Transactions = []*tx.Transaction{}
+				break
+			}
+			resp.Transactions = append(resp.Transactions, b.Transactions[idx])
+		}
+	}
+	json.NewEncoder(stream).Encode(&resp)
+}
+
+// request sends req over a new stream and decodes the reply into resp.
+func (n *Node) request(ctx context.Context, pid peer.ID, proto protocol.ID, req, resp any) error {
+	stream, err := n.host.NewStream(ctx, pid, proto)
+	if err != nil {
+		return fmt.Errorf("open %s stream: %w", proto, err)
+	}
+	defer stream.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = stream.SetDeadline(deadline)
+	}
+	if err := json.NewEncoder(stream).Encode(req); err != nil {
+		stream.Reset()
+		return fmt.Errorf("send request: %w", err)
+	}
+	stream.CloseWrite()
+
+	if err := json.NewDecoder(io.LimitReader(stream, maxSyncResponseBytes)).Decode(resp); err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// mockTxSource is a TxSource over a fixed set of transactions.
+type mockTxSource map[types.Hash]*tx.Transaction
+
+func (m mockTxSource) Has(h types.Hash) bool            { return m[h] != nil }
+func (m mockTxSource) Get(h types.Hash) *tx.Transaction { return m[h] }
+func (m mockTxSource) Transactions() []*tx.Transaction {
+	txs := make([]*tx.Transaction, 0, len(m))
+	for _, t := range m {
+		txs = append(txs, t)
+	}
+	return txs
+}
+
+func testInvTx(value uint64) *tx.Transaction {
+	return &tx.Transaction{
+		Version: 1,
+		Outputs: []tx.Output{{Value: value, Script: types.Script{Type: types.ScriptTypeP2PKH, Data: make([]byte, 20)}}},
+	}
+}
+
+// testInvBlock builds a block over txs with a valid merkle root.
+func testInvBlock(height uint64, txs ...*tx.Transaction) *block.Block {
+	hashes := make([]types.Hash, len(txs))
+	for i, t := range txs {
+		hashes[i] = t.Hash()
+	}
+	return block.NewBlock(&block.Header{
+		Version:    1,
+		Height:     height,
+		Timestamp:  uint64(time.Now().Unix()),
+		MerkleRoot: block.ComputeMerkleRoot(hashes),
+	}, txs)
+}
+
+func TestCompactBlock_Reconstruct(t *testing.T) {
+	coinbase, tx1, tx2, tx3 := testInvTx(1), testInvTx(2), testInvTx(3), testInvTx(4)
+	blk := testInvBlock(7, coinbase, tx1, tx2, tx3)
+
+	cb := NewCompactBlock(blk)
+	if len(cb.Prefilled) != 1 || cb.Prefilled[0].Index != 0 {
+		t.Fatalf("prefilled = %+v, want coinbase only", cb.Prefilled)
+	}
+	if len(cb.ShortIDs) != 3 || cb.TxCount() != 4 {
+		t.Fatalf("short IDs = %d, tx count = %d", len(cb.ShortIDs), cb.TxCount())
+	}
+	if err := cb.check(); err != nil {
+		t.Fatalf("check: %v", err)
+	}
+
+	txs, missing := cb.reconstruct([]*tx.Transaction{tx1, tx3, testInvTx(99)})
+	if len(missing) != 1 || missing[0] != 2 {
+		t.Fatalf("missing = %v, want [2]", missing)
+	}
+	if txs[0] != coinbase || txs[1] != tx1 || txs[2] != nil || txs[3] != tx3 {
+		t.Error("transactions placed at wrong indexes")
+	}
+
+	txs[2] = tx2
+	if !merkleMatches(blk.Header, txs) {
+		t.Error("merkle root should match the rebuilt block")
+	}
+	txs[2] = testInvTx(99)
+	if merkleMatches(blk.Header, txs) {
+		t.Error("merkle root should not match a wrong transaction")
+	}
+}
+
+func TestCompactBlock_Check(t *testing.T) {
+	blk := testInvBlock(1, testInvTx(1), testInvTx(2))
+
+	cb := NewCompactBlock(blk)
+	cb.Prefilled[0].Index = 2
+	if err := cb.check(); err == nil {
+		t.Error("expected error for prefilled index past the end")
+	}
+
+	cb = NewCompactBlock(blk)
+	cb.Header = nil
+	if err := cb.check(); err == nil {
+		t.Error("expected error for missing header")
+	}
+}
+
+func TestCompactBlock_Matches(t *testing.T) {
+	coinbase, tx1, tx2 := testInvTx(1), testInvTx(2), testInvTx(3)
+	cb := NewCompactBlock(testInvBlock(3, coinbase, tx1, tx2))
+
+	if !cb.matches([]int{1, 2}, []*tx.Transaction{tx1, tx2}) {
+		t.Error("block transactions should match their short IDs")
+	}
+	if cb.matches([]int{1, 2}, []*tx.Transaction{tx1, nil}) {
+		t.Error("nil entry should not match")
+	}
+	if cb.matches([]int{1, 2}, []*tx.Transaction{tx2, tx1}) {
+		t.Error("swapped transactions should not match")
+	}
+	if cb.matches([]int{1, 2}, []*tx.Transaction{tx1}) {
+		t.Error("short response should not match")
+	}
+	if cb.matches([]int{0}, []*tx.Transaction{coinbase}) {
+		t.Error("prefilled index should not match")
+	}
+}
+
+func TestInventory_Want(t *testing.T) {
+	inv := newInventory()
+	announced, known, fresh := testInvTx(1), testInvTx(2), testInvTx(3)
+	inv.announce(announced, nil)
+	inv.source = mockTxSource{known.Hash(): known}
+
+	now := time.Now()
+	hashes := []types.Hash{announced.Hash(), known.Hash(), fresh.Hash(), fresh.Hash()}
+	wanted := inv.want(hashes, now)
+	if len(wanted) != 1 || wanted[0] != fresh.Hash() {
+		t.Fatalf("want = %v, want only the unknown hash", wanted)
+	}
+
+	// In flight: not requested again until done or stale.
+	if got := inv.want(hashes, now); len(got) != 0 {
+		t.Errorf("want while in flight = %v, want none", got)
+	}
+	if got := inv.want(hashes, now.Add(3*invFetchTimeout)); len(got) != 1 {
+		t.Errorf("want after stale fetch = %v, want one", got)
+	}
+	inv.done(wanted)
+	if got := inv.want(hashes, now); len(got) != 1 {
+		t.Errorf("want after done = %v, want one", got)
+	}
+}
+
+func TestTwoNodes_CompactBlockFetchesMissing(t *testing.T) {
+	nodeA := startTestNode(t)
+	nodeB := startTestNode(t)
+	connectNodes(t, nodeA, nodeB)
+
+	coinbase, known, unknown := testInvTx(1), testInvTx(2), testInvTx(3)
+	testBlock := testInvBlock(42, coinbase, known, unknown)
+
+	// B holds one of the two non-coinbase transactions.
+	nodeB.SetTxSource(mockTxSource{known.Hash(): known})
+
+	var received atomic.Value
+	nodeB.SetBlockHandler(func(_ peer.ID, data []byte) {
+		var blk block.Block
+		if err := json.Unmarshal(data, &blk); err == nil {
+			received.Store(&blk)
+		}
+	})
+
+	time.Sleep(300 * time.Millisecond)
+
+	if err := nodeA.BroadcastBlock(testBlock); err != nil {
+		t.Fatalf("BroadcastBlock: %v", err)
+	}
+
+	deadline := time.After(5 * time.Second)
+	for {
+		if v := received.Load(); v != nil {
+			rxBlock := v.(*block.Block)
+			if rxBlock.Hash() != testBlock.Hash() {
+				t.Errorf("block hash = %s, want %s", rxBlock.Hash(), testBlock.Hash())
+			}
+			if len(rxBlock.Transactions) != 3 {
+				t.Fatalf("expected 3 txs, got %d", len(rxBlock.Transactions))
+			}
+			if !merkleMatches(rxBlock.Header, rxBlock.Transactions) {
+				t.Error("rebuilt block does not match its merkle root")
+			}
+			return
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for compact block")
+		default:
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+}
+
+func TestFillBlockTxns_SkipsBadResponses(t *testing.T) {
+	nodeA := startTestNode(t)
+	nilPeer := startTestNode(t)
+	wrongPeer := startTestNode(t)
+	nodeB := startTestNode(t)
+	connectNodes(t, nilPeer, nodeB)
+	connectNodes(t, wrongPeer, nodeB)
+	connectNodes(t, nodeA, nodeB)
+
+	coinbase, tx1, tx2 := testInvTx(1), testInvTx(2), testInvTx(3)
+	blk := testInvBlock(9, coinbase, tx1, tx2)
+	nodeA.inv.addBlock(blk)
+	// Same header, so the same block hash, but bad transactions.
+	nilPeer.inv.addBlock(block.NewBlock(blk.Header, []*tx.Transaction{coinbase, tx1, nil}))
+	wrongPeer.inv.addBlock(block.NewBlock(blk.Header, []*tx.Transaction{coinbase, tx1, testInvTx(99)}))
+
+	cb := NewCompactBlock(blk)
+	txs, missing := cb.reconstruct([]*tx.Transaction{tx1})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	peers := []peer.ID{nilPeer.host.ID(), wrongPeer.host.ID(), nodeA.host.ID()}
+	if err := nodeB.fillBlockTxns(ctx, peers, cb, txs, missing); err != nil {
+		t.Fatalf("fillBlockTxns: %v", err)
+	}
+	if txs[2] == nil || txs[2].Hash() != tx2.Hash() {
+		t.Fatal("missing transaction not filled from the honest peer")
+	}
+	if !merkleMatches(blk.Header, txs) {
+		t.Error("filled block does not match its merkle root")
+	}
+
+	// Only bad peers: the fetch fails instead of accepting their entries.
+	txs, missing = cb.reconstruct([]*tx.Transaction{tx1})
+	if err := nodeB.fillBlockTxns(ctx, peers[:2], cb, txs, missing); err == nil {
+		t.Error("expected error when no peer serves matching transactions")
+	}
+	if txs[2] != nil {
+		t.Error("rejected response should not be applied")
+	}
+}
+
+func TestValidateBlock_MissingTxsIgnoredWithoutBlocking(t *testing.T) {
+	n := startTestNode(t)
+	from := generateTestPeerID(t) // Unreachable: the fetch can only fail.
+
+	coinbase, tx1 := testInvTx(1), testInvTx(2)
+	blk := testInvBlock(5, coinbase, tx1)
+	msg := testMessage(t, NewCompactBlock(blk))
+
+	start := time.Now()
+	if res := n.validateBlock(context.Background(), from, msg); res != pubsub.ValidationIgnore {
+		t.Fatalf("validateBlock = %v, want ignore", res)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("validateBlock took %s, want no fetch round trip", elapsed)
+	}
+
+	// With the transaction known the block is rebuilt and accepted in place.
+	n.SetTxSource(mockTxSource{tx1.Hash(): tx1})
+	if res := n.validateBlock(context.Background(), from, msg); res != pubsub.ValidationAccept {
+		t.Fatalf("validateBlock = %v, want accept", res)
+	}
+	if n.inv.block(blk.Hash()) == nil {
+		t.Error("accepted block should be kept to serve BlockTxn requests")
+	}
+}
@@ -0,0 +1,144 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/lightclient"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+const (
+	// LightProtocol is the protocol ID for light client requests.
+	LightProtocol = protocol.ID("/klingnet/light/1.0.0")
+
+	// lightReadTimeout is the max time to read a light client response.
+	lightReadTimeout = 30 * time.Second
+
+	// maxLightRequestBytes limits the size of a light client request.
+	maxLightRequestBytes = 4096
+
+	// Light client requests each peer may send per second, and in a burst.
+	lightRequestRate  = 5.0
+	lightRequestBurst = 50
+)
+
+// LightProvider serves light client requests from the local chain.
+type LightProvider interface {
+	Headers(from uint64, max uint32) []*block.Header
+	StakeChanges(from uint64, max uint32) ([]*lightclient.StakeChange, error)
+	TxProof(hash types.Hash) (*lightclient.TxProof, error)
+}
+
+// RegisterLightHandler registers the light client stream handler. Each
+// peer's requests are rate limited, and stake change requests, which load
+// the blocks of every spent output, cover at most
+// lightclient.MaxStakeBlocks blocks.
+func (s *Syncer) RegisterLightHandler(provider LightProvider) {
+	s.host.SetStreamHandler(LightProtocol, func(stream network.Stream) {
+		defer stream.Close()
+
+		var req lightclient.Request
+		if err := json.NewDecoder(io.LimitReader(stream, maxLightRequestBytes)).Decode(&req); err != nil {
+			return
+		}
+		if l := s.node.lightLimiter; l != nil && !l.allow(stream.Conn().RemotePeer(), time.Now()) {
+			json.NewEncoder(stream).Encode(&lightclient.Response{Error: "rate limited"})
+			return
+		}
+		limit := uint32(lightclient.MaxHeaders)
+		if req.Type == lightclient.RequestStakes {
+			limit = lightclient.MaxStakeBlocks
+		}
+		if req.Max == 0 || req.Max > limit {
+			req.Max = limit
+		}
+
+		var resp lightclient.Response
+		var err error
+		switch req.Type {
+		case lightclient.RequestHeaders:
+			resp.Headers = provider.Headers(req.From, req.Max)
+		case lightclient.RequestStakes:
+			resp.Stakes, err = provider.StakeChanges(req.From, req.Max)
+		case lightclient.RequestTx:
+			resp.Proof, err = provider.TxProof(req.TxHash)
+		default:
+			err = fmt.Errorf("unknown request type %q", req.Type)
+		}
+		if err != nil {
+			resp = lightclient.Response{Error: err.Error()}
+		}
+		json.NewEncoder(stream).Encode(&resp)
+	})
+	s.node.SetCapability(CapLight, true)
+}
+
+// LightSource returns a light client source that queries peerID.
+func (s *Syncer) LightSource(peerID peer.ID) lightclient.Source {
+	return &lightSource{syncer: s, peer: peerID}
+}
+
+// lightSource implements lightclient.Source over LightProtocol.
+type lightSource struct {
+	syncer *Syncer
+	peer   peer.ID
+}
+
+func (ls *lightSource) Headers(ctx context.Context, from uint64, max uint32) ([]*block.Header, error) {
+	resp, err := ls.request(ctx, lightclient.Request{Type: lightclient.RequestHeaders, From: from, Max: max})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Headers, nil
+}
+
+func (ls *lightSource) StakeChanges(ctx context.Context, from uint64, max uint32) ([]*lightclient.StakeChange, error) {
+	resp, err := ls.request(ctx, lightclient.Request{Type: lightclient.RequestStakes, From: from, Max: max})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Stakes, nil
+}
+
+func (ls *lightSource) TxProof(ctx context.Context, hash types.Hash) (*lightclient.TxProof, error) {
+	resp, err := ls.request(ctx, lightclient.Request{Type: lightclient.RequestTx, TxHash: hash})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Proof, nil
+}
+
+// request sends one light client request and reads the response.
+func (ls *lightSource) request(ctx context.Context, req lightclient.Request) (*lightclient.Response, error) {
+	stream, err := ls.syncer.host.NewStream(ctx, ls.peer, LightProtocol)
+	if err != nil {
+		return nil, fmt.Errorf("open light stream: %w", err)
+	}
+	defer stream.Close()
+
+	if err := json.NewEncoder(stream).Encode(&req); err != nil {
+		return nil, fmt.Errorf("send light request: %w", err)
+	}
+
+	// Signal we're done writing.
+	stream.CloseWrite()
+
+	_ = stream.SetReadDeadline(time.Now().Add(lightReadTimeout))
+
+	var resp lightclient.Response
+	if err := json.NewDecoder(io.LimitReader(stream, maxSyncResponseBytes)).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("read light response: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("light %s request: %s", req.Type, resp.Error)
+	}
+	return &resp, nil
+}
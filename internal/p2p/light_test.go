@@ -0,0 +1,174 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/crypto"
+	"github.com/Klingon-tech/klingnet-chain/pkg/lightclient"
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// memLightProvider serves a fixed chain; blocks[i] is at height i.
+type memLightProvider struct {
+	blocks    []*block.Block
+	stakesMax atomic.Uint32 // Max of the last stake changes request.
+}
+
+func (p *memLightProvider) Headers(from uint64, max uint32) []*block.Header {
+	var headers []*block.Header
+	for h := from; h < uint64(len(p.blocks)) && h < from+uint64(max); h++ {
+		headers = append(headers, p.blocks[h].Header)
+	}
+	return headers
+}
+
+func (p *memLightProvider) StakeChanges(_ uint64, max uint32) ([]*lightclient.StakeChange, error) {
+	p.stakesMax.Store(max)
+	return nil, nil
+}
+
+func (p *memLightProvider) TxProof(hash types.Hash) (*lightclient.TxProof, error) {
+	for _, b := range p.blocks {
+		if proof, err := lightclient.NewTxProof(b, hash); err == nil {
+			return proof, nil
+		}
+	}
+	return nil, errors.New("tx not found")
+}
+
+// signedChain builds n single-validator PoA blocks on top of a genesis
+// header, each with one transaction.
+func signedChain(t *testing.T, key *crypto.PrivateKey, n int) []*block.Block {
+	t.Helper()
+	gen := &block.Header{Version: block.CurrentVersion, Timestamp: 1000}
+	blocks := []*block.Block{block.NewBlock(gen, nil)}
+	for i := 1; i <= n; i++ {
+		parent := blocks[i-1].Header
+		txn := tx.NewBuilder().
+			AddInput(types.Outpoint{}).
+			AddOutput(uint64(i), types.Script{Type: types.ScriptTypeP2PKH, Data: make([]byte, 20)}).
+			Build()
+		h := &block.Header{
+			Version:    block.CurrentVersion,
+			PrevHash:   parent.Hash(),
+			MerkleRoot: block.ComputeMerkleRoot([]types.Hash{txn.Hash()}),
+			Timestamp:  parent.Timestamp + 3,
+			Height:     parent.Height + 1,
+			Difficulty: lightclient.DiffInTurn,
+		}
+		hash := h.Hash()
+		sig, err := key.Sign(hash[:])
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		h.ValidatorSig = sig
+		blocks = append(blocks, block.NewBlock(h, []*tx.Transaction{txn}))
+	}
+	return blocks
+}
+
+func TestLightProtocol_SyncAndProve(t *testing.T) {
+	h1, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatalf("create host1: %v", err)
+	}
+	defer h1.Close()
+	h2, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatalf("create host2: %v", err)
+	}
+	defer h2.Close()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := signedChain(t, key, 5)
+
+	// Node1 is the full node.
+	syncer1 := NewSyncer(&Node{host: h1})
+	syncer1.RegisterLightHandler(&memLightProvider{blocks: blocks})
+
+	if err := h2.Connect(context.Background(), peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	syncer2 := NewSyncer(&Node{host: h2})
+	src := syncer2.LightSource(h1.ID())
+
+	client, err := lightclient.New(blocks[0].Header, [][]byte{key.PublicKey()}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := client.Sync(ctx, src); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if client.Height() != 5 {
+		t.Fatalf("light client height = %d, want 5", client.Height())
+	}
+
+	txHash := blocks[2].Transactions[0].Hash()
+	proof, confs, err := client.FetchTx(ctx, src, txHash)
+	if err != nil {
+		t.Fatalf("FetchTx: %v", err)
+	}
+	if proof.Height != 2 || confs != 4 {
+		t.Errorf("proof height %d confs %d, want 2 and 4", proof.Height, confs)
+	}
+
+	// Errors from the full node reach the client.
+	if _, _, err := client.FetchTx(ctx, src, types.Hash{0xff}); err == nil {
+		t.Error("expected error for unknown tx")
+	}
+}
+
+func TestLightProtocol_Limits(t *testing.T) {
+	h1, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatalf("create host1: %v", err)
+	}
+	defer h1.Close()
+	h2, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatalf("create host2: %v", err)
+	}
+	defer h2.Close()
+
+	provider := &memLightProvider{}
+	syncer1 := NewSyncer(&Node{host: h1, lightLimiter: newPeerRateLimiter(0.001, 2)})
+	syncer1.RegisterLightHandler(provider)
+
+	if err := h2.Connect(context.Background(), peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	src := NewSyncer(&Node{host: h2}).LightSource(h1.ID())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Stake change requests are cut to a bounded block range.
+	if _, err := src.StakeChanges(ctx, 1, lightclient.MaxHeaders); err != nil {
+		t.Fatalf("StakeChanges: %v", err)
+	}
+	if got := provider.stakesMax.Load(); got != lightclient.MaxStakeBlocks {
+		t.Errorf("provider asked for %d blocks, want %d", got, lightclient.MaxStakeBlocks)
+	}
+
+	if _, err := src.Headers(ctx, 0, 1); err != nil {
+		t.Fatalf("Headers within burst: %v", err)
+	}
+	if _, err := src.Headers(ctx, 0, 1); err == nil {
+		t.Error("expected request beyond the peer's burst to be refused")
+	}
+}
@@ -0,0 +1,86 @@
+package p2p
+
+import (
+	"strings"
+
+	klog "github.com/Klingon-tech/klingnet-chain/internal/log"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// natOptions returns the libp2p options for the configured NAT traversal.
+// Relay clients reserve slots on the seeds, which must run the relay
+// service for unreachable nodes to be dialled through them.
+func (n *Node) natOptions() []libp2p.Option {
+	var opts []libp2p.Option
+	if n.config.NATPortMap {
+		opts = append(opts, libp2p.NATPortMap())
+	}
+	if n.config.AutoNAT {
+		opts = append(opts, libp2p.EnableNATService(), libp2p.EnableAutoNATv2())
+	}
+	if n.config.RelayService {
+		opts = append(opts, libp2p.EnableRelayService())
+	}
+	if n.config.Relay {
+		if relays := seedAddrInfos(n.config.Seeds); len(relays) > 0 {
+			opts = append(opts, libp2p.EnableAutoRelayWithStaticRelays(relays))
+		}
+	}
+	if n.config.HolePunch {
+		opts = append(opts, libp2p.EnableHolePunching())
+	}
+	return opts
+}
+
+// seedAddrInfos parses the seed multiaddrs, skipping invalid ones.
+func seedAddrInfos(seeds []string) []peer.AddrInfo {
+	var infos []peer.AddrInfo
+	for _, s := range seeds {
+		info, err := peer.AddrInfoFromString(s)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, *info)
+	}
+	return infos
+}
+
+// watchReachability tracks the reachability reported by AutoNAT.
+func (n *Node) watchReachability() {
+	sub, err := n.host.EventBus().Subscribe(new(event.EvtLocalReachabilityChanged))
+	if err != nil {
+		logger := klog.WithComponent("p2p")
+		logger.Warn().Err(err).Msg("Failed to subscribe to reachability events")
+		return
+	}
+	go func() {
+		defer sub.Close()
+		for {
+			select {
+			case <-n.ctx.Done():
+				return
+			case e, ok := <-sub.Out():
+				if !ok {
+					return
+				}
+				r := e.(event.EvtLocalReachabilityChanged).Reachability
+				n.reachability.Store(int32(r))
+				logger := klog.WithComponent("p2p")
+				logger.Info().Str("reachability", reachabilityString(r)).Msg("Reachability changed")
+			}
+		}
+	}()
+}
+
+// Reachability returns whether peers can dial this node as detected by
+// AutoNAT: "public", "private" or "unknown".
+func (n *Node) Reachability() string {
+	return reachabilityString(network.Reachability(n.reachability.Load()))
+}
+
+func reachabilityString(r network.Reachability) string {
+	return strings.ToLower(r.String())
+}
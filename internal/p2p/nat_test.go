@@ -0,0 +1,57 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+func TestSeedAddrInfos(t *testing.T) {
+	infos := seedAddrInfos([]string{
+		"/ip4/149.102.158.68/tcp/30303/p2p/12D3KooWSU83DfT1QXgji1XiC2d9RtkGFKt5zwG4sQQVN2R3nNGG",
+		"/ip4/127.0.0.1/tcp/30303", // no peer ID
+		"not-a-multiaddr",
+	})
+	if len(infos) != 1 {
+		t.Fatalf("got %d relay candidates, want 1", len(infos))
+	}
+}
+
+func TestNode_Reachability(t *testing.T) {
+	n := New(Config{
+		ListenAddr:   "127.0.0.1",
+		NoDiscover:   true,
+		AutoNAT:      true,
+		Relay:        true,
+		RelayService: true,
+		HolePunch:    true,
+		Seeds:        []string{"/ip4/127.0.0.1/tcp/1/p2p/12D3KooWSU83DfT1QXgji1XiC2d9RtkGFKt5zwG4sQQVN2R3nNGG"},
+	})
+	if err := n.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer n.Stop()
+
+	if got := n.Reachability(); got != "unknown" {
+		t.Errorf("Reachability before AutoNAT = %q, want unknown", got)
+	}
+
+	em, err := n.host.EventBus().Emitter(new(event.EvtLocalReachabilityChanged))
+	if err != nil {
+		t.Fatalf("Emitter: %v", err)
+	}
+	defer em.Close()
+	if err := em.Emit(event.EvtLocalReachabilityChanged{Reachability: network.ReachabilityPrivate}); err != nil {
+		t.Fatalf("Emit: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for n.Reachability() != "private" {
+		if time.Now().After(deadline) {
+			t.Fatalf("Reachability = %q, want private", n.Reachability())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -5,24 +5,28 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/Klingon-tech/klingnet-chain/config"
 	klog "github.com/Klingon-tech/klingnet-chain/internal/log"
 	"github.com/Klingon-tech/klingnet-chain/internal/storage"
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
 	"github.com/Klingon-tech/klingnet-chain/pkg/types"
 	"github.com/libp2p/go-libp2p"
 	dht "github.com/libp2p/go-libp2p-kad-dht"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	libp2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/metrics"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/p2p/discovery/mdns"
@@ -47,7 +51,7 @@ const (
 
 // Config holds P2P node configuration.
 type Config struct {
-	ListenAddr string
+	ListenAddr string // TCP listen IP, used when ListenAddrs is empty
 	Port       int
 	Seeds      []string
 	MaxPeers   int
@@ -57,6 +61,42 @@ type Config struct {
 	NetworkID  string     // e.g. "klingnet-mainnet-1" — isolates DHT per network
 	DataDir    string     // Data directory for persisting node identity
 	ClearBans  bool       // Clear all peer bans on startup.
+
+	// Multiaddrs, TCP or QUIC over IPv4 or IPv6, to listen on and to
+	// advertise to peers. Advertised addresses default to the listen ones.
+	ListenAddrs   []string // Replaces ListenAddr and Port when set.
+	AnnounceAddrs []string // Advertised instead of the listen addresses.
+	ExternalAddrs []string // Advertised in addition to the listen addresses.
+
+	// NAT traversal.
+	NATPortMap   bool // Map listen ports on the router with UPnP or NAT-PMP.
+	AutoNAT      bool // Detect reachability with AutoNAT and answer peers' probes.
+	Relay        bool // Reserve circuit relay v2 slots on seeds while unreachable.
+	RelayService bool // Act as a circuit relay v2 relay for other peers.
+	HolePunch    bool // Upgrade relayed connections with DCUtR hole punching.
+
+	// Sentry topology (see sentry.go).
+	PrivatePeers    []string // Multiaddrs of the only peers to talk to.
+	PersistentPeers []string // Multiaddrs always reconnected, exempt from MaxPeers.
+	HiddenPeers     []string // Peer IDs never advertised to other peers.
+
+	// Per-peer transaction relay limits (0 = defaults).
+	TxRelayRate  float64 // Sustained transactions per second.
+	TxRelayBurst int     // Transactions accepted in a burst.
+
+	// Privacy of transactions passed to SubmitTx.
+	Dandelion      bool          // Relay through a stem peer before announcing.
+	BroadcastDelay time.Duration // Max random delay before announcing (0 = none).
+
+	// Upload caps on block serving in bytes per second (0 = unlimited).
+	UploadRate     int // Across all peers.
+	PeerUploadRate int // To a single peer.
+
+	// In-process networks. Host replaces the listening host built from
+	// the settings above, e.g. with a mocknet peer; PubSubOptions are
+	// added to the GossipSub options.
+	Host          host.Host
+	PubSubOptions []pubsub.Option
 }
 
 // Node represents a P2P node built on libp2p.
@@ -67,13 +107,26 @@ type Node struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
+	reachability atomic.Int32 // network.Reachability from AutoNAT
+
 	topicTx    *pubsub.Topic
 	topicBlock *pubsub.Topic
 	subTx      *pubsub.Subscription
 	subBlock   *pubsub.Subscription
 
-	txHandler    func(peer.ID, []byte)
-	blockHandler func(peer.ID, []byte)
+	txHandler     func(peer.ID, []byte)
+	blockHandler  func(peer.ID, []byte)
+	blockVerifier func(*block.Header) error // Header check before relay (nil = none).
+	txLimiter     *peerRateLimiter          // Per-peer relay allowance on tx topics.
+	lightLimiter  *peerRateLimiter          // Per-peer light client request allowance.
+	inv           *inventory                // Announced, requested and relayed inventory.
+	stemHandler   func(peer.ID, []byte)
+	stem          stemRoute // Dandelion stem peer for this epoch.
+
+	// Traffic accounting and block-serving upload caps (see bandwidth.go).
+	bandwidth      *metrics.BandwidthCounter // Per protocol and per peer.
+	topicBandwidth *metrics.BandwidthCounter // Per GossipSub topic.
+	upload         *uploadLimiter
 
 	// Heartbeat topic for validator liveness.
 	topicHeartbeat   *pubsub.Topic
@@ -81,16 +134,17 @@ type Node struct {
 	heartbeatHandler func(*HeartbeatMessage)
 
 	// Sub-chain per-chain GossipSub topics.
-	scMu            sync.RWMutex
-	scTopics        map[string]*pubsub.Topic           // chainID hex → block topic
-	scSubs          map[string]*pubsub.Subscription    // chainID hex → block subscription
-	scTxTopics      map[string]*pubsub.Topic           // chainID hex → tx topic
-	scTxSubs        map[string]*pubsub.Subscription    // chainID hex → tx subscription
-	scBlockHandlers map[string]func(peer.ID, []byte)   // chainID hex → block handler
-	scTxHandlers    map[string]func(peer.ID, []byte)   // chainID hex → tx handler
-	scHBTopics      map[string]*pubsub.Topic           // chainID hex → heartbeat topic
-	scHBSubs        map[string]*pubsub.Subscription    // chainID hex → heartbeat subscription
-	scHBHandlers    map[string]func(*HeartbeatMessage) // chainID hex → heartbeat handler
+	scMu             sync.RWMutex
+	scTopics         map[string]*pubsub.Topic             // chainID hex → block topic
+	scSubs           map[string]*pubsub.Subscription      // chainID hex → block subscription
+	scTxTopics       map[string]*pubsub.Topic             // chainID hex → tx topic
+	scTxSubs         map[string]*pubsub.Subscription      // chainID hex → tx subscription
+	scBlockHandlers  map[string]func(peer.ID, []byte)     // chainID hex → block handler
+	scTxHandlers     map[string]func(peer.ID, []byte)     // chainID hex → tx handler
+	scHBTopics       map[string]*pubsub.Topic             // chainID hex → heartbeat topic
+	scHBSubs         map[string]*pubsub.Subscription      // chainID hex → heartbeat subscription
+	scHBHandlers     map[string]func(*HeartbeatMessage)   // chainID hex → heartbeat handler
+	scBlockVerifiers map[string]func(*block.Header) error // chainID hex → header check
 
 	mu    sync.RWMutex
 	peers map[peer.ID]*Peer
@@ -102,29 +156,51 @@ type Node struct {
 	onPeerConnected func()           // optional callback when a peer connects
 	seedPeers       map[peer.ID]bool // Seed peers are exempt from banning.
 
+	// Sentry topology, set by loadPeerGroups.
+	privatePeers    map[peer.ID]bool
+	persistentPeers []peer.AddrInfo // Private peers included.
+	hiddenPeers     map[peer.ID]bool
+
+	// Peers skipped by sync until the given time (see reputation.go).
+	// Kept across reconnects, guarded by mu.
+	isolated map[peer.ID]time.Time
+
 	// Handshake fields.
 	genesisHash      types.Hash
 	handshakeEnabled bool
 	heightFn         func() uint64
+
+	// Capabilities and sub-chains advertised in handshakes.
+	capMu           sync.Mutex
+	capabilities    Capability
+	subChains       map[string]bool // chainID hex
+	announcePending atomic.Bool     // A re-handshake round is scheduled.
 }
 
 // New creates a new P2P node with the given config.
 func New(cfg Config) *Node {
 	ctx, cancel := context.WithCancel(context.Background())
 	n := &Node{
-		config:          cfg,
-		ctx:             ctx,
-		cancel:          cancel,
-		peers:           make(map[peer.ID]*Peer),
-		scTopics:        make(map[string]*pubsub.Topic),
-		scSubs:          make(map[string]*pubsub.Subscription),
-		scTxTopics:      make(map[string]*pubsub.Topic),
-		scTxSubs:        make(map[string]*pubsub.Subscription),
-		scBlockHandlers: make(map[string]func(peer.ID, []byte)),
-		scTxHandlers:    make(map[string]func(peer.ID, []byte)),
-		scHBTopics:      make(map[string]*pubsub.Topic),
-		scHBSubs:        make(map[string]*pubsub.Subscription),
-		scHBHandlers:    make(map[string]func(*HeartbeatMessage)),
+		config:           cfg,
+		ctx:              ctx,
+		cancel:           cancel,
+		peers:            make(map[peer.ID]*Peer),
+		txLimiter:        newTxRateLimiter(cfg.TxRelayRate, cfg.TxRelayBurst),
+		lightLimiter:     newPeerRateLimiter(lightRequestRate, lightRequestBurst),
+		inv:              newInventory(),
+		bandwidth:        metrics.NewBandwidthCounter(),
+		topicBandwidth:   metrics.NewBandwidthCounter(),
+		upload:           newUploadLimiter(cfg.UploadRate, cfg.PeerUploadRate),
+		scTopics:         make(map[string]*pubsub.Topic),
+		scSubs:           make(map[string]*pubsub.Subscription),
+		scTxTopics:       make(map[string]*pubsub.Topic),
+		scTxSubs:         make(map[string]*pubsub.Subscription),
+		scBlockHandlers:  make(map[string]func(peer.ID, []byte)),
+		scTxHandlers:     make(map[string]func(peer.ID, []byte)),
+		scHBTopics:       make(map[string]*pubsub.Topic),
+		scHBSubs:         make(map[string]*pubsub.Subscription),
+		scHBHandlers:     make(map[string]func(*HeartbeatMessage)),
+		scBlockVerifiers: make(map[string]func(*block.Header) error),
 	}
 	if cfg.DB != nil {
 		n.peerStore = NewPeerStore(cfg.DB)
@@ -132,6 +208,57 @@ func New(cfg Config) *Node {
 	return n
 }
 
+// newHost creates the libp2p host listening on the configured addresses.
+func (n *Node) newHost() (host.Host, error) {
+	listen, err := n.config.listenMultiaddrs()
+	if err != nil {
+		return nil, err
+	}
+	announce, err := parseMultiaddrs("announce", n.config.AnnounceAddrs)
+	if err != nil {
+		return nil, err
+	}
+	external, err := parseMultiaddrs("external", n.config.ExternalAddrs)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []libp2p.Option{
+		libp2p.ListenAddrs(listen...),
+		libp2p.BandwidthReporter(n.bandwidth),
+		libp2p.AddrsFactory(advertisedAddrs(announce, external)),
+		libp2p.ConnectionGater(&banGater{banMgr: n.BanManager, private: n.privatePeers}),
+	}
+	if !n.isPrivate() {
+		opts = append(opts, n.natOptions()...)
+	}
+
+	// Load or generate persistent identity so peer ID survives restarts.
+	if n.config.DataDir != "" {
+		privKey, err := loadOrCreateIdentity(n.config.DataDir)
+		if err != nil {
+			return nil, fmt.Errorf("load p2p identity: %w", err)
+		}
+		opts = append(opts, libp2p.Identity(privKey))
+	}
+
+	rm, err := newResourceManager()
+	if err != nil {
+		return nil, fmt.Errorf("create resource manager: %w", err)
+	}
+	opts = append(opts, libp2p.ResourceManager(rm))
+
+	h, err := libp2p.New(opts...)
+	if err != nil {
+		rm.Close()
+		if errors.Is(err, syscall.EADDRINUSE) {
+			return nil, fmt.Errorf("cannot bind to P2P address %v: address already in use (is another klingnetd instance running?)", listen)
+		}
+		return nil, fmt.Errorf("start P2P listener on %v: %w", listen, err)
+	}
+	return h, nil
+}
+
 // rendezvous returns the DHT/mDNS discovery namespace for this node.
 // When NetworkID is set, it isolates peer discovery per network.
 func (n *Node) rendezvous() string {
@@ -143,7 +270,9 @@ func (n *Node) rendezvous() string {
 
 // Start initializes the libp2p host, pubsub, and begins listening.
 func (n *Node) Start() error {
-	addr := fmt.Sprintf("/ip4/%s/tcp/%d", n.config.ListenAddr, n.config.Port)
+	if err := n.loadPeerGroups(); err != nil {
+		return err
+	}
 
 	// Create ban manager (before host, so the gater can reference it).
 	if n.config.DB != nil {
@@ -160,28 +289,15 @@ func (n *Node) Start() error {
 		n.BanManager = NewBanManager(nil, n)
 	}
 
-	opts := []libp2p.Option{
-		libp2p.ListenAddrStrings(addr),
-		libp2p.ConnectionGater(&banGater{banMgr: n.BanManager}),
-	}
-
-	// Load or generate persistent identity so peer ID survives restarts.
-	if n.config.DataDir != "" {
-		privKey, err := loadOrCreateIdentity(n.config.DataDir)
-		if err != nil {
-			return fmt.Errorf("load p2p identity: %w", err)
-		}
-		opts = append(opts, libp2p.Identity(privKey))
-	}
-
-	h, err := libp2p.New(opts...)
-	if err != nil {
-		if errors.Is(err, syscall.EADDRINUSE) {
-			return fmt.Errorf("cannot bind to P2P port %d: address already in use (is another klingnetd instance running?)", n.config.Port)
+	h := n.config.Host
+	if h == nil {
+		var err error
+		if h, err = n.newHost(); err != nil {
+			return err
 		}
-		return fmt.Errorf("start P2P listener on %s:%d: %w", n.config.ListenAddr, n.config.Port, err)
 	}
 	n.host = h
+	n.watchReachability()
 
 	// Register connection notifier for peer tracking.
 	n.connNotify = &connNotifier{node: n}
@@ -195,10 +311,15 @@ func (n *Node) Start() error {
 		}
 	}
 
-	// Set up GossipSub for message propagation.
-	ps, err := pubsub.NewGossipSub(n.ctx, h,
-		pubsub.WithMaxMessageSize(config.MaxBlockSize+64*1024),
-	)
+	// Set up GossipSub for message propagation, with peer scoring fed by
+	// the topic validators and the BanManager.
+	psOpts := append([]pubsub.Option{
+		pubsub.WithMaxMessageSize(config.MaxBlockSize + 64*1024),
+		pubsub.WithPeerScore(n.peerScoreParams(), peerScoreThresholds()),
+		pubsub.WithPeerScoreInspect(pubsub.ExtendedPeerScoreInspectFn(n.inspectPeerScores), scoreInspectInterval),
+		pubsub.WithRawTracer(&topicTracer{counter: n.topicBandwidth}),
+	}, n.config.PubSubOptions...)
+	ps, err := pubsub.NewGossipSub(n.ctx, h, psOpts...)
 	if err != nil {
 		n.closeDHT()
 		h.Close()
@@ -218,12 +339,18 @@ func (n *Node) Start() error {
 		n.registerHandshakeHandler()
 	}
 
-	// Start message loops.
-	go n.readLoop(n.subTx, n.handleTxMessage)
+	n.registerInventoryHandlers()
+
+	// Start message loops. Transaction announcements are consumed by the
+	// topic validator, so the tx subscription delivers nothing.
 	go n.readLoop(n.subBlock, n.handleBlockMessage)
+	go n.runAnnouncer()
 
 	// Load and reconnect persisted peers in background.
-	go n.loadPersistedPeers()
+	if !n.isPrivate() {
+		go n.loadPersistedPeers()
+	}
+	go n.runPersistentPeers()
 
 	// Connect to seed peers (first attempt is blocking, retries run in background).
 	if len(n.config.Seeds) > 0 {
@@ -247,9 +374,13 @@ func (n *Node) Start() error {
 		go n.runDHTDiscovery()
 	}
 
-	// Start peer persistence loop.
+	// Start peer persistence and exchange.
 	if n.peerStore != nil {
 		go n.runPersistLoop()
+		if !n.isPrivate() {
+			n.registerPexHandler()
+			go n.runPeerExchange()
+		}
 	}
 
 	return nil
@@ -387,11 +518,22 @@ func (n *Node) PeerList() []*Peer {
 	defer n.mu.RUnlock()
 	out := make([]*Peer, 0, len(n.peers))
 	for _, p := range n.peers {
-		out = append(out, p)
+		cp := *p
+		out = append(out, &cp)
 	}
 	return out
 }
 
+// PeersWithSubChain returns the sync peers, as ordered by SyncPeers,
+// that may have the sub-chain: those whose handshake lists it, and those
+// that did not advertise sub-chains because they predate
+// SignedHandshakeVersion.
+func (n *Node) PeersWithSubChain(chainIDHex string) []*Peer {
+	return n.syncPeers(func(p *Peer) bool {
+		return p.Info == nil || p.Info.ProtocolVersion < SignedHandshakeVersion || p.Info.HasSubChain(chainIDHex)
+	})
+}
+
 func (n *Node) addPeer(id peer.ID) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
@@ -407,9 +549,18 @@ func (n *Node) removePeer(id peer.ID) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 	delete(n.peers, id)
+	n.txLimiter.forget(id)
+	n.lightLimiter.forget(id)
+	n.upload.forget(id)
 }
 
 func (n *Node) joinTopics() error {
+	if err := n.pubsub.RegisterTopicValidator(TopicTransactions, n.validateTxAnnouncement); err != nil {
+		return fmt.Errorf("register tx validator: %w", err)
+	}
+	if err := n.pubsub.RegisterTopicValidator(TopicBlocks, n.validateBlock); err != nil {
+		return fmt.Errorf("register block validator: %w", err)
+	}
 	var err error
 	n.topicTx, err = n.pubsub.Join(TopicTransactions)
 	if err != nil {
@@ -443,20 +594,32 @@ func (n *Node) readLoop(sub *pubsub.Subscription, handler func(*pubsub.Message))
 	}
 }
 
-func (n *Node) handleTxMessage(msg *pubsub.Message) {
-	defer func() { recover() }()
-	n.addPeer(msg.ReceivedFrom)
-	if n.txHandler != nil {
-		n.txHandler(msg.ReceivedFrom, msg.Data)
+// handleBlockMessage hands the block rebuilt by validateBlock to the
+// block handler as JSON.
+func (n *Node) handleBlockMessage(msg *pubsub.Message) {
+	var cb CompactBlock
+	if err := json.Unmarshal(msg.Data, &cb); err != nil || cb.Header == nil {
+		return
+	}
+	blk := n.inv.block(cb.Header.Hash())
+	if blk == nil {
+		return
 	}
+	n.deliverBlock(msg.ReceivedFrom, blk)
 }
 
-func (n *Node) handleBlockMessage(msg *pubsub.Message) {
+// deliverBlock hands a rebuilt block to the block handler as JSON.
+func (n *Node) deliverBlock(from peer.ID, blk *block.Block) {
 	defer func() { recover() }()
-	n.addPeer(msg.ReceivedFrom)
-	if n.blockHandler != nil {
-		n.blockHandler(msg.ReceivedFrom, msg.Data)
+	n.addPeer(from)
+	if n.blockHandler == nil {
+		return
 	}
+	data, err := json.Marshal(blk)
+	if err != nil {
+		return
+	}
+	n.blockHandler(from, data)
 }
 
 func (n *Node) startMDNS() {
@@ -578,7 +741,13 @@ func (n *Node) initDHT() error {
 	if n.config.DHTServer {
 		mode = dht.ModeServer
 	}
-	kadDHT, err := dht.New(n.ctx, n.host, dht.Mode(mode))
+	// Hidden peers never enter the routing table, so they are never
+	// returned to other peers' queries.
+	kadDHT, err := dht.New(n.ctx, n.host, dht.Mode(mode),
+		dht.RoutingTableFilter(func(_ interface{}, id peer.ID) bool {
+			return !n.isHiddenPeer(id)
+		}),
+	)
 	if err != nil {
 		return fmt.Errorf("create kad-dht: %w", err)
 	}
@@ -629,7 +798,7 @@ func (n *Node) findDHTPeers(routingDiscovery *drouting.RoutingDiscovery) {
 		}
 
 		// Respect MaxPeers.
-		if n.config.MaxPeers > 0 && n.PeerCount() >= n.config.MaxPeers {
+		if n.config.MaxPeers > 0 && n.limitedPeerCount() >= n.config.MaxPeers {
 			return
 		}
 
@@ -661,20 +830,13 @@ func (n *Node) persistPeers() {
 	}
 	n.mu.RUnlock()
 
-	now := time.Now().Unix()
 	for _, id := range snapshot {
 		addrs := n.host.Peerstore().Addrs(id)
 		addrStrs := make([]string, len(addrs))
 		for i, a := range addrs {
 			addrStrs[i] = a.String()
 		}
-		rec := PeerRecord{
-			ID:       id.String(),
-			Addrs:    addrStrs,
-			LastSeen: now,
-			Source:   sources[id],
-		}
-		n.peerStore.Save(rec) // Best-effort, ignore errors.
+		n.peerStore.MarkSeen(id, addrStrs, sources[id]) // Best-effort, ignore errors.
 	}
 }
 
@@ -686,38 +848,8 @@ func (n *Node) loadPersistedPeers() {
 	// Prune stale records first.
 	n.peerStore.PruneStale(staleThreshold)
 
-	records, err := n.peerStore.LoadAll()
-	if err != nil {
-		return
-	}
-
-	for _, rec := range records {
-		id, err := peer.Decode(rec.ID)
-		if err != nil {
-			continue
-		}
-		if id == n.host.ID() {
-			continue
-		}
-
-		// Build AddrInfo from stored addresses.
-		info := peer.AddrInfo{ID: id}
-		for _, addr := range rec.Addrs {
-			ma, err := peer.AddrInfoFromString(fmt.Sprintf("%s/p2p/%s", addr, rec.ID))
-			if err != nil {
-				continue
-			}
-			info.Addrs = append(info.Addrs, ma.Addrs...)
-		}
-
-		if len(info.Addrs) == 0 {
-			continue
-		}
-
-		ctx, cancel := context.WithTimeout(n.ctx, peerConnectTimeout)
-		n.host.Connect(ctx, info) // Best-effort reconnect.
-		cancel()
-	}
+	// Reconnect to a netgroup-diverse selection rather than every record.
+	n.dialAddressBook(n.seedRetryTarget())
 }
 
 func (n *Node) runPersistLoop() {
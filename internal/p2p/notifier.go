@@ -21,8 +21,15 @@ func (cn *connNotifier) Connected(_ network.Network, conn network.Conn) {
 	if fn := cn.node.onPeerConnected; fn != nil {
 		go fn()
 	}
+	if conn.Stat().Direction != network.DirOutbound {
+		return
+	}
+	// A connection we dialled proves the peer is reachable.
+	if ps := cn.node.peerStore; ps != nil {
+		go ps.MarkAttempt(remotePeer, true) // Best-effort.
+	}
 	// Initiate handshake for outbound connections only (inbound handled by stream handler).
-	if cn.node.handshakeEnabled && conn.Stat().Direction == network.DirOutbound {
+	if cn.node.handshakeEnabled {
 		go cn.node.doHandshake(remotePeer)
 	}
 }
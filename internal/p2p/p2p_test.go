@@ -525,6 +525,40 @@ func TestTwoNodes_SyncBlocks(t *testing.T) {
 	}
 }
 
+func TestTwoNodes_SyncHeaders(t *testing.T) {
+	nodeA := startTestNode(t)
+	nodeB := startTestNode(t)
+	connectNodes(t, nodeA, nodeB)
+
+	tx0 := &tx.Transaction{Version: 1}
+	syncerA := NewSyncer(nodeA)
+	syncerA.RegisterHandler(func(fromHeight uint64, max uint32) []*block.Block {
+		var result []*block.Block
+		for h := fromHeight; h < 3 && uint32(len(result)) < max; h++ {
+			result = append(result, &block.Block{Header: &block.Header{Height: h, Version: 1}, Transactions: []*tx.Transaction{tx0}})
+		}
+		return result
+	})
+
+	syncerB := NewSyncer(nodeB)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	headers, err := syncerB.RequestHeaders(ctx, nodeA.host.ID(), 1, 10)
+	if err != nil {
+		t.Fatalf("RequestHeaders: %v", err)
+	}
+	if len(headers) != 2 || headers[0].Height != 1 || headers[1].Height != 2 {
+		t.Fatalf("unexpected headers: %+v", headers)
+	}
+
+	// Only headers cross the wire.
+	resp, err := syncerB.fetch(ctx, nodeA.host.ID(), SyncProtocol, SyncRequest{FromHeight: 1, MaxBlocks: 10, HeadersOnly: true})
+	if err != nil || len(resp.Blocks) != 0 || len(resp.Headers) != 2 {
+		t.Errorf("headers-only response: %+v, %v", resp, err)
+	}
+}
+
 func TestTwoNodes_SyncBlocks_Empty(t *testing.T) {
 	nodeA := startTestNode(t)
 	nodeB := startTestNode(t)
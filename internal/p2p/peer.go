@@ -10,5 +10,8 @@ import (
 type Peer struct {
 	ID          peer.ID
 	ConnectedAt time.Time
-	Source      string // "dht", "mdns", "seed", "gossip"
+	Source      string    // "dht", "mdns", "seed", "gossip", "pex"
+	Info        *PeerInfo // From the handshake; nil until it completes.
+
+	SyncReputation int // How well it served blocks (see reputation.go).
 }
@@ -1,12 +1,18 @@
 package p2p
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/Klingon-tech/klingnet-chain/internal/storage"
 	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
 )
 
 const (
@@ -14,19 +20,110 @@ const (
 	staleThreshold    = 24 * time.Hour
 	persistInterval   = 5 * time.Minute
 	maxPersistedPeers = 500
+
+	// maxTriedPeers caps the tried bucket. When it is full, the entry with
+	// the oldest success is demoted to the new bucket.
+	maxTriedPeers = 200
+
+	// maxNewPeers caps the new bucket. When it is full, the entry with the
+	// most failures is evicted.
+	maxNewPeers = maxPersistedPeers - maxTriedPeers
+
+	// maxNewPerNetgroup caps new bucket entries from a single netgroup, so
+	// one network range cannot fill the bucket.
+	maxNewPerNetgroup = 32
+
+	// maxPeerFailures is the number of consecutive failed dials after which
+	// a new entry is dropped and a tried entry is demoted.
+	maxPeerFailures = 5
+
+	// maxGossipAddrs caps how many addresses gossip can accumulate on a new
+	// bucket entry.
+	maxGossipAddrs = 8
 )
 
 // PeerRecord is a persisted peer entry.
+//
+// Records sit in one of two buckets. New entries are addresses we have
+// heard of but never reached; tried entries are peers we have connected
+// to. Outbound selection draws from both, one peer per netgroup.
 type PeerRecord struct {
-	ID       string   `json:"id"`        // base58 peer ID
-	Addrs    []string `json:"addrs"`     // multiaddr strings
-	LastSeen int64    `json:"last_seen"` // unix timestamp
-	Source   string   `json:"source"`    // "dht", "mdns", "seed", "gossip"
+	ID          string   `json:"id"`                     // base58 peer ID
+	Addrs       []string `json:"addrs"`                  // multiaddr strings
+	LastSeen    int64    `json:"last_seen"`              // unix timestamp
+	Source      string   `json:"source"`                 // "dht", "mdns", "seed", "gossip", "pex"
+	Tried       bool     `json:"tried,omitempty"`        // in the tried bucket
+	Successes   int      `json:"successes,omitempty"`    // successful dials
+	Failures    int      `json:"failures,omitempty"`     // failed dials since the last success
+	LastAttempt int64    `json:"last_attempt,omitempty"` // unix timestamp of the last dial
+	LastSuccess int64    `json:"last_success,omitempty"` // unix timestamp of the last successful dial
+}
+
+// Netgroup returns the network range the record's first IP address
+// belongs to: the /16 for IPv4 and the /32 for IPv6. DNS addresses group
+// by name. Records without a usable address return "".
+func (r *PeerRecord) Netgroup() string {
+	for _, s := range r.Addrs {
+		addr, err := ma.NewMultiaddr(s)
+		if err != nil {
+			continue
+		}
+		if g := netgroup(addr); g != "" {
+			return g
+		}
+	}
+	return ""
+}
+
+// netgroup returns the netgroup of a single multiaddr, or "".
+func netgroup(addr ma.Multiaddr) string {
+	for _, c := range addr {
+		switch c.Protocol().Code {
+		case ma.P_IP4:
+			ip := net.ParseIP(c.Value()).To4()
+			if ip == nil {
+				return ""
+			}
+			return fmt.Sprintf("ip4/%d.%d", ip[0], ip[1])
+		case ma.P_IP6:
+			ip := net.ParseIP(c.Value())
+			if ip == nil {
+				return ""
+			}
+			if v4 := ip.To4(); v4 != nil {
+				return fmt.Sprintf("ip4/%d.%d", v4[0], v4[1])
+			}
+			return fmt.Sprintf("ip6/%x", []byte(ip[:4]))
+		case ma.P_DNS, ma.P_DNS4, ma.P_DNS6, ma.P_DNSADDR:
+			return "dns/" + c.Value()
+		}
+	}
+	return ""
+}
+
+// addrInfo builds the dialable AddrInfo for a record.
+func (r *PeerRecord) addrInfo() (peer.AddrInfo, bool) {
+	id, err := peer.Decode(r.ID)
+	if err != nil {
+		return peer.AddrInfo{}, false
+	}
+	info := peer.AddrInfo{ID: id}
+	for _, s := range r.Addrs {
+		addr, err := ma.NewMultiaddr(s)
+		if err != nil {
+			continue
+		}
+		info.Addrs = append(info.Addrs, addr)
+	}
+	return info, len(info.Addrs) > 0
 }
 
 // PeerStore persists peer records in a storage.DB under the "peer/" prefix.
+// Besides plain records it keeps an address book: tried and new buckets,
+// dial counters and netgroup-diverse outbound selection.
 type PeerStore struct {
 	db storage.DB
+	mu sync.Mutex // Serializes read-modify-write updates to the address book.
 }
 
 // NewPeerStore creates a new PeerStore backed by the given DB.
@@ -149,3 +246,278 @@ func (ps *PeerStore) Count() (int, error) {
 	}
 	return count, nil
 }
+
+// AddAddress adds an address learned from another peer to the new bucket.
+// Known peers only have their last-seen time refreshed and, for new
+// bucket entries, addresses they lack appended up to maxGossipAddrs.
+// Gossip never replaces or removes an address and cannot move a peer
+// between buckets; only MarkSeen and MarkAttempt overwrite addresses.
+// Returns true if a new entry was created.
+func (ps *PeerStore) AddAddress(rec PeerRecord) (bool, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	now := time.Now().Unix()
+	if rec.LastSeen > now {
+		rec.LastSeen = now
+	}
+
+	records, err := ps.LoadAll()
+	if err != nil {
+		return false, err
+	}
+	if i := slices.IndexFunc(records, func(r PeerRecord) bool { return r.ID == rec.ID }); i >= 0 {
+		existing := records[i]
+		if rec.LastSeen <= existing.LastSeen {
+			return false, nil
+		}
+		existing.LastSeen = rec.LastSeen
+		if !existing.Tried {
+			for _, a := range rec.Addrs {
+				if len(existing.Addrs) >= maxGossipAddrs {
+					break
+				}
+				if !slices.Contains(existing.Addrs, a) {
+					existing.Addrs = append(existing.Addrs, a)
+				}
+			}
+		}
+		return false, ps.put(existing)
+	}
+
+	addrs := rec.Addrs
+	if len(addrs) > maxGossipAddrs {
+		addrs = addrs[:maxGossipAddrs]
+	}
+	return ps.addNew(records, PeerRecord{ID: rec.ID, Addrs: addrs, LastSeen: rec.LastSeen, Source: rec.Source})
+}
+
+// addNew inserts entry into the new bucket, subject to the netgroup and
+// bucket limits. records is the current contents of the store. Callers
+// must hold ps.mu.
+func (ps *PeerStore) addNew(records []PeerRecord, entry PeerRecord) (bool, error) {
+	group := entry.Netgroup()
+	var fresh []PeerRecord
+	sameGroup := 0
+	for _, r := range records {
+		if r.Tried {
+			continue
+		}
+		fresh = append(fresh, r)
+		if r.Netgroup() == group {
+			sameGroup++
+		}
+	}
+	if sameGroup >= maxNewPerNetgroup {
+		return false, nil
+	}
+	if len(fresh) >= maxNewPeers {
+		worst := slices.MinFunc(fresh, compareQuality)
+		if compareQuality(entry, worst) <= 0 {
+			return false, nil
+		}
+		if err := ps.db.Delete(peerKeyFromString(worst.ID)); err != nil {
+			return false, fmt.Errorf("evict peer: %w", err)
+		}
+	}
+	return true, ps.put(entry)
+}
+
+// MarkSeen records that we are connected to a peer: its addresses and
+// last-seen time are updated. Being connected says nothing about whether
+// the peer can be dialled, so an unknown peer joins the new bucket and
+// only MarkAttempt moves peers to the tried bucket.
+func (ps *PeerStore) MarkSeen(id peer.ID, addrs []string, source string) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	records, err := ps.LoadAll()
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(records, func(r PeerRecord) bool { return r.ID == id.String() })
+	if i < 0 {
+		_, err := ps.addNew(records, PeerRecord{ID: id.String(), Addrs: addrs, LastSeen: time.Now().Unix(), Source: source})
+		return err
+	}
+	rec := records[i]
+	rec.LastSeen = time.Now().Unix()
+	if len(addrs) > 0 {
+		rec.Addrs = addrs
+	}
+	if source != "" {
+		rec.Source = source
+	}
+	return ps.put(rec)
+}
+
+// MarkAttempt records the outcome of an outbound dial. A success moves
+// the peer to the tried bucket, adding it if it was unknown. After
+// maxPeerFailures consecutive failures a tried peer is demoted to the new
+// bucket and a new one is dropped.
+func (ps *PeerStore) MarkAttempt(id peer.ID, ok bool) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	now := time.Now().Unix()
+	if ok {
+		rec, err := ps.loadOrNew(id)
+		if err != nil {
+			return err
+		}
+		rec.LastAttempt = now
+		rec.Successes++
+		rec.Failures = 0
+		rec.LastSuccess = now
+		rec.LastSeen = now
+		if !rec.Tried {
+			if err := ps.makeRoomTried(); err != nil {
+				return err
+			}
+			rec.Tried = true
+		}
+		return ps.put(*rec)
+	}
+
+	rec, err := ps.Load(id)
+	if err != nil {
+		return err
+	}
+	rec.LastAttempt = now
+	rec.Failures++
+	if rec.Failures >= maxPeerFailures {
+		if !rec.Tried {
+			return ps.Delete(id)
+		}
+		rec.Tried = false
+		rec.Failures = 0
+	}
+	return ps.put(*rec)
+}
+
+// Select picks up to n records for outbound dialling, skipping IDs for
+// which skip returns true. Tried and new entries alternate, and each
+// netgroup is used once before any is used twice, so a single network
+// range cannot supply all of our outbound peers.
+func (ps *PeerStore) Select(n int, skip func(peer.ID) bool) ([]PeerRecord, error) {
+	records, err := ps.LoadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var tried, fresh []PeerRecord
+	for _, r := range records {
+		id, err := peer.Decode(r.ID)
+		if err != nil || len(r.Addrs) == 0 || (skip != nil && skip(id)) {
+			continue
+		}
+		if r.Tried {
+			tried = append(tried, r)
+		} else {
+			fresh = append(fresh, r)
+		}
+	}
+	rand.Shuffle(len(tried), func(i, j int) { tried[i], tried[j] = tried[j], tried[i] })
+	rand.Shuffle(len(fresh), func(i, j int) { fresh[i], fresh[j] = fresh[j], fresh[i] })
+
+	// Interleave the buckets, then take one per netgroup in that order
+	// before filling up with the rest.
+	candidates := make([]PeerRecord, 0, len(tried)+len(fresh))
+	for i := 0; i < len(tried) || i < len(fresh); i++ {
+		if i < len(tried) {
+			candidates = append(candidates, tried[i])
+		}
+		if i < len(fresh) {
+			candidates = append(candidates, fresh[i])
+		}
+	}
+
+	var out []PeerRecord
+	used := make(map[string]bool)
+	picked := make([]bool, len(candidates))
+	for i, r := range candidates {
+		if len(out) >= n {
+			return out, nil
+		}
+		g := r.Netgroup()
+		if used[g] {
+			continue
+		}
+		used[g] = true
+		picked[i] = true
+		out = append(out, r)
+	}
+	for i, r := range candidates {
+		if len(out) >= n {
+			break
+		}
+		if !picked[i] {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// Shareable returns up to max tried records, most recently seen first,
+// for sharing with other peers.
+func (ps *PeerStore) Shareable(max int) ([]PeerRecord, error) {
+	records, err := ps.LoadAll()
+	if err != nil {
+		return nil, err
+	}
+	records = slices.DeleteFunc(records, func(r PeerRecord) bool { return !r.Tried || len(r.Addrs) == 0 })
+	slices.SortFunc(records, func(a, b PeerRecord) int { return cmp.Compare(b.LastSeen, a.LastSeen) })
+	if len(records) > max {
+		records = records[:max]
+	}
+	return records, nil
+}
+
+// compareQuality orders records from worst to best: more consecutive
+// failures first, then the oldest last-seen time.
+func compareQuality(a, b PeerRecord) int {
+	if c := cmp.Compare(b.Failures, a.Failures); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.LastSeen, b.LastSeen)
+}
+
+// makeRoomTried demotes the tried entry with the oldest success when the
+// tried bucket is full. Callers hold ps.mu.
+func (ps *PeerStore) makeRoomTried() error {
+	records, err := ps.LoadAll()
+	if err != nil {
+		return err
+	}
+	tried := slices.DeleteFunc(records, func(r PeerRecord) bool { return !r.Tried })
+	if len(tried) < maxTriedPeers {
+		return nil
+	}
+	oldest := slices.MinFunc(tried, func(a, b PeerRecord) int {
+		return cmp.Compare(max(a.LastSuccess, a.LastSeen), max(b.LastSuccess, b.LastSeen))
+	})
+	oldest.Tried = false
+	return ps.put(oldest)
+}
+
+// loadOrNew loads a record, or returns an empty one for an unknown peer.
+func (ps *PeerStore) loadOrNew(id peer.ID) (*PeerRecord, error) {
+	has, err := ps.db.Has(peerKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("check peer exists: %w", err)
+	}
+	if !has {
+		return &PeerRecord{ID: id.String()}, nil
+	}
+	return ps.Load(id)
+}
+
+// put writes a record without the capacity check in Save; the address
+// book enforces its own bucket limits.
+func (ps *PeerStore) put(rec PeerRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal peer record: %w", err)
+	}
+	return ps.db.Put(peerKeyFromString(rec.ID), data)
+}
@@ -1,6 +1,8 @@
 package p2p
 
 import (
+	"fmt"
+	"slices"
 	"testing"
 	"time"
 
@@ -238,3 +240,219 @@ func TestPeerStore_Empty(t *testing.T) {
 		t.Errorf("expected 0 records, got %d", len(all))
 	}
 }
+
+func TestPeerRecord_Netgroup(t *testing.T) {
+	tests := []struct {
+		addrs []string
+		want  string
+	}{
+		{[]string{"/ip4/192.168.1.1/tcp/4001"}, "ip4/192.168"},
+		{[]string{"/ip4/192.168.200.7/udp/4001/quic-v1"}, "ip4/192.168"},
+		{[]string{"/ip6/2001:db8:1::1/tcp/4001"}, "ip6/20010db8"},
+		{[]string{"/dns4/seed.example.org/tcp/4001"}, "dns/seed.example.org"},
+		{[]string{"garbage", "/ip4/10.1.2.3/tcp/1"}, "ip4/10.1"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		rec := PeerRecord{Addrs: tt.addrs}
+		if got := rec.Netgroup(); got != tt.want {
+			t.Errorf("Netgroup(%v) = %q, want %q", tt.addrs, got, tt.want)
+		}
+	}
+}
+
+func TestPeerStore_AddressBookBuckets(t *testing.T) {
+	ps := newTestPeerStore()
+	id := generateTestPeerID(t)
+	rec := PeerRecord{ID: id.String(), Addrs: []string{"/ip4/10.0.0.1/tcp/4001"}, LastSeen: time.Now().Unix(), Source: "pex"}
+
+	added, err := ps.AddAddress(rec)
+	if err != nil || !added {
+		t.Fatalf("AddAddress = %v, %v", added, err)
+	}
+	if added, _ := ps.AddAddress(rec); added {
+		t.Error("known peer added twice")
+	}
+
+	// A successful dial moves the peer to the tried bucket.
+	if err := ps.MarkAttempt(id, true); err != nil {
+		t.Fatalf("MarkAttempt: %v", err)
+	}
+	loaded, _ := ps.Load(id)
+	if !loaded.Tried || loaded.Successes != 1 || loaded.LastSuccess == 0 {
+		t.Fatalf("after success: %+v", loaded)
+	}
+
+	// Gossip about a tried peer does not demote it.
+	rec.LastSeen = time.Now().Unix() + 1
+	ps.AddAddress(rec)
+	if loaded, _ := ps.Load(id); !loaded.Tried {
+		t.Error("AddAddress demoted a tried peer")
+	}
+
+	// Repeated failures demote a tried peer, then drop it.
+	for i := 0; i < maxPeerFailures; i++ {
+		ps.MarkAttempt(id, false)
+	}
+	loaded, err = ps.Load(id)
+	if err != nil || loaded.Tried {
+		t.Fatalf("after failures: %+v, %v", loaded, err)
+	}
+	for i := 0; i < maxPeerFailures; i++ {
+		ps.MarkAttempt(id, false)
+	}
+	if _, err := ps.Load(id); err == nil {
+		t.Error("failing new peer was not dropped")
+	}
+}
+
+func TestPeerStore_MarkSeen(t *testing.T) {
+	ps := newTestPeerStore()
+	id := generateTestPeerID(t)
+
+	if err := ps.MarkSeen(id, []string{"/ip4/10.0.0.1/tcp/4001"}, "dht"); err != nil {
+		t.Fatalf("MarkSeen: %v", err)
+	}
+	rec, err := ps.Load(id)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if rec.Source != "dht" || rec.Tried || len(rec.Addrs) != 1 {
+		t.Errorf("connected peer should be a new entry, got %+v", rec)
+	}
+
+	// Being seen again does not promote it; a successful dial does.
+	ps.MarkSeen(id, nil, "")
+	if shared, _ := ps.Shareable(10); len(shared) != 0 {
+		t.Errorf("Shareable = %v, want none", shared)
+	}
+	ps.MarkAttempt(id, true)
+	shared, err := ps.Shareable(10)
+	if err != nil || len(shared) != 1 || shared[0].ID != id.String() {
+		t.Fatalf("Shareable = %v, %v", shared, err)
+	}
+}
+
+func TestPersistPeers_InboundStaysNew(t *testing.T) {
+	newNode := func() *Node {
+		n := New(Config{ListenAddr: "127.0.0.1", NoDiscover: true, DB: storage.NewMemory()})
+		if err := n.Start(); err != nil {
+			t.Fatalf("start node: %v", err)
+		}
+		t.Cleanup(func() { n.Stop() })
+		return n
+	}
+	a, b := newNode(), newNode()
+	connectNodes(t, a, b) // b dials a.
+
+	a.persistPeers()
+	if rec, err := a.peerStore.Load(b.host.ID()); err != nil || rec.Tried {
+		t.Errorf("inbound peer: %+v, %v; want a new entry", rec, err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		b.persistPeers()
+		rec, err := b.peerStore.Load(a.host.ID())
+		if err == nil && rec.Tried && len(rec.Addrs) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("dialled peer: %+v, %v; want a tried entry", rec, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestPeerStore_AddAddressKeepsAddrs(t *testing.T) {
+	ps := newTestPeerStore()
+	id := generateTestPeerID(t)
+	now := time.Now().Unix()
+	ps.AddAddress(PeerRecord{ID: id.String(), Addrs: []string{"/ip4/10.0.0.1/tcp/4001"}, LastSeen: now - 20})
+
+	// Newer gossip appends addresses but never replaces existing ones.
+	ps.AddAddress(PeerRecord{ID: id.String(), Addrs: []string{"/ip4/10.9.9.9/tcp/4001", "/ip4/10.0.0.1/tcp/4001"}, LastSeen: now - 10})
+	loaded, _ := ps.Load(id)
+	want := []string{"/ip4/10.0.0.1/tcp/4001", "/ip4/10.9.9.9/tcp/4001"}
+	if !slices.Equal(loaded.Addrs, want) {
+		t.Fatalf("Addrs = %v, want %v", loaded.Addrs, want)
+	}
+
+	// Appends stop at maxGossipAddrs.
+	var many []string
+	for i := range 2 * maxGossipAddrs {
+		many = append(many, fmt.Sprintf("/ip4/10.1.0.%d/tcp/4001", i+1))
+	}
+	ps.AddAddress(PeerRecord{ID: id.String(), Addrs: many, LastSeen: now - 5})
+	loaded, _ = ps.Load(id)
+	if len(loaded.Addrs) != maxGossipAddrs || loaded.Addrs[0] != want[0] {
+		t.Errorf("Addrs after flood = %v", loaded.Addrs)
+	}
+
+	// A dial-confirmed address is kept as is.
+	ps.MarkSeen(id, []string{"/ip4/10.2.0.1/tcp/4001"}, "dial")
+	ps.MarkAttempt(id, true)
+	ps.AddAddress(PeerRecord{ID: id.String(), Addrs: []string{"/ip4/10.3.0.1/tcp/4001"}, LastSeen: now})
+	loaded, _ = ps.Load(id)
+	if !slices.Equal(loaded.Addrs, []string{"/ip4/10.2.0.1/tcp/4001"}) {
+		t.Errorf("tried Addrs = %v", loaded.Addrs)
+	}
+}
+
+func TestPeerStore_AddAddressNetgroupCap(t *testing.T) {
+	ps := newTestPeerStore()
+	now := time.Now().Unix()
+	for i := 0; i < maxNewPerNetgroup+5; i++ {
+		ps.AddAddress(PeerRecord{
+			ID:       generateTestPeerID(t).String(),
+			Addrs:    []string{fmt.Sprintf("/ip4/10.5.%d.%d/tcp/4001", i/200, i%200+1)},
+			LastSeen: now,
+		})
+	}
+	if count, _ := ps.Count(); count != maxNewPerNetgroup {
+		t.Errorf("count = %d, want %d", count, maxNewPerNetgroup)
+	}
+
+	// Other netgroups are still accepted.
+	added, err := ps.AddAddress(PeerRecord{ID: generateTestPeerID(t).String(), Addrs: []string{"/ip4/10.6.0.1/tcp/4001"}, LastSeen: now})
+	if err != nil || !added {
+		t.Errorf("AddAddress from another netgroup = %v, %v", added, err)
+	}
+}
+
+func TestPeerStore_SelectDiversity(t *testing.T) {
+	ps := newTestPeerStore()
+	now := time.Now().Unix()
+
+	// Many peers in one /16, one in each of three others.
+	for i := 0; i < 10; i++ {
+		ps.AddAddress(PeerRecord{ID: generateTestPeerID(t).String(), Addrs: []string{fmt.Sprintf("/ip4/10.0.0.%d/tcp/4001", i+1)}, LastSeen: now})
+	}
+	for _, ip := range []string{"172.16.0.1", "192.168.0.1", "8.8.8.8"} {
+		id := generateTestPeerID(t)
+		ps.MarkSeen(id, []string{"/ip4/" + ip + "/tcp/4001"}, "dht")
+	}
+
+	selected, err := ps.Select(4, nil)
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	groups := make(map[string]bool)
+	for _, r := range selected {
+		groups[r.Netgroup()] = true
+	}
+	if len(selected) != 4 || len(groups) != 4 {
+		t.Errorf("selected %d peers from %d netgroups, want 4 and 4", len(selected), len(groups))
+	}
+
+	// Once every netgroup is used, the rest are filled in.
+	if selected, _ := ps.Select(8, nil); len(selected) != 8 {
+		t.Errorf("selected %d peers, want 8", len(selected))
+	}
+
+	// Skipped peers are never selected.
+	selected, _ = ps.Select(20, func(peer.ID) bool { return true })
+	if len(selected) != 0 {
+		t.Errorf("selected %d skipped peers", len(selected))
+	}
+}
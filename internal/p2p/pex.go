@@ -0,0 +1,221 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"time"
+
+	klog "github.com/Klingon-tech/klingnet-chain/internal/log"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// Peer exchange lets connected peers share addresses from their tried
+// buckets. Learned addresses go into our new bucket, which together with
+// netgroup-diverse selection keeps us reachable to honest peers when the
+// DHT is unavailable or poisoned.
+
+const (
+	// PexProtocol is the protocol ID for peer address exchange.
+	PexProtocol = protocol.ID("/klingnet/pex/1.0.0")
+
+	// pexInterval is how often we ask connected peers for addresses and
+	// top up outbound connections from the address book.
+	pexInterval = 2 * time.Minute
+
+	// pexPeersPerRound is how many connected peers are asked per round.
+	pexPeersPerRound = 3
+
+	// maxPexAddrs caps the records in a response, in both directions.
+	maxPexAddrs = 64
+
+	// maxPexAddrsPerPeer caps the addresses accepted for a single record.
+	maxPexAddrsPerPeer = 8
+
+	// pexReadTimeout is the max time to read a peer exchange response.
+	pexReadTimeout = 10 * time.Second
+
+	// maxPexResponseBytes limits the size of a peer exchange response.
+	maxPexResponseBytes = 64 * 1024
+)
+
+// PexAddr is one peer shared over the peer exchange protocol.
+type PexAddr struct {
+	ID       string   `json:"id"`
+	Addrs    []string `json:"addrs"`
+	LastSeen int64    `json:"last_seen"`
+}
+
+// PexResponse is the reply to a peer exchange request.
+type PexResponse struct {
+	Peers []PexAddr `json:"peers"`
+}
+
+// registerPexHandler serves tried addresses to peers that ask. Hidden and
+// private peers, and the requester itself, are never shared.
+func (n *Node) registerPexHandler() {
+	n.host.SetStreamHandler(PexProtocol, func(stream network.Stream) {
+		defer stream.Close()
+
+		remote := stream.Conn().RemotePeer()
+		records, err := n.peerStore.Shareable(maxPexAddrs + len(n.hiddenPeers) + 1)
+		if err != nil {
+			return
+		}
+		var resp PexResponse
+		for _, rec := range records {
+			if len(resp.Peers) >= maxPexAddrs {
+				break
+			}
+			id, err := peer.Decode(rec.ID)
+			if err != nil || id == remote || n.isHiddenPeer(id) {
+				continue
+			}
+			resp.Peers = append(resp.Peers, PexAddr{ID: rec.ID, Addrs: rec.Addrs, LastSeen: rec.LastSeen})
+		}
+		json.NewEncoder(stream).Encode(&resp)
+	})
+}
+
+// RequestPeers asks a peer for addresses it knows to be good.
+func (n *Node) RequestPeers(ctx context.Context, peerID peer.ID) ([]PexAddr, error) {
+	stream, err := n.host.NewStream(ctx, peerID, PexProtocol)
+	if err != nil {
+		return nil, fmt.Errorf("open pex stream: %w", err)
+	}
+	defer stream.Close()
+
+	// Signal we're done writing (request is empty, just opening the stream).
+	stream.CloseWrite()
+
+	_ = stream.SetReadDeadline(time.Now().Add(pexReadTimeout))
+
+	var resp PexResponse
+	if err := json.NewDecoder(io.LimitReader(stream, maxPexResponseBytes)).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("read pex response: %w", err)
+	}
+	if len(resp.Peers) > maxPexAddrs {
+		resp.Peers = resp.Peers[:maxPexAddrs]
+	}
+	return resp.Peers, nil
+}
+
+// learnPeers adds addresses received from another peer to the new bucket.
+// Returns the number of new entries.
+func (n *Node) learnPeers(addrs []PexAddr) int {
+	added := 0
+	for _, a := range addrs {
+		id, err := peer.Decode(a.ID)
+		if err != nil || id == n.host.ID() || n.isHiddenPeer(id) {
+			continue
+		}
+		if n.BanManager != nil && n.BanManager.IsBanned(id) {
+			continue
+		}
+		var valid []string
+		for _, s := range a.Addrs {
+			if len(valid) >= maxPexAddrsPerPeer {
+				break
+			}
+			if _, err := ma.NewMultiaddr(s); err == nil {
+				valid = append(valid, s)
+			}
+		}
+		if len(valid) == 0 {
+			continue
+		}
+		ok, err := n.peerStore.AddAddress(PeerRecord{ID: a.ID, Addrs: valid, LastSeen: a.LastSeen, Source: "pex"})
+		if err == nil && ok {
+			added++
+		}
+	}
+	return added
+}
+
+// runPeerExchange periodically asks a few connected peers for addresses
+// and dials from the address book while below the peer target.
+func (n *Node) runPeerExchange() {
+	logger := klog.WithComponent("p2p")
+	ticker := time.NewTicker(pexInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-ticker.C:
+			added := 0
+			for _, id := range n.pexTargets() {
+				ctx, cancel := context.WithTimeout(n.ctx, pexReadTimeout)
+				addrs, err := n.RequestPeers(ctx, id)
+				cancel()
+				if err != nil {
+					continue
+				}
+				added += n.learnPeers(addrs)
+			}
+			if added > 0 {
+				logger.Debug().Int("added", added).Msg("Learned peer addresses")
+			}
+			if n.PeerCount() < n.seedRetryTarget() {
+				n.dialAddressBook(n.seedRetryTarget() - n.PeerCount())
+			}
+		}
+	}
+}
+
+// pexTargets returns up to pexPeersPerRound random connected peers.
+func (n *Node) pexTargets() []peer.ID {
+	n.mu.RLock()
+	ids := make([]peer.ID, 0, len(n.peers))
+	for id := range n.peers {
+		ids = append(ids, id)
+	}
+	n.mu.RUnlock()
+
+	rand.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+	if len(ids) > pexPeersPerRound {
+		ids = ids[:pexPeersPerRound]
+	}
+	return ids
+}
+
+// dialAddressBook dials up to count peers picked from the address book
+// and records each outcome.
+func (n *Node) dialAddressBook(count int) {
+	records, err := n.peerStore.Select(count, func(id peer.ID) bool {
+		return id == n.host.ID() ||
+			n.host.Network().Connectedness(id) == network.Connected ||
+			(n.BanManager != nil && n.BanManager.IsBanned(id))
+	})
+	if err != nil {
+		return
+	}
+
+	for _, rec := range records {
+		info, ok := rec.addrInfo()
+		if !ok {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(n.ctx, peerConnectTimeout)
+		err := n.host.Connect(ctx, info)
+		cancel()
+		if n.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			n.peerStore.MarkAttempt(info.ID, false) // Best-effort; successes are recorded on connect.
+			continue
+		}
+		n.mu.Lock()
+		if p, ok := n.peers[info.ID]; ok && p.Source == "" {
+			p.Source = rec.Source
+		}
+		n.mu.Unlock()
+	}
+}
@@ -0,0 +1,54 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingnet-chain/internal/storage"
+)
+
+func TestPeerExchange(t *testing.T) {
+	known := generateTestPeerID(t)
+	hidden := generateTestPeerID(t)
+
+	a := New(Config{ListenAddr: "127.0.0.1", NoDiscover: true, DB: storage.NewMemory(), HiddenPeers: []string{hidden.String()}})
+	if err := a.Start(); err != nil {
+		t.Fatalf("start a: %v", err)
+	}
+	t.Cleanup(func() { a.Stop() })
+	a.peerStore.MarkSeen(known, []string{"/ip4/10.1.2.3/tcp/4001"}, "dht")
+	a.peerStore.MarkSeen(hidden, []string{"/ip4/10.9.9.9/tcp/4001"}, "dht")
+	a.peerStore.MarkAttempt(known, true)
+	a.peerStore.MarkAttempt(hidden, true)
+
+	b := New(Config{ListenAddr: "127.0.0.1", NoDiscover: true, DB: storage.NewMemory()})
+	if err := b.Start(); err != nil {
+		t.Fatalf("start b: %v", err)
+	}
+	t.Cleanup(func() { b.Stop() })
+	connectNodes(t, a, b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := b.RequestPeers(ctx, a.host.ID())
+	if err != nil {
+		t.Fatalf("RequestPeers: %v", err)
+	}
+	for _, addr := range addrs {
+		if addr.ID == hidden.String() {
+			t.Error("hidden peer was shared")
+		}
+	}
+	if got := b.learnPeers(addrs); got != 1 {
+		t.Fatalf("learned %d peers, want 1", got)
+	}
+
+	rec, err := b.peerStore.Load(known)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if rec.Tried || rec.Source != "pex" || len(rec.Addrs) != 1 {
+		t.Errorf("learned peer should be a new pex entry, got %+v", rec)
+	}
+}
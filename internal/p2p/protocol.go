@@ -8,8 +8,8 @@ import (
 
 // GossipSub topic names.
 const (
-	TopicTransactions = "/klingnet/tx/1.0.0"
-	TopicBlocks       = "/klingnet/block/1.0.0"
+	TopicTransactions = "/klingnet/txinv/1.0.0"      // Transaction hash announcements.
+	TopicBlocks       = "/klingnet/cmpctblock/1.0.0" // Compact blocks.
 	TopicHeartbeat    = "/klingnet/heartbeat/1.0.0"
 )
 
@@ -20,13 +20,25 @@ const (
 
 	// ProtocolVersion is the current protocol version advertised during handshake.
 	// v2: fixed sync/reorg bugs that caused nodes to get stuck with orphan blocks.
-	ProtocolVersion uint32 = 2
+	// v3: transaction announcements and compact blocks replace full gossip.
+	// v4: signed handshake with capabilities, sub-chains and user agent.
+	ProtocolVersion uint32 = 4
 
 	// MinProtocolVersion is the minimum protocol version we accept from peers.
-	// v2 required: v1 peers may have corrupted block stores that return empty batches.
-	MinProtocolVersion uint32 = 2
+	// v3 required: older peers gossip full transactions and blocks on topics
+	// v3 peers no longer read.
+	MinProtocolVersion uint32 = 3
+
+	// SignedHandshakeVersion is the first protocol version whose handshake
+	// is signed and carries capabilities. Older peers are still accepted,
+	// but their capabilities are unknown.
+	SignedHandshakeVersion uint32 = 4
 )
 
+// Version is the software version in the handshake user agent, set at
+// build time with -ldflags "-X github.com/Klingon-tech/klingnet-chain/internal/p2p.Version=v1.2.3".
+var Version = "dev"
+
 // SubChainBlockTopic returns the GossipSub topic for a sub-chain's blocks.
 func SubChainBlockTopic(chainIDHex string) string {
 	return fmt.Sprintf("/klingnet/sc/%s/block/1.0.0", chainIDHex)
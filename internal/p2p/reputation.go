@@ -0,0 +1,156 @@
+package p2p
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	klog "github.com/Klingon-tech/klingnet-chain/internal/log"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Sync reputation scores peers by how well they served blocks. Sync and
+// fork resolution try peers in reputation order, and peers that lie about
+// their chain or sit on a dead fork are isolated from sync for a while
+// instead of being retried every round. Unlike bans, isolation does not
+// disconnect: the peer may be honest but behind.
+
+const (
+	// maxSyncReputation and minSyncReputation bound the score.
+	maxSyncReputation = 100
+	minSyncReputation = -100
+
+	// syncIsolateThreshold isolates a peer whose score drops to it.
+	syncIsolateThreshold = -50
+
+	// syncIsolationDuration is how long an isolated peer is skipped.
+	syncIsolationDuration = 10 * time.Minute
+)
+
+// ErrPeerIsolated is returned when requesting sync data from an isolated peer.
+var ErrPeerIsolated = errors.New("peer is isolated from sync")
+
+// SyncOutcome is the result of one sync exchange with a peer.
+type SyncOutcome int
+
+const (
+	SyncDelivered    SyncOutcome = iota // Served blocks that validated.
+	SyncEmpty                           // Served an empty or non-contiguous batch.
+	SyncTimeout                         // Did not answer in time.
+	SyncInvalid                         // Served blocks that failed validation.
+	SyncForkMismatch                    // Its fork lost to our validated chain.
+	SyncFalseHeight                     // Claimed a height or tip its blocks contradict.
+)
+
+// syncOutcomeScores is the reputation change of each outcome.
+var syncOutcomeScores = map[SyncOutcome]int{
+	SyncDelivered:    1,
+	SyncEmpty:        -5,
+	SyncTimeout:      -5,
+	SyncInvalid:      -25,
+	SyncForkMismatch: -20,
+	SyncFalseHeight:  -20,
+}
+
+// String returns the outcome name used in logs.
+func (o SyncOutcome) String() string {
+	switch o {
+	case SyncDelivered:
+		return "delivered"
+	case SyncEmpty:
+		return "empty batch"
+	case SyncTimeout:
+		return "timeout"
+	case SyncInvalid:
+		return "invalid blocks"
+	case SyncForkMismatch:
+		return "dead fork"
+	case SyncFalseHeight:
+		return "false height"
+	default:
+		return "unknown"
+	}
+}
+
+// isolates reports whether the outcome isolates the peer regardless of
+// its score: its fork lost to headers we validated. A false height alone
+// does not, since an honest peer can reorg between our queries; repeated
+// ones drive its score down to syncIsolateThreshold instead.
+func (o SyncOutcome) isolates() bool {
+	return o == SyncForkMismatch
+}
+
+// RecordSyncOutcome updates a connected peer's sync reputation and
+// isolates it when its fork lost to our chain or its score drops to
+// syncIsolateThreshold.
+func (n *Node) RecordSyncOutcome(id peer.ID, outcome SyncOutcome) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	score := 0
+	if p, ok := n.peers[id]; ok {
+		p.SyncReputation = max(minSyncReputation, min(maxSyncReputation, p.SyncReputation+syncOutcomeScores[outcome]))
+		score = p.SyncReputation
+	}
+	if !outcome.isolates() && score > syncIsolateThreshold {
+		return
+	}
+	if n.isolated == nil {
+		n.isolated = make(map[peer.ID]time.Time)
+	}
+	n.isolated[id] = time.Now().Add(syncIsolationDuration)
+
+	logger := klog.WithComponent("p2p")
+	logger.Info().
+		Str("peer", id.String()[:16]).
+		Str("outcome", outcome.String()).
+		Int("reputation", score).
+		Dur("for", syncIsolationDuration).
+		Msg("Isolating peer from sync")
+}
+
+// IsIsolated reports whether a peer is currently isolated from sync.
+func (n *Node) IsIsolated(id peer.ID) bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.isIsolatedLocked(id, time.Now())
+}
+
+// isIsolatedLocked is IsIsolated with n.mu held.
+func (n *Node) isIsolatedLocked(id peer.ID, now time.Time) bool {
+	until, ok := n.isolated[id]
+	return ok && now.Before(until)
+}
+
+// SyncPeers returns copies of the connected peers that are not isolated,
+// best sync reputation first.
+func (n *Node) SyncPeers() []*Peer {
+	return n.syncPeers(func(*Peer) bool { return true })
+}
+
+// syncPeers returns copies of the non-isolated peers accepted by keep,
+// best sync reputation first. Expired isolations are dropped.
+func (n *Node) syncPeers(keep func(*Peer) bool) []*Peer {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now()
+	for id, until := range n.isolated {
+		if !now.Before(until) {
+			delete(n.isolated, id)
+		}
+	}
+
+	var out []*Peer
+	for id, p := range n.peers {
+		if n.isIsolatedLocked(id, now) || !keep(p) {
+			continue
+		}
+		cp := *p
+		out = append(out, &cp)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].SyncReputation > out[j].SyncReputation
+	})
+	return out
+}
@@ -0,0 +1,140 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestRecordSyncOutcome_Reputation(t *testing.T) {
+	n := New(Config{ListenAddr: "127.0.0.1", Port: 0})
+	id := generateTestPeerID(t)
+	n.addPeer(id)
+
+	n.RecordSyncOutcome(id, SyncDelivered)
+	n.RecordSyncOutcome(id, SyncDelivered)
+	n.RecordSyncOutcome(id, SyncTimeout)
+	if got := n.PeerList()[0].SyncReputation; got != 2-5 {
+		t.Errorf("reputation: got %d, want %d", got, 2-5)
+	}
+	if n.IsIsolated(id) {
+		t.Error("peer isolated above threshold")
+	}
+
+	// Repeated invalid blocks drive the score to the threshold.
+	for i := 0; i < 10; i++ {
+		n.RecordSyncOutcome(id, SyncInvalid)
+	}
+	if got := n.PeerList()[0].SyncReputation; got != minSyncReputation {
+		t.Errorf("reputation: got %d, want clamped to %d", got, minSyncReputation)
+	}
+	if !n.IsIsolated(id) {
+		t.Error("peer below threshold not isolated")
+	}
+
+	// Good service is capped too.
+	other := generateTestPeerID(t)
+	n.addPeer(other)
+	for i := 0; i < maxSyncReputation+10; i++ {
+		n.RecordSyncOutcome(other, SyncDelivered)
+	}
+	for _, p := range n.PeerList() {
+		if p.ID == other && p.SyncReputation != maxSyncReputation {
+			t.Errorf("reputation: got %d, want clamped to %d", p.SyncReputation, maxSyncReputation)
+		}
+	}
+}
+
+func TestRecordSyncOutcome_ForkMismatchIsolates(t *testing.T) {
+	n := New(Config{ListenAddr: "127.0.0.1", Port: 0})
+	id := generateTestPeerID(t)
+	n.addPeer(id)
+	for i := 0; i < 20; i++ {
+		n.RecordSyncOutcome(id, SyncDelivered)
+	}
+
+	n.RecordSyncOutcome(id, SyncForkMismatch)
+	if !n.IsIsolated(id) {
+		t.Error("peer on a dead fork not isolated")
+	}
+}
+
+func TestRecordSyncOutcome_FalseHeightRepeated(t *testing.T) {
+	n := New(Config{ListenAddr: "127.0.0.1", Port: 0})
+	id := generateTestPeerID(t)
+	n.addPeer(id)
+
+	// A single false claim may be a race with the peer's own reorg.
+	n.RecordSyncOutcome(id, SyncFalseHeight)
+	if n.IsIsolated(id) {
+		t.Fatal("peer isolated after one false claim")
+	}
+	for i := 0; i < 2; i++ {
+		n.RecordSyncOutcome(id, SyncFalseHeight)
+	}
+	if !n.IsIsolated(id) {
+		t.Error("peer with repeated false claims not isolated")
+	}
+}
+
+func TestRecordSyncOutcome_IsolationOutlivesConnection(t *testing.T) {
+	n := New(Config{ListenAddr: "127.0.0.1", Port: 0})
+	id := generateTestPeerID(t)
+	n.addPeer(id)
+	n.RecordSyncOutcome(id, SyncForkMismatch)
+
+	n.removePeer(id)
+	n.addPeer(id)
+	if !n.IsIsolated(id) {
+		t.Error("reconnecting lifted the isolation")
+	}
+	if len(n.SyncPeers()) != 0 {
+		t.Error("isolated peer offered for sync")
+	}
+
+	// Expired isolations are lifted.
+	n.mu.Lock()
+	n.isolated[id] = time.Now().Add(-time.Second)
+	n.mu.Unlock()
+	if n.IsIsolated(id) || len(n.SyncPeers()) != 1 {
+		t.Error("expired isolation still applied")
+	}
+}
+
+func TestSyncPeers_ReputationOrder(t *testing.T) {
+	n := New(Config{ListenAddr: "127.0.0.1", Port: 0})
+	good, fair, poor, isolated := generateTestPeerID(t), generateTestPeerID(t), generateTestPeerID(t), generateTestPeerID(t)
+	for _, id := range []peer.ID{good, fair, poor, isolated} {
+		n.addPeer(id)
+	}
+	for i := 0; i < 5; i++ {
+		n.RecordSyncOutcome(good, SyncDelivered)
+	}
+	n.RecordSyncOutcome(poor, SyncEmpty)
+	n.RecordSyncOutcome(isolated, SyncForkMismatch)
+
+	peers := n.SyncPeers()
+	if len(peers) != 3 {
+		t.Fatalf("got %d sync peers, want 3", len(peers))
+	}
+	for i, want := range []peer.ID{good, fair, poor} {
+		if peers[i].ID != want {
+			t.Errorf("position %d: got %s, want %s", i, peers[i].ID, want)
+		}
+	}
+}
+
+func TestSyncer_RequestBlocksIsolated(t *testing.T) {
+	n := New(Config{ListenAddr: "127.0.0.1", Port: 0})
+	id := generateTestPeerID(t)
+	n.addPeer(id)
+	n.RecordSyncOutcome(id, SyncForkMismatch)
+
+	_, err := NewSyncer(n).RequestBlocks(context.Background(), id, 1, 10)
+	if !errors.Is(err, ErrPeerIsolated) {
+		t.Errorf("got %v, want ErrPeerIsolated", err)
+	}
+}
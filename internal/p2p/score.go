@@ -0,0 +1,165 @@
+package p2p
+
+import (
+	"net"
+	"time"
+
+	klog "github.com/Klingon-tech/klingnet-chain/internal/log"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// GossipSub peer score thresholds. Below gossipThreshold a peer gets no
+// gossip from us, below publishThreshold none of our own messages, and
+// below graylistThreshold its messages are dropped unread.
+const (
+	gossipThreshold   = -500
+	publishThreshold  = -1000
+	graylistThreshold = -2500
+
+	// offenseScoreWeight turns a peer's BanManager offense score into an
+	// application-specific GossipSub score: a peer halfway to a ban is
+	// close to losing gossip.
+	offenseScoreWeight = -5
+	// bannedScore keeps banned peers, until disconnected, graylisted.
+	bannedScore = 2 * graylistThreshold
+
+	// scoreInspectInterval is how often gossip scores are checked for
+	// peers to penalise in the BanManager.
+	scoreInspectInterval = 30 * time.Second
+)
+
+// peerScoreParams returns the GossipSub scoring parameters. Root-chain
+// topics are scored here; sub-chain topics get theirs when joined.
+func (n *Node) peerScoreParams() *pubsub.PeerScoreParams {
+	return &pubsub.PeerScoreParams{
+		SkipAtomicValidation: true,
+		Topics: map[string]*pubsub.TopicScoreParams{
+			TopicBlocks:       blockTopicScore(),
+			TopicTransactions: txTopicScore(),
+			TopicHeartbeat:    heartbeatTopicScore(),
+		},
+		TopicScoreCap:     100,
+		AppSpecificScore:  n.offenseScore,
+		AppSpecificWeight: 1,
+
+		// Many peers behind one IP are likely one operator; local
+		// addresses are exempt for tests and private networks.
+		IPColocationFactorWeight:    -5,
+		IPColocationFactorThreshold: 10,
+		IPColocationFactorWhitelist: []*net.IPNet{
+			{IP: net.IPv4(127, 0, 0, 0), Mask: net.CIDRMask(8, 32)},
+			{IP: net.IPv6loopback, Mask: net.CIDRMask(128, 128)},
+		},
+
+		// Protocol misbehaviour such as broken promises of IHAVE gossip.
+		BehaviourPenaltyWeight:    -10,
+		BehaviourPenaltyThreshold: 6,
+		BehaviourPenaltyDecay:     pubsub.ScoreParameterDecay(10 * time.Minute),
+
+		DecayInterval: pubsub.DefaultDecayInterval,
+		DecayToZero:   pubsub.DefaultDecayToZero,
+		RetainScore:   time.Hour,
+	}
+}
+
+// peerScoreThresholds returns the GossipSub score thresholds.
+func peerScoreThresholds() *pubsub.PeerScoreThresholds {
+	return &pubsub.PeerScoreThresholds{
+		GossipThreshold:   gossipThreshold,
+		PublishThreshold:  publishThreshold,
+		GraylistThreshold: graylistThreshold,
+		AcceptPXThreshold: 10,
+	}
+}
+
+// blockTopicScore scores block topics: first deliveries of valid blocks
+// earn the most, invalid blocks cost the most.
+func blockTopicScore() *pubsub.TopicScoreParams {
+	return &pubsub.TopicScoreParams{
+		SkipAtomicValidation: true,
+		TopicWeight:          1,
+
+		TimeInMeshWeight:  0.01,
+		TimeInMeshQuantum: time.Second,
+		TimeInMeshCap:     3600,
+
+		FirstMessageDeliveriesWeight: 1,
+		FirstMessageDeliveriesDecay:  pubsub.ScoreParameterDecay(time.Hour),
+		FirstMessageDeliveriesCap:    50,
+
+		InvalidMessageDeliveriesWeight: -100,
+		InvalidMessageDeliveriesDecay:  pubsub.ScoreParameterDecay(time.Hour),
+	}
+}
+
+// txTopicScore scores transaction topics. Transactions are plentiful and
+// cheap to forge, so each counts for little either way.
+func txTopicScore() *pubsub.TopicScoreParams {
+	return &pubsub.TopicScoreParams{
+		SkipAtomicValidation: true,
+		TopicWeight:          0.5,
+
+		TimeInMeshWeight:  0.01,
+		TimeInMeshQuantum: time.Second,
+		TimeInMeshCap:     3600,
+
+		FirstMessageDeliveriesWeight: 0.1,
+		FirstMessageDeliveriesDecay:  pubsub.ScoreParameterDecay(10 * time.Minute),
+		FirstMessageDeliveriesCap:    100,
+
+		InvalidMessageDeliveriesWeight: -20,
+		InvalidMessageDeliveriesDecay:  pubsub.ScoreParameterDecay(30 * time.Minute),
+	}
+}
+
+// heartbeatTopicScore scores validator heartbeat topics.
+func heartbeatTopicScore() *pubsub.TopicScoreParams {
+	return &pubsub.TopicScoreParams{
+		SkipAtomicValidation: true,
+		TopicWeight:          0.5,
+
+		// Time in mesh is not rewarded, but GossipSub divides by the
+		// quantum whenever it scores a mesh peer.
+		TimeInMeshQuantum: time.Second,
+
+		FirstMessageDeliveriesWeight: 0.5,
+		FirstMessageDeliveriesDecay:  pubsub.ScoreParameterDecay(30 * time.Minute),
+		FirstMessageDeliveriesCap:    20,
+
+		InvalidMessageDeliveriesWeight: -50,
+		InvalidMessageDeliveriesDecay:  pubsub.ScoreParameterDecay(time.Hour),
+	}
+}
+
+// offenseScore is the application-specific GossipSub score of a peer,
+// taken from its BanManager offense score.
+func (n *Node) offenseScore(id peer.ID) float64 {
+	if n.BanManager == nil {
+		return 0
+	}
+	if n.BanManager.IsBanned(id) {
+		return bannedScore
+	}
+	return offenseScoreWeight * float64(n.BanManager.Score(id))
+}
+
+// inspectPeerScores records a BanManager offense against peers whose
+// gossip behaviour alone, without the offense score fed back through
+// offenseScore, has graylisted them.
+func (n *Node) inspectPeerScores(scores map[peer.ID]*pubsub.PeerScoreSnapshot) {
+	if n.BanManager == nil {
+		return
+	}
+	for id, s := range scores {
+		if s.Score-s.AppSpecificScore >= graylistThreshold || n.isExemptPeer(id) {
+			continue
+		}
+		logger := klog.WithComponent("p2p")
+		logger.Debug().
+			Str("peer", id.String()).
+			Float64("score", s.Score).
+			Msg("Peer graylisted by gossip score")
+		n.BanManager.RecordOffense(id, PenaltyGossipScore, "low gossip score")
+	}
+}
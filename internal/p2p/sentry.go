@@ -0,0 +1,159 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	klog "github.com/Klingon-tech/klingnet-chain/internal/log"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// A validator hides behind sentry nodes by listing them as PrivatePeers:
+// it then talks only to its sentries, with discovery, seeds, NAT traversal
+// and every other connection turned off. Each sentry lists the validator
+// in PersistentPeers, to keep the connection up, and in HiddenPeers, so it
+// never advertises the validator to anyone else.
+
+// persistentRetryInterval is how often disconnected persistent peers are
+// redialled.
+const persistentRetryInterval = 10 * time.Second
+
+// persistentPeerTag protects persistent peer connections from the
+// connection manager.
+const persistentPeerTag = "klingnet-persistent"
+
+// loadPeerGroups parses the private, persistent and hidden peers. In
+// private mode seeds and discovery are switched off.
+func (n *Node) loadPeerGroups() error {
+	private, err := parseAddrInfos("private peer", n.config.PrivatePeers)
+	if err != nil {
+		return err
+	}
+	persistent, err := parseAddrInfos("persistent peer", n.config.PersistentPeers)
+	if err != nil {
+		return err
+	}
+
+	n.privatePeers = make(map[peer.ID]bool, len(private))
+	for _, info := range private {
+		n.privatePeers[info.ID] = true
+	}
+	// Private peers are the only way out, so they are persistent too.
+	n.persistentPeers = append(private, persistent...)
+
+	n.hiddenPeers = make(map[peer.ID]bool, len(n.config.HiddenPeers))
+	for _, s := range n.config.HiddenPeers {
+		id, err := peer.Decode(s)
+		if err != nil {
+			return fmt.Errorf("invalid hidden peer ID %q: %w", s, err)
+		}
+		n.hiddenPeers[id] = true
+	}
+
+	if n.isPrivate() {
+		n.config.Seeds = nil
+		n.config.NoDiscover = true
+	}
+	return nil
+}
+
+// parseAddrInfos parses multiaddrs that end in /p2p/<peer ID>; kind names
+// the list in errors.
+func parseAddrInfos(kind string, addrs []string) ([]peer.AddrInfo, error) {
+	infos := make([]peer.AddrInfo, 0, len(addrs))
+	for _, s := range addrs {
+		info, err := peer.AddrInfoFromString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", kind, s, err)
+		}
+		infos = append(infos, *info)
+	}
+	return infos, nil
+}
+
+// isPrivate reports whether the node only talks to its private peers.
+func (n *Node) isPrivate() bool {
+	return len(n.privatePeers) > 0
+}
+
+// isPersistentPeer reports whether id is a private or persistent peer.
+func (n *Node) isPersistentPeer(id peer.ID) bool {
+	for _, info := range n.persistentPeers {
+		if info.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// isExemptPeer reports whether id is a seed or persistent peer. Operators
+// configured these explicitly, so they are never banned.
+func (n *Node) isExemptPeer(id peer.ID) bool {
+	return n.isSeedPeer(id) || n.isPersistentPeer(id)
+}
+
+// isHiddenPeer reports whether id must not be advertised to other peers.
+func (n *Node) isHiddenPeer(id peer.ID) bool {
+	return n.hiddenPeers[id]
+}
+
+// limitedPeerCount returns the number of connected peers that count
+// towards MaxPeers; persistent peers do not.
+func (n *Node) limitedPeerCount() int {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	count := 0
+	for id := range n.peers {
+		if !n.isPersistentPeer(id) {
+			count++
+		}
+	}
+	return count
+}
+
+// runPersistentPeers keeps connections to persistent peers up.
+func (n *Node) runPersistentPeers() {
+	if len(n.persistentPeers) == 0 {
+		return
+	}
+	for _, info := range n.persistentPeers {
+		n.host.ConnManager().Protect(info.ID, persistentPeerTag)
+	}
+
+	ticker := time.NewTicker(persistentRetryInterval)
+	defer ticker.Stop()
+	for {
+		n.connectPersistentPeers()
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// connectPersistentPeers dials every disconnected persistent peer,
+// lifting any ban first.
+func (n *Node) connectPersistentPeers() {
+	logger := klog.WithComponent("p2p")
+	for _, info := range n.persistentPeers {
+		if info.ID == n.host.ID() || n.host.Network().Connectedness(info.ID) == network.Connected {
+			continue
+		}
+		if n.BanManager != nil && n.BanManager.IsBanned(info.ID) {
+			n.BanManager.Unban(info.ID)
+		}
+
+		ctx, cancel := context.WithTimeout(n.ctx, peerConnectTimeout)
+		err := n.host.Connect(ctx, info)
+		cancel()
+		if err != nil {
+			logger.Debug().Str("peer", info.ID.String()).Err(err).Msg("Persistent peer connect failed")
+			continue
+		}
+		n.addPeer(info.ID)
+		logger.Info().Str("peer", info.ID.String()).Msg("Persistent peer connected")
+	}
+}
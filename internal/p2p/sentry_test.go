@@ -0,0 +1,87 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// peerAddr returns n's first address as a multiaddr with its peer ID.
+func peerAddr(n *Node) string {
+	return fmt.Sprintf("%s/p2p/%s", n.host.Addrs()[0], n.host.ID())
+}
+
+func TestLoadPeerGroups(t *testing.T) {
+	const id = "12D3KooWSU83DfT1QXgji1XiC2d9RtkGFKt5zwG4sQQVN2R3nNGG"
+	n := New(Config{
+		Seeds:        []string{"/ip4/127.0.0.1/tcp/1/p2p/" + id},
+		PrivatePeers: []string{"/ip4/127.0.0.1/tcp/2/p2p/" + id},
+		HiddenPeers:  []string{id},
+	})
+	if err := n.loadPeerGroups(); err != nil {
+		t.Fatalf("loadPeerGroups: %v", err)
+	}
+	pid, _ := peer.Decode(id)
+	if !n.isPrivate() || !n.isPersistentPeer(pid) || !n.isExemptPeer(pid) || !n.isHiddenPeer(pid) {
+		t.Error("private peer should be persistent, exempt and hidden as configured")
+	}
+	if len(n.config.Seeds) != 0 || !n.config.NoDiscover {
+		t.Error("private mode should disable seeds and discovery")
+	}
+
+	bad := New(Config{PersistentPeers: []string{"/ip4/127.0.0.1/tcp/2"}})
+	if err := bad.loadPeerGroups(); err == nil {
+		t.Error("expected error for persistent peer without peer ID")
+	}
+}
+
+func TestSentry_ValidatorTalksOnlyToSentry(t *testing.T) {
+	sentry := startTestNode(t)
+	outsider := startTestNode(t)
+
+	validator := New(Config{ListenAddr: "127.0.0.1", PrivatePeers: []string{peerAddr(sentry)}})
+	if err := validator.Start(); err != nil {
+		t.Fatalf("start validator: %v", err)
+	}
+	t.Cleanup(func() { validator.Stop() })
+
+	// The validator dials its sentry on its own.
+	deadline := time.Now().Add(5 * time.Second)
+	for validator.host.Network().Connectedness(sentry.host.ID()) != network.Connected {
+		if time.Now().After(deadline) {
+			t.Fatal("validator did not connect to its sentry")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// Nobody else gets through, in either direction.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	// The validator drops inbound connections after the security
+	// handshake, so the outsider's dial itself may succeed.
+	outsider.host.Connect(ctx, peer.AddrInfo{ID: validator.host.ID(), Addrs: validator.host.Addrs()})
+	time.Sleep(200 * time.Millisecond)
+	if validator.host.Network().Connectedness(outsider.host.ID()) == network.Connected {
+		t.Error("outsider should not connect to a private validator")
+	}
+	if err := validator.host.Connect(ctx, peer.AddrInfo{ID: outsider.host.ID(), Addrs: outsider.host.Addrs()}); err == nil {
+		t.Error("private validator should not dial outsiders")
+	}
+}
+
+func TestLimitedPeerCount_ExcludesPersistent(t *testing.T) {
+	a := startTestNode(t)
+	b := startTestNode(t)
+	c := startTestNode(t)
+	a.persistentPeers = []peer.AddrInfo{{ID: b.host.ID()}}
+
+	connectNodes(t, a, b)
+	connectNodes(t, a, c)
+	if got := a.limitedPeerCount(); got != 1 {
+		t.Errorf("limitedPeerCount = %d, want 1", got)
+	}
+}
@@ -27,30 +27,44 @@ func (n *Node) JoinSubChain(chainIDHex string) error {
 		return nil
 	}
 
-	// Join block topic.
-	blockTopic, err := n.pubsub.Join(SubChainBlockTopic(chainIDHex))
-	if err != nil {
-		return fmt.Errorf("join sub-chain block topic %s: %w", chainIDHex, err)
+	// Validators and score parameters for both topics. The tx topic has
+	// the same per-peer relay limits as the root.
+	blockName, txName := SubChainBlockTopic(chainIDHex), SubChainTxTopic(chainIDHex)
+	var cleanup []func()
+	fail := func(err error) error {
+		for i := len(cleanup) - 1; i >= 0; i-- {
+			cleanup[i]()
+		}
+		return err
 	}
-	blockSub, err := blockTopic.Subscribe()
-	if err != nil {
-		blockTopic.Close()
-		return fmt.Errorf("subscribe sub-chain block topic %s: %w", chainIDHex, err)
+	join := func(name string, validator pubsub.ValidatorEx, score *pubsub.TopicScoreParams) (*pubsub.Topic, *pubsub.Subscription, error) {
+		if err := n.pubsub.RegisterTopicValidator(name, validator); err != nil {
+			return nil, nil, fail(fmt.Errorf("register validator for %s: %w", name, err))
+		}
+		cleanup = append(cleanup, func() { n.pubsub.UnregisterTopicValidator(name) })
+		topic, err := n.pubsub.Join(name)
+		if err != nil {
+			return nil, nil, fail(fmt.Errorf("join %s: %w", name, err))
+		}
+		cleanup = append(cleanup, func() { topic.Close() })
+		if err := topic.SetScoreParams(score); err != nil {
+			return nil, nil, fail(fmt.Errorf("score %s: %w", name, err))
+		}
+		sub, err := topic.Subscribe()
+		if err != nil {
+			return nil, nil, fail(fmt.Errorf("subscribe %s: %w", name, err))
+		}
+		cleanup = append(cleanup, sub.Cancel)
+		return topic, sub, nil
 	}
 
-	// Join tx topic.
-	txTopic, err := n.pubsub.Join(SubChainTxTopic(chainIDHex))
+	blockTopic, blockSub, err := join(blockName, n.subChainBlockValidator(chainIDHex), blockTopicScore())
 	if err != nil {
-		blockSub.Cancel()
-		blockTopic.Close()
-		return fmt.Errorf("join sub-chain tx topic %s: %w", chainIDHex, err)
+		return fmt.Errorf("sub-chain %s: %w", chainIDHex, err)
 	}
-	txSub, err := txTopic.Subscribe()
+	txTopic, txSub, err := join(txName, n.validateSubChainTx, txTopicScore())
 	if err != nil {
-		txTopic.Close()
-		blockSub.Cancel()
-		blockTopic.Close()
-		return fmt.Errorf("subscribe sub-chain tx topic %s: %w", chainIDHex, err)
+		return fmt.Errorf("sub-chain %s: %w", chainIDHex, err)
 	}
 
 	n.scTopics[chainIDHex] = blockTopic
@@ -80,14 +94,17 @@ func (n *Node) LeaveSubChain(chainIDHex string) {
 	}
 	if t, ok := n.scTopics[chainIDHex]; ok {
 		t.Close()
+		n.pubsub.UnregisterTopicValidator(SubChainBlockTopic(chainIDHex))
 		delete(n.scTopics, chainIDHex)
 	}
 	if t, ok := n.scTxTopics[chainIDHex]; ok {
 		t.Close()
+		n.pubsub.UnregisterTopicValidator(SubChainTxTopic(chainIDHex))
 		delete(n.scTxTopics, chainIDHex)
 	}
 	delete(n.scBlockHandlers, chainIDHex)
 	delete(n.scTxHandlers, chainIDHex)
+	delete(n.scBlockVerifiers, chainIDHex)
 }
 
 // SetSubChainBlockHandler sets the handler for incoming sub-chain blocks.
@@ -25,15 +25,18 @@ const (
 	maxSyncResponseBytes = 10 * 1024 * 1024
 )
 
-// SyncRequest asks a peer for blocks starting at a given height.
+// SyncRequest asks a peer for blocks starting at a given height. With
+// HeadersOnly set only the block headers are returned.
 type SyncRequest struct {
-	FromHeight uint64 `json:"from_height"`
-	MaxBlocks  uint32 `json:"max_blocks"`
+	FromHeight  uint64 `json:"from_height"`
+	MaxBlocks   uint32 `json:"max_blocks"`
+	HeadersOnly bool   `json:"headers_only,omitempty"`
 }
 
-// SyncResponse contains blocks returned by a peer.
+// SyncResponse contains blocks, or headers, returned by a peer.
 type SyncResponse struct {
-	Blocks []*block.Block `json:"blocks"`
+	Blocks  []*block.Block  `json:"blocks"`
+	Headers []*block.Header `json:"headers,omitempty"`
 }
 
 // Syncer handles chain synchronization with peers.
@@ -57,24 +60,37 @@ func NewSyncer(node *Node) *Syncer {
 // The provider function returns blocks for a given height range.
 func (s *Syncer) RegisterHandler(provider func(fromHeight uint64, max uint32) []*block.Block) {
 	s.host.SetStreamHandler(SyncProtocol, func(stream network.Stream) {
-		defer stream.Close()
+		s.serveSync(stream, provider)
+	})
+	s.node.SetCapability(CapHistory, true)
+}
 
-		var req SyncRequest
-		if err := json.NewDecoder(io.LimitReader(stream, maxSyncResponseBytes)).Decode(&req); err != nil {
-			return
-		}
+// serveSync answers one sync request from provider.
+func (s *Syncer) serveSync(stream network.Stream, provider func(uint64, uint32) []*block.Block) {
+	defer stream.Close()
 
-		if req.MaxBlocks == 0 || req.MaxBlocks > 500 {
-			req.MaxBlocks = 500
-		}
+	var req SyncRequest
+	if err := json.NewDecoder(io.LimitReader(stream, maxSyncResponseBytes)).Decode(&req); err != nil {
+		return
+	}
 
-		blocks := provider(req.FromHeight, req.MaxBlocks)
-		resp := SyncResponse{Blocks: blocks}
-		json.NewEncoder(stream).Encode(&resp)
-	})
+	if req.MaxBlocks == 0 || req.MaxBlocks > 500 {
+		req.MaxBlocks = 500
+	}
+
+	blocks := provider(req.FromHeight, req.MaxBlocks)
+	resp := SyncResponse{Blocks: blocks}
+	if req.HeadersOnly {
+		resp = SyncResponse{Headers: make([]*block.Header, len(blocks))}
+		for i, blk := range blocks {
+			resp.Headers[i] = blk.Header
+		}
+	}
+	json.NewEncoder(s.node.blockWriter(stream)).Encode(&resp)
 }
 
 // RequestBlocks asks a specific peer for blocks starting at fromHeight.
+// Callers pick peers from Node.SyncPeers, best reputation first.
 func (s *Syncer) RequestBlocks(ctx context.Context, peerID peer.ID, fromHeight uint64, maxBlocks uint32) ([]*block.Block, error) {
 	return s.requestBlocks(ctx, peerID, SyncProtocol, fromHeight, maxBlocks)
 }
@@ -82,27 +98,16 @@ func (s *Syncer) RequestBlocks(ctx context.Context, peerID peer.ID, fromHeight u
 // RegisterSubChainHandler registers a block-provider for a sub-chain sync protocol.
 func (s *Syncer) RegisterSubChainHandler(chainIDHex string, provider func(uint64, uint32) []*block.Block) {
 	s.host.SetStreamHandler(SubChainSyncProtocol(chainIDHex), func(stream network.Stream) {
-		defer stream.Close()
-
-		var req SyncRequest
-		if err := json.NewDecoder(io.LimitReader(stream, maxSyncResponseBytes)).Decode(&req); err != nil {
-			return
-		}
-
-		if req.MaxBlocks == 0 || req.MaxBlocks > 500 {
-			req.MaxBlocks = 500
-		}
-
-		blocks := provider(req.FromHeight, req.MaxBlocks)
-		resp := SyncResponse{Blocks: blocks}
-		json.NewEncoder(stream).Encode(&resp)
+		s.serveSync(stream, provider)
 	})
+	s.node.addSubChain(chainIDHex)
 }
 
 // RemoveSubChainHandler removes sync and height stream handlers for a sub-chain.
 func (s *Syncer) RemoveSubChainHandler(chainIDHex string) {
 	s.host.RemoveStreamHandler(SubChainSyncProtocol(chainIDHex))
 	s.host.RemoveStreamHandler(SubChainHeightProtocol(chainIDHex))
+	s.node.removeSubChain(chainIDHex)
 }
 
 // RequestSubChainBlocks asks a peer for blocks from a specific sub-chain.
@@ -110,15 +115,58 @@ func (s *Syncer) RequestSubChainBlocks(ctx context.Context, peerID peer.ID, chai
 	return s.requestBlocks(ctx, peerID, SubChainSyncProtocol(chainIDHex), fromHeight, maxBlocks)
 }
 
+// RequestHeaders asks a specific peer for the headers of blocks starting
+// at fromHeight. Peers that ignore HeadersOnly answer with full blocks,
+// whose headers are used instead.
+func (s *Syncer) RequestHeaders(ctx context.Context, peerID peer.ID, fromHeight uint64, maxHeaders uint32) ([]*block.Header, error) {
+	resp, err := s.request(ctx, peerID, SyncProtocol, SyncRequest{FromHeight: fromHeight, MaxBlocks: maxHeaders, HeadersOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Headers) > 0 || len(resp.Blocks) == 0 {
+		return resp.Headers, nil
+	}
+	headers := make([]*block.Header, len(resp.Blocks))
+	for i, blk := range resp.Blocks {
+		if blk == nil {
+			return nil, fmt.Errorf("nil block in sync response")
+		}
+		headers[i] = blk.Header
+	}
+	return headers, nil
+}
+
 // requestBlocks is the shared implementation for block requests.
 func (s *Syncer) requestBlocks(ctx context.Context, peerID peer.ID, proto protocol.ID, fromHeight uint64, maxBlocks uint32) ([]*block.Block, error) {
+	resp, err := s.request(ctx, peerID, proto, SyncRequest{FromHeight: fromHeight, MaxBlocks: maxBlocks})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Blocks, nil
+}
+
+// request sends a sync request and reads the response. Isolated peers are
+// refused, and failures to answer count against the peer's sync
+// reputation; callers record the outcome of the blocks themselves.
+func (s *Syncer) request(ctx context.Context, peerID peer.ID, proto protocol.ID, req SyncRequest) (*SyncResponse, error) {
+	if s.node.IsIsolated(peerID) {
+		return nil, ErrPeerIsolated
+	}
+	resp, err := s.fetch(ctx, peerID, proto, req)
+	if err != nil && s.node.ctx.Err() == nil {
+		s.node.RecordSyncOutcome(peerID, SyncTimeout)
+	}
+	return resp, err
+}
+
+// fetch sends a sync request and reads the response.
+func (s *Syncer) fetch(ctx context.Context, peerID peer.ID, proto protocol.ID, req SyncRequest) (*SyncResponse, error) {
 	stream, err := s.host.NewStream(ctx, peerID, proto)
 	if err != nil {
 		return nil, fmt.Errorf("open sync stream: %w", err)
 	}
 	defer stream.Close()
 
-	req := SyncRequest{FromHeight: fromHeight, MaxBlocks: maxBlocks}
 	if err := json.NewEncoder(stream).Encode(&req); err != nil {
 		return nil, fmt.Errorf("send sync request: %w", err)
 	}
@@ -134,5 +182,5 @@ func (s *Syncer) requestBlocks(ctx context.Context, peerID peer.ID, proto protoc
 		return nil, fmt.Errorf("read sync response: %w", err)
 	}
 
-	return resp.Blocks, nil
+	return &resp, nil
 }
@@ -0,0 +1,97 @@
+package p2p
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Default per-peer transaction relay limits, shared by the root and
+// sub-chain transaction topics.
+const (
+	DefaultTxRelayRate  = 20.0 // Sustained transactions per second.
+	DefaultTxRelayBurst = 200  // Transactions accepted in a burst.
+)
+
+// tokenBucket is one peer's allowance.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// peerRateLimiter limits how many messages or requests each peer may send
+// us. Every peer gets a token bucket that refills at rate per second up to
+// burst; a message without a token is dropped.
+type peerRateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[peer.ID]*tokenBucket
+}
+
+func newPeerRateLimiter(rate float64, burst int) *peerRateLimiter {
+	return &peerRateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[peer.ID]*tokenBucket),
+	}
+}
+
+// newTxRateLimiter limits relayed transactions, using the defaults for
+// unset limits.
+func newTxRateLimiter(rate float64, burst int) *peerRateLimiter {
+	if rate <= 0 {
+		rate = DefaultTxRelayRate
+	}
+	if burst <= 0 {
+		burst = DefaultTxRelayBurst
+	}
+	return newPeerRateLimiter(rate, burst)
+}
+
+// allow takes a token from id's bucket, reporting whether one was left.
+func (l *peerRateLimiter) allow(id peer.ID, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[id]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[id] = b
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(l.burst, b.tokens+elapsed*l.rate)
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// forget drops a disconnected peer's bucket.
+func (l *peerRateLimiter) forget(id peer.ID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.buckets, id)
+}
+
+// validateTx is the GossipSub validator for transaction topics. Messages
+// from banned peers are rejected; messages beyond a peer's relay allowance
+// are ignored, so they are neither delivered nor relayed further.
+func (n *Node) validateTx(_ context.Context, from peer.ID, _ *pubsub.Message) pubsub.ValidationResult {
+	if n.host != nil && from == n.host.ID() {
+		return pubsub.ValidationAccept
+	}
+	if n.BanManager != nil && n.BanManager.IsBanned(from) {
+		return pubsub.ValidationReject
+	}
+	if !n.txLimiter.allow(from, time.Now()) {
+		return pubsub.ValidationIgnore
+	}
+	return pubsub.ValidationAccept
+}
@@ -0,0 +1,82 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestTxRateLimiter_Burst(t *testing.T) {
+	l := newTxRateLimiter(1, 3)
+	id := peer.ID("flooder")
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if !l.allow(id, now) {
+			t.Fatalf("tx %d within burst was refused", i)
+		}
+	}
+	if l.allow(id, now) {
+		t.Error("tx beyond burst should be refused")
+	}
+
+	// Other peers have their own allowance.
+	if !l.allow(peer.ID("other"), now) {
+		t.Error("other peer should not be limited")
+	}
+}
+
+func TestTxRateLimiter_Refill(t *testing.T) {
+	l := newTxRateLimiter(2, 2)
+	id := peer.ID("peer")
+	now := time.Now()
+
+	l.allow(id, now)
+	l.allow(id, now)
+	if l.allow(id, now) {
+		t.Fatal("bucket should be empty")
+	}
+	// Half a second at 2/s refills one token.
+	if !l.allow(id, now.Add(500*time.Millisecond)) {
+		t.Error("bucket should have refilled one token")
+	}
+	// Refill is capped at burst.
+	later := now.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		if !l.allow(id, later) {
+			t.Fatalf("tx %d after refill was refused", i)
+		}
+	}
+	if l.allow(id, later) {
+		t.Error("refill should be capped at burst")
+	}
+}
+
+func TestTxRateLimiter_Forget(t *testing.T) {
+	l := newTxRateLimiter(1, 1)
+	id := peer.ID("peer")
+	now := time.Now()
+
+	l.allow(id, now)
+	if l.allow(id, now) {
+		t.Fatal("bucket should be empty")
+	}
+	l.forget(id)
+	if !l.allow(id, now) {
+		t.Error("a forgotten peer should start with a full bucket")
+	}
+}
+
+func TestNode_RejoinSubChain(t *testing.T) {
+	n := startTestNode(t)
+
+	// The tx topic validator must be released on leave.
+	chainID := "55667788"
+	for i := 0; i < 2; i++ {
+		if err := n.JoinSubChain(chainID); err != nil {
+			t.Fatalf("JoinSubChain %d: %v", i, err)
+		}
+		n.LeaveSubChain(chainID)
+	}
+}
@@ -0,0 +1,127 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+)
+
+// GossipSub topic validators run before a message is delivered or relayed.
+// They make the cheap structural and signature checks: Reject marks a
+// message as invalid, which costs the relaying peer both GossipSub score
+// and a BanManager offense; Ignore drops it without penalty.
+
+// Heartbeats outside this window around our clock are not relayed.
+const (
+	heartbeatMaxAge  = 5 * time.Minute
+	heartbeatMaxSkew = 30 * time.Second
+)
+
+// SetBlockVerifier sets the header check, typically the consensus
+// engine's VerifyHeader, that root-chain blocks must pass before they are
+// rebuilt and relayed.
+func (n *Node) SetBlockVerifier(fn func(*block.Header) error) {
+	n.blockVerifier = fn
+}
+
+// SetSubChainBlockVerifier sets the header check for a sub-chain's blocks.
+func (n *Node) SetSubChainBlockVerifier(chainIDHex string, fn func(*block.Header) error) {
+	n.scMu.Lock()
+	defer n.scMu.Unlock()
+	n.scBlockVerifiers[chainIDHex] = fn
+}
+
+// rejectMessage records an offense against the peer that relayed an
+// invalid message.
+func (n *Node) rejectMessage(from peer.ID, penalty int, reason string) pubsub.ValidationResult {
+	if n.BanManager != nil {
+		n.BanManager.RecordOffense(from, penalty, reason)
+	}
+	return pubsub.ValidationReject
+}
+
+func (n *Node) isSelf(id peer.ID) bool {
+	return n.host != nil && id == n.host.ID()
+}
+
+// validateHeartbeat is the GossipSub validator for heartbeat topics. The
+// decoded heartbeat is passed to the read loop as ValidatorData.
+func (n *Node) validateHeartbeat(_ context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	if n.BanManager != nil && n.BanManager.IsBanned(from) {
+		return pubsub.ValidationReject
+	}
+	var hb HeartbeatMessage
+	if err := json.Unmarshal(msg.Data, &hb); err != nil {
+		return n.rejectMessage(from, PenaltyBadHeartbeat, "malformed heartbeat")
+	}
+	if !VerifyHeartbeat(&hb) {
+		return n.rejectMessage(from, PenaltyBadHeartbeat, "bad heartbeat signature")
+	}
+	if !n.isSelf(from) {
+		age := time.Since(time.Unix(hb.Timestamp, 0))
+		if age > heartbeatMaxAge || age < -heartbeatMaxSkew {
+			return pubsub.ValidationIgnore
+		}
+	}
+	msg.ValidatorData = &hb
+	return pubsub.ValidationAccept
+}
+
+// validateSubChainTx is the GossipSub validator for sub-chain transaction
+// topics: relay limits, then structure and signatures. Spent or unknown
+// inputs are left to the sub-chain's mempool.
+func (n *Node) validateSubChainTx(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	if n.isSelf(from) {
+		return pubsub.ValidationAccept
+	}
+	if res := n.validateTx(ctx, from, msg); res != pubsub.ValidationAccept {
+		return res
+	}
+	var t tx.Transaction
+	if err := json.Unmarshal(msg.Data, &t); err != nil {
+		return n.rejectMessage(from, PenaltyMalformedTx, "malformed sub-chain tx")
+	}
+	if err := t.Validate(); err != nil {
+		return n.rejectMessage(from, PenaltyInvalidTx, "sub-chain tx: "+err.Error())
+	}
+	if err := t.VerifySignatures(); err != nil {
+		return n.rejectMessage(from, PenaltyInvalidTx, "sub-chain tx: "+err.Error())
+	}
+	return pubsub.ValidationAccept
+}
+
+// subChainBlockValidator returns the GossipSub validator for a sub-chain's
+// block topic: block structure, then the chain's header verifier.
+func (n *Node) subChainBlockValidator(chainIDHex string) pubsub.ValidatorEx {
+	return func(_ context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+		if n.isSelf(from) {
+			return pubsub.ValidationAccept
+		}
+		if n.BanManager != nil && n.BanManager.IsBanned(from) {
+			return pubsub.ValidationReject
+		}
+		var blk block.Block
+		if err := json.Unmarshal(msg.Data, &blk); err != nil {
+			return n.rejectMessage(from, PenaltyInvalidBlock, "malformed sub-chain block")
+		}
+		if err := blk.Validate(); err != nil {
+			return n.rejectMessage(from, PenaltyInvalidBlock, "sub-chain block: "+err.Error())
+		}
+
+		n.scMu.RLock()
+		verify := n.scBlockVerifiers[chainIDHex]
+		n.scMu.RUnlock()
+		if verify != nil {
+			if err := verify(blk.Header); err != nil {
+				return n.rejectMessage(from, PenaltyInvalidBlock, "sub-chain block header: "+err.Error())
+			}
+		}
+		return pubsub.ValidationAccept
+	}
+}
@@ -0,0 +1,170 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/crypto"
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+func newValidatorTestNode() *Node {
+	n := New(Config{NoDiscover: true})
+	n.BanManager = NewBanManager(nil, n)
+	return n
+}
+
+func testMessage(t *testing.T, v any) *pubsub.Message {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return &pubsub.Message{Message: &pb.Message{Data: data}}
+}
+
+func signedHeartbeat(t *testing.T, ts int64) *HeartbeatMessage {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	hash := crypto.Hash(HeartbeatSigningBytes(key.PublicKey(), 7, ts))
+	sig, err := key.Sign(hash[:])
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	return &HeartbeatMessage{PubKey: key.PublicKey(), Height: 7, Timestamp: ts, Signature: sig}
+}
+
+func TestValidateHeartbeat(t *testing.T) {
+	n := newValidatorTestNode()
+	from := peer.ID("hb-peer")
+
+	msg := testMessage(t, signedHeartbeat(t, time.Now().Unix()))
+	if res := n.validateHeartbeat(context.Background(), from, msg); res != pubsub.ValidationAccept {
+		t.Fatalf("valid heartbeat: got %v, want accept", res)
+	}
+	if hb, ok := msg.ValidatorData.(*HeartbeatMessage); !ok || hb.Height != 7 {
+		t.Fatalf("ValidatorData = %#v, want decoded heartbeat", msg.ValidatorData)
+	}
+	if score := n.BanManager.Score(from); score != 0 {
+		t.Errorf("score after valid heartbeat = %d, want 0", score)
+	}
+
+	stale := testMessage(t, signedHeartbeat(t, time.Now().Add(-time.Hour).Unix()))
+	if res := n.validateHeartbeat(context.Background(), from, stale); res != pubsub.ValidationIgnore {
+		t.Errorf("stale heartbeat: got %v, want ignore", res)
+	}
+
+	forged := signedHeartbeat(t, time.Now().Unix())
+	forged.Height++
+	if res := n.validateHeartbeat(context.Background(), from, testMessage(t, forged)); res != pubsub.ValidationReject {
+		t.Errorf("forged heartbeat: got %v, want reject", res)
+	}
+	if score := n.BanManager.Score(from); score != PenaltyBadHeartbeat {
+		t.Errorf("score after forged heartbeat = %d, want %d", score, PenaltyBadHeartbeat)
+	}
+}
+
+func TestValidateSubChainTx(t *testing.T) {
+	n := newValidatorTestNode()
+	from := peer.ID("tx-peer")
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	b := tx.NewBuilder().
+		AddInput(types.Outpoint{TxID: types.Hash{1}, Index: 0}).
+		AddOutput(1000, types.Script{Type: types.ScriptTypeP2PKH, Data: make([]byte, 20)})
+	if err := b.Sign(key); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	signed := b.Build()
+	if res := n.validateSubChainTx(context.Background(), from, testMessage(t, signed)); res != pubsub.ValidationAccept {
+		t.Fatalf("signed tx: got %v, want accept", res)
+	}
+
+	forged := *signed
+	forged.Outputs = []tx.Output{{Value: 2000, Script: signed.Outputs[0].Script}}
+	if res := n.validateSubChainTx(context.Background(), from, testMessage(t, &forged)); res != pubsub.ValidationReject {
+		t.Errorf("forged tx: got %v, want reject", res)
+	}
+	if score := n.BanManager.Score(from); score != PenaltyInvalidTx {
+		t.Errorf("score after forged tx = %d, want %d", score, PenaltyInvalidTx)
+	}
+}
+
+func TestSubChainBlockValidator_Verifier(t *testing.T) {
+	n := newValidatorTestNode()
+	from := peer.ID("block-peer")
+
+	coinbase := &tx.Transaction{
+		Version: 1,
+		Inputs:  []tx.Input{{}},
+		Outputs: []tx.Output{{Value: 1000, Script: types.Script{Type: types.ScriptTypeP2PKH, Data: make([]byte, 20)}}},
+	}
+	header := &block.Header{
+		Version:    1,
+		Height:     3,
+		Timestamp:  uint64(time.Now().Unix()),
+		MerkleRoot: block.ComputeMerkleRoot([]types.Hash{coinbase.Hash()}),
+	}
+	blk := block.NewBlock(header, []*tx.Transaction{coinbase})
+
+	validate := n.subChainBlockValidator("aa")
+	if res := validate(context.Background(), from, testMessage(t, blk)); res != pubsub.ValidationAccept {
+		t.Fatalf("no verifier: got %v, want accept", res)
+	}
+
+	n.SetSubChainBlockVerifier("aa", func(*block.Header) error { return errors.New("bad signer") })
+	if res := validate(context.Background(), from, testMessage(t, blk)); res != pubsub.ValidationReject {
+		t.Errorf("failing verifier: got %v, want reject", res)
+	}
+	if score := n.BanManager.Score(from); score != PenaltyInvalidBlock {
+		t.Errorf("score after rejected block = %d, want %d", score, PenaltyInvalidBlock)
+	}
+}
+
+func TestOffenseScore(t *testing.T) {
+	n := newValidatorTestNode()
+	id := peer.ID("scored-peer")
+
+	if s := n.offenseScore(id); s != 0 {
+		t.Errorf("clean peer score = %v, want 0", s)
+	}
+	n.BanManager.RecordOffense(id, PenaltyInvalidTx, "bad tx")
+	if s := n.offenseScore(id); s != offenseScoreWeight*PenaltyInvalidTx {
+		t.Errorf("offending peer score = %v, want %v", s, offenseScoreWeight*PenaltyInvalidTx)
+	}
+	n.BanManager.RecordOffense(id, BanThreshold, "bad")
+	if s := n.offenseScore(id); s >= graylistThreshold {
+		t.Errorf("banned peer score = %v, want below graylist threshold %v", s, graylistThreshold)
+	}
+}
+
+func TestInspectPeerScores(t *testing.T) {
+	n := newValidatorTestNode()
+	bad, offender := peer.ID("bad-gossip"), peer.ID("offender")
+
+	n.inspectPeerScores(map[peer.ID]*pubsub.PeerScoreSnapshot{
+		bad:      {Score: 2 * graylistThreshold},
+		offender: {Score: 2 * graylistThreshold, AppSpecificScore: 2 * graylistThreshold},
+	})
+	if score := n.BanManager.Score(bad); score != PenaltyGossipScore {
+		t.Errorf("graylisted peer score = %d, want %d", score, PenaltyGossipScore)
+	}
+	if score := n.BanManager.Score(offender); score != 0 {
+		t.Errorf("peer graylisted by offenses only: score = %d, want 0", score)
+	}
+}
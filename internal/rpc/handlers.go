@@ -3,19 +3,24 @@ package rpc
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/big"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Klingon-tech/klingnet-chain/config"
 	"github.com/Klingon-tech/klingnet-chain/internal/consensus"
+	"github.com/Klingon-tech/klingnet-chain/internal/mempool"
 	"github.com/Klingon-tech/klingnet-chain/internal/miner"
 	"github.com/Klingon-tech/klingnet-chain/internal/utxo"
 	"github.com/Klingon-tech/klingnet-chain/pkg/block"
 	"github.com/Klingon-tech/klingnet-chain/pkg/crypto"
 	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
 	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+	"github.com/libp2p/go-libp2p/core/metrics"
 )
 
 // ── Chain endpoints ─────────────────────────────────────────────────────
@@ -25,12 +30,29 @@ func (s *Server) handleChainGetInfo(req *Request) (interface{}, *Error) {
 	if err != nil {
 		return nil, err
 	}
-	return &ChainInfoResult{
+	defer cc.release()
+	height := cc.view.Height()
+	result := &ChainInfoResult{
 		ChainID: cc.genesis.ChainID,
 		Symbol:  cc.genesis.Symbol,
-		Height:  cc.chain.Height(),
-		TipHash: cc.chain.TipHash().String(),
-	}, nil
+		Height:  height,
+		TipHash: cc.view.TipHash().String(),
+	}
+	cps := cc.chain.Checkpoints()
+	result.CheckpointHeight = cps.LastHeight
+	if cps.AssumeValidHeight > 0 {
+		progress := float64(height) / float64(cps.AssumeValidHeight)
+		if progress > 1 {
+			progress = 1
+		}
+		result.AssumeValid = &AssumeValidInfo{
+			Height:   cps.AssumeValidHeight,
+			Hash:     cps.AssumeValidHash.String(),
+			Active:   height < cps.AssumeValidHeight,
+			Progress: progress,
+		}
+	}
+	return result, nil
 }
 
 func (s *Server) handleChainGetBlockByHash(req *Request) (interface{}, *Error) {
@@ -46,6 +68,7 @@ func (s *Server) handleChainGetBlockByHash(req *Request) (interface{}, *Error) {
 	if rpcErr != nil {
 		return nil, rpcErr
 	}
+	defer cc.release()
 
 	hashBytes, decErr := hex.DecodeString(params.Hash)
 	if decErr != nil || len(hashBytes) != types.HashSize {
@@ -55,7 +78,7 @@ func (s *Server) handleChainGetBlockByHash(req *Request) (interface{}, *Error) {
 	var hash types.Hash
 	copy(hash[:], hashBytes)
 
-	blk, err := cc.chain.GetBlock(hash)
+	blk, err := cc.view.GetBlock(hash)
 	if err != nil {
 		return nil, &Error{Code: CodeNotFound, Message: fmt.Sprintf("block not found: %v", err)}
 	}
@@ -72,14 +95,73 @@ func (s *Server) handleChainGetBlockByHeight(req *Request) (interface{}, *Error)
 	if rpcErr != nil {
 		return nil, rpcErr
 	}
+	defer cc.release()
 
-	blk, err := cc.chain.GetBlockByHeight(params.Height)
+	blk, err := cc.view.GetBlockByHeight(params.Height)
 	if err != nil {
 		return nil, &Error{Code: CodeNotFound, Message: fmt.Sprintf("block not found at height %d: %v", params.Height, err)}
 	}
 	return NewBlockResult(blk), nil
 }
 
+// maxBlockHashesPage caps the number of entries chain_getBlockHashes returns.
+const maxBlockHashesPage = 1000
+
+func (s *Server) handleChainGetBlockHashes(req *Request) (interface{}, *Error) {
+	var params BlockHashesParam
+	if err := parseParams(req, &params); err != nil {
+		return nil, err
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > maxBlockHashesPage {
+		limit = maxBlockHashesPage
+	}
+
+	cc, rpcErr := s.resolveChain(params.ChainID)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	defer cc.release()
+
+	tip := cc.view.Height()
+	var lo, hi uint64
+	if params.Reverse {
+		hi = tip
+		if params.FromHeight != nil && *params.FromHeight < tip {
+			hi = *params.FromHeight
+		}
+	} else {
+		if params.FromHeight != nil {
+			lo = *params.FromHeight
+		}
+		hi = tip
+	}
+
+	blocks, err := cc.view.HeightRange(lo, hi, limit, params.Reverse)
+	if err != nil {
+		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("read height index: %v", err)}
+	}
+	result := &BlockHashesResult{Blocks: make([]BlockHashEntry, len(blocks))}
+	for i, b := range blocks {
+		result.Blocks[i] = BlockHashEntry{Height: b.Height, Hash: b.Hash.String()}
+	}
+	if n := len(blocks); n == limit {
+		last := blocks[n-1].Height
+		switch {
+		case params.Reverse && last > 0:
+			next := last - 1
+			result.NextHeight = &next
+		case !params.Reverse && last < tip:
+			next := last + 1
+			result.NextHeight = &next
+		}
+	}
+	return result, nil
+}
+
 func (s *Server) handleChainGetTransaction(req *Request) (interface{}, *Error) {
 	var params HashParam
 	if err := parseParams(req, &params); err != nil {
@@ -93,6 +175,7 @@ func (s *Server) handleChainGetTransaction(req *Request) (interface{}, *Error) {
 	if rpcErr != nil {
 		return nil, rpcErr
 	}
+	defer cc.release()
 
 	hashBytes, decErr := hex.DecodeString(params.Hash)
 	if decErr != nil || len(hashBytes) != types.HashSize {
@@ -108,7 +191,7 @@ func (s *Server) handleChainGetTransaction(req *Request) (interface{}, *Error) {
 	}
 
 	// Lookup via transaction index.
-	t, err := cc.chain.GetTransaction(txHash)
+	t, err := cc.view.GetTransaction(txHash)
 	if err != nil {
 		return nil, &Error{Code: CodeNotFound, Message: "transaction not found"}
 	}
@@ -130,6 +213,7 @@ func (s *Server) handleUTXOGet(req *Request) (interface{}, *Error) {
 	if rpcErr != nil {
 		return nil, rpcErr
 	}
+	defer cc.release()
 
 	txIDBytes, decErr := hex.DecodeString(params.TxID)
 	if decErr != nil || len(txIDBytes) != types.HashSize {
@@ -160,21 +244,38 @@ func (s *Server) handleUTXOGetByAddress(req *Request) (interface{}, *Error) {
 	if rpcErr != nil {
 		return nil, rpcErr
 	}
+	defer cc.release()
 
 	addr, addrErr := decodeAddress(params.Address)
 	if addrErr != nil {
 		return nil, addrErr
 	}
 
-	utxos, err := cc.utxos.GetByAddress(addr)
+	if params.Limit < 0 {
+		return nil, &Error{Code: CodeInvalidParams, Message: "limit must not be negative"}
+	}
+	var after *types.Outpoint
+	if params.Cursor != "" {
+		op, err := parseOutpointCursor(params.Cursor)
+		if err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		after = &op
+	}
+
+	utxos, next, err := cc.utxos.GetByAddressPage(addr, after, params.Limit)
 	if err != nil {
 		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("get utxos: %v", err)}
 	}
 
-	return &UTXOListResult{
+	result := &UTXOListResult{
 		Address: params.Address,
 		UTXOs:   utxos,
-	}, nil
+	}
+	if next != nil {
+		result.NextCursor = next.String()
+	}
+	return result, nil
 }
 
 func (s *Server) handleUTXOGetBalance(req *Request) (interface{}, *Error) {
@@ -190,6 +291,7 @@ func (s *Server) handleUTXOGetBalance(req *Request) (interface{}, *Error) {
 	if rpcErr != nil {
 		return nil, rpcErr
 	}
+	defer cc.release()
 
 	addr, addrErr := decodeAddress(params.Address)
 	if addrErr != nil {
@@ -206,7 +308,7 @@ func (s *Server) handleUTXOGetBalance(req *Request) (interface{}, *Error) {
 	stakeUTXOs, _ := stakesByAddress(cc.utxos, addr)
 	utxos = append(utxos, stakeUTXOs...)
 
-	chainHeight := cc.chain.Height()
+	chainHeight := cc.view.Height()
 	result := classifyUTXOs(utxos, chainHeight)
 	result.Address = params.Address
 
@@ -228,15 +330,16 @@ func (s *Server) handleTxSubmit(req *Request) (interface{}, *Error) {
 	if rpcErr != nil {
 		return nil, rpcErr
 	}
+	defer cc.release()
 
 	_, err := cc.pool.Add(params.Transaction)
 	if err != nil {
-		return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("rejected: %v", err)}
+		return nil, &Error{Code: txRejectCode(err), Message: fmt.Sprintf("rejected: %v", err)}
 	}
 
 	// Broadcast to P2P network (root chain only — sub-chain P2P is not yet implemented).
 	if params.ChainID == "" && s.p2pNode != nil {
-		if err := s.p2pNode.BroadcastTx(params.Transaction); err != nil {
+		if err := s.p2pNode.SubmitTx(params.Transaction); err != nil {
 			s.logger.Warn().Err(err).Msg("Failed to broadcast transaction")
 		}
 	}
@@ -246,6 +349,69 @@ func (s *Server) handleTxSubmit(req *Request) (interface{}, *Error) {
 	}, nil
 }
 
+// txRejectCode returns the error code for a mempool rejection.
+func txRejectCode(err error) int {
+	switch {
+	case errors.Is(err, mempool.ErrNonStandard):
+		return CodeTxNonStandard
+	case errors.Is(err, mempool.ErrDust):
+		return CodeTxDust
+	case errors.Is(err, mempool.ErrScriptData):
+		return CodeTxScriptData
+	case errors.Is(err, mempool.ErrScriptType):
+		return CodeTxScriptType
+	case errors.Is(err, mempool.ErrTokenOutputs):
+		return CodeTxTokenOutputs
+	case errors.Is(err, mempool.ErrAddressRate):
+		return CodeTxAddressRate
+	case errors.Is(err, mempool.ErrPolicyRule):
+		return CodeTxPolicyRule
+	default:
+		return CodeInvalidParams
+	}
+}
+
+func (s *Server) handleTxSubmitPackage(req *Request) (interface{}, *Error) {
+	var params TxSubmitPackageParam
+	if err := parseParams(req, &params); err != nil {
+		return nil, err
+	}
+	if len(params.Transactions) == 0 {
+		return nil, &Error{Code: CodeInvalidParams, Message: "transactions are required"}
+	}
+	for i, t := range params.Transactions {
+		if t == nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("transaction %d is empty", i)}
+		}
+	}
+
+	cc, rpcErr := s.resolveChain(params.ChainID)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	defer cc.release()
+
+	fees, err := cc.pool.AddPackage(params.Transactions)
+	if err != nil {
+		return nil, &Error{Code: txRejectCode(err), Message: fmt.Sprintf("rejected: %v", err)}
+	}
+
+	result := &TxSubmitPackageResult{
+		TxHashes: make([]string, len(params.Transactions)),
+		Fees:     fees,
+	}
+	for i, t := range params.Transactions {
+		result.TxHashes[i] = t.Hash().String()
+		// Root chain only, parents first.
+		if params.ChainID == "" && s.p2pNode != nil {
+			if err := s.p2pNode.SubmitTx(t); err != nil {
+				s.logger.Warn().Err(err).Msg("Failed to broadcast transaction")
+			}
+		}
+	}
+	return result, nil
+}
+
 func (s *Server) handleTxValidate(req *Request) (interface{}, *Error) {
 	var params TxSubmitParam
 	if err := parseParams(req, &params); err != nil {
@@ -259,6 +425,7 @@ func (s *Server) handleTxValidate(req *Request) (interface{}, *Error) {
 	if rpcErr != nil {
 		return nil, rpcErr
 	}
+	defer cc.release()
 
 	adapter := miner.NewUTXOAdapter(cc.utxos)
 	fee, err := params.Transaction.ValidateWithUTXOs(adapter)
@@ -282,9 +449,61 @@ func (s *Server) handleMempoolGetInfo(req *Request) (interface{}, *Error) {
 	if err != nil {
 		return nil, err
 	}
+	defer cc.release()
+	removed := cc.pool.Removals()
 	return &MempoolInfoResult{
 		Count:      cc.pool.Count(),
 		MinFeeRate: cc.pool.MinFeeRate(),
+		Bytes:      cc.pool.Bytes(),
+		MaxBytes:   cc.pool.MaxBytes(),
+		MaxAge:     uint64(cc.pool.MaxAge() / time.Second),
+		Removed: MempoolRemovals{
+			Confirmed: removed.Confirmed,
+			Conflict:  removed.Conflict,
+			Expired:   removed.Expired,
+			Evicted:   removed.Evicted,
+			Replaced:  removed.Replaced,
+			Invalid:   removed.Invalid,
+		},
+	}, nil
+}
+
+func (s *Server) handleMempoolSave(req *Request) (interface{}, *Error) {
+	if s.mempoolFile == "" {
+		return nil, &Error{Code: CodeInternalError, Message: "mempool persistence is disabled"}
+	}
+	saved, err := s.pool.Save(s.mempoolFile)
+	if err != nil {
+		return nil, &Error{Code: CodeInternalError, Message: err.Error()}
+	}
+	return &MempoolSaveResult{Saved: saved, Path: s.mempoolFile}, nil
+}
+
+func (s *Server) handleFeeEstimate(req *Request) (interface{}, *Error) {
+	var params FeeEstimateParam
+	if req.Params != nil {
+		if err := parseParams(req, &params); err != nil {
+			return nil, err
+		}
+	}
+	if params.TargetBlocks < 0 || params.TargetBlocks > mempool.MaxEstimateTarget {
+		return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("target_blocks must be between 1 and %d", mempool.MaxEstimateTarget)}
+	}
+	if params.TargetBlocks == 0 {
+		params.TargetBlocks = mempool.DefaultEstimateTarget
+	}
+
+	cc, rpcErr := s.resolveChain(params.ChainID)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	defer cc.release()
+
+	rate, estimated := cc.pool.EstimateFeeRate(params.TargetBlocks)
+	return &FeeEstimateResult{
+		FeeRate:      rate,
+		TargetBlocks: params.TargetBlocks,
+		Estimated:    estimated,
 	}, nil
 }
 
@@ -293,6 +512,7 @@ func (s *Server) handleMempoolGetContent(req *Request) (interface{}, *Error) {
 	if err != nil {
 		return nil, err
 	}
+	defer cc.release()
 	hashes := cc.pool.Hashes()
 	hexHashes := make([]string, len(hashes))
 	for i, h := range hashes {
@@ -316,6 +536,16 @@ func (s *Server) handleNetGetPeerInfo(_ *Request) (interface{}, *Error) {
 		infos[i] = PeerInfo{
 			ID:          p.ID.String(),
 			ConnectedAt: p.ConnectedAt.UTC().Format("2006-01-02T15:04:05Z"),
+			Bandwidth:   bandwidthStats(s.p2pNode.BandwidthForPeer(p.ID)),
+
+			SyncReputation: p.SyncReputation,
+			Isolated:       s.p2pNode.IsIsolated(p.ID),
+		}
+		if p.Info != nil {
+			infos[i].ProtocolVersion = p.Info.ProtocolVersion
+			infos[i].UserAgent = p.Info.UserAgent
+			infos[i].Capabilities = p.Info.Capabilities.Names()
+			infos[i].SubChains = p.Info.SubChains
 		}
 	}
 
@@ -327,15 +557,46 @@ func (s *Server) handleNetGetPeerInfo(_ *Request) (interface{}, *Error) {
 
 func (s *Server) handleNetGetNodeInfo(_ *Request) (interface{}, *Error) {
 	if s.p2pNode == nil {
-		return &NodeInfoResult{ID: "", Addrs: []string{}}, nil
+		return &NodeInfoResult{ID: "", Addrs: []string{}, ListenAddrs: []string{}, Reachability: "unknown"}, nil
 	}
 
 	return &NodeInfoResult{
-		ID:    s.p2pNode.ID().String(),
-		Addrs: s.p2pNode.Addrs(),
+		ID:           s.p2pNode.ID().String(),
+		Addrs:        s.p2pNode.Addrs(),
+		ListenAddrs:  s.p2pNode.ListenAddrs(),
+		Reachability: s.p2pNode.Reachability(),
 	}, nil
 }
 
+func (s *Server) handleNetGetBandwidth(_ *Request) (interface{}, *Error) {
+	result := &BandwidthResult{
+		Protocols: map[string]BandwidthStats{},
+		Topics:    map[string]BandwidthStats{},
+	}
+	if s.p2pNode == nil {
+		return result, nil
+	}
+
+	result.Totals = bandwidthStats(s.p2pNode.BandwidthTotals())
+	for proto, st := range s.p2pNode.BandwidthByProtocol() {
+		result.Protocols[string(proto)] = bandwidthStats(st)
+	}
+	for topic, st := range s.p2pNode.BandwidthByTopic() {
+		result.Topics[topic] = bandwidthStats(st)
+	}
+	return result, nil
+}
+
+// bandwidthStats converts libp2p bandwidth stats for RPC output.
+func bandwidthStats(st metrics.Stats) BandwidthStats {
+	return BandwidthStats{
+		TotalIn:  st.TotalIn,
+		TotalOut: st.TotalOut,
+		RateIn:   st.RateIn,
+		RateOut:  st.RateOut,
+	}
+}
+
 func (s *Server) handleNetGetBanList(_ *Request) (interface{}, *Error) {
 	if s.banManager == nil {
 		return &BanListResult{Count: 0, Bans: []BanEntry{}}, nil
@@ -389,7 +650,9 @@ func (s *Server) handleStakeGetInfo(req *Request) (interface{}, *Error) {
 	}
 
 	// Query stake UTXOs.
-	stakes, stakeErr := s.utxos.GetStakes(pubKeyBytes)
+	view := s.chain.AcquireView()
+	defer view.Release()
+	stakes, stakeErr := view.UTXOs().GetStakes(pubKeyBytes)
 	if stakeErr != nil {
 		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("get stakes: %v", stakeErr)}
 	}
@@ -662,16 +925,19 @@ func (s *Server) handleSubChainGetBalance(req *Request) (interface{}, *Error) {
 		return nil, addrErr
 	}
 
-	utxos, err := sr.UTXOs.GetByAddress(addr)
+	view := sr.Chain.AcquireView()
+	defer view.Release()
+
+	utxos, err := view.UTXOs().GetByAddress(addr)
 	if err != nil {
 		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("get utxos: %v", err)}
 	}
 
 	// Also include stake UTXOs for this address.
-	stakeUTXOs, _ := stakesByAddress(sr.UTXOs, addr)
+	stakeUTXOs, _ := stakesByAddress(view.UTXOs(), addr)
 	utxos = append(utxos, stakeUTXOs...)
 
-	chainHeight := sr.Chain.Height()
+	chainHeight := view.Height()
 	bal := classifyUTXOs(utxos, chainHeight)
 
 	return &SubChainBalanceResult{
@@ -743,7 +1009,10 @@ func (s *Server) handleTokenGetBalance(req *Request) (interface{}, *Error) {
 		return nil, addrErr
 	}
 
-	utxos, err := s.utxos.GetByAddress(addr)
+	view := s.chain.AcquireView()
+	defer view.Release()
+
+	utxos, err := view.UTXOs().GetByAddress(addr)
 	if err != nil {
 		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("get utxos: %v", err)}
 	}
@@ -844,10 +1113,17 @@ func (s *Server) handleMiningGetBlockTemplate(req *Request) (interface{}, *Error
 	}
 
 	// Build block template (same as miner.ProduceBlock, but skip Seal).
+	height := sr.Chain.Height() + 1
 	var selected []*tx.Transaction
 	var totalFees uint64
 	if sr.Pool != nil {
-		selected = sr.Pool.SelectForBlock(499) // Reserve slot for coinbase.
+		// Reserve a slot and room for the coinbase.
+		budget := miner.TxByteBudget(coinbaseAddr, height)
+		if sr.Genesis.Protocol.Forks.InBlockSpends(height) {
+			selected = sr.Pool.SelectPackages(config.MaxBlockTxs-1, budget)
+		} else {
+			selected = sr.Pool.SelectConfirmed(config.MaxBlockTxs-1, budget)
+		}
 		for _, t := range selected {
 			totalFees += sr.Pool.GetFee(t.Hash())
 		}
@@ -871,7 +1147,6 @@ func (s *Server) handleMiningGetBlockTemplate(req *Request) (interface{}, *Error
 		return bytes.Compare(hi[:], hj[:]) < 0
 	})
 
-	height := sr.Chain.Height() + 1
 	coinbaseTx := miner.BuildCoinbase(coinbaseAddr, reward+totalFees, height)
 	txs := make([]*tx.Transaction, 0, 1+len(selected))
 	txs = append(txs, coinbaseTx)
@@ -1024,3 +1299,23 @@ func decodeAddress(s string) (types.Address, *Error) {
 	}
 	return addr, nil
 }
+
+// parseOutpointCursor parses a "txid:index" page cursor.
+func parseOutpointCursor(s string) (types.Outpoint, error) {
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return types.Outpoint{}, fmt.Errorf("invalid cursor: want txid:index")
+	}
+	txid, err := hex.DecodeString(s[:i])
+	if err != nil || len(txid) != types.HashSize {
+		return types.Outpoint{}, fmt.Errorf("invalid cursor: bad txid")
+	}
+	index, err := strconv.ParseUint(s[i+1:], 10, 32)
+	if err != nil {
+		return types.Outpoint{}, fmt.Errorf("invalid cursor: bad index")
+	}
+	var op types.Outpoint
+	copy(op.TxID[:], txid)
+	op.Index = uint32(index)
+	return op, nil
+}
@@ -0,0 +1,73 @@
+package rpc
+
+import (
+	"sync"
+
+	"github.com/Klingon-tech/klingnet-chain/internal/mempool"
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// Rebroadcaster keeps announcing root-chain transactions sent by the
+// wallet until they are mined. A transaction is dropped once it can no
+// longer enter the mempool: mined, double spent or priced out.
+type Rebroadcaster struct {
+	pool     *mempool.Pool
+	announce func(*tx.Transaction) error
+
+	mu  sync.Mutex
+	txs map[types.Hash]*tx.Transaction
+}
+
+// NewRebroadcaster creates a rebroadcaster that re-adds transactions to
+// pool and announces them with announce.
+func NewRebroadcaster(pool *mempool.Pool, announce func(*tx.Transaction) error) *Rebroadcaster {
+	return &Rebroadcaster{
+		pool:     pool,
+		announce: announce,
+		txs:      make(map[types.Hash]*tx.Transaction),
+	}
+}
+
+// Track adds a wallet transaction.
+func (r *Rebroadcaster) Track(t *tx.Transaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.txs[t.Hash()] = t
+}
+
+// Count returns the number of tracked transactions.
+func (r *Rebroadcaster) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.txs)
+}
+
+// Rebroadcast announces every tracked transaction still unconfirmed,
+// re-adding it to the mempool if it was dropped. Returns how many were
+// announced and how many stopped being tracked.
+func (r *Rebroadcaster) Rebroadcast() (announced, dropped int) {
+	r.mu.Lock()
+	txs := make([]*tx.Transaction, 0, len(r.txs))
+	for _, t := range r.txs {
+		txs = append(txs, t)
+	}
+	r.mu.Unlock()
+
+	for _, t := range txs {
+		h := t.Hash()
+		if !r.pool.Has(h) {
+			if _, err := r.pool.Add(t); err != nil {
+				r.mu.Lock()
+				delete(r.txs, h)
+				r.mu.Unlock()
+				dropped++
+				continue
+			}
+		}
+		if r.announce(t) == nil {
+			announced++
+		}
+	}
+	return announced, dropped
+}
@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/Klingon-tech/klingnet-chain/config"
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+func TestRebroadcaster(t *testing.T) {
+	env := setupTestEnv(t)
+
+	utxos, err := env.utxoStore.GetByAddress(env.validatorAddr)
+	if err != nil || len(utxos) == 0 {
+		t.Fatalf("GetByAddress: %v (%d utxos)", err, len(utxos))
+	}
+	in := utxos[0]
+	script := types.Script{Type: types.ScriptTypeP2PKH, Data: env.validatorAddr[:]}
+	build := func(value uint64) *tx.Transaction {
+		b := tx.NewBuilder().AddInput(in.Outpoint).AddOutput(value, script)
+		if err := b.Sign(env.validatorKey); err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return b.Build()
+	}
+
+	var announced []types.Hash
+	rb := NewRebroadcaster(env.pool, func(t *tx.Transaction) error {
+		announced = append(announced, t.Hash())
+		return nil
+	})
+
+	sent := build(in.Value - config.MilliCoin)
+	rb.Track(sent)
+
+	// Dropped from the mempool: added back and announced.
+	if n, dropped := rb.Rebroadcast(); n != 1 || dropped != 0 {
+		t.Fatalf("Rebroadcast = %d, %d; want 1, 0", n, dropped)
+	}
+	if !env.pool.Has(sent.Hash()) || len(announced) != 1 || announced[0] != sent.Hash() {
+		t.Fatalf("tx not re-added and announced (announced %v)", announced)
+	}
+
+	// Still pending: announced again.
+	if n, _ := rb.Rebroadcast(); n != 1 {
+		t.Errorf("second Rebroadcast announced %d, want 1", n)
+	}
+
+	// Double spent: no longer tracked.
+	env.pool.Remove(sent.Hash())
+	if _, err := env.pool.Add(build(in.Value - 2*config.MilliCoin)); err != nil {
+		t.Fatalf("add conflicting tx: %v", err)
+	}
+	if n, dropped := rb.Rebroadcast(); n != 0 || dropped != 1 {
+		t.Errorf("Rebroadcast after double spend = %d, %d; want 0, 1", n, dropped)
+	}
+	if rb.Count() != 0 {
+		t.Errorf("tracked = %d, want 0", rb.Count())
+	}
+}
@@ -34,27 +34,29 @@ const maxBodySize = 1 << 20
 
 // Server is the JSON-RPC 2.0 HTTP server.
 type Server struct {
-	addr        string
-	chain       *chain.Chain
-	utxos       *utxo.Store
-	pool        *mempool.Pool
-	p2pNode     *p2p.Node
-	genesis     *config.Genesis
-	engine      consensus.Engine                       // For validator queries.
-	scManager   *subchain.Manager                      // For sub-chain queries (nil = disabled).
-	keystore    *wallet.Keystore                       // For wallet RPC (nil = disabled).
-	tokenStore  *token.Store                           // For token queries (nil = disabled).
-	tracker     *consensus.ValidatorTracker            // For root chain validator status (nil = disabled).
-	scTrackers  map[string]*consensus.ValidatorTracker // chainID hex → sub-chain tracker
-	trackersMu  sync.RWMutex                           // guards tracker + scTrackers
-	txIndex     *WalletTxIndex                         // For indexed wallet history (nil = scan fallback).
-	banManager  *p2p.BanManager                        // For net_getBanList (nil = disabled).
-	server      *http.Server
-	logger      zerolog.Logger
-	ln          net.Listener
-	allowedNets []*net.IPNet // Empty = allow all.
-	denyAllRPC  bool         // True when allowlist config is invalid; fail closed.
-	corsOrigins []string     // Empty = no CORS headers.
+	addr          string
+	chain         *chain.Chain
+	utxos         *utxo.Store
+	pool          *mempool.Pool
+	p2pNode       *p2p.Node
+	genesis       *config.Genesis
+	engine        consensus.Engine                       // For validator queries.
+	scManager     *subchain.Manager                      // For sub-chain queries (nil = disabled).
+	keystore      *wallet.Keystore                       // For wallet RPC (nil = disabled).
+	tokenStore    *token.Store                           // For token queries (nil = disabled).
+	tracker       *consensus.ValidatorTracker            // For root chain validator status (nil = disabled).
+	scTrackers    map[string]*consensus.ValidatorTracker // chainID hex → sub-chain tracker
+	trackersMu    sync.RWMutex                           // guards tracker + scTrackers
+	txIndex       *WalletTxIndex                         // For indexed wallet history (nil = scan fallback).
+	banManager    *p2p.BanManager                        // For net_getBanList (nil = disabled).
+	mempoolFile   string                                 // For mempool_save ("" = disabled).
+	rebroadcaster *Rebroadcaster                         // Wallet tx rebroadcast (nil = disabled).
+	server        *http.Server
+	logger        zerolog.Logger
+	ln            net.Listener
+	allowedNets   []*net.IPNet // Empty = allow all.
+	denyAllRPC    bool         // True when allowlist config is invalid; fail closed.
+	corsOrigins   []string     // Empty = no CORS headers.
 }
 
 // New creates a new RPC server. The rpcCfg parameter controls IP filtering
@@ -220,6 +222,17 @@ func (s *Server) SetBanManager(bm *p2p.BanManager) {
 	s.banManager = bm
 }
 
+// SetMempoolFile sets the path mempool_save writes the root mempool to.
+func (s *Server) SetMempoolFile(path string) {
+	s.mempoolFile = path
+}
+
+// SetRebroadcaster sets where wallet transactions are tracked for
+// rebroadcast until mined.
+func (s *Server) SetRebroadcaster(rb *Rebroadcaster) {
+	s.rebroadcaster = rb
+}
+
 // SetWalletTxIndex sets the persistent wallet transaction index.
 func (s *Server) SetWalletTxIndex(idx *WalletTxIndex) {
 	s.txIndex = idx
@@ -307,6 +320,8 @@ func (s *Server) dispatch(req *Request) (interface{}, *Error) {
 		return s.handleChainGetBlockByHash(req)
 	case "chain_getBlockByHeight":
 		return s.handleChainGetBlockByHeight(req)
+	case "chain_getBlockHashes":
+		return s.handleChainGetBlockHashes(req)
 	case "chain_getTransaction":
 		return s.handleChainGetTransaction(req)
 	case "utxo_get":
@@ -317,18 +332,26 @@ func (s *Server) dispatch(req *Request) (interface{}, *Error) {
 		return s.handleUTXOGetBalance(req)
 	case "tx_submit":
 		return s.handleTxSubmit(req)
+	case "tx_submitPackage":
+		return s.handleTxSubmitPackage(req)
 	case "tx_validate":
 		return s.handleTxValidate(req)
 	case "mempool_getInfo":
 		return s.handleMempoolGetInfo(req)
 	case "mempool_getContent":
 		return s.handleMempoolGetContent(req)
+	case "mempool_save":
+		return s.handleMempoolSave(req)
+	case "fee_estimate":
+		return s.handleFeeEstimate(req)
 	case "net_getPeerInfo":
 		return s.handleNetGetPeerInfo(req)
 	case "net_getNodeInfo":
 		return s.handleNetGetNodeInfo(req)
 	case "net_getBanList":
 		return s.handleNetGetBanList(req)
+	case "net_getBandwidth":
+		return s.handleNetGetBandwidth(req)
 	case "stake_getInfo":
 		return s.handleStakeGetInfo(req)
 	case "stake_getValidators":
@@ -361,6 +384,8 @@ func (s *Server) dispatch(req *Request) (interface{}, *Error) {
 		return s.handleWalletConsolidate(req)
 	case "wallet_sendMany":
 		return s.handleWalletSendMany(req)
+	case "wallet_bumpFee":
+		return s.handleWalletBumpFee(req)
 	case "wallet_exportKey":
 		return s.handleWalletExportKey(req)
 	case "wallet_getPubKey":
@@ -471,13 +496,35 @@ func parseParams(req *Request, target interface{}) *Error {
 }
 
 // chainContext holds chain/utxo/pool/genesis for either root or a sub-chain.
+// view is a consistent read snapshot of the chain tip; utxos reads from it.
+// Callers must call release once the request has been served.
 type chainContext struct {
 	chain   *chain.Chain
+	view    *chain.View
 	utxos   *utxo.Store
 	pool    *mempool.Pool
 	genesis *config.Genesis
 }
 
+// newChainContext acquires a read view of ch and builds a context around it.
+func newChainContext(ch *chain.Chain, pool *mempool.Pool, genesis *config.Genesis) *chainContext {
+	view := ch.AcquireView()
+	return &chainContext{
+		chain:   ch,
+		view:    view,
+		utxos:   view.UTXOs(),
+		pool:    pool,
+		genesis: genesis,
+	}
+}
+
+// release drops the context's read view.
+func (cc *chainContext) release() {
+	if cc.view != nil {
+		cc.view.Release()
+	}
+}
+
 // extractChainID pulls an optional chain_id string from raw request params.
 // Returns "" if params is nil or chain_id is absent.
 func extractChainID(req *Request) string {
@@ -498,15 +545,11 @@ func extractChainID(req *Request) string {
 }
 
 // resolveChain returns root or sub-chain context based on chainIDHex.
-// An empty string returns the root chain context.
+// An empty string returns the root chain context. On success the caller
+// must release the returned context.
 func (s *Server) resolveChain(chainIDHex string) (*chainContext, *Error) {
 	if chainIDHex == "" {
-		return &chainContext{
-			chain:   s.chain,
-			utxos:   s.utxos,
-			pool:    s.pool,
-			genesis: s.genesis,
-		}, nil
+		return newChainContext(s.chain, s.pool, s.genesis), nil
 	}
 
 	if s.scManager == nil {
@@ -526,10 +569,5 @@ func (s *Server) resolveChain(chainIDHex string) (*chainContext, *Error) {
 		return nil, &Error{Code: CodeNotFound, Message: fmt.Sprintf("sub-chain %s not synced on this node", chainIDHex)}
 	}
 
-	return &chainContext{
-		chain:   sr.Chain,
-		utxos:   sr.UTXOs,
-		pool:    sr.Pool,
-		genesis: sr.Genesis,
-	}, nil
+	return newChainContext(sr.Chain, sr.Pool, sr.Genesis), nil
 }
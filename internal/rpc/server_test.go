@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"math/big"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -362,6 +365,7 @@ func TestRPC_UTXOGetBalance_IncludesStakes(t *testing.T) {
 	if err := env.utxoStore.Put(stakeUTXO); err != nil {
 		t.Fatalf("put stake utxo: %v", err)
 	}
+	env.chain.RefreshView()
 
 	// Query balance by address — should include stakes even though
 	// stake UTXOs are indexed by pubkey, not address.
@@ -434,6 +438,12 @@ func TestRPC_MempoolGetInfo(t *testing.T) {
 	if result.MinFeeRate != 10 {
 		t.Errorf("min_fee_rate = %d, want %d", result.MinFeeRate, 10)
 	}
+	if result.MaxBytes != mempool.DefaultMaxBytes {
+		t.Errorf("max_bytes = %d, want %d", result.MaxBytes, mempool.DefaultMaxBytes)
+	}
+	if result.MaxAge != uint64(mempool.DefaultMaxAge.Seconds()) {
+		t.Errorf("max_age = %d, want %d", result.MaxAge, uint64(mempool.DefaultMaxAge.Seconds()))
+	}
 }
 
 func TestRPC_MempoolGetContent(t *testing.T) {
@@ -453,6 +463,127 @@ func TestRPC_MempoolGetContent(t *testing.T) {
 	}
 }
 
+func TestRPC_MempoolSave(t *testing.T) {
+	env := setupTestEnv(t)
+
+	resp := rpcCall(t, env.url, "mempool_save", nil)
+	if resp.Error == nil {
+		t.Fatal("expected error when persistence is disabled")
+	}
+
+	path := filepath.Join(t.TempDir(), "mempool.json")
+	env.server.SetMempoolFile(path)
+	resp = rpcCall(t, env.url, "mempool_save", nil)
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %v", resp.Error.Message)
+	}
+	data, _ := json.Marshal(resp.Result)
+	var result MempoolSaveResult
+	json.Unmarshal(data, &result)
+
+	if result.Saved != 0 || result.Path != path {
+		t.Errorf("result = %+v", result)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("mempool file not written: %v", err)
+	}
+}
+
+func TestRPC_FeeEstimate(t *testing.T) {
+	env := setupTestEnv(t)
+	env.pool.SetFeeEstimator(mempool.NewFeeEstimator())
+
+	resp := rpcCall(t, env.url, "fee_estimate", nil)
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %v", resp.Error.Message)
+	}
+	data, _ := json.Marshal(resp.Result)
+	var result FeeEstimateResult
+	json.Unmarshal(data, &result)
+
+	// No blocks observed yet: fall back to the pool minimum.
+	if result.Estimated || result.FeeRate != env.pool.MinFeeRate() || result.TargetBlocks != mempool.DefaultEstimateTarget {
+		t.Errorf("result = %+v", result)
+	}
+
+	resp = rpcCall(t, env.url, "fee_estimate", FeeEstimateParam{TargetBlocks: mempool.MaxEstimateTarget + 1})
+	if resp.Error == nil {
+		t.Error("expected error for target beyond the maximum")
+	}
+}
+
+func TestRPC_TxSubmitPolicyReject(t *testing.T) {
+	env := setupTestEnv(t)
+
+	utxos, err := env.utxoStore.GetByAddress(env.validatorAddr)
+	if err != nil || len(utxos) == 0 {
+		t.Fatalf("GetByAddress: %v (%d utxos)", err, len(utxos))
+	}
+	in := utxos[0]
+	b := tx.NewBuilder().
+		AddInput(in.Outpoint).
+		AddOutput(in.Value-config.MilliCoin-config.Coin, types.Script{Type: types.ScriptTypeP2PKH, Data: env.validatorAddr[:]}).
+		AddOutput(config.Coin, types.Script{Type: types.ScriptTypeP2SH, Data: env.validatorAddr[:]})
+	if err := b.Sign(env.validatorKey); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	env.pool.SetPolicy(&mempool.Policy{AllowedScripts: []types.ScriptType{types.ScriptTypeP2PKH}})
+	resp := rpcCall(t, env.url, "tx_submit", TxSubmitParam{Transaction: b.Build()})
+	if resp.Error == nil || resp.Error.Code != CodeTxScriptType {
+		t.Fatalf("error = %+v, want code %d", resp.Error, CodeTxScriptType)
+	}
+}
+
+func TestRPC_TxSubmitPackage(t *testing.T) {
+	env := setupTestEnv(t)
+
+	utxos, err := env.utxoStore.GetByAddress(env.validatorAddr)
+	if err != nil || len(utxos) == 0 {
+		t.Fatalf("GetByAddress: %v (%d utxos)", err, len(utxos))
+	}
+	in := utxos[0]
+	script := types.Script{Type: types.ScriptTypeP2PKH, Data: env.validatorAddr[:]}
+	build := func(prevOut types.Outpoint, value uint64) *tx.Transaction {
+		b := tx.NewBuilder().AddInput(prevOut).AddOutput(value, script)
+		if err := b.Sign(env.validatorKey); err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return b.Build()
+	}
+
+	// The parent pays below the minimum fee rate; the child pays for both.
+	// Amounts keep few significant digits to survive JSON number decoding.
+	env.pool.SetMinFeeRate(config.MilliCoin)
+	parent := build(in.Outpoint, in.Value-config.MilliCoin)
+	child := build(types.Outpoint{TxID: parent.Hash(), Index: 0}, in.Value-config.MilliCoin-config.Coin)
+
+	resp := rpcCall(t, env.url, "tx_submit", TxSubmitParam{Transaction: parent})
+	if resp.Error == nil || !strings.Contains(resp.Error.Message, "fee below minimum") {
+		t.Fatalf("parent alone should be rejected for its fee, got %+v", resp.Error)
+	}
+
+	resp = rpcCall(t, env.url, "tx_submitPackage", TxSubmitPackageParam{
+		Transactions: []*tx.Transaction{parent, child},
+	})
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %v", resp.Error.Message)
+	}
+	data, _ := json.Marshal(resp.Result)
+	var result TxSubmitPackageResult
+	json.Unmarshal(data, &result)
+
+	if len(result.TxHashes) != 2 || result.TxHashes[1] != child.Hash().String() {
+		t.Errorf("tx_hashes = %v", result.TxHashes)
+	}
+	if len(result.Fees) != 2 || result.Fees[0] != config.MilliCoin || result.Fees[1] != config.Coin {
+		t.Errorf("fees = %v, want [%d %d]", result.Fees, config.MilliCoin, config.Coin)
+	}
+	if env.pool.Count() != 2 {
+		t.Errorf("pool count = %d, want 2", env.pool.Count())
+	}
+}
+
 func TestRPC_NetGetNodeInfo(t *testing.T) {
 	env := setupTestEnv(t)
 
@@ -488,6 +619,24 @@ func TestRPC_NetGetPeerInfo(t *testing.T) {
 	}
 }
 
+func TestRPC_NetGetBandwidth(t *testing.T) {
+	env := setupTestEnv(t)
+
+	resp := rpcCall(t, env.url, "net_getBandwidth", nil)
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %v", resp.Error.Message)
+	}
+
+	data, _ := json.Marshal(resp.Result)
+	var result BandwidthResult
+	json.Unmarshal(data, &result)
+
+	// P2P node is nil in test, so no traffic is reported.
+	if result.Totals.TotalIn != 0 || len(result.Protocols) != 0 || len(result.Topics) != 0 {
+		t.Errorf("expected empty bandwidth without P2P node, got %+v", result)
+	}
+}
+
 func TestRPC_MethodNotFound(t *testing.T) {
 	env := setupTestEnv(t)
 
@@ -1341,6 +1490,7 @@ func TestRPC_WalletUnstake(t *testing.T) {
 	if err := env.utxoStore.Put(stakeUTXO); err != nil {
 		t.Fatalf("put stake utxo: %v", err)
 	}
+	env.chain.RefreshView()
 
 	// Also put the same UTXO in the provider (adapter) so mempool can validate.
 	// The adapter reads from utxoStore, so it should be available.
@@ -1517,6 +1667,7 @@ func TestRPC_TokenGetBalance_WithTokenUTXOs(t *testing.T) {
 	if err := env.utxoStore.Put(tokenUTXO); err != nil {
 		t.Fatalf("put token utxo: %v", err)
 	}
+	env.chain.RefreshView()
 
 	// Plant metadata for enrichment.
 	ts := token.NewStore(env.db)
@@ -16,6 +16,18 @@ const (
 	CodeNotFound       = -32000
 )
 
+// Transaction rejections under the node's mempool policy, returned by
+// tx_submit and tx_submitPackage. Other rejections use CodeInvalidParams.
+const (
+	CodeTxNonStandard  = -32010 // Over the policy size limit
+	CodeTxDust         = -32011 // Output below the dust threshold
+	CodeTxScriptData   = -32012 // Output script data over the policy limit
+	CodeTxScriptType   = -32013 // Output script type not allowed
+	CodeTxTokenOutputs = -32014 // Too many token outputs
+	CodeTxAddressRate  = -32015 // Spending address over its rate limit
+	CodeTxPolicyRule   = -32016 // Rejected by an embedder's policy rule
+)
+
 // Request is a JSON-RPC 2.0 request.
 type Request struct {
 	JSONRPC string      `json:"jsonrpc"`
@@ -53,6 +65,14 @@ type HeightParam struct {
 	ChainID string `json:"chain_id,omitempty"`
 }
 
+// BlockHashesParam is used by chain_getBlockHashes.
+type BlockHashesParam struct {
+	FromHeight *uint64 `json:"from_height,omitempty"` // Default: 0, or the tip when reverse
+	Limit      int     `json:"limit,omitempty"`       // Default 100, max 1000
+	Reverse    bool    `json:"reverse,omitempty"`     // Walk from FromHeight toward genesis
+	ChainID    string  `json:"chain_id,omitempty"`
+}
+
 // OutpointParam is used by utxo_get.
 type OutpointParam struct {
 	TxID    string `json:"tx_id"`
@@ -61,9 +81,13 @@ type OutpointParam struct {
 }
 
 // AddressParam is used by utxo_getByAddress and utxo_getBalance.
+// Limit and Cursor page utxo_getByAddress results and are ignored by
+// utxo_getBalance.
 type AddressParam struct {
 	Address string `json:"address"`
 	ChainID string `json:"chain_id,omitempty"`
+	Limit   int    `json:"limit,omitempty"`  // 0 = all
+	Cursor  string `json:"cursor,omitempty"` // next_cursor from a previous page
 }
 
 // TxSubmitParam is used by tx_submit and tx_validate.
@@ -72,6 +96,12 @@ type TxSubmitParam struct {
 	ChainID     string          `json:"chain_id,omitempty"`
 }
 
+// TxSubmitPackageParam is used by tx_submitPackage.
+type TxSubmitPackageParam struct {
+	Transactions []*tx.Transaction `json:"transactions"` // Parents before children.
+	ChainID      string            `json:"chain_id,omitempty"`
+}
+
 // ── Block/Tx result types ───────────────────────────────────────────────
 
 // BlockResult wraps a block with its precomputed hash for RPC responses.
@@ -118,10 +148,20 @@ func NewTxResult(t *tx.Transaction) *TxResult {
 
 // ChainInfoResult is returned by chain_getInfo.
 type ChainInfoResult struct {
-	ChainID string `json:"chain_id"`
-	Symbol  string `json:"symbol,omitempty"`
-	Height  uint64 `json:"height"`
-	TipHash string `json:"tip_hash"`
+	ChainID          string           `json:"chain_id"`
+	Symbol           string           `json:"symbol,omitempty"`
+	Height           uint64           `json:"height"`
+	TipHash          string           `json:"tip_hash"`
+	CheckpointHeight uint64           `json:"checkpoint_height,omitempty"` // Highest configured checkpoint
+	AssumeValid      *AssumeValidInfo `json:"assume_valid,omitempty"`
+}
+
+// AssumeValidInfo reports assume-valid fast sync progress.
+type AssumeValidInfo struct {
+	Height   uint64  `json:"height"`
+	Hash     string  `json:"hash"`
+	Active   bool    `json:"active"`   // Still syncing toward the assume-valid block
+	Progress float64 `json:"progress"` // Fraction of blocks up to the assume-valid block synced (0-1)
 }
 
 // BalanceResult is returned by utxo_getBalance.
@@ -134,10 +174,23 @@ type BalanceResult struct {
 	Locked    uint64 `json:"locked"`    // Unstake cooldown (LockedUntil > height)
 }
 
+// BlockHashesResult is returned by chain_getBlockHashes.
+type BlockHashesResult struct {
+	Blocks     []BlockHashEntry `json:"blocks"`
+	NextHeight *uint64          `json:"next_height,omitempty"` // from_height for the next page; absent on the last page
+}
+
+// BlockHashEntry is a single height → hash pair in a BlockHashesResult.
+type BlockHashEntry struct {
+	Height uint64 `json:"height"`
+	Hash   string `json:"hash"`
+}
+
 // UTXOListResult is returned by utxo_getByAddress.
 type UTXOListResult struct {
-	Address string       `json:"address"`
-	UTXOs   []*utxo.UTXO `json:"utxos"`
+	Address    string       `json:"address"`
+	UTXOs      []*utxo.UTXO `json:"utxos"`
+	NextCursor string       `json:"next_cursor,omitempty"` // Empty on the last page.
 }
 
 // TxSubmitResult is returned by tx_submit.
@@ -145,6 +198,12 @@ type TxSubmitResult struct {
 	TxHash string `json:"tx_hash"`
 }
 
+// TxSubmitPackageResult is returned by tx_submitPackage.
+type TxSubmitPackageResult struct {
+	TxHashes []string `json:"tx_hashes"`
+	Fees     []uint64 `json:"fees"`
+}
+
 // TxValidateResult is returned by tx_validate.
 type TxValidateResult struct {
 	Valid bool   `json:"valid"`
@@ -154,8 +213,41 @@ type TxValidateResult struct {
 
 // MempoolInfoResult is returned by mempool_getInfo.
 type MempoolInfoResult struct {
-	Count      int    `json:"count"`
-	MinFeeRate uint64 `json:"min_fee_rate"`
+	Count      int             `json:"count"`
+	MinFeeRate uint64          `json:"min_fee_rate"`
+	Bytes      int             `json:"bytes"`
+	MaxBytes   int             `json:"max_bytes"` // 0 = unlimited.
+	MaxAge     uint64          `json:"max_age"`   // Seconds, 0 = forever.
+	Removed    MempoolRemovals `json:"removed"`
+}
+
+// MempoolRemovals counts transactions removed from the mempool, by reason.
+type MempoolRemovals struct {
+	Confirmed uint64 `json:"confirmed"`
+	Conflict  uint64 `json:"conflict"`
+	Expired   uint64 `json:"expired"`
+	Evicted   uint64 `json:"evicted"`
+	Replaced  uint64 `json:"replaced"`
+	Invalid   uint64 `json:"invalid"`
+}
+
+// FeeEstimateParam is used by fee_estimate.
+type FeeEstimateParam struct {
+	TargetBlocks int    `json:"target_blocks,omitempty"` // Default 6, max 48.
+	ChainID      string `json:"chain_id,omitempty"`
+}
+
+// FeeEstimateResult is returned by fee_estimate.
+type FeeEstimateResult struct {
+	FeeRate      uint64 `json:"fee_rate"` // Base units per byte.
+	TargetBlocks int    `json:"target_blocks"`
+	Estimated    bool   `json:"estimated"` // False: not enough data, fee_rate is the pool minimum.
+}
+
+// MempoolSaveResult is returned by mempool_save.
+type MempoolSaveResult struct {
+	Saved int    `json:"saved"`
+	Path  string `json:"path"`
 }
 
 // MempoolContentResult is returned by mempool_getContent.
@@ -165,8 +257,35 @@ type MempoolContentResult struct {
 
 // PeerInfo describes a connected peer.
 type PeerInfo struct {
-	ID          string `json:"id"`
-	ConnectedAt string `json:"connected_at"`
+	ID          string         `json:"id"`
+	ConnectedAt string         `json:"connected_at"`
+	Bandwidth   BandwidthStats `json:"bandwidth"`
+
+	SyncReputation int  `json:"sync_reputation"`
+	Isolated       bool `json:"isolated"` // Skipped by sync for serving a false or dead chain.
+
+	// From the peer's handshake; empty until it completes.
+	ProtocolVersion uint32   `json:"protocol_version,omitempty"`
+	UserAgent       string   `json:"user_agent,omitempty"`
+	Capabilities    []string `json:"capabilities,omitempty"`
+	SubChains       []string `json:"sub_chains,omitempty"`
+}
+
+// BandwidthStats is the traffic of a protocol, topic or peer since
+// startup. Rates are bytes per second, smoothed over recent traffic.
+type BandwidthStats struct {
+	TotalIn  int64   `json:"total_in"`
+	TotalOut int64   `json:"total_out"`
+	RateIn   float64 `json:"rate_in"`
+	RateOut  float64 `json:"rate_out"`
+}
+
+// BandwidthResult is returned by net_getBandwidth. Topics count GossipSub
+// message bytes, which travel inside the pubsub protocols' traffic.
+type BandwidthResult struct {
+	Totals    BandwidthStats            `json:"totals"`
+	Protocols map[string]BandwidthStats `json:"protocols"`
+	Topics    map[string]BandwidthStats `json:"topics"`
 }
 
 // PeerInfoResult is returned by net_getPeerInfo.
@@ -177,8 +296,12 @@ type PeerInfoResult struct {
 
 // NodeInfoResult is returned by net_getNodeInfo.
 type NodeInfoResult struct {
-	ID    string   `json:"id"`
-	Addrs []string `json:"addrs"`
+	ID          string   `json:"id"`
+	Addrs       []string `json:"addrs"`        // Advertised to peers, with /p2p/<id>.
+	ListenAddrs []string `json:"listen_addrs"` // Bound by the host.
+
+	// Reachability is "public", "private" or "unknown" as detected by AutoNAT.
+	Reachability string `json:"reachability"`
 }
 
 // BanEntry describes a single banned peer.
@@ -282,10 +405,13 @@ type WalletNewAddressParam struct {
 
 // WalletSendParam is used by wallet_send.
 type WalletSendParam struct {
-	Name     string `json:"name"`
-	Password string `json:"password"`
-	To       string `json:"to"`
-	Amount   uint64 `json:"amount"`
+	Name         string `json:"name"`
+	Password     string `json:"password"`
+	To           string `json:"to"`
+	Amount       uint64 `json:"amount"`
+	FeeRate      uint64 `json:"fee_rate,omitempty"`      // Base units per byte (default: fee_estimate for target_blocks).
+	TargetBlocks int    `json:"target_blocks,omitempty"` // Confirmation target for the estimate (default 6).
+	Replaceable  bool   `json:"replaceable,omitempty"`   // Signal replace-by-fee so wallet_bumpFee can replace it.
 }
 
 // WalletExportKeyParam is used by wallet_exportKey.
@@ -370,6 +496,23 @@ type WalletSendResult struct {
 	TxHash string `json:"tx_hash"`
 }
 
+// WalletBumpFeeParam is used by wallet_bumpFee.
+type WalletBumpFeeParam struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+	TxHash   string `json:"tx_hash"`
+	FeeRate  uint64 `json:"fee_rate,omitempty"` // Base units per byte (default: minimum accepted replacement)
+	ChainID  string `json:"chain_id,omitempty"` // Optional: bump a sub-chain transaction instead of root.
+}
+
+// WalletBumpFeeResult is returned by wallet_bumpFee.
+type WalletBumpFeeResult struct {
+	TxHash         string `json:"tx_hash"`
+	ReplacedTxHash string `json:"replaced_tx_hash"`
+	OldFee         uint64 `json:"old_fee"`
+	NewFee         uint64 `json:"new_fee"`
+}
+
 // WalletConsolidateParam is used by wallet_consolidate.
 type WalletConsolidateParam struct {
 	Name      string `json:"name"`
@@ -396,9 +539,12 @@ type Recipient struct {
 
 // WalletSendManyParam is used by wallet_sendMany.
 type WalletSendManyParam struct {
-	Name       string      `json:"name"`
-	Password   string      `json:"password"`
-	Recipients []Recipient `json:"recipients"`
+	Name         string      `json:"name"`
+	Password     string      `json:"password"`
+	Recipients   []Recipient `json:"recipients"`
+	FeeRate      uint64      `json:"fee_rate,omitempty"`      // Base units per byte (default: fee_estimate for target_blocks).
+	TargetBlocks int         `json:"target_blocks,omitempty"` // Confirmation target for the estimate (default 6).
+	Replaceable  bool        `json:"replaceable,omitempty"`   // Signal replace-by-fee so wallet_bumpFee can replace it.
 }
 
 // WalletSendManyResult is returned by wallet_sendMany.
@@ -496,11 +642,12 @@ type SubChainBalanceResult struct {
 
 // SubChainSendParam is used by subchain_send.
 type SubChainSendParam struct {
-	ChainID  string `json:"chain_id"`
-	Name     string `json:"name"`
-	Password string `json:"password"`
-	To       string `json:"to"`
-	Amount   uint64 `json:"amount"`
+	ChainID     string `json:"chain_id"`
+	Name        string `json:"name"`
+	Password    string `json:"password"`
+	To          string `json:"to"`
+	Amount      uint64 `json:"amount"`
+	Replaceable bool   `json:"replaceable,omitempty"` // Signal replace-by-fee so wallet_bumpFee can replace it.
 }
 
 // SubChainSendResult is returned by subchain_send.
@@ -592,6 +739,7 @@ type WalletGetHistoryParam struct {
 	Password string `json:"password"`
 	Limit    int    `json:"limit,omitempty"`
 	Offset   int    `json:"offset,omitempty"`
+	Cursor   string `json:"cursor,omitempty"` // next_cursor from a previous page; overrides offset
 }
 
 // TxHistoryEntry describes a single transaction in wallet history.
@@ -612,8 +760,9 @@ type TxHistoryEntry struct {
 
 // WalletGetHistoryResult is returned by wallet_getHistory.
 type WalletGetHistoryResult struct {
-	Total   int              `json:"total"`
-	Entries []TxHistoryEntry `json:"entries"`
+	Total      int              `json:"total"`
+	Entries    []TxHistoryEntry `json:"entries"`
+	NextCursor string           `json:"next_cursor,omitempty"` // Empty on the last page.
 }
 
 // WalletRescanParam is used by wallet_rescan.
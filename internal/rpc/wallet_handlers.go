@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/Klingon-tech/klingnet-chain/config"
+	"github.com/Klingon-tech/klingnet-chain/internal/mempool"
 	"github.com/Klingon-tech/klingnet-chain/internal/subchain"
 	"github.com/Klingon-tech/klingnet-chain/internal/token"
 	"github.com/Klingon-tech/klingnet-chain/internal/utxo"
@@ -138,6 +139,37 @@ func filterNativeUTXOs(utxos []wallet.UTXO) []wallet.UTXO {
 	return native
 }
 
+// broadcastWalletTx relays a root-chain wallet transaction and keeps
+// rebroadcasting it until mined.
+func (s *Server) broadcastWalletTx(t *tx.Transaction) error {
+	if s.rebroadcaster != nil {
+		s.rebroadcaster.Track(t)
+	}
+	if s.p2pNode == nil {
+		return nil
+	}
+	return s.p2pNode.SubmitTx(t)
+}
+
+// sendFeeRate returns the fee rate for a wallet send: feeRate if given,
+// otherwise the pool's estimate for target blocks (default 6).
+func sendFeeRate(pool *mempool.Pool, feeRate uint64, target int) (uint64, *Error) {
+	if target < 0 || target > mempool.MaxEstimateTarget {
+		return 0, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("target_blocks must be between 1 and %d", mempool.MaxEstimateTarget)}
+	}
+	if feeRate > 0 {
+		if minRate := pool.MinFeeRate(); feeRate < minRate {
+			return 0, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("fee_rate %d is below the minimum %d", feeRate, minRate)}
+		}
+		return feeRate, nil
+	}
+	if target == 0 {
+		target = mempool.DefaultEstimateTarget
+	}
+	rate, _ := pool.EstimateFeeRate(target)
+	return rate, nil
+}
+
 // hasPendingStakeForPubKey reports whether any mempool tx currently creates
 // a stake output for the given validator pubkey.
 func hasPendingStakeForPubKey(txs []*tx.Transaction, pubKey []byte) bool {
@@ -317,6 +349,10 @@ func (s *Server) handleWalletImport(req *Request) (interface{}, *Error) {
 func (s *Server) scanWalletAddresses(walletName string, master *wallet.HDKey) {
 	const gapLimit = 20
 
+	view := s.chain.AcquireView()
+	defer view.Release()
+	utxoStore := view.UTXOs()
+
 	// Scan external chain (change=0), then internal/change chain (change=1).
 	for _, chain := range []struct {
 		change     uint32
@@ -335,12 +371,12 @@ func (s *Server) scanWalletAddresses(walletName string, master *wallet.HDKey) {
 			}
 			addr := hdKey.Address()
 
-			utxos, err := s.utxos.GetByAddress(addr)
+			utxos, err := utxoStore.GetByAddress(addr)
 			hasUTXOs := err == nil && len(utxos) > 0
 
 			// Also check stake UTXOs (indexed by pubkey, not address).
 			if !hasUTXOs {
-				stakes, sErr := stakesByAddress(s.utxos, addr)
+				stakes, sErr := stakesByAddress(utxoStore, addr)
 				hasUTXOs = sErr == nil && len(stakes) > 0
 			}
 
@@ -533,8 +569,11 @@ func (s *Server) handleWalletSend(req *Request) (interface{}, *Error) {
 		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("derive master key: %v", masterErr)}
 	}
 
+	view := s.chain.AcquireView()
+	defer view.Release()
+
 	// Collect UTXOs from all wallet addresses (external + change).
-	wset, collectErr := s.collectWalletUTXOs(master, params.Name, s.utxos, s.chain.Height())
+	wset, collectErr := s.collectWalletUTXOs(master, params.Name, view.UTXOs(), view.Height())
 	if collectErr != nil {
 		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("collect utxos: %v", collectErr)}
 	}
@@ -551,7 +590,10 @@ func (s *Server) handleWalletSend(req *Request) (interface{}, *Error) {
 	}
 
 	// Fee estimation with iterative coin selection.
-	feeRate := s.genesis.Protocol.Consensus.MinFeeRate
+	feeRate, rateErr := sendFeeRate(s.pool, params.FeeRate, params.TargetBlocks)
+	if rateErr != nil {
+		return nil, rateErr
+	}
 	fee := tx.EstimateTxFee(1, 2, feeRate) // 1 input, 2 outputs (recipient + change)
 	selection, selErr := wallet.SelectCoins(nativeUTXOs, params.Amount+fee)
 	if selErr != nil {
@@ -576,6 +618,9 @@ func (s *Server) handleWalletSend(req *Request) (interface{}, *Error) {
 
 	// Build transaction.
 	builder := tx.NewBuilder()
+	if params.Replaceable {
+		builder.SetReplaceable()
+	}
 	for _, input := range selection.Inputs {
 		builder.AddInput(input.Outpoint)
 	}
@@ -622,10 +667,8 @@ func (s *Server) handleWalletSend(req *Request) (interface{}, *Error) {
 	}
 
 	// Broadcast to P2P network.
-	if s.p2pNode != nil {
-		if err := s.p2pNode.BroadcastTx(transaction); err != nil {
-			s.logger.Warn().Err(err).Msg("Failed to broadcast transaction")
-		}
+	if err := s.broadcastWalletTx(transaction); err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to broadcast transaction")
 	}
 
 	// Track change address and advance index.
@@ -671,18 +714,18 @@ func (s *Server) handleWalletConsolidate(req *Request) (interface{}, *Error) {
 	}
 
 	// Resolve target chain context.
-	store := utxoGetter(s.utxos)
-	currentHeight := s.chain.Height()
+	view := s.chain.AcquireView()
+	defer view.Release()
+	store := utxoGetter(view.UTXOs())
+	currentHeight := view.Height()
 	feeRate := s.genesis.Protocol.Consensus.MinFeeRate
 	addToPool := func(t *tx.Transaction) error {
 		_, err := s.pool.Add(t)
 		return err
 	}
 	broadcast := func(t *tx.Transaction) {
-		if s.p2pNode != nil {
-			if err := s.p2pNode.BroadcastTx(t); err != nil {
-				s.logger.Warn().Err(err).Msg("Failed to broadcast consolidation tx")
-			}
+		if err := s.broadcastWalletTx(t); err != nil {
+			s.logger.Warn().Err(err).Msg("Failed to broadcast consolidation tx")
 		}
 	}
 
@@ -703,8 +746,10 @@ func (s *Server) handleWalletConsolidate(req *Request) (interface{}, *Error) {
 			return nil, &Error{Code: CodeNotFound, Message: fmt.Sprintf("sub-chain %s not synced on this node", params.ChainID)}
 		}
 
-		store = sr.UTXOs
-		currentHeight = sr.Chain.Height()
+		scView := sr.Chain.AcquireView()
+		defer scView.Release()
+		store = scView.UTXOs()
+		currentHeight = scView.Height()
 		feeRate = sr.Genesis.Protocol.Consensus.MinFeeRate
 		addToPool = func(t *tx.Transaction) error {
 			_, err := sr.Pool.Add(t)
@@ -881,8 +926,11 @@ func (s *Server) handleWalletSendMany(req *Request) (interface{}, *Error) {
 		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("derive master key: %v", masterErr)}
 	}
 
+	view := s.chain.AcquireView()
+	defer view.Release()
+
 	// Collect UTXOs from all wallet addresses.
-	wset, collectErr := s.collectWalletUTXOs(master, params.Name, s.utxos, s.chain.Height())
+	wset, collectErr := s.collectWalletUTXOs(master, params.Name, view.UTXOs(), view.Height())
 	if collectErr != nil {
 		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("collect utxos: %v", collectErr)}
 	}
@@ -893,7 +941,10 @@ func (s *Server) handleWalletSendMany(req *Request) (interface{}, *Error) {
 	}
 
 	// Fee estimation with iterative coin selection.
-	feeRate := s.genesis.Protocol.Consensus.MinFeeRate
+	feeRate, rateErr := sendFeeRate(s.pool, params.FeeRate, params.TargetBlocks)
+	if rateErr != nil {
+		return nil, rateErr
+	}
 	numOutputs := len(recipients) + 1 // recipients + change
 	fee := tx.EstimateTxFee(1, numOutputs, feeRate)
 	selection, selErr := wallet.SelectCoins(nativeUTXOs, totalAmount+fee)
@@ -913,6 +964,9 @@ func (s *Server) handleWalletSendMany(req *Request) (interface{}, *Error) {
 
 	// Build transaction.
 	builder := tx.NewBuilder()
+	if params.Replaceable {
+		builder.SetReplaceable()
+	}
 	for _, input := range selection.Inputs {
 		builder.AddInput(input.Outpoint)
 	}
@@ -961,10 +1015,8 @@ func (s *Server) handleWalletSendMany(req *Request) (interface{}, *Error) {
 	}
 
 	// Broadcast to P2P network.
-	if s.p2pNode != nil {
-		if err := s.p2pNode.BroadcastTx(transaction); err != nil {
-			s.logger.Warn().Err(err).Msg("Failed to broadcast transaction")
-		}
+	if err := s.broadcastWalletTx(transaction); err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to broadcast transaction")
 	}
 
 	// Track change address and advance index.
@@ -985,6 +1037,177 @@ func (s *Server) handleWalletSendMany(req *Request) (interface{}, *Error) {
 	}, nil
 }
 
+func (s *Server) handleWalletBumpFee(req *Request) (interface{}, *Error) {
+	if err := s.requireWallet(); err != nil {
+		return nil, err
+	}
+
+	var params WalletBumpFeeParam
+	if err := parseParams(req, &params); err != nil {
+		return nil, err
+	}
+	if params.Name == "" || params.Password == "" || params.TxHash == "" {
+		return nil, &Error{Code: CodeInvalidParams, Message: "name, password, and tx_hash are required"}
+	}
+
+	hashBytes, decErr := hex.DecodeString(params.TxHash)
+	if decErr != nil || len(hashBytes) != types.HashSize {
+		return nil, &Error{Code: CodeInvalidParams, Message: "invalid tx_hash: must be 32-byte hex"}
+	}
+	var txHash types.Hash
+	copy(txHash[:], hashBytes)
+
+	// Resolve root chain or sub-chain.
+	view := s.chain.AcquireView()
+	defer view.Release()
+	pool, store, height := s.pool, view.UTXOs(), view.Height()
+	broadcast := s.broadcastWalletTx
+	if params.ChainID != "" {
+		if err := s.requireSubChainManager(); err != nil {
+			return nil, err
+		}
+		chainIDBytes, err := hex.DecodeString(params.ChainID)
+		if err != nil || len(chainIDBytes) != types.HashSize {
+			return nil, &Error{Code: CodeInvalidParams, Message: "invalid chain_id: must be 32-byte hex"}
+		}
+		var chainID types.ChainID
+		copy(chainID[:], chainIDBytes)
+		sr, ok := s.scManager.GetChain(chainID)
+		if !ok {
+			return nil, &Error{Code: CodeNotFound, Message: fmt.Sprintf("sub-chain %s not synced on this node", params.ChainID)}
+		}
+		scView := sr.Chain.AcquireView()
+		defer scView.Release()
+		pool, store, height = sr.Pool, scView.UTXOs(), scView.Height()
+		idHex := hex.EncodeToString(chainID[:])
+		broadcast = func(t *tx.Transaction) error {
+			return s.p2pNode.BroadcastSubChainTx(idHex, t)
+		}
+	}
+
+	original := pool.Get(txHash)
+	if original == nil {
+		return nil, &Error{Code: CodeNotFound, Message: "transaction not in mempool"}
+	}
+	if !original.Replaceable() {
+		return nil, &Error{Code: CodeInvalidParams, Message: "transaction does not signal replace-by-fee"}
+	}
+
+	// Load wallet.
+	seed, loadErr := s.keystore.Load(params.Name, []byte(params.Password))
+	if loadErr != nil {
+		s.logger.Debug().Err(loadErr).Msg("wallet load failed")
+		return nil, &Error{Code: CodeInvalidParams, Message: "invalid wallet name or password"}
+	}
+
+	master, masterErr := wallet.NewMasterKey(seed)
+	for i := range seed {
+		seed[i] = 0
+	}
+	if masterErr != nil {
+		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("derive master key: %v", masterErr)}
+	}
+
+	wset, collectErr := s.collectWalletUTXOs(master, params.Name, store, height)
+	if collectErr != nil {
+		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("collect utxos: %v", collectErr)}
+	}
+	defer wset.zeroSigners()
+
+	// Every input must be a confirmed output of this wallet so it can be re-signed.
+	for _, in := range original.Inputs {
+		if _, ok := wset.addrByOutpoint[in.PrevOut]; !ok {
+			return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("input %s is not a confirmed output of this wallet", in.PrevOut)}
+		}
+	}
+
+	// The higher fee comes out of the wallet's change output.
+	accounts, err := s.keystore.ListAccounts(params.Name)
+	if err != nil {
+		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("list accounts: %v", err)}
+	}
+	changeAddrs := make(map[types.Address]bool)
+	for _, acct := range accounts {
+		if acct.Change != wallet.ChangeInternal {
+			continue
+		}
+		if addr, parseErr := types.ParseAddress(acct.Address); parseErr == nil {
+			changeAddrs[addr] = true
+		}
+	}
+	changeIdx := -1
+	for i, out := range original.Outputs {
+		if out.Token != nil || out.Script.Type != types.ScriptTypeP2PKH {
+			continue
+		}
+		if addr := scriptToAddress(out.Script); addr != nil && changeAddrs[*addr] {
+			changeIdx = i
+		}
+	}
+	if changeIdx < 0 {
+		return nil, &Error{Code: CodeInvalidParams, Message: "transaction has no change output to pay a higher fee"}
+	}
+
+	// New fee: at least what the replacement rules require.
+	oldFee := pool.GetFee(txHash)
+	size := len(original.SigningBytes())
+	newFee, feeErr := pool.MinReplacementFee(txHash, size)
+	if feeErr != nil {
+		return nil, &Error{Code: CodeInvalidParams, Message: feeErr.Error()}
+	}
+	if requested := params.FeeRate * uint64(size); requested > newFee {
+		newFee = requested
+	}
+	delta := newFee - oldFee
+	change := original.Outputs[changeIdx].Value
+	if change < delta {
+		return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("change output %d too small to raise fee by %d", change, delta)}
+	}
+
+	// Rebuild with the same inputs and the change output reduced
+	// (dropped if the bump uses all of it).
+	builder := tx.NewBuilder().SetReplaceable().SetLockTime(original.LockTime)
+	for _, in := range original.Inputs {
+		builder.AddInput(in.PrevOut)
+	}
+	for i, out := range original.Outputs {
+		value := out.Value
+		if i == changeIdx {
+			value -= delta
+			if value == 0 {
+				continue
+			}
+		}
+		if out.Token != nil {
+			builder.AddTokenOutput(value, out.Script, *out.Token)
+		} else {
+			builder.AddOutput(value, out.Script)
+		}
+	}
+	if err := builder.SignMulti(wset.signers, wset.addrByOutpoint); err != nil {
+		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("sign transaction: %v", err)}
+	}
+	replacement := builder.Build()
+
+	// Swap into the mempool and re-gossip.
+	fee, poolErr := pool.Add(replacement)
+	if poolErr != nil {
+		return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("rejected: %v", poolErr)}
+	}
+	if s.p2pNode != nil {
+		if err := broadcast(replacement); err != nil {
+			s.logger.Warn().Err(err).Msg("Failed to broadcast replacement transaction")
+		}
+	}
+
+	return &WalletBumpFeeResult{
+		TxHash:         replacement.Hash().String(),
+		ReplacedTxHash: txHash.String(),
+		OldFee:         oldFee,
+		NewFee:         fee,
+	}, nil
+}
+
 func (s *Server) handleWalletExportKey(req *Request) (interface{}, *Error) {
 	if err := s.requireWallet(); err != nil {
 		return nil, err
@@ -1132,8 +1355,11 @@ func (s *Server) handleWalletStake(req *Request) (interface{}, *Error) {
 	}
 	pubKeyBytes := hdKey.PublicKeyBytes()
 
+	view := s.chain.AcquireView()
+	defer view.Release()
+
 	// Block duplicate active/pending stake for this validator pubkey.
-	existingStakes, stakeErr := s.utxos.GetStakes(pubKeyBytes)
+	existingStakes, stakeErr := view.UTXOs().GetStakes(pubKeyBytes)
 	if stakeErr != nil {
 		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("get existing stakes: %v", stakeErr)}
 	}
@@ -1142,7 +1368,7 @@ func (s *Server) handleWalletStake(req *Request) (interface{}, *Error) {
 	}
 
 	// Collect UTXOs from all wallet addresses (external + change).
-	wset, collectErr := s.collectWalletUTXOs(master, params.Name, s.utxos, s.chain.Height())
+	wset, collectErr := s.collectWalletUTXOs(master, params.Name, view.UTXOs(), view.Height())
 	if collectErr != nil {
 		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("collect utxos: %v", collectErr)}
 	}
@@ -1228,10 +1454,8 @@ func (s *Server) handleWalletStake(req *Request) (interface{}, *Error) {
 	}
 
 	// Broadcast to P2P network.
-	if s.p2pNode != nil {
-		if err := s.p2pNode.BroadcastTx(transaction); err != nil {
-			s.logger.Warn().Err(err).Msg("Failed to broadcast stake transaction")
-		}
+	if err := s.broadcastWalletTx(transaction); err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to broadcast stake transaction")
 	}
 
 	// Track change address and advance index.
@@ -1322,8 +1546,11 @@ func (s *Server) handleWalletMintToken(req *Request) (interface{}, *Error) {
 		recipientAddr = parsed
 	}
 
+	view := s.chain.AcquireView()
+	defer view.Release()
+
 	// Collect UTXOs from all wallet addresses (external + change).
-	wset, collectErr := s.collectWalletUTXOs(master, params.Name, s.utxos, s.chain.Height())
+	wset, collectErr := s.collectWalletUTXOs(master, params.Name, view.UTXOs(), view.Height())
 	if collectErr != nil {
 		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("collect utxos: %v", collectErr)}
 	}
@@ -1421,10 +1648,8 @@ func (s *Server) handleWalletMintToken(req *Request) (interface{}, *Error) {
 	}
 
 	// Broadcast to P2P network.
-	if s.p2pNode != nil {
-		if err := s.p2pNode.BroadcastTx(transaction); err != nil {
-			s.logger.Warn().Err(err).Msg("Failed to broadcast mint transaction")
-		}
+	if err := s.broadcastWalletTx(transaction); err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to broadcast mint transaction")
 	}
 
 	// Track change address and advance index.
@@ -1499,8 +1724,11 @@ func (s *Server) handleWalletUnstake(req *Request) (interface{}, *Error) {
 	senderAddr := hdKey.Address()
 	pubKeyBytes := hdKey.PublicKeyBytes()
 
+	view := s.chain.AcquireView()
+	defer view.Release()
+
 	// Fetch all stake UTXOs for this pubkey.
-	stakes, stakeErr := s.utxos.GetStakes(pubKeyBytes)
+	stakes, stakeErr := view.UTXOs().GetStakes(pubKeyBytes)
 	if stakeErr != nil {
 		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("get stakes: %v", stakeErr)}
 	}
@@ -1553,10 +1781,8 @@ func (s *Server) handleWalletUnstake(req *Request) (interface{}, *Error) {
 	}
 
 	// Broadcast to P2P network.
-	if s.p2pNode != nil {
-		if err := s.p2pNode.BroadcastTx(transaction); err != nil {
-			s.logger.Warn().Err(err).Msg("Failed to broadcast unstake transaction")
-		}
+	if err := s.broadcastWalletTx(transaction); err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to broadcast unstake transaction")
 	}
 
 	return &WalletUnstakeResult{
@@ -1615,8 +1841,11 @@ func (s *Server) handleWalletSendToken(req *Request) (interface{}, *Error) {
 	}
 	senderAddr := hdKey0.Address()
 
+	view := s.chain.AcquireView()
+	defer view.Release()
+
 	// Collect UTXOs from all wallet addresses (external + change).
-	wset, collectErr := s.collectWalletUTXOs(master, params.Name, s.utxos, s.chain.Height())
+	wset, collectErr := s.collectWalletUTXOs(master, params.Name, view.UTXOs(), view.Height())
 	if collectErr != nil {
 		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("collect utxos: %v", collectErr)}
 	}
@@ -1736,10 +1965,8 @@ func (s *Server) handleWalletSendToken(req *Request) (interface{}, *Error) {
 	}
 
 	// Broadcast to P2P network.
-	if s.p2pNode != nil {
-		if err := s.p2pNode.BroadcastTx(transaction); err != nil {
-			s.logger.Warn().Err(err).Msg("Failed to broadcast token transfer")
-		}
+	if err := s.broadcastWalletTx(transaction); err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to broadcast token transfer")
 	}
 
 	// Track KGX change address and advance index.
@@ -1826,8 +2053,11 @@ func (s *Server) handleWalletCreateSubChain(req *Request) (interface{}, *Error)
 		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("derive master key: %v", masterErr)}
 	}
 
+	view := s.chain.AcquireView()
+	defer view.Release()
+
 	// Collect UTXOs from all wallet addresses (external + change).
-	wset, collectErr := s.collectWalletUTXOs(master, params.Name, s.utxos, s.chain.Height())
+	wset, collectErr := s.collectWalletUTXOs(master, params.Name, view.UTXOs(), view.Height())
 	if collectErr != nil {
 		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("collect utxos: %v", collectErr)}
 	}
@@ -1913,10 +2143,8 @@ func (s *Server) handleWalletCreateSubChain(req *Request) (interface{}, *Error)
 	}
 
 	// Broadcast to P2P network.
-	if s.p2pNode != nil {
-		if err := s.p2pNode.BroadcastTx(transaction); err != nil {
-			s.logger.Warn().Err(err).Msg("Failed to broadcast sub-chain registration tx")
-		}
+	if err := s.broadcastWalletTx(transaction); err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to broadcast sub-chain registration tx")
 	}
 
 	// Track change address and advance index.
@@ -2024,6 +2252,9 @@ func (s *Server) handleSubChainSend(req *Request) (interface{}, *Error) {
 
 	// Build transaction.
 	builder := tx.NewBuilder()
+	if params.Replaceable {
+		builder.SetReplaceable()
+	}
 	for _, input := range selection.Inputs {
 		builder.AddInput(input.Outpoint)
 	}
@@ -2452,7 +2683,7 @@ func (s *Server) handleWalletGetHistory(req *Request) (interface{}, *Error) {
 
 	// If we have a persistent index, use the indexed path.
 	if s.txIndex != nil {
-		return s.getHistoryIndexed(params.Name, "root", addrSet, limit, offset)
+		return s.getHistoryIndexed(params.Name, "root", addrSet, limit, offset, params.Cursor)
 	}
 
 	// Fallback: scan blocks from tip down (newest first).
@@ -2462,7 +2693,7 @@ func (s *Server) handleWalletGetHistory(req *Request) (interface{}, *Error) {
 // getHistoryIndexed uses the persistent WalletTxIndex. It incrementally
 // indexes new blocks since the last call, handles reorgs by rolling back
 // entries above the current tip, then queries the index.
-func (s *Server) getHistoryIndexed(walletName, chainID string, addrSet map[types.Address]bool, limit, offset int) (interface{}, *Error) {
+func (s *Server) getHistoryIndexed(walletName, chainID string, addrSet map[types.Address]bool, limit, offset int, cursor string) (interface{}, *Error) {
 	tipHeight := s.chain.Height()
 
 	meta, err := s.txIndex.GetMeta(walletName, chainID)
@@ -2504,15 +2735,33 @@ func (s *Server) getHistoryIndexed(walletName, chainID string, addrSet map[types
 		}
 	}
 
-	// Query the index.
-	entries, total, err := s.txIndex.Query(walletName, chainID, limit, offset)
+	// Query the index. A cursor resumes directly after the previous page
+	// without walking the entries before it.
+	if cursor != "" {
+		entries, next, err := s.txIndex.QueryAfter(walletName, chainID, cursor, limit)
+		if err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("query index: %v", err)}
+		}
+		meta, err := s.txIndex.GetMeta(walletName, chainID)
+		if err != nil {
+			return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("read index: %v", err)}
+		}
+		return &WalletGetHistoryResult{
+			Total:      meta.Count,
+			Entries:    entries,
+			NextCursor: next,
+		}, nil
+	}
+
+	entries, total, next, err := s.txIndex.query(walletName, chainID, limit, offset)
 	if err != nil {
 		return nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("query index: %v", err)}
 	}
 
 	return &WalletGetHistoryResult{
-		Total:   total,
-		Entries: entries,
+		Total:      total,
+		Entries:    entries,
+		NextCursor: next,
 	}, nil
 }
 
@@ -2981,7 +3230,6 @@ func (s *Server) handleWalletRescan(req *Request) (interface{}, *Error) {
 
 	// Resolve chain and UTXO store (root or sub-chain).
 	scanChain := s.chain
-	var scanUTXOs utxoGetter = s.utxos
 	if params.ChainID != "" {
 		if err := s.requireSubChainManager(); err != nil {
 			return nil, err
@@ -2997,11 +3245,13 @@ func (s *Server) handleWalletRescan(req *Request) (interface{}, *Error) {
 			return nil, &Error{Code: CodeNotFound, Message: fmt.Sprintf("sub-chain %s not synced on this node", params.ChainID)}
 		}
 		scanChain = sr.Chain
-		scanUTXOs = sr.UTXOs
 	}
+	scanView := scanChain.AcquireView()
+	defer scanView.Release()
+	var scanUTXOs utxoGetter = scanView.UTXOs()
 
 	// Phase 2: Scan blocks from fromHeight to tip, marking addresses that appear in outputs.
-	tipHeight := scanChain.State().Height
+	tipHeight := scanView.Height()
 	fromHeight := params.FromHeight
 	if fromHeight > tipHeight {
 		fromHeight = tipHeight
@@ -3009,7 +3259,7 @@ func (s *Server) handleWalletRescan(req *Request) (interface{}, *Error) {
 	usedAddrs := make(map[types.Address]bool)
 
 	for h := fromHeight; h <= tipHeight; h++ {
-		blk, err := scanChain.GetBlockByHeight(h)
+		blk, err := scanView.GetBlockByHeight(h)
 		if err != nil {
 			continue
 		}
@@ -232,6 +232,7 @@ func TestRPC_WalletImport_AddressDiscovery(t *testing.T) {
 		}); err != nil {
 			t.Fatalf("put utxo %d: %v", i, err)
 		}
+		env.chain.RefreshView()
 	}
 
 	// Import the wallet — scanWalletAddresses should discover all 3 addresses.
@@ -434,6 +435,7 @@ func TestRPC_WalletSend(t *testing.T) {
 	if err := env.utxoStore.Put(fakeUTXO); err != nil {
 		t.Fatalf("put utxo: %v", err)
 	}
+	env.chain.RefreshView()
 
 	// Send transaction.
 	resp := rpcCall(t, env.url, "wallet_send", WalletSendParam{
@@ -480,6 +482,127 @@ func TestRPC_WalletSend_InsufficientFunds(t *testing.T) {
 	}
 }
 
+// ── Wallet bump fee ───────────────────────────────────────────────────
+
+func TestRPC_WalletBumpFee(t *testing.T) {
+	env := setupWalletTestEnv(t)
+
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	importResp := rpcCall(t, env.url, "wallet_import", WalletImportParam{
+		Name: "bumper", Password: "pass", Mnemonic: mnemonic,
+	})
+	if importResp.Error != nil {
+		t.Fatalf("import: %s", importResp.Error.Message)
+	}
+
+	var importResult WalletImportResult
+	d, _ := json.Marshal(importResp.Result)
+	json.Unmarshal(d, &importResult)
+	senderAddr, _ := types.ParseAddress(importResult.Address)
+
+	fakeOutpoint := types.Outpoint{Index: 0}
+	copy(fakeOutpoint.TxID[:], []byte("test-tx-for-bump-00000000000000"))
+	if err := env.utxoStore.Put(&utxo.UTXO{
+		Outpoint: fakeOutpoint,
+		Value:    10 * config.Coin,
+		Script:   types.Script{Type: types.ScriptTypeP2PKH, Data: senderAddr.Bytes()},
+	}); err != nil {
+		t.Fatalf("put utxo: %v", err)
+	}
+	env.chain.RefreshView()
+
+	sendResp := rpcCall(t, env.url, "wallet_send", WalletSendParam{
+		Name: "bumper", Password: "pass", To: env.addrHex, Amount: 1 * config.Coin, Replaceable: true,
+	})
+	if sendResp.Error != nil {
+		t.Fatalf("wallet_send error: %s", sendResp.Error.Message)
+	}
+	var sendResult WalletSendResult
+	sd, _ := json.Marshal(sendResp.Result)
+	json.Unmarshal(sd, &sendResult)
+
+	resp := rpcCall(t, env.url, "wallet_bumpFee", WalletBumpFeeParam{
+		Name: "bumper", Password: "pass", TxHash: sendResult.TxHash,
+	})
+	if resp.Error != nil {
+		t.Fatalf("wallet_bumpFee error: %s", resp.Error.Message)
+	}
+	var result WalletBumpFeeResult
+	rd, _ := json.Marshal(resp.Result)
+	json.Unmarshal(rd, &result)
+
+	if result.ReplacedTxHash != sendResult.TxHash || result.TxHash == sendResult.TxHash {
+		t.Errorf("unexpected hashes: %+v", result)
+	}
+	if result.NewFee <= result.OldFee {
+		t.Errorf("new fee %d should exceed old fee %d", result.NewFee, result.OldFee)
+	}
+	if env.pool.Count() != 1 {
+		t.Fatalf("mempool count = %d, want 1", env.pool.Count())
+	}
+	newHash, _ := types.HexToHash(result.TxHash)
+	if !env.pool.Has(newHash) {
+		t.Error("replacement should be in mempool")
+	}
+
+	// The original is gone and cannot be bumped again.
+	again := rpcCall(t, env.url, "wallet_bumpFee", WalletBumpFeeParam{
+		Name: "bumper", Password: "pass", TxHash: sendResult.TxHash,
+	})
+	if again.Error == nil {
+		t.Error("expected error bumping a replaced transaction")
+	}
+}
+
+func TestRPC_WalletSend_NotReplaceableByDefault(t *testing.T) {
+	env := setupWalletTestEnv(t)
+
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	importResp := rpcCall(t, env.url, "wallet_import", WalletImportParam{
+		Name: "final", Password: "pass", Mnemonic: mnemonic,
+	})
+	if importResp.Error != nil {
+		t.Fatalf("import: %s", importResp.Error.Message)
+	}
+
+	var importResult WalletImportResult
+	d, _ := json.Marshal(importResp.Result)
+	json.Unmarshal(d, &importResult)
+	senderAddr, _ := types.ParseAddress(importResult.Address)
+
+	fakeOutpoint := types.Outpoint{Index: 0}
+	copy(fakeOutpoint.TxID[:], []byte("test-tx-for-final-0000000000000"))
+	if err := env.utxoStore.Put(&utxo.UTXO{
+		Outpoint: fakeOutpoint,
+		Value:    10 * config.Coin,
+		Script:   types.Script{Type: types.ScriptTypeP2PKH, Data: senderAddr.Bytes()},
+	}); err != nil {
+		t.Fatalf("put utxo: %v", err)
+	}
+	env.chain.RefreshView()
+
+	sendResp := rpcCall(t, env.url, "wallet_send", WalletSendParam{
+		Name: "final", Password: "pass", To: env.addrHex, Amount: 1 * config.Coin,
+	})
+	if sendResp.Error != nil {
+		t.Fatalf("wallet_send error: %s", sendResp.Error.Message)
+	}
+	var sendResult WalletSendResult
+	sd, _ := json.Marshal(sendResp.Result)
+	json.Unmarshal(sd, &sendResult)
+
+	txHash, _ := types.HexToHash(sendResult.TxHash)
+	if sent := env.pool.Get(txHash); sent == nil || sent.Replaceable() {
+		t.Fatal("send without replaceable should not signal replace-by-fee")
+	}
+	resp := rpcCall(t, env.url, "wallet_bumpFee", WalletBumpFeeParam{
+		Name: "final", Password: "pass", TxHash: sendResult.TxHash,
+	})
+	if resp.Error == nil {
+		t.Error("expected error bumping a non-replaceable transaction")
+	}
+}
+
 // ── Wallet send many ─────────────────────────────────────────────────
 
 func TestRPC_WalletSendMany(t *testing.T) {
@@ -512,6 +635,7 @@ func TestRPC_WalletSendMany(t *testing.T) {
 	if err := env.utxoStore.Put(fakeUTXO); err != nil {
 		t.Fatalf("put utxo: %v", err)
 	}
+	env.chain.RefreshView()
 
 	// Send to 2 recipients.
 	resp := rpcCall(t, env.url, "wallet_sendMany", WalletSendManyParam{
@@ -601,6 +725,7 @@ func TestRPC_WalletSendMany_InvalidAddress(t *testing.T) {
 	}); err != nil {
 		t.Fatalf("put utxo: %v", err)
 	}
+	env.chain.RefreshView()
 
 	resp := rpcCall(t, env.url, "wallet_sendMany", WalletSendManyParam{
 		Name:     "sendmany-badaddr",
@@ -785,6 +910,7 @@ func TestRPC_WalletMintToken(t *testing.T) {
 	if err := env.utxoStore.Put(fakeUTXO); err != nil {
 		t.Fatalf("put utxo: %v", err)
 	}
+	env.chain.RefreshView()
 
 	// Mint token.
 	resp := rpcCall(t, env.url, "wallet_mintToken", WalletMintTokenParam{
@@ -877,6 +1003,7 @@ func TestRPC_WalletStake(t *testing.T) {
 	if err := env.utxoStore.Put(fakeUTXO); err != nil {
 		t.Fatalf("put utxo: %v", err)
 	}
+	env.chain.RefreshView()
 
 	// Stake transaction (genesis min stake is 0 in test, so any amount works).
 	resp := rpcCall(t, env.url, "wallet_stake", WalletStakeParam{
@@ -945,6 +1072,7 @@ func TestRPC_WalletDisabled(t *testing.T) {
 		{"wallet_newAddress", WalletNewAddressParam{Name: "x", Password: "p"}},
 		{"wallet_listAddresses", WalletUnlockParam{Name: "x", Password: "p"}},
 		{"wallet_send", WalletSendParam{Name: "x", Password: "p", To: "aa", Amount: 1}},
+		{"wallet_bumpFee", WalletBumpFeeParam{Name: "x", Password: "p", TxHash: "aa"}},
 		{"wallet_exportKey", WalletExportKeyParam{Name: "x", Password: "p"}},
 		{"wallet_stake", WalletStakeParam{Name: "x", Password: "p", Amount: 1}},
 		{"wallet_mintToken", WalletMintTokenParam{Name: "x", Password: "p", TokenName: "T", Symbol: "T", Amount: 1}},
@@ -1102,6 +1230,7 @@ func TestRPC_WalletCreateSubChain(t *testing.T) {
 	if err := env.utxoStore.Put(fakeUTXO); err != nil {
 		t.Fatalf("put utxo: %v", err)
 	}
+	env.chain.RefreshView()
 
 	// Create sub-chain.
 	validatorPubHex := hex.EncodeToString(env.validatorKey.PublicKey())
@@ -1212,6 +1341,7 @@ func TestRPC_SubChainGetBalance(t *testing.T) {
 	if err := sr.UTXOs.Put(testUTXO); err != nil {
 		t.Fatalf("put sub-chain utxo: %v", err)
 	}
+	sr.Chain.RefreshView()
 
 	// Query balance.
 	addrHex := hex.EncodeToString(testAddr[:])
@@ -1311,6 +1441,7 @@ func TestRPC_SubChainSend(t *testing.T) {
 	if err := sr.UTXOs.Put(scUTXO); err != nil {
 		t.Fatalf("put sub-chain utxo: %v", err)
 	}
+	sr.Chain.RefreshView()
 
 	recipientAddr := hex.EncodeToString(make([]byte, 20))
 
@@ -1451,6 +1582,7 @@ func TestRPC_WalletGetHistory_Sent(t *testing.T) {
 	if err := env.utxoStore.Put(fakeUTXO); err != nil {
 		t.Fatalf("put utxo: %v", err)
 	}
+	env.chain.RefreshView()
 
 	// Send a transaction.
 	sendResp := rpcCall(t, env.url, "wallet_send", WalletSendParam{
@@ -1638,6 +1770,7 @@ func TestRPC_WalletGetHistory_TokenSent(t *testing.T) {
 	}); err != nil {
 		t.Fatalf("put utxo: %v", err)
 	}
+	env.chain.RefreshView()
 
 	// 1) Mint tokens.
 	mintResp := rpcCall(t, env.url, "wallet_mintToken", WalletMintTokenParam{
@@ -1776,6 +1909,7 @@ func TestRPC_WalletSend_FromChangeAddress(t *testing.T) {
 	if err := env.utxoStore.Put(changeUTXO); err != nil {
 		t.Fatalf("put change utxo: %v", err)
 	}
+	env.chain.RefreshView()
 
 	// Send from the wallet — it should find the change address UTXO.
 	resp := rpcCall(t, env.url, "wallet_send", WalletSendParam{
@@ -1847,6 +1981,7 @@ func TestRPC_WalletSend_MultipleAddresses(t *testing.T) {
 	}); err != nil {
 		t.Fatalf("put utxo 0: %v", err)
 	}
+	env.chain.RefreshView()
 
 	fakeOp1 := types.Outpoint{Index: 0}
 	copy(fakeOp1.TxID[:], []byte("test-tx-multi-addr1-000000000000"))
@@ -1857,6 +1992,7 @@ func TestRPC_WalletSend_MultipleAddresses(t *testing.T) {
 	}); err != nil {
 		t.Fatalf("put utxo 1: %v", err)
 	}
+	env.chain.RefreshView()
 
 	// Send 1.5 KGX — requires combining UTXOs from both addresses.
 	resp := rpcCall(t, env.url, "wallet_send", WalletSendParam{
@@ -2001,6 +2137,7 @@ func TestRPC_WalletMintToken_ValidMetadata(t *testing.T) {
 	}); err != nil {
 		t.Fatalf("put utxo: %v", err)
 	}
+	env.chain.RefreshView()
 
 	// Valid: alphanumeric name with spaces/hyphens, uppercase symbol, decimals <= 18.
 	resp := rpcCall(t, env.url, "wallet_mintToken", WalletMintTokenParam{
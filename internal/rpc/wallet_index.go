@@ -2,9 +2,9 @@ package rpc
 
 import (
 	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"sort"
 
 	"github.com/Klingon-tech/klingnet-chain/internal/chain"
 	"github.com/Klingon-tech/klingnet-chain/internal/storage"
@@ -22,7 +22,7 @@ import (
 //	Metadata: "m/<wallet>/<chain>"                      → JSON indexMeta
 //
 // revHeight is (math.MaxUint64 - blockHeight) encoded as 8 big-endian bytes,
-// ensuring ForEach iterates from newest to oldest.
+// so a forward iterator walks entries from newest to oldest.
 type WalletTxIndex struct {
 	db storage.DB
 }
@@ -103,27 +103,20 @@ func (idx *WalletTxIndex) PutEntries(wallet, chainID string, height uint64, entr
 // It also adjusts the metadata count.
 func (idx *WalletTxIndex) DeleteAbove(wallet, chainID string, maxHeight uint64) error {
 	prefix := entryKeyPrefix(wallet, chainID)
-	var toDelete [][]byte
 
-	// Iterate all entries. Since keys are reverse-height, entries for heights
-	// above maxHeight have revHeight < ^maxHeight, meaning they come first in
-	// sorted order. We must iterate everything and check.
-	err := idx.db.ForEach(prefix, func(key, value []byte) error {
-		// Extract height from the key suffix (last 12 bytes of the unprefixed key).
-		suffix := key[len(prefix):]
-		if len(suffix) < 12 {
-			return nil
-		}
-		revHeight := binary.BigEndian.Uint64(suffix[:8])
-		height := ^revHeight
-		if height > maxHeight {
-			k := make([]byte, len(key))
-			copy(k, key)
-			toDelete = append(toDelete, k)
-		}
-		return nil
-	})
-	if err != nil {
+	// Keys are reverse-height, so entries above maxHeight are exactly the
+	// keys before revHeight(maxHeight).
+	end := make([]byte, len(prefix)+8)
+	copy(end, prefix)
+	binary.BigEndian.PutUint64(end[len(prefix):], ^maxHeight)
+
+	var toDelete [][]byte
+	it := idx.db.NewIterator(storage.IterOptions{Prefix: prefix, End: end})
+	for it.Next() {
+		toDelete = append(toDelete, it.Key())
+	}
+	it.Close()
+	if err := it.Err(); err != nil {
 		return err
 	}
 
@@ -148,55 +141,83 @@ func (idx *WalletTxIndex) DeleteAbove(wallet, chainID string, maxHeight uint64)
 }
 
 // Query retrieves paginated history entries for a wallet on a chain.
-// Returns entries newest-first with total count.
+// Returns entries newest-first with total count. Only the requested page
+// is decoded; the rest of the index is walked by key to count it.
 func (idx *WalletTxIndex) Query(wallet, chainID string, limit, offset int) ([]TxHistoryEntry, int, error) {
-	prefix := entryKeyPrefix(wallet, chainID)
+	entries, total, _, err := idx.query(wallet, chainID, limit, offset)
+	return entries, total, err
+}
 
-	// Collect all matching entries. ForEach iteration order is not guaranteed
-	// to be sorted (MemoryDB uses map), so we collect and sort.
-	type kv struct {
-		key   string
-		value []byte
+// query is Query that also returns the QueryAfter cursor for the entry
+// following the page, or "" if the page reaches the end.
+func (idx *WalletTxIndex) query(wallet, chainID string, limit, offset int) ([]TxHistoryEntry, int, string, error) {
+	prefix := entryKeyPrefix(wallet, chainID)
+	it := idx.db.NewIterator(storage.IterOptions{Prefix: prefix})
+	defer it.Close()
+
+	entries := []TxHistoryEntry{}
+	var next string
+	total := 0
+	for it.Next() {
+		if total >= offset && total < offset+limit {
+			var e TxHistoryEntry
+			if err := json.Unmarshal(it.Value(), &e); err == nil {
+				entries = append(entries, e)
+			} // Skip corrupt entries.
+			if total == offset+limit-1 {
+				next = hex.EncodeToString(it.Key()[len(prefix):])
+			}
+		}
+		total++
 	}
-	var all []kv
-
-	err := idx.db.ForEach(prefix, func(key, value []byte) error {
-		v := make([]byte, len(value))
-		copy(v, value)
-		all = append(all, kv{key: string(key), value: v})
-		return nil
-	})
-	if err != nil {
-		return nil, 0, err
+	if err := it.Err(); err != nil {
+		return nil, 0, "", err
 	}
+	if offset+limit >= total {
+		next = ""
+	}
+	return entries, total, next, nil
+}
 
-	// Sort by key (reverse height ordering built into the key).
-	sort.Slice(all, func(i, j int) bool {
-		return all[i].key < all[j].key
-	})
-
-	total := len(all)
-
-	// Apply pagination.
-	if offset >= total {
-		return []TxHistoryEntry{}, total, nil
+// QueryAfter returns up to limit entries newest-first, starting after the
+// given cursor ("" starts at the newest entry). It returns the cursor for
+// the next page, or "" when no entries follow the page. Unlike Query it
+// never walks entries outside the requested page.
+func (idx *WalletTxIndex) QueryAfter(wallet, chainID, cursor string, limit int) ([]TxHistoryEntry, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("invalid limit %d", limit)
 	}
-	end := offset + limit
-	if end > total {
-		end = total
+	prefix := entryKeyPrefix(wallet, chainID)
+	opts := storage.IterOptions{Prefix: prefix}
+	if cursor != "" {
+		suffix, err := hex.DecodeString(cursor)
+		if err != nil || len(suffix) != 12 {
+			return nil, "", fmt.Errorf("invalid cursor")
+		}
+		opts.Start = append(append(append([]byte{}, prefix...), suffix...), 0)
 	}
-	page := all[offset:end]
 
-	entries := make([]TxHistoryEntry, 0, len(page))
-	for _, kv := range page {
+	it := idx.db.NewIterator(opts)
+	defer it.Close()
+
+	entries := []TxHistoryEntry{}
+	var lastKey []byte
+	for it.Next() {
 		var e TxHistoryEntry
-		if err := json.Unmarshal(kv.value, &e); err != nil {
+		if err := json.Unmarshal(it.Value(), &e); err != nil {
 			continue // Skip corrupt entries.
 		}
+		if len(entries) == limit {
+			// Another entry follows the page.
+			return entries, hex.EncodeToString(lastKey[len(prefix):]), nil
+		}
+		lastKey = it.Key()
 		entries = append(entries, e)
 	}
-
-	return entries, total, nil
+	if err := it.Err(); err != nil {
+		return nil, "", err
+	}
+	return entries, "", nil
 }
 
 // IndexBlocks scans blocks from startHeight to endHeight (inclusive) and
@@ -286,3 +286,110 @@ func TestWalletTxIndex_MetaFresh(t *testing.T) {
 		t.Errorf("fresh meta = %+v, want zero", meta)
 	}
 }
+
+func TestWalletTxIndex_QueryAfter(t *testing.T) {
+	idx := newTestIndex()
+
+	for h := uint64(0); h < 5; h++ {
+		if err := idx.PutEntries("w1", "root", h, []TxHistoryEntry{
+			{TxHash: "tx" + string(rune('A'+h)), Type: "mined", Height: h},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Walk the history two entries at a time, newest first.
+	var heights []uint64
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("cursor did not terminate")
+		}
+		page, next, err := idx.QueryAfter("w1", "root", cursor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range page {
+			heights = append(heights, e.Height)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	want := []uint64{4, 3, 2, 1, 0}
+	if len(heights) != len(want) {
+		t.Fatalf("heights = %v, want %v", heights, want)
+	}
+	for i := range want {
+		if heights[i] != want[i] {
+			t.Fatalf("heights = %v, want %v", heights, want)
+		}
+	}
+
+	// The offset query hands out the same cursor.
+	_, _, next, err := idx.query("w1", "root", 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, _, err := idx.QueryAfter("w1", "root", next, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || page[0].Height != 2 {
+		t.Errorf("page after offset cursor = %v, want heights 2,1", page)
+	}
+
+	if _, _, err := idx.QueryAfter("w1", "root", "zz", 2); err == nil {
+		t.Error("expected error for malformed cursor")
+	}
+}
+
+func TestWalletTxIndex_QueryAfterLimit(t *testing.T) {
+	idx := newTestIndex()
+	for _, limit := range []int{0, -1} {
+		if _, _, err := idx.QueryAfter("w1", "root", "", limit); err == nil {
+			t.Errorf("limit %d: expected error", limit)
+		}
+	}
+}
+
+func TestWalletTxIndex_QueryAfterLastPage(t *testing.T) {
+	idx := newTestIndex()
+	for h := uint64(0); h < 4; h++ {
+		if err := idx.PutEntries("w1", "root", h, []TxHistoryEntry{
+			{TxHash: "tx" + string(rune('A'+h)), Type: "mined", Height: h},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A page that ends exactly at the last entry has no cursor.
+	page, next, err := idx.QueryAfter("w1", "root", "", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 4 || next != "" {
+		t.Errorf("full page: got %d entries, cursor %q; want 4, no cursor", len(page), next)
+	}
+
+	page, next, err = idx.QueryAfter("w1", "root", "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, next, err = idx.QueryAfter("w1", "root", next, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || next != "" {
+		t.Errorf("last page: got %d entries, cursor %q; want 2, no cursor", len(page), next)
+	}
+
+	// A corrupt trailing entry is not a further row.
+	if err := idx.db.Put(entryKey("w1", "root", 0, 1), []byte("{corrupt")); err != nil {
+		t.Fatal(err)
+	}
+	if _, next, _ = idx.QueryAfter("w1", "root", "", 4); next != "" {
+		t.Errorf("cursor %q returned for a corrupt trailing entry", next)
+	}
+}
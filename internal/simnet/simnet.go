@@ -0,0 +1,569 @@
+// Package simnet runs a network of nodes in one process.
+//
+// Each simulated node is a full node.Node with its own in-memory database,
+// talking to the others over an in-memory libp2p network (mocknet): block
+// gossip, sync, handshakes and fork resolution are the production code
+// paths. Block production runs on a virtual clock that the simulator
+// advances slot by slot; after each step it waits, in real time, for the
+// network to settle. Validator keys, the production schedule and message
+// drops follow from Config.Seed, while message timing comes from real
+// goroutines, so scenarios assert on the state the network settles in.
+package simnet
+
+import (
+	"container/heap"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/Klingon-tech/klingnet-chain/config"
+	"github.com/Klingon-tech/klingnet-chain/internal/node"
+	"github.com/Klingon-tech/klingnet-chain/internal/p2p"
+	"github.com/Klingon-tech/klingnet-chain/internal/storage"
+	"github.com/Klingon-tech/klingnet-chain/pkg/crypto"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+)
+
+// Defaults for zero Config fields.
+const (
+	DefaultBlockTime = 3                    // Seconds.
+	DefaultLatency   = 5 * time.Millisecond // One-way link latency, real time.
+
+	// syncInterval matches the node's periodic sync loop.
+	syncInterval = 10 * time.Second
+)
+
+// Real-time waits for the in-memory network.
+const (
+	connectWait  = 300 * time.Millisecond // GossipSub and handshakes after (re)connecting.
+	settlePoll   = 5 * time.Millisecond
+	settleQuiet  = 50 * time.Millisecond // No tip changes for this long, plus latency.
+	settleMax    = 5 * time.Second
+	closeTimeout = 5 * time.Second
+)
+
+// defaultStart is the virtual time a network starts at.
+var defaultStart = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Config describes a simulated network.
+type Config struct {
+	Validators int           // Block-producing nodes.
+	Observers  int           // Non-validating nodes.
+	BlockTime  int           // PoA slot length in seconds.
+	Latency    time.Duration // Default one-way latency between nodes, in real time.
+	Seed       int64         // Seeds validator keys and message drops.
+	Start      time.Time     // Virtual start time.
+	DataDir    string        // Per-node directories for logs; required.
+}
+
+// Network is a set of simulated nodes sharing a virtual clock.
+type Network struct {
+	cfg     Config
+	genesis *config.Genesis
+	mn      mocknet.Mocknet
+	nodes   []*simNode
+	now     atomic.Int64 // Virtual time in Unix nanoseconds; read by node goroutines.
+	events  eventQueue
+	seq     uint64
+
+	dropRate atomic.Uint64            // math.Float64bits of the block drop probability.
+	latency  map[[2]int]time.Duration // Per-link overrides, keyed by (low, high) node index.
+}
+
+type simNode struct {
+	*node.Node
+	host      host.Host
+	validator bool
+	crashed   bool
+	group     int // Partition group; nodes talk only within their group.
+}
+
+// New creates and starts the network's nodes and connects them. The
+// first cfg.Validators nodes are the genesis validators.
+func New(cfg Config) (*Network, error) {
+	if cfg.Validators < 1 {
+		return nil, errors.New("simnet: need at least one validator")
+	}
+	if cfg.DataDir == "" {
+		return nil, errors.New("simnet: DataDir is required")
+	}
+	if cfg.BlockTime <= 0 {
+		cfg.BlockTime = DefaultBlockTime
+	}
+	if cfg.Latency <= 0 {
+		cfg.Latency = DefaultLatency
+	}
+	if cfg.Start.IsZero() {
+		cfg.Start = defaultStart
+	}
+
+	net := &Network{
+		cfg:     cfg,
+		mn:      mocknet.New(),
+		latency: make(map[[2]int]time.Duration),
+	}
+	net.now.Store(cfg.Start.UnixNano())
+	net.mn.SetLinkDefaults(mocknet.LinkOptions{Latency: cfg.Latency})
+
+	keys := make([]*crypto.PrivateKey, cfg.Validators)
+	for i := range keys {
+		k, err := validatorKey(cfg.Seed, i)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = k
+	}
+	net.genesis = simGenesis(keys, cfg)
+
+	for i := 0; i < cfg.Validators+cfg.Observers; i++ {
+		var key *crypto.PrivateKey
+		if i < cfg.Validators {
+			key = keys[i]
+		}
+		sn, err := net.newNode(i, key)
+		if err != nil {
+			net.Close()
+			return nil, fmt.Errorf("simnet: node %d: %w", i, err)
+		}
+		net.nodes = append(net.nodes, sn)
+	}
+	if err := net.rewire(); err != nil {
+		net.Close()
+		return nil, fmt.Errorf("simnet: connect nodes: %w", err)
+	}
+
+	// First slot boundary after the start, then the sync loop.
+	start := net.Now()
+	bt := time.Duration(cfg.BlockTime) * time.Second
+	net.schedule(start.Truncate(bt).Add(bt), net.slot)
+	net.schedule(start.Add(syncInterval), net.syncAll)
+	return net, nil
+}
+
+// validatorKey derives validator i's key from the seed.
+func validatorKey(seed int64, i int) (*crypto.PrivateKey, error) {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(seed))
+	binary.BigEndian.PutUint64(buf[8:], uint64(i))
+	h := crypto.Hash(buf[:])
+	return crypto.PrivateKeyFromBytes(h[:])
+}
+
+// simGenesis is the testnet genesis with the simulated validators, no
+// checkpoints and no sub-chains.
+func simGenesis(keys []*crypto.PrivateKey, cfg Config) *config.Genesis {
+	g := config.TestnetGenesis()
+	g.ChainID = "klingnet-simnet"
+	g.ChainName = "Klingnet Simnet"
+	g.Timestamp = uint64(cfg.Start.Unix()) - 1
+	g.Checkpoints = nil
+	g.Protocol.Consensus.BlockTime = cfg.BlockTime
+	g.Protocol.SubChain.Enabled = false
+	g.Protocol.Consensus.Validators = nil
+	for _, k := range keys {
+		g.Protocol.Consensus.Validators = append(g.Protocol.Consensus.Validators, hex.EncodeToString(k.PublicKey()))
+	}
+	return g
+}
+
+// newNode creates node i on a mocknet host, with discovery, RPC and the
+// miner loop switched off; the simulator drives block production.
+func (net *Network) newNode(i int, key *crypto.PrivateKey) (*simNode, error) {
+	h, err := net.mn.GenPeer()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := config.Default(config.Testnet)
+	cfg.DataDir = filepath.Join(net.cfg.DataDir, fmt.Sprintf("node%d", i))
+	cfg.P2P.Enabled = true
+	cfg.P2P.NoDiscover = true
+	cfg.P2P.Seeds = nil
+	cfg.RPC.Enabled = false
+	cfg.Wallet.Enabled = false
+	cfg.Mining.Enabled = false
+	cfg.Mempool.Persist = false
+	cfg.Log.Level = "error"
+	cfg.Log.File = filepath.Join(net.cfg.DataDir, "simnet.log")
+	if err := os.MkdirAll(cfg.DataDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	n, err := node.NewWithOptions(cfg, node.Options{
+		Genesis:       net.genesis,
+		DB:            storage.NewMemory(),
+		ValidatorKey:  key,
+		Clock:         net.Now,
+		Host:          h,
+		PubSubOptions: []pubsub.Option{pubsub.WithDefaultValidator(net.dropValidator(i, h.ID()))},
+	})
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	if err := n.Start(); err != nil {
+		n.Stop()
+		return nil, err
+	}
+	return &simNode{Node: n, host: h, validator: key != nil}, nil
+}
+
+// Close stops every node.
+func (net *Network) Close() {
+	for _, n := range net.nodes {
+		n.Stop()
+	}
+	net.nodes = nil
+	net.mn.Close()
+}
+
+// Now returns the virtual time.
+func (net *Network) Now() time.Time {
+	return time.Unix(0, net.now.Load()).UTC()
+}
+
+// Len returns the number of nodes.
+func (net *Network) Len() int {
+	return len(net.nodes)
+}
+
+// Node returns node i.
+func (net *Network) Node(i int) *node.Node {
+	return net.nodes[i].Node
+}
+
+// Run advances virtual time by d, processing every event due.
+func (net *Network) Run(d time.Duration) {
+	net.RunUntil(nil, d)
+}
+
+// RunUntil advances virtual time until done reports true or max has
+// elapsed, and returns whether done was reached.
+func (net *Network) RunUntil(done func() bool, max time.Duration) bool {
+	end := net.Now().Add(max)
+	for {
+		if done != nil && done() {
+			return true
+		}
+		if len(net.events) == 0 || net.events[0].at.After(end) {
+			net.now.Store(end.UnixNano())
+			return done != nil && done()
+		}
+		ev := heap.Pop(&net.events).(*event)
+		net.now.Store(ev.at.UnixNano())
+		ev.fn()
+		net.settle()
+	}
+}
+
+// settle waits in real time for the gossip and syncs started by the last
+// event: until the running nodes of every partition share a tip, or no tip
+// has changed for a quiet period.
+func (net *Network) settle() {
+	quiet := settleQuiet + 2*net.maxLatency()
+	deadline := time.Now().Add(settleMax)
+	last, stable := net.Tips(), time.Now()
+	for time.Now().Before(deadline) {
+		if net.groupsConverged() {
+			return
+		}
+		time.Sleep(settlePoll)
+		tips := net.Tips()
+		if !slices.Equal(tips, last) {
+			last, stable = tips, time.Now()
+			continue
+		}
+		if time.Since(stable) >= quiet {
+			return
+		}
+	}
+}
+
+// groupsConverged reports whether running nodes that can reach each other
+// have the same tip.
+func (net *Network) groupsConverged() bool {
+	tips := make(map[int]types.Hash)
+	for _, n := range net.nodes {
+		if n.crashed {
+			continue
+		}
+		tip := n.TipHash()
+		if ref, ok := tips[n.group]; ok && ref != tip {
+			return false
+		}
+		tips[n.group] = tip
+	}
+	return true
+}
+
+// ── Faults ──────────────────────────────────────────────────────────
+
+// Partition splits the network: each group only talks to itself, and
+// nodes in no group form one more group. Links across the new boundaries
+// are cut, losing the messages in flight on them.
+func (net *Network) Partition(groups ...[]int) {
+	for _, n := range net.nodes {
+		n.group = 0
+	}
+	for g, members := range groups {
+		for _, i := range members {
+			net.nodes[i].group = g + 1
+		}
+	}
+	net.mustRewire()
+}
+
+// Heal removes all partitions.
+func (net *Network) Heal() {
+	net.Partition()
+}
+
+// SetLatency sets the one-way latency, in real time, between nodes a and
+// b in both directions.
+func (net *Network) SetLatency(a, b int, d time.Duration) {
+	key := linkKey(a, b)
+	net.latency[key] = d
+	for _, l := range net.mn.LinksBetweenPeers(net.nodes[a].host.ID(), net.nodes[b].host.ID()) {
+		l.SetOptions(mocknet.LinkOptions{Latency: d})
+	}
+}
+
+// SetDropRate sets the probability that a node loses a gossiped block.
+// Each node's drop decision for a block is derived from the seed, so a
+// scenario loses the same blocks on every run.
+func (net *Network) SetDropRate(p float64) {
+	net.dropRate.Store(math.Float64bits(p))
+}
+
+// Crash disconnects node i from every peer and stops it producing blocks.
+func (net *Network) Crash(i int) {
+	net.nodes[i].crashed = true
+	net.mustRewire()
+}
+
+// Restart brings a crashed node back with its chain intact, as after a
+// process restart, reconnects it and syncs it from its peers.
+func (net *Network) Restart(i int) {
+	net.nodes[i].crashed = false
+	net.mustRewire()
+	net.nodes[i].Sync()
+	net.settle()
+}
+
+// ── Assertions ──────────────────────────────────────────────────────
+
+// Tips returns every node's tip hash.
+func (net *Network) Tips() []types.Hash {
+	tips := make([]types.Hash, len(net.nodes))
+	for i, n := range net.nodes {
+		tips[i] = n.TipHash()
+	}
+	return tips
+}
+
+// CheckConverged returns an error unless every running node has the
+// same tip.
+func (net *Network) CheckConverged() error {
+	ref := -1
+	for i, n := range net.nodes {
+		if n.crashed {
+			continue
+		}
+		if ref < 0 {
+			ref = i
+			continue
+		}
+		if r := net.nodes[ref]; n.TipHash() != r.TipHash() {
+			return fmt.Errorf("node %d at height %d tip %s, node %d at height %d tip %s",
+				i, n.Height(), n.TipHash(), ref, r.Height(), r.TipHash())
+		}
+	}
+	return nil
+}
+
+// CheckMinHeight returns an error if a running node is below height.
+func (net *Network) CheckMinHeight(height uint64) error {
+	for i, n := range net.nodes {
+		if !n.crashed && n.Height() < height {
+			return fmt.Errorf("node %d at height %d, want at least %d", i, n.Height(), height)
+		}
+	}
+	return nil
+}
+
+// ── Block production ────────────────────────────────────────────────
+
+// slot runs at every slot boundary: each running validator produces now
+// or after its backup delay, as its miner loop would.
+func (net *Network) slot() {
+	now := net.Now()
+	slot := uint64(now.Unix())
+	for i, n := range net.nodes {
+		if !n.validator || n.crashed {
+			continue
+		}
+		delay, ok := n.SlotDelay(slot)
+		if !ok {
+			continue
+		}
+		i, next := i, n.Height()+1
+		net.schedule(now.Add(delay), func() { net.produce(i, slot, next) })
+	}
+	net.schedule(now.Add(time.Duration(net.cfg.BlockTime)*time.Second), net.slot)
+}
+
+// produce has node i produce and gossip block height unless it already
+// has it.
+func (net *Network) produce(i int, slot, height uint64) {
+	n := net.nodes[i]
+	if n.crashed || n.Height() >= height {
+		return
+	}
+	_, _ = n.ProduceBlock(slot, uint64(net.Now().Unix()))
+}
+
+// dropValidator is node i's GossipSub validator that loses blocks at the
+// drop rate. Lost messages are ignored, which does not count against the
+// sender's gossip score.
+func (net *Network) dropValidator(i int, self peer.ID) pubsub.ValidatorEx {
+	return func(_ context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+		if from == self || msg.GetTopic() != p2p.TopicBlocks {
+			return pubsub.ValidationAccept
+		}
+		p := math.Float64frombits(net.dropRate.Load())
+		if p <= 0 {
+			return pubsub.ValidationAccept
+		}
+		var buf [16]byte
+		binary.BigEndian.PutUint64(buf[:8], uint64(net.cfg.Seed))
+		binary.BigEndian.PutUint64(buf[8:], uint64(i))
+		h := crypto.HashConcat(crypto.Hash(buf[:]), crypto.Hash(msg.GetData()))
+		if float64(binary.BigEndian.Uint64(h[:8]))/float64(math.MaxUint64) < p {
+			return pubsub.ValidationIgnore
+		}
+		return pubsub.ValidationAccept
+	}
+}
+
+// ── Links ───────────────────────────────────────────────────────────
+
+func (net *Network) connected(a, b int) bool {
+	na, nb := net.nodes[a], net.nodes[b]
+	return !na.crashed && !nb.crashed && na.group == nb.group
+}
+
+func linkKey(a, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
+
+// rewire links and connects every pair of nodes that can reach each
+// other and cuts every other link, then waits for the nodes to notice.
+func (net *Network) rewire() error {
+	changed := false
+	for a := range net.nodes {
+		for b := a + 1; b < len(net.nodes); b++ {
+			pa, pb := net.nodes[a].host.ID(), net.nodes[b].host.ID()
+			linked := len(net.mn.LinksBetweenPeers(pa, pb)) > 0
+			switch want := net.connected(a, b); {
+			case want && !linked:
+				l, err := net.mn.LinkPeers(pa, pb)
+				if err != nil {
+					return err
+				}
+				if d, ok := net.latency[linkKey(a, b)]; ok {
+					l.SetOptions(mocknet.LinkOptions{Latency: d})
+				}
+				if _, err := net.mn.ConnectPeers(pa, pb); err != nil {
+					return err
+				}
+				changed = true
+			case !want && linked:
+				if err := net.mn.DisconnectPeers(pa, pb); err != nil {
+					return err
+				}
+				if err := net.mn.UnlinkPeers(pa, pb); err != nil {
+					return err
+				}
+				changed = true
+			}
+		}
+	}
+	if changed {
+		time.Sleep(connectWait)
+	}
+	return nil
+}
+
+// mustRewire is rewire for fault injection, where mocknet cannot fail
+// short of a simulator bug.
+func (net *Network) mustRewire() {
+	if err := net.rewire(); err != nil {
+		panic(fmt.Sprintf("simnet: rewire: %v", err))
+	}
+}
+
+func (net *Network) maxLatency() time.Duration {
+	d := net.cfg.Latency
+	for _, l := range net.latency {
+		d = max(d, l)
+	}
+	return d
+}
+
+// ── Sync ────────────────────────────────────────────────────────────
+
+// syncAll is the periodic sync loop of every node, run on virtual time.
+func (net *Network) syncAll() {
+	for _, n := range net.nodes {
+		if !n.crashed {
+			n.Sync()
+		}
+	}
+	net.schedule(net.Now().Add(syncInterval), net.syncAll)
+}
+
+// ── Event queue ─────────────────────────────────────────────────────
+
+type event struct {
+	at  time.Time
+	seq uint64 // Breaks ties in scheduling order.
+	fn  func()
+}
+
+func (net *Network) schedule(at time.Time, fn func()) {
+	net.seq++
+	heap.Push(&net.events, &event{at: at, seq: net.seq, fn: fn})
+}
+
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if !q[i].at.Equal(q[j].at) {
+		return q[i].at.Before(q[j].at)
+	}
+	return q[i].seq < q[j].seq
+}
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x any)   { *q = append(*q, x.(*event)) }
+func (q *eventQueue) Pop() any {
+	old := *q
+	ev := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return ev
+}
@@ -0,0 +1,116 @@
+package simnet
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestNetwork(t *testing.T, cfg Config) *Network {
+	t.Helper()
+	cfg.DataDir = t.TempDir()
+	net, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(net.Close)
+	return net
+}
+
+func TestNetwork_Converges(t *testing.T) {
+	net := newTestNetwork(t, Config{Validators: 3, Observers: 1})
+
+	net.Run(time.Minute)
+
+	if err := net.CheckMinHeight(15); err != nil {
+		t.Fatal(err)
+	}
+	// Let the last block arrive.
+	if !net.RunUntil(func() bool { return net.CheckConverged() == nil }, time.Second) {
+		t.Fatal(net.CheckConverged())
+	}
+}
+
+func TestNetwork_Deterministic(t *testing.T) {
+	run := func() []string {
+		net := newTestNetwork(t, Config{Validators: 3, Seed: 7})
+		net.SetDropRate(0.2)
+		net.SetLatency(0, 1, 20*time.Millisecond)
+		net.Run(30 * time.Second)
+
+		// Message timing is real, so compare the settled chains.
+		net.SetDropRate(0)
+		if !net.RunUntil(func() bool { return net.CheckConverged() == nil }, 30*time.Second) {
+			t.Fatal(net.CheckConverged())
+		}
+		var tips []string
+		for _, h := range net.Tips() {
+			tips = append(tips, h.String())
+		}
+		return tips
+	}
+
+	a, b := run(), run()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("node %d tip differs between runs: %s vs %s", i, a[i], b[i])
+		}
+	}
+}
+
+func TestNetwork_PartitionHeals(t *testing.T) {
+	net := newTestNetwork(t, Config{Validators: 4})
+	net.Run(15 * time.Second)
+
+	net.Partition([]int{0, 1}, []int{2, 3})
+	net.Run(time.Minute)
+
+	if net.Node(0).TipHash() != net.Node(1).TipHash() {
+		t.Error("nodes 0 and 1 diverged inside their partition")
+	}
+	if net.Node(0).TipHash() == net.Node(2).TipHash() {
+		t.Fatal("partitioned halves share a tip")
+	}
+
+	net.Heal()
+	if !net.RunUntil(func() bool { return net.CheckConverged() == nil }, time.Minute) {
+		t.Fatal(net.CheckConverged())
+	}
+}
+
+func TestNetwork_ValidatorCrash(t *testing.T) {
+	net := newTestNetwork(t, Config{Validators: 3})
+	net.Run(15 * time.Second)
+
+	// The others cover the crashed validator's slots with backup blocks.
+	net.Crash(2)
+	before := net.Node(0).Height()
+	net.Run(time.Minute)
+	if got := net.Node(0).Height(); got < before+15 {
+		t.Errorf("height went from %d to %d with one validator down", before, got)
+	}
+	stale := net.Node(2).Height()
+
+	net.Restart(2)
+	if net.Node(2).Height() <= stale {
+		t.Error("restarted node did not catch up")
+	}
+	if !net.RunUntil(func() bool { return net.CheckConverged() == nil }, 30*time.Second) {
+		t.Fatal(net.CheckConverged())
+	}
+}
+
+func TestNetwork_MessageDrops(t *testing.T) {
+	net := newTestNetwork(t, Config{Validators: 3, Seed: 1})
+	net.SetDropRate(0.3)
+	net.Run(time.Minute)
+
+	// Periodic sync repairs what gossip lost.
+	net.SetDropRate(0)
+	net.Run(30 * time.Second)
+	if !net.RunUntil(func() bool { return net.CheckConverged() == nil }, 10*time.Second) {
+		t.Fatal(net.CheckConverged())
+	}
+	if err := net.CheckMinHeight(15); err != nil {
+		t.Fatal(err)
+	}
+}
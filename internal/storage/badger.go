@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 
@@ -94,25 +95,98 @@ func (b *BadgerDB) Has(key []byte) (bool, error) {
 // ForEach iterates over all keys with the given prefix.
 func (b *BadgerDB) ForEach(prefix []byte, fn func(key, value []byte) error) error {
 	return b.db.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.Prefix = prefix
-		it := txn.NewIterator(opts)
-		defer it.Close()
-
-		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
-			item := it.Item()
-			key := item.KeyCopy(nil)
-			err := item.Value(func(val []byte) error {
-				return fn(key, val)
-			})
-			if err != nil {
-				return err
-			}
-		}
-		return nil
+		return badgerForEach(txn, prefix, fn)
 	})
 }
 
+// badgerForEach iterates over all keys with the given prefix within txn.
+func badgerForEach(txn *badger.Txn, prefix []byte, fn func(key, value []byte) error) error {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		item := it.Item()
+		key := item.KeyCopy(nil)
+		err := item.Value(func(val []byte) error {
+			return fn(key, val)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewIterator returns an iterator over a read-only transaction that is
+// discarded when the iterator is closed.
+func (b *BadgerDB) NewIterator(opts IterOptions) Iterator {
+	txn := b.db.NewTransaction(false)
+	return newBoundedIterator(newBadgerCursor(txn, opts, true), opts)
+}
+
+// badgerCursor drives a Badger iterator. Values are not prefetched, so
+// key-only scans never touch the value log.
+type badgerCursor struct {
+	txn          *badger.Txn
+	it           *badger.Iterator
+	lower, upper []byte
+	reverse      bool
+	ownsTxn      bool
+}
+
+func newBadgerCursor(txn *badger.Txn, opts IterOptions, ownsTxn bool) *badgerCursor {
+	lower, upper := iterBounds(opts)
+	itOpts := badger.DefaultIteratorOptions
+	itOpts.PrefetchValues = false
+	itOpts.Reverse = opts.Reverse
+	return &badgerCursor{
+		txn:     txn,
+		it:      txn.NewIterator(itOpts),
+		lower:   lower,
+		upper:   upper,
+		reverse: opts.Reverse,
+		ownsTxn: ownsTxn,
+	}
+}
+
+func (c *badgerCursor) first() {
+	if !c.reverse {
+		c.it.Seek(c.lower)
+		return
+	}
+	if c.upper == nil {
+		c.it.Rewind()
+		return
+	}
+	// Reverse Seek lands on the highest key <= upper; step past upper itself.
+	c.it.Seek(c.upper)
+	if c.it.Valid() && bytes.Equal(c.it.Item().Key(), c.upper) {
+		c.it.Next()
+	}
+}
+
+func (c *badgerCursor) seek(key []byte) { c.it.Seek(key) }
+func (c *badgerCursor) next()           { c.it.Next() }
+func (c *badgerCursor) valid() bool     { return c.it.Valid() }
+func (c *badgerCursor) key() []byte     { return c.it.Item().Key() }
+
+func (c *badgerCursor) value() ([]byte, error) {
+	v, err := c.it.Item().ValueCopy(nil)
+	if err != nil {
+		return nil, fmt.Errorf("badger value: %w", err)
+	}
+	return v, nil
+}
+
+func (c *badgerCursor) close() {
+	c.it.Close()
+	if c.ownsTxn {
+		c.txn.Discard()
+	}
+}
+
 // Close closes the database.
 func (b *BadgerDB) Close() error {
 	return b.db.Close()
@@ -138,3 +212,51 @@ func (bb *badgerBatch) Delete(key []byte) error {
 func (bb *badgerBatch) Commit() error {
 	return bb.txn.Commit()
 }
+
+// NewSnapshot opens a read-only Badger transaction. Badger's MVCC keeps
+// every version visible to the transaction alive until it is released.
+func (b *BadgerDB) NewSnapshot() Snapshot {
+	return &badgerSnapshot{txn: b.db.NewTransaction(false)}
+}
+
+type badgerSnapshot struct {
+	txn *badger.Txn
+}
+
+func (bs *badgerSnapshot) Get(key []byte) ([]byte, error) {
+	item, err := bs.txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil, fmt.Errorf("key not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("badger snapshot get: %w", err)
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, fmt.Errorf("badger snapshot get: %w", err)
+	}
+	return val, nil
+}
+
+func (bs *badgerSnapshot) Has(key []byte) (bool, error) {
+	_, err := bs.txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("badger snapshot has: %w", err)
+	}
+	return true, nil
+}
+
+func (bs *badgerSnapshot) ForEach(prefix []byte, fn func(key, value []byte) error) error {
+	return badgerForEach(bs.txn, prefix, fn)
+}
+
+func (bs *badgerSnapshot) NewIterator(opts IterOptions) Iterator {
+	return newBoundedIterator(newBadgerCursor(bs.txn, opts, false), opts)
+}
+
+func (bs *badgerSnapshot) Release() {
+	bs.txn.Discard()
+}
@@ -0,0 +1,282 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltBucket holds every key; the flat keyspace mirrors the other engines.
+var boltBucket = []byte("kv")
+
+// boltForEachChunk bounds how many entries ForEach reads per transaction.
+const boltForEachChunk = 1024
+
+// BoltDB implements DB using bbolt, a single-file B+tree store.
+//
+// bbolt read transactions block writers that need to grow the mmap, so
+// BoltDB does not implement Snapshotter (callers get a live view) and
+// ForEach never holds a transaction open while running the callback.
+type BoltDB struct {
+	db *bolt.DB
+}
+
+// NewBolt creates a new bbolt database file at the given path.
+func NewBolt(path string) (*BoltDB, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		if errors.Is(err, bolt.ErrTimeout) {
+			return nil, fmt.Errorf("database at %s is locked by another process (is another klingnetd instance running?): %w", path, err)
+		}
+		return nil, fmt.Errorf("open database at %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create bucket: %w", err)
+	}
+	return &BoltDB{db: db}, nil
+}
+
+// Get retrieves a value by key. Returns an error if the key does not exist.
+func (b *BoltDB) Get(key []byte) ([]byte, error) {
+	var val []byte
+	err := b.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltBucket).Get(key)
+		if v == nil {
+			return nil
+		}
+		val = make([]byte, len(v))
+		copy(val, v)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bolt get: %w", err)
+	}
+	if val == nil {
+		return nil, fmt.Errorf("key not found")
+	}
+	return val, nil
+}
+
+// Put stores a key-value pair.
+func (b *BoltDB) Put(key, value []byte) error {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put(key, value)
+	})
+	if err != nil {
+		return fmt.Errorf("bolt put: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a key.
+func (b *BoltDB) Delete(key []byte) error {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete(key)
+	})
+	if err != nil {
+		return fmt.Errorf("bolt delete: %w", err)
+	}
+	return nil
+}
+
+// Has checks if a key exists.
+func (b *BoltDB) Has(key []byte) (bool, error) {
+	var exists bool
+	err := b.db.View(func(tx *bolt.Tx) error {
+		exists = tx.Bucket(boltBucket).Get(key) != nil
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("bolt has: %w", err)
+	}
+	return exists, nil
+}
+
+// ForEach iterates over all keys with the given prefix. Entries are read
+// in chunks and the callback runs outside any transaction, so fn may
+// write to the database.
+func (b *BoltDB) ForEach(prefix []byte, fn func(key, value []byte) error) error {
+	seek := prefix
+	skipFirst := false
+	for {
+		var keys, vals [][]byte
+		err := b.db.View(func(tx *bolt.Tx) error {
+			c := tx.Bucket(boltBucket).Cursor()
+			k, v := c.Seek(seek)
+			if skipFirst && k != nil && bytes.Equal(k, seek) {
+				k, v = c.Next()
+			}
+			for ; k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+				keys = append(keys, append([]byte(nil), k...))
+				vals = append(vals, append([]byte(nil), v...))
+				if len(keys) == boltForEachChunk {
+					break
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("bolt iterate: %w", err)
+		}
+		for i := range keys {
+			if err := fn(keys[i], vals[i]); err != nil {
+				return err
+			}
+		}
+		if len(keys) < boltForEachChunk {
+			return nil
+		}
+		seek = keys[len(keys)-1]
+		skipFirst = true
+	}
+}
+
+// NewIterator returns an iterator that, like ForEach, reads entries in
+// chunks from short-lived transactions rather than holding one open.
+func (b *BoltDB) NewIterator(opts IterOptions) Iterator {
+	lower, upper := iterBounds(opts)
+	return newBoundedIterator(&boltCursor{db: b.db, lower: lower, upper: upper, reverse: opts.Reverse}, opts)
+}
+
+// boltCursor buffers up to boltForEachChunk entries at a time and refills
+// from the last buffered key when the buffer runs out.
+type boltCursor struct {
+	db           *bolt.DB
+	lower, upper []byte
+	reverse      bool
+
+	keys, vals [][]byte
+	pos        int
+	more       bool // the last fill stopped at the chunk limit
+}
+
+func (c *boltCursor) first() {
+	if c.reverse {
+		c.fill(c.upper, false, c.upper == nil)
+	} else {
+		c.fill(c.lower, true, false)
+	}
+}
+
+func (c *boltCursor) seek(key []byte) {
+	c.fill(key, true, false)
+}
+
+func (c *boltCursor) next() {
+	c.pos++
+	if c.pos < len(c.keys) || !c.more {
+		return
+	}
+	c.fill(c.keys[len(c.keys)-1], false, false)
+}
+
+func (c *boltCursor) valid() bool            { return c.pos < len(c.keys) }
+func (c *boltCursor) key() []byte            { return c.keys[c.pos] }
+func (c *boltCursor) value() ([]byte, error) { return c.vals[c.pos], nil }
+func (c *boltCursor) close()                 {}
+
+// fill loads the next chunk starting at from, which is included when
+// inclusive is set. fromEnd starts a reverse scan at the last key.
+func (c *boltCursor) fill(from []byte, inclusive, fromEnd bool) {
+	c.keys, c.vals, c.pos, c.more = c.keys[:0], c.vals[:0], 0, false
+	c.db.View(func(tx *bolt.Tx) error {
+		cur := tx.Bucket(boltBucket).Cursor()
+		var k, v []byte
+		if c.reverse {
+			if fromEnd {
+				k, v = cur.Last()
+			} else {
+				k, v = cur.Seek(from)
+				if k == nil {
+					k, v = cur.Last()
+				} else if bytes.Compare(k, from) > 0 || (!inclusive && bytes.Equal(k, from)) {
+					k, v = cur.Prev()
+				}
+			}
+		} else {
+			k, v = cur.Seek(from)
+			if !inclusive && k != nil && bytes.Equal(k, from) {
+				k, v = cur.Next()
+			}
+		}
+		for k != nil {
+			if c.reverse && bytes.Compare(k, c.lower) < 0 {
+				break
+			}
+			if !c.reverse && c.upper != nil && bytes.Compare(k, c.upper) >= 0 {
+				break
+			}
+			c.keys = append(c.keys, append([]byte(nil), k...))
+			c.vals = append(c.vals, append([]byte(nil), v...))
+			if len(c.keys) == boltForEachChunk {
+				c.more = true
+				break
+			}
+			if c.reverse {
+				k, v = cur.Prev()
+			} else {
+				k, v = cur.Next()
+			}
+		}
+		return nil
+	})
+}
+
+// Close closes the database.
+func (b *BoltDB) Close() error {
+	return b.db.Close()
+}
+
+// NewBatch creates an atomic write batch. Writes are buffered and applied
+// in a single bbolt transaction on Commit.
+func (b *BoltDB) NewBatch() Batch {
+	return &boltBatch{db: b.db}
+}
+
+type boltOp struct {
+	key   []byte
+	value []byte // nil means delete
+}
+
+type boltBatch struct {
+	db  *bolt.DB
+	ops []boltOp
+}
+
+func (bb *boltBatch) Put(key, value []byte) error {
+	v := make([]byte, len(value))
+	copy(v, value)
+	bb.ops = append(bb.ops, boltOp{key: append([]byte(nil), key...), value: v})
+	return nil
+}
+
+func (bb *boltBatch) Delete(key []byte) error {
+	bb.ops = append(bb.ops, boltOp{key: append([]byte(nil), key...)})
+	return nil
+}
+
+func (bb *boltBatch) Commit() error {
+	return bb.db.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(boltBucket)
+		for _, op := range bb.ops {
+			var err error
+			if op.value == nil {
+				err = bkt.Delete(op.key)
+			} else {
+				err = bkt.Put(op.key, op.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -11,6 +11,9 @@ type DB interface {
 	// The callback receives a copy of the key and value.
 	// Return a non-nil error from fn to stop iteration early.
 	ForEach(prefix []byte, fn func(key, value []byte) error) error
+	// NewIterator returns a sorted iterator over the keys selected by opts.
+	// The caller must Close it.
+	NewIterator(opts IterOptions) Iterator
 	Close() error
 }
 
@@ -2,6 +2,9 @@ package storage
 
 import (
 	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -203,3 +206,63 @@ func TestBadgerDB_Persistence(t *testing.T) {
 		t.Errorf("persisted value = %q, want %q", val, "data")
 	}
 }
+
+func TestPebbleDB(t *testing.T) {
+	db, err := NewPebble(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewPebble() error: %v", err)
+	}
+	defer db.Close()
+	testDB(t, db)
+}
+
+func TestPebbleDB_Lock(t *testing.T) {
+	dir := t.TempDir()
+	db1, err := NewPebble(dir)
+	if err != nil {
+		t.Fatalf("NewPebble() error: %v", err)
+	}
+
+	if _, err := NewPebble(dir); err == nil || !strings.Contains(err.Error(), "is locked") {
+		t.Fatalf("second NewPebble() error = %v, want locked", err)
+	}
+
+	// Closing releases the lock.
+	db1.Close()
+	db2, err := NewPebble(dir)
+	if err != nil {
+		t.Fatalf("NewPebble() reopen error: %v", err)
+	}
+	db2.Close()
+}
+
+func TestPebbleDB_ForEachRetainsValues(t *testing.T) {
+	db, err := NewPebble(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewPebble() error: %v", err)
+	}
+	defer db.Close()
+
+	for i := 0; i < 100; i++ {
+		db.Put([]byte(fmt.Sprintf("k%03d", i)), []byte(fmt.Sprintf("v%03d", i)))
+	}
+	var vals [][]byte
+	db.ForEach([]byte("k"), func(_, value []byte) error {
+		vals = append(vals, value)
+		return nil
+	})
+	for i, v := range vals {
+		if want := fmt.Sprintf("v%03d", i); string(v) != want {
+			t.Fatalf("value %d = %q after iteration, want %q", i, v, want)
+		}
+	}
+}
+
+func TestBoltDB(t *testing.T) {
+	db, err := NewBolt(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewBolt() error: %v", err)
+	}
+	defer db.Close()
+	testDB(t, db)
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Engine names a storage backend.
+type Engine string
+
+const (
+	EngineBadger Engine = "badger" // Default; LSM with a separate value log.
+	EnginePebble Engine = "pebble" // Pure-Go LSM, values inline.
+	EngineBolt   Engine = "bolt"   // Single-file B+tree (bbolt).
+)
+
+// boltFile is the database file name inside a bolt engine directory.
+const boltFile = "chain.db"
+
+// ErrNotEmpty is returned by Copy when the destination already holds data.
+var ErrNotEmpty = errors.New("destination database is not empty")
+
+// ParseEngine validates an engine name.
+func ParseEngine(name string) (Engine, error) {
+	switch e := Engine(name); e {
+	case EngineBadger, EnginePebble, EngineBolt:
+		return e, nil
+	default:
+		return "", fmt.Errorf("unknown storage engine %q (want badger, pebble or bolt)", name)
+	}
+}
+
+// Open opens (or creates) a database of the given engine in dir.
+func Open(engine Engine, dir string) (DB, error) {
+	switch engine {
+	case EngineBadger, "":
+		return NewBadger(dir)
+	case EnginePebble:
+		return NewPebble(dir)
+	case EngineBolt:
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, fmt.Errorf("create database dir %s: %w", dir, err)
+		}
+		return NewBolt(filepath.Join(dir, boltFile))
+	default:
+		return nil, fmt.Errorf("unknown storage engine %q", engine)
+	}
+}
+
+// subChainPrefix is the key prefix under which sub-chains keep their
+// PrefixDB namespaces ("sc/<chainid>/").
+var subChainPrefix = []byte("sc/")
+
+// namespaceOf returns the sub-chain namespace a key belongs to, or ""
+// for root chain keys.
+func namespaceOf(key []byte) string {
+	if !bytes.HasPrefix(key, subChainPrefix) {
+		return ""
+	}
+	rest := key[len(subChainPrefix):]
+	if i := bytes.IndexByte(rest, '/'); i > 0 {
+		return string(key[:len(subChainPrefix)+i+1])
+	}
+	return ""
+}
+
+// KeyCounts holds the number of keys per namespace. The root chain is
+// keyed by "" and each sub-chain by its "sc/<chainid>/" prefix.
+type KeyCounts map[string]int
+
+// Total returns the number of keys across all namespaces.
+func (kc KeyCounts) Total() int {
+	n := 0
+	for _, c := range kc {
+		n += c
+	}
+	return n
+}
+
+// CountKeys counts every key in db, grouped by namespace.
+func CountKeys(db DB) (KeyCounts, error) {
+	counts := make(KeyCounts)
+	err := db.ForEach(nil, func(key, _ []byte) error {
+		counts[namespaceOf(key)]++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// copyBatchSize is the number of writes per batch commit during Copy.
+const copyBatchSize = 10000
+
+// Copy writes every key in src to dst, then verifies that the key counts
+// of both databases match for the root chain and every sub-chain
+// namespace. dst must be empty. Returns the verified counts.
+func Copy(dst, src DB) (KeyCounts, error) {
+	empty := true
+	errStop := errors.New("stop")
+	err := dst.ForEach(nil, func(_, _ []byte) error {
+		empty = false
+		return errStop
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return nil, fmt.Errorf("check destination: %w", err)
+	}
+	if !empty {
+		return nil, ErrNotEmpty
+	}
+
+	batcher, _ := dst.(Batcher)
+	var batch Batch
+	pending := 0
+	flush := func() error {
+		if batch == nil {
+			return nil
+		}
+		err := batch.Commit()
+		batch, pending = nil, 0
+		return err
+	}
+
+	srcCounts := make(KeyCounts)
+	err = src.ForEach(nil, func(key, value []byte) error {
+		srcCounts[namespaceOf(key)]++
+		if batcher == nil {
+			return dst.Put(key, value)
+		}
+		if batch == nil {
+			batch = batcher.NewBatch()
+		}
+		if err := batch.Put(key, value); err != nil {
+			return err
+		}
+		pending++
+		if pending >= copyBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("copy: %w", err)
+	}
+	if err := flush(); err != nil {
+		return nil, fmt.Errorf("copy: %w", err)
+	}
+
+	dstCounts, err := CountKeys(dst)
+	if err != nil {
+		return nil, fmt.Errorf("count destination: %w", err)
+	}
+	for ns, n := range srcCounts {
+		if dstCounts[ns] != n {
+			return nil, fmt.Errorf("verify namespace %q: source has %d keys, destination %d", ns, n, dstCounts[ns])
+		}
+	}
+	if len(dstCounts) != len(srcCounts) {
+		return nil, fmt.Errorf("verify: destination has %d namespaces, source %d", len(dstCounts), len(srcCounts))
+	}
+	return srcCounts, nil
+}
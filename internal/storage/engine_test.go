@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseEngine(t *testing.T) {
+	for _, name := range []string{"badger", "pebble", "bolt"} {
+		if _, err := ParseEngine(name); err != nil {
+			t.Errorf("ParseEngine(%q) error: %v", name, err)
+		}
+	}
+	if _, err := ParseEngine("leveldb"); err == nil {
+		t.Error("ParseEngine(leveldb) should fail")
+	}
+}
+
+func TestOpen_AllEngines(t *testing.T) {
+	for _, e := range []Engine{EngineBadger, EnginePebble, EngineBolt} {
+		t.Run(string(e), func(t *testing.T) {
+			dir := t.TempDir()
+			db, err := Open(e, dir)
+			if err != nil {
+				t.Fatalf("Open() error: %v", err)
+			}
+			db.Put([]byte("persist"), []byte("data"))
+			db.Close()
+
+			db, err = Open(e, dir)
+			if err != nil {
+				t.Fatalf("Open() reopen error: %v", err)
+			}
+			defer db.Close()
+			val, err := db.Get([]byte("persist"))
+			if err != nil || !bytes.Equal(val, []byte("data")) {
+				t.Errorf("Get() after reopen = %q, %v", val, err)
+			}
+			if _, ok := db.(Batcher); !ok {
+				t.Error("engine should support batches")
+			}
+		})
+	}
+}
+
+func TestCopy(t *testing.T) {
+	src := NewMemory()
+	for i := 0; i < 50; i++ {
+		src.Put([]byte(fmt.Sprintf("b/%03d", i)), []byte("root"))
+	}
+	for i := 0; i < 20; i++ {
+		src.Put([]byte(fmt.Sprintf("sc/aa/u/%03d", i)), []byte("sub1"))
+	}
+	for i := 0; i < 5; i++ {
+		src.Put([]byte(fmt.Sprintf("sc/bb/u/%03d", i)), []byte("sub2"))
+	}
+
+	dst, err := NewPebble(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewPebble() error: %v", err)
+	}
+	defer dst.Close()
+
+	counts, err := Copy(dst, src)
+	if err != nil {
+		t.Fatalf("Copy() error: %v", err)
+	}
+	if counts[""] != 50 || counts["sc/aa/"] != 20 || counts["sc/bb/"] != 5 {
+		t.Errorf("Copy() counts = %v", counts)
+	}
+	if counts.Total() != 75 {
+		t.Errorf("Total() = %d, want 75", counts.Total())
+	}
+
+	val, err := dst.Get([]byte("sc/bb/u/004"))
+	if err != nil || !bytes.Equal(val, []byte("sub2")) {
+		t.Errorf("dst Get(sc/bb/u/004) = %q, %v", val, err)
+	}
+
+	// A second copy into the now-populated destination is refused.
+	if _, err := Copy(dst, src); !errors.Is(err, ErrNotEmpty) {
+		t.Errorf("Copy() into non-empty dst error = %v, want ErrNotEmpty", err)
+	}
+}
+
+func TestBoltDB_ForEachChunksAllowWrites(t *testing.T) {
+	db, err := Open(EngineBolt, t.TempDir())
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer db.Close()
+
+	n := boltForEachChunk*2 + 7
+	for i := 0; i < n; i++ {
+		db.Put([]byte(fmt.Sprintf("k/%05d", i)), []byte("v"))
+	}
+
+	// Deleting from inside the callback must not deadlock or skip keys.
+	seen := 0
+	err = db.ForEach([]byte("k/"), func(key, _ []byte) error {
+		seen++
+		return db.Delete(key)
+	})
+	if err != nil {
+		t.Fatalf("ForEach() error: %v", err)
+	}
+	if seen != n {
+		t.Errorf("ForEach visited %d keys, want %d", seen, n)
+	}
+	counts, _ := CountKeys(db)
+	if counts.Total() != 0 {
+		t.Errorf("%d keys left after delete", counts.Total())
+	}
+}
@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"bytes"
+	"sort"
+)
+
+// IterOptions bounds and orders an Iterator.
+type IterOptions struct {
+	// Prefix restricts iteration to keys with this prefix (nil = all keys).
+	Prefix []byte
+	// Start is the inclusive lower bound (nil = start of Prefix).
+	Start []byte
+	// End is the exclusive upper bound (nil = end of Prefix).
+	End []byte
+	// Reverse iterates from the highest key down to the lowest.
+	Reverse bool
+}
+
+// Iterator walks keys in sorted byte order within the bounds given by
+// IterOptions. It starts positioned before the first key, so callers loop
+// with `for it.Next() { ... }`. Key and Value return copies that remain
+// valid after the iterator moves. Close must always be called.
+type Iterator interface {
+	// Next advances to the next key and reports whether there is one.
+	Next() bool
+	// Seek repositions the iterator so that the following Next lands on
+	// the first key >= key (or the last key <= key when reversed),
+	// clamped to the iterator's bounds.
+	Seek(key []byte)
+	// Key returns the current key.
+	Key() []byte
+	// Value returns the current value.
+	Value() []byte
+	// Err returns the first error encountered, if any.
+	Err() error
+	// Close releases the iterator's resources.
+	Close()
+}
+
+// iterBounds resolves opts into a [lower, upper) key range. A nil bound is
+// unbounded on that side.
+func iterBounds(opts IterOptions) (lower, upper []byte) {
+	lower = opts.Prefix
+	if opts.Start != nil && bytes.Compare(opts.Start, lower) > 0 {
+		lower = opts.Start
+	}
+	upper = prefixUpperBound(opts.Prefix)
+	if opts.End != nil && (upper == nil || bytes.Compare(opts.End, upper) < 0) {
+		upper = opts.End
+	}
+	return lower, upper
+}
+
+// cursor is the engine-specific positioning primitive behind
+// boundedIterator. Bounds are enforced by boundedIterator, so a cursor
+// only has to move in its configured direction.
+type cursor interface {
+	// first positions at the first key in iteration order: the lowest
+	// key >= lower going forward, the highest key < upper in reverse.
+	first()
+	// seek positions at the lowest key >= key going forward, or the
+	// highest key <= key in reverse.
+	seek(key []byte)
+	next()
+	valid() bool
+	key() []byte
+	value() ([]byte, error)
+	close()
+}
+
+// boundedIterator adapts a cursor to Iterator, applying the key range and
+// the Seek/Next protocol uniformly across engines.
+type boundedIterator struct {
+	cur          cursor
+	lower, upper []byte
+	reverse      bool
+
+	started   bool
+	exhausted bool   // past the last key; only Seek revives the iterator
+	seekTo    []byte // pending Seek target, applied on the next Next
+	key       []byte
+	err       error
+	closed    bool
+}
+
+func newBoundedIterator(cur cursor, opts IterOptions) *boundedIterator {
+	lower, upper := iterBounds(opts)
+	return &boundedIterator{cur: cur, lower: lower, upper: upper, reverse: opts.Reverse}
+}
+
+func (it *boundedIterator) Next() bool {
+	if it.closed || it.err != nil {
+		return false
+	}
+	switch {
+	case it.seekTo != nil:
+		it.position(it.seekTo)
+		it.seekTo = nil
+	case it.exhausted:
+		return false
+	case !it.started:
+		it.cur.first()
+	default:
+		it.cur.next()
+	}
+	it.started = true
+
+	if !it.cur.valid() {
+		it.key, it.exhausted = nil, true
+		return false
+	}
+	k := it.cur.key()
+	if (!it.reverse && it.upper != nil && bytes.Compare(k, it.upper) >= 0) ||
+		(it.reverse && bytes.Compare(k, it.lower) < 0) {
+		it.key, it.exhausted = nil, true
+		return false
+	}
+	it.key = append(it.key[:0:0], k...)
+	return true
+}
+
+// position moves the cursor to target, clamped to [lower, upper).
+func (it *boundedIterator) position(target []byte) {
+	if !it.reverse {
+		if bytes.Compare(target, it.lower) < 0 {
+			it.cur.first()
+		} else {
+			it.cur.seek(target)
+		}
+		return
+	}
+	if it.upper != nil && bytes.Compare(target, it.upper) >= 0 {
+		it.cur.first()
+	} else {
+		it.cur.seek(target)
+	}
+}
+
+func (it *boundedIterator) Seek(key []byte) {
+	it.seekTo = append([]byte{}, key...)
+	it.exhausted = false
+}
+
+func (it *boundedIterator) Key() []byte {
+	return it.key
+}
+
+func (it *boundedIterator) Value() []byte {
+	if it.key == nil {
+		return nil
+	}
+	v, err := it.cur.value()
+	if err != nil {
+		it.err = err
+		return nil
+	}
+	return v
+}
+
+func (it *boundedIterator) Err() error {
+	return it.err
+}
+
+func (it *boundedIterator) Close() {
+	if !it.closed {
+		it.closed = true
+		it.cur.close()
+	}
+}
+
+// sliceCursor iterates a sorted in-memory key/value list holding only the
+// keys within the iterator's bounds. MemoryDB uses it over a copy taken
+// when the iterator is created.
+type sliceCursor struct {
+	keys    []string
+	vals    [][]byte
+	reverse bool
+	pos     int
+}
+
+// newSliceCursor returns a cursor over the entries that fall within opts.
+func newSliceCursor(entries map[string][]byte, opts IterOptions) *sliceCursor {
+	lower, upper := iterBounds(opts)
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		if k < string(lower) || (upper != nil && k >= string(upper)) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	vals := make([][]byte, len(keys))
+	for i, k := range keys {
+		vals[i] = entries[k]
+	}
+	return &sliceCursor{keys: keys, vals: vals, reverse: opts.Reverse, pos: -1}
+}
+
+func (c *sliceCursor) first() {
+	if c.reverse {
+		c.pos = len(c.keys) - 1
+	} else {
+		c.pos = 0
+	}
+}
+
+func (c *sliceCursor) seek(key []byte) {
+	k := string(key)
+	if !c.reverse {
+		c.pos = sort.SearchStrings(c.keys, k)
+		return
+	}
+	// Highest key <= k.
+	c.pos = sort.Search(len(c.keys), func(i int) bool { return c.keys[i] > k }) - 1
+}
+
+func (c *sliceCursor) next() {
+	if c.reverse {
+		c.pos--
+	} else {
+		c.pos++
+	}
+}
+
+func (c *sliceCursor) valid() bool {
+	return c.pos >= 0 && c.pos < len(c.keys)
+}
+
+func (c *sliceCursor) key() []byte {
+	return []byte(c.keys[c.pos])
+}
+
+func (c *sliceCursor) value() ([]byte, error) {
+	v := c.vals[c.pos]
+	out := make([]byte, len(v))
+	copy(out, v)
+	return out, nil
+}
+
+func (c *sliceCursor) close() {}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// collect drains it and returns its keys as strings.
+func collect(t *testing.T, it Iterator) []string {
+	t.Helper()
+	defer it.Close()
+	var keys []string
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("iterator error: %v", err)
+	}
+	return keys
+}
+
+// testIterator runs the shared iterator suite against a DB implementation.
+func testIterator(t *testing.T, db DB) {
+	t.Helper()
+
+	// "p0" is the first key past the "p/" prefix and "o/" sorts before it,
+	// so both bound checks are exercised.
+	for _, k := range []string{"o/z", "p/a", "p/b", "p/c", "p/d", "p0"} {
+		db.Put([]byte(k), []byte("v:"+k))
+	}
+
+	t.Run("Forward", func(t *testing.T) {
+		got := collect(t, db.NewIterator(IterOptions{Prefix: []byte("p/")}))
+		want := []string{"p/a", "p/b", "p/c", "p/d"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("keys = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Reverse", func(t *testing.T) {
+		got := collect(t, db.NewIterator(IterOptions{Prefix: []byte("p/"), Reverse: true}))
+		want := []string{"p/d", "p/c", "p/b", "p/a"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("keys = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Bounds", func(t *testing.T) {
+		opts := IterOptions{Prefix: []byte("p/"), Start: []byte("p/b"), End: []byte("p/d")}
+		if got, want := collect(t, db.NewIterator(opts)), []string{"p/b", "p/c"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("forward keys = %v, want %v", got, want)
+		}
+		opts.Reverse = true
+		if got, want := collect(t, db.NewIterator(opts)), []string{"p/c", "p/b"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("reverse keys = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Seek", func(t *testing.T) {
+		it := db.NewIterator(IterOptions{Prefix: []byte("p/")})
+		it.Seek([]byte("p/bb"))
+		if got, want := collect(t, it), []string{"p/c", "p/d"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("forward seek keys = %v, want %v", got, want)
+		}
+
+		it = db.NewIterator(IterOptions{Prefix: []byte("p/"), Reverse: true})
+		it.Seek([]byte("p/bb"))
+		if got, want := collect(t, it), []string{"p/b", "p/a"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("reverse seek keys = %v, want %v", got, want)
+		}
+
+		// Seeks outside the bounds clamp to them.
+		it = db.NewIterator(IterOptions{Prefix: []byte("p/")})
+		it.Seek([]byte("a"))
+		if got := collect(t, it); len(got) != 4 {
+			t.Errorf("clamped seek keys = %v, want 4", got)
+		}
+	})
+
+	t.Run("Value", func(t *testing.T) {
+		it := db.NewIterator(IterOptions{Prefix: []byte("p/c")})
+		defer it.Close()
+		if !it.Next() {
+			t.Fatal("Next() = false, want p/c")
+		}
+		if got := string(it.Value()); got != "v:p/c" {
+			t.Errorf("Value() = %q, want %q", got, "v:p/c")
+		}
+	})
+
+	t.Run("EarlyClose", func(t *testing.T) {
+		it := db.NewIterator(IterOptions{Prefix: []byte("p/")})
+		if !it.Next() {
+			t.Fatal("Next() = false")
+		}
+		it.Close()
+		if it.Next() {
+			t.Error("Next() after Close() should return false")
+		}
+	})
+}
+
+func TestMemoryDB_Iterator(t *testing.T) {
+	testIterator(t, NewMemory())
+}
+
+func TestBadgerDB_Iterator(t *testing.T) {
+	db, err := NewBadger(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewBadger() error: %v", err)
+	}
+	defer db.Close()
+	testIterator(t, db)
+}
+
+func TestPebbleDB_Iterator(t *testing.T) {
+	db, err := NewPebble(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewPebble() error: %v", err)
+	}
+	defer db.Close()
+	testIterator(t, db)
+}
+
+func TestBoltDB_Iterator(t *testing.T) {
+	db, err := NewBolt(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewBolt() error: %v", err)
+	}
+	defer db.Close()
+	testIterator(t, db)
+}
+
+func TestPrefixDB_Iterator(t *testing.T) {
+	inner := NewMemory()
+	// Keys in a neighbouring namespace must not leak into the iteration.
+	inner.Put([]byte("sc/1/p/zz"), []byte("other"))
+	testIterator(t, NewPrefixDB(inner, []byte("sc/0/")))
+}
+
+func TestSnapshot_Iterator(t *testing.T) {
+	db, err := NewBadger(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewBadger() error: %v", err)
+	}
+	defer db.Close()
+	db.Put([]byte("k/1"), []byte("a"))
+	db.Put([]byte("k/2"), []byte("b"))
+
+	snap := NewSnapshot(NewPrefixDB(db, []byte("k/")))
+	defer snap.Release()
+	db.Put([]byte("k/3"), []byte("c"))
+
+	got := collect(t, snap.NewIterator(IterOptions{Reverse: true}))
+	if want := []string{"2", "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot keys = %v, want %v", got, want)
+	}
+}
+
+func TestBoltDB_IteratorAcrossChunks(t *testing.T) {
+	db, err := NewBolt(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewBolt() error: %v", err)
+	}
+	defer db.Close()
+
+	n := boltForEachChunk*2 + 3
+	for i := 0; i < n; i++ {
+		db.Put([]byte(fmt.Sprintf("k/%05d", i)), []byte("v"))
+	}
+	for _, reverse := range []bool{false, true} {
+		got := collect(t, db.NewIterator(IterOptions{Prefix: []byte("k/"), Reverse: reverse}))
+		if len(got) != n {
+			t.Fatalf("reverse=%v: %d keys, want %d", reverse, len(got), n)
+		}
+		first, last := fmt.Sprintf("k/%05d", 0), fmt.Sprintf("k/%05d", n-1)
+		if reverse {
+			first, last = last, first
+		}
+		if got[0] != first || got[n-1] != last {
+			t.Errorf("reverse=%v: range %s..%s, want %s..%s", reverse, got[0], got[n-1], first, last)
+		}
+	}
+}
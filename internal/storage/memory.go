@@ -2,61 +2,169 @@ package storage
 
 import (
 	"errors"
-	"strings"
+	"math"
+	"sync"
 )
 
-// MemoryDB implements DB using an in-memory map.
+// MemoryDB implements DB using an in-memory map. Snapshots are versioned:
+// while one is open, writes keep the value it sees instead of overwriting
+// it, so taking a snapshot costs nothing and each write costs O(1).
 type MemoryDB struct {
-	data map[string][]byte
+	mu      sync.RWMutex
+	data    map[string][]memoryEntry // Oldest version first.
+	version uint64                   // Version of new writes.
+	snaps   map[uint64]int           // Open snapshot versions → count.
+	stale   map[string]struct{}      // Keys holding more than their latest value.
+}
+
+// memoryEntry is one version of a key's value. A nil value is a delete
+// kept for an open snapshot.
+type memoryEntry struct {
+	version uint64
+	value   []byte
 }
 
 // NewMemory creates a new in-memory database.
 func NewMemory() *MemoryDB {
 	return &MemoryDB{
-		data: make(map[string][]byte),
+		data:  make(map[string][]memoryEntry),
+		snaps: make(map[uint64]int),
+		stale: make(map[string]struct{}),
 	}
 }
 
-// Get retrieves a value by key.
-func (m *MemoryDB) Get(key []byte) ([]byte, error) {
-	v, ok := m.data[string(key)]
-	if !ok {
+// latest returns the value visible at version at, or nil.
+func latest(entries []memoryEntry, at uint64) []byte {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].version <= at {
+			return entries[i].value
+		}
+	}
+	return nil
+}
+
+func (m *MemoryDB) getAt(key []byte, at uint64) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v := latest(m.data[string(key)], at)
+	if v == nil {
 		return nil, errors.New("key not found")
 	}
 	return v, nil
 }
 
+// Get retrieves a value by key.
+func (m *MemoryDB) Get(key []byte) ([]byte, error) {
+	return m.getAt(key, math.MaxUint64)
+}
+
 // Put stores a key-value pair.
 func (m *MemoryDB) Put(key, value []byte) error {
-	m.data[string(key)] = value
+	if value == nil {
+		value = []byte{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.set(string(key), value)
 	return nil
 }
 
 // Delete removes a key.
 func (m *MemoryDB) Delete(key []byte) error {
-	delete(m.data, string(key))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.set(string(key), nil)
 	return nil
 }
 
+// set writes a version of key with m.mu held.
+func (m *MemoryDB) set(key string, value []byte) {
+	entries := m.data[key]
+	if n := len(entries); n > 0 && entries[n-1].version == m.version {
+		entries[n-1].value = value // No snapshot sees the previous value.
+	} else {
+		entries = append(entries, memoryEntry{version: m.version, value: value})
+	}
+	m.data[key] = entries
+	m.compact(key)
+}
+
+// compact drops the versions of key that no open snapshot can see, with
+// m.mu held.
+func (m *MemoryDB) compact(key string) {
+	oldest := uint64(math.MaxUint64)
+	for v := range m.snaps {
+		oldest = min(oldest, v)
+	}
+	entries := m.data[key]
+	keep := 0
+	for keep+1 < len(entries) && (len(m.snaps) == 0 || entries[keep+1].version <= oldest) {
+		keep++
+	}
+	entries = entries[keep:]
+
+	switch {
+	case len(entries) == 1 && entries[0].value == nil:
+		delete(m.data, key)
+		delete(m.stale, key)
+	case len(entries) == 1:
+		m.data[key] = entries
+		delete(m.stale, key)
+	default:
+		m.data[key] = entries
+		m.stale[key] = struct{}{}
+	}
+}
+
 // Has checks if a key exists.
 func (m *MemoryDB) Has(key []byte) (bool, error) {
-	_, ok := m.data[string(key)]
-	return ok, nil
+	_, err := m.Get(key)
+	return err == nil, nil
 }
 
 // ForEach iterates over all keys with the given prefix.
 func (m *MemoryDB) ForEach(prefix []byte, fn func(key, value []byte) error) error {
-	p := string(prefix)
-	for k, v := range m.data {
-		if strings.HasPrefix(k, p) {
-			if err := fn([]byte(k), v); err != nil {
-				return err
-			}
+	return m.forEachAt(prefix, math.MaxUint64, fn)
+}
+
+func (m *MemoryDB) forEachAt(prefix []byte, at uint64, fn func(key, value []byte) error) error {
+	view := m.viewAt(IterOptions{Prefix: prefix}, at)
+	for k, v := range view {
+		if err := fn([]byte(k), v); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// viewAt returns the keys within opts' bounds and their values at a
+// version. Callbacks run on the copy, so they may write to the DB.
+func (m *MemoryDB) viewAt(opts IterOptions, at uint64) map[string][]byte {
+	lower, upper := iterBounds(opts)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	view := make(map[string][]byte)
+	for k, entries := range m.data {
+		if k < string(lower) || (upper != nil && k >= string(upper)) {
+			continue
+		}
+		if v := latest(entries, at); v != nil {
+			view[k] = v
+		}
+	}
+	return view
+}
+
+// NewIterator returns an iterator over a sorted copy of the keys within
+// the requested bounds.
+func (m *MemoryDB) NewIterator(opts IterOptions) Iterator {
+	return m.iteratorAt(opts, math.MaxUint64)
+}
+
+func (m *MemoryDB) iteratorAt(opts IterOptions, at uint64) Iterator {
+	return newBoundedIterator(newSliceCursor(m.viewAt(opts, at), opts), opts)
+}
+
 // Close closes the database.
 func (m *MemoryDB) Close() error {
 	return nil
@@ -90,12 +198,54 @@ func (mb *memoryBatch) Delete(key []byte) error {
 }
 
 func (mb *memoryBatch) Commit() error {
+	mb.db.mu.Lock()
+	defer mb.db.mu.Unlock()
 	for _, op := range mb.ops {
-		if op.value == nil {
-			delete(mb.db.data, op.key)
-		} else {
-			mb.db.data[op.key] = op.value
-		}
+		mb.db.set(op.key, op.value)
 	}
 	return nil
 }
+
+// NewSnapshot pins the current version. Later writes are versioned
+// until the snapshot is released.
+func (m *MemoryDB) NewSnapshot() Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	at := m.version
+	m.version++
+	m.snaps[at]++
+	return &memorySnapshot{db: m, at: at}
+}
+
+type memorySnapshot struct {
+	db       *MemoryDB
+	at       uint64
+	released bool
+}
+
+func (ms *memorySnapshot) Get(key []byte) ([]byte, error) { return ms.db.getAt(key, ms.at) }
+func (ms *memorySnapshot) Has(key []byte) (bool, error) {
+	_, err := ms.db.getAt(key, ms.at)
+	return err == nil, nil
+}
+func (ms *memorySnapshot) ForEach(prefix []byte, fn func(key, value []byte) error) error {
+	return ms.db.forEachAt(prefix, ms.at, fn)
+}
+func (ms *memorySnapshot) NewIterator(opts IterOptions) Iterator {
+	return ms.db.iteratorAt(opts, ms.at)
+}
+func (ms *memorySnapshot) Release() {
+	ms.db.mu.Lock()
+	defer ms.db.mu.Unlock()
+	if ms.released {
+		return
+	}
+	ms.released = true
+	if ms.db.snaps[ms.at]--; ms.db.snaps[ms.at] > 0 {
+		return
+	}
+	delete(ms.db.snaps, ms.at)
+	for key := range ms.db.stale {
+		ms.db.compact(key)
+	}
+}
@@ -0,0 +1,291 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/cockroachdb/pebble/v2"
+	"github.com/cockroachdb/pebble/v2/vfs"
+)
+
+// PebbleDB implements DB using Pebble, a pure-Go LSM engine. Unlike
+// Badger it keeps values inline in the LSM, so there is no separate value
+// log to garbage-collect on long-running nodes.
+type PebbleDB struct {
+	db   *pebble.DB
+	lock *pebble.Lock
+}
+
+// NewPebble creates a new Pebble database in the given directory.
+func NewPebble(path string) (*PebbleDB, error) {
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return nil, fmt.Errorf("open database at %s: %w", path, err)
+	}
+	// Take the directory lock before Open so a held lock is told apart
+	// from other open failures. Creating the lock file fails with a
+	// *fs.PathError; anything else is the lock being held.
+	lock, err := pebble.LockDirectory(path, vfs.Default)
+	if err != nil {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			return nil, fmt.Errorf("open database at %s: %w", path, err)
+		}
+		return nil, fmt.Errorf("database at %s is locked by another process (is another klingnetd instance running?): %w", path, err)
+	}
+
+	opts := &pebble.Options{
+		Logger: quietPebbleLogger{}, // Disable pebble's built-in logging.
+		Lock:   lock,
+	}
+	db, err := pebble.Open(path, opts)
+	if err != nil {
+		lock.Close()
+		return nil, fmt.Errorf("open database at %s: %w", path, err)
+	}
+	return &PebbleDB{db: db, lock: lock}, nil
+}
+
+// pebbleReader is the read surface shared by *pebble.DB and *pebble.Snapshot.
+type pebbleReader interface {
+	Get(key []byte) ([]byte, io.Closer, error)
+	NewIter(o *pebble.IterOptions) (*pebble.Iterator, error)
+}
+
+func pebbleGet(r pebbleReader, key []byte) ([]byte, error) {
+	val, closer, err := r.Get(key)
+	if errors.Is(err, pebble.ErrNotFound) {
+		return nil, fmt.Errorf("key not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("pebble get: %w", err)
+	}
+	defer closer.Close()
+	out := make([]byte, len(val))
+	copy(out, val)
+	return out, nil
+}
+
+func pebbleHas(r pebbleReader, key []byte) (bool, error) {
+	_, closer, err := r.Get(key)
+	if errors.Is(err, pebble.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("pebble has: %w", err)
+	}
+	closer.Close()
+	return true, nil
+}
+
+// pebbleForEach iterates over all keys with the given prefix in r.
+func pebbleForEach(r pebbleReader, prefix []byte, fn func(key, value []byte) error) error {
+	it, err := r.NewIter(&pebble.IterOptions{
+		LowerBound: prefix,
+		UpperBound: prefixUpperBound(prefix),
+	})
+	if err != nil {
+		return fmt.Errorf("pebble iterator: %w", err)
+	}
+	defer it.Close()
+
+	for it.First(); it.Valid(); it.Next() {
+		key := make([]byte, len(it.Key()))
+		copy(key, it.Key())
+		v, err := it.ValueAndErr()
+		if err != nil {
+			return fmt.Errorf("pebble value: %w", err)
+		}
+		val := make([]byte, len(v))
+		copy(val, v)
+		if err := fn(key, val); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// pebbleCursor drives a Pebble iterator. Bounds are pushed down to Pebble
+// so it can skip whole tables outside the range.
+type pebbleCursor struct {
+	it      *pebble.Iterator
+	reverse bool
+	ok      bool
+}
+
+// newPebbleIterator opens an Iterator over r. Creation errors surface
+// through Err on the returned iterator.
+func newPebbleIterator(r pebbleReader, opts IterOptions) Iterator {
+	lower, upper := iterBounds(opts)
+	it, err := r.NewIter(&pebble.IterOptions{LowerBound: lower, UpperBound: upper})
+	if err != nil {
+		bi := newBoundedIterator(&sliceCursor{pos: -1}, opts)
+		bi.err = fmt.Errorf("pebble iterator: %w", err)
+		return bi
+	}
+	return newBoundedIterator(&pebbleCursor{it: it, reverse: opts.Reverse}, opts)
+}
+
+func (c *pebbleCursor) first() {
+	if c.reverse {
+		c.ok = c.it.Last()
+	} else {
+		c.ok = c.it.First()
+	}
+}
+
+func (c *pebbleCursor) seek(key []byte) {
+	if !c.reverse {
+		c.ok = c.it.SeekGE(key)
+		return
+	}
+	// Highest key <= key is the highest key < key+0x00.
+	c.ok = c.it.SeekLT(append(append([]byte{}, key...), 0))
+}
+
+func (c *pebbleCursor) next() {
+	if c.reverse {
+		c.ok = c.it.Prev()
+	} else {
+		c.ok = c.it.Next()
+	}
+}
+
+func (c *pebbleCursor) valid() bool { return c.ok }
+func (c *pebbleCursor) key() []byte { return c.it.Key() }
+
+func (c *pebbleCursor) value() ([]byte, error) {
+	v, err := c.it.ValueAndErr()
+	if err != nil {
+		return nil, fmt.Errorf("pebble value: %w", err)
+	}
+	out := make([]byte, len(v))
+	copy(out, v)
+	return out, nil
+}
+
+func (c *pebbleCursor) close() { c.it.Close() }
+
+// prefixUpperBound returns the smallest key greater than every key with the
+// given prefix, or nil if no such key exists (empty or all-0xff prefix).
+func prefixUpperBound(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xff {
+			end := make([]byte, i+1)
+			copy(end, prefix)
+			end[i]++
+			return end
+		}
+	}
+	return nil
+}
+
+// Get retrieves a value by key. Returns an error if the key does not exist.
+func (p *PebbleDB) Get(key []byte) ([]byte, error) {
+	return pebbleGet(p.db, key)
+}
+
+// Put stores a key-value pair. Like Badger, single writes are not synced;
+// batch commits are.
+func (p *PebbleDB) Put(key, value []byte) error {
+	if err := p.db.Set(key, value, pebble.NoSync); err != nil {
+		return fmt.Errorf("pebble put: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a key.
+func (p *PebbleDB) Delete(key []byte) error {
+	if err := p.db.Delete(key, pebble.NoSync); err != nil {
+		return fmt.Errorf("pebble delete: %w", err)
+	}
+	return nil
+}
+
+// Has checks if a key exists.
+func (p *PebbleDB) Has(key []byte) (bool, error) {
+	return pebbleHas(p.db, key)
+}
+
+// ForEach iterates over all keys with the given prefix.
+func (p *PebbleDB) ForEach(prefix []byte, fn func(key, value []byte) error) error {
+	return pebbleForEach(p.db, prefix, fn)
+}
+
+// NewIterator returns an iterator over the live database.
+func (p *PebbleDB) NewIterator(opts IterOptions) Iterator {
+	return newPebbleIterator(p.db, opts)
+}
+
+// Close flushes the WAL and closes the database.
+func (p *PebbleDB) Close() error {
+	defer p.lock.Close()
+	if err := p.db.Flush(); err != nil {
+		p.db.Close()
+		return fmt.Errorf("pebble flush: %w", err)
+	}
+	return p.db.Close()
+}
+
+// NewBatch creates an atomic write batch. Batches are synced to the WAL on
+// commit.
+func (p *PebbleDB) NewBatch() Batch {
+	return &pebbleBatch{b: p.db.NewBatch()}
+}
+
+type pebbleBatch struct {
+	b *pebble.Batch
+}
+
+func (pb *pebbleBatch) Put(key, value []byte) error {
+	return pb.b.Set(key, value, nil)
+}
+
+func (pb *pebbleBatch) Delete(key []byte) error {
+	return pb.b.Delete(key, nil)
+}
+
+func (pb *pebbleBatch) Commit() error {
+	defer pb.b.Close()
+	return pb.b.Commit(pebble.Sync)
+}
+
+// NewSnapshot returns a Pebble snapshot pinning the current sequence number.
+func (p *PebbleDB) NewSnapshot() Snapshot {
+	return &pebbleSnapshot{snap: p.db.NewSnapshot()}
+}
+
+type pebbleSnapshot struct {
+	snap *pebble.Snapshot
+}
+
+func (ps *pebbleSnapshot) Get(key []byte) ([]byte, error) {
+	return pebbleGet(ps.snap, key)
+}
+
+func (ps *pebbleSnapshot) Has(key []byte) (bool, error) {
+	return pebbleHas(ps.snap, key)
+}
+
+func (ps *pebbleSnapshot) ForEach(prefix []byte, fn func(key, value []byte) error) error {
+	return pebbleForEach(ps.snap, prefix, fn)
+}
+
+func (ps *pebbleSnapshot) NewIterator(opts IterOptions) Iterator {
+	return newPebbleIterator(ps.snap, opts)
+}
+
+func (ps *pebbleSnapshot) Release() {
+	ps.snap.Close()
+}
+
+// quietPebbleLogger drops informational output but keeps fatal errors fatal.
+type quietPebbleLogger struct{}
+
+func (quietPebbleLogger) Infof(string, ...interface{})  {}
+func (quietPebbleLogger) Errorf(string, ...interface{}) {}
+func (quietPebbleLogger) Fatalf(format string, args ...interface{}) {
+	pebble.DefaultLogger.Fatalf(format, args...)
+}
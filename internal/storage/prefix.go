@@ -54,6 +54,55 @@ func (p *PrefixDB) ForEach(prefix []byte, fn func(key, value []byte) error) erro
 	})
 }
 
+// NewIterator iterates this PrefixDB's namespace. Bounds, Seek targets and
+// returned keys are all in the logical (unprefixed) keyspace.
+func (p *PrefixDB) NewIterator(opts IterOptions) Iterator {
+	return newPrefixIterator(p.inner, p.prefix, opts)
+}
+
+// prefixIterator strips the namespace prefix from an inner iterator.
+type prefixIterator struct {
+	Iterator
+	prefix []byte
+}
+
+func newPrefixIterator(inner Reader, prefix []byte, opts IterOptions) *prefixIterator {
+	scoped := func(k []byte) []byte {
+		if k == nil {
+			return nil
+		}
+		out := make([]byte, len(prefix)+len(k))
+		copy(out, prefix)
+		copy(out[len(prefix):], k)
+		return out
+	}
+	innerOpts := IterOptions{
+		Prefix:  scoped(opts.Prefix),
+		Start:   scoped(opts.Start),
+		End:     scoped(opts.End),
+		Reverse: opts.Reverse,
+	}
+	if innerOpts.Prefix == nil {
+		innerOpts.Prefix = prefix
+	}
+	return &prefixIterator{Iterator: inner.NewIterator(innerOpts), prefix: prefix}
+}
+
+func (pi *prefixIterator) Seek(key []byte) {
+	k := make([]byte, len(pi.prefix)+len(key))
+	copy(k, pi.prefix)
+	copy(k[len(pi.prefix):], key)
+	pi.Iterator.Seek(k)
+}
+
+func (pi *prefixIterator) Key() []byte {
+	k := pi.Iterator.Key()
+	if k == nil {
+		return nil
+	}
+	return k[len(pi.prefix):]
+}
+
 // DeleteAll removes all keys under this PrefixDB's namespace from the inner DB.
 func (p *PrefixDB) DeleteAll() error {
 	// Collect all keys first to avoid modifying during iteration.
@@ -80,6 +129,46 @@ func (p *PrefixDB) Close() error {
 	return nil
 }
 
+// NewSnapshot takes a snapshot of the inner DB and scopes it to this
+// PrefixDB's namespace. Inner DBs without snapshot support yield a live view.
+func (p *PrefixDB) NewSnapshot() Snapshot {
+	return &prefixSnapshot{inner: NewSnapshot(p.inner), prefix: p.prefix}
+}
+
+type prefixSnapshot struct {
+	inner  Snapshot
+	prefix []byte
+}
+
+func (ps *prefixSnapshot) prefixed(key []byte) []byte {
+	out := make([]byte, len(ps.prefix)+len(key))
+	copy(out, ps.prefix)
+	copy(out[len(ps.prefix):], key)
+	return out
+}
+
+func (ps *prefixSnapshot) Get(key []byte) ([]byte, error) {
+	return ps.inner.Get(ps.prefixed(key))
+}
+
+func (ps *prefixSnapshot) Has(key []byte) (bool, error) {
+	return ps.inner.Has(ps.prefixed(key))
+}
+
+func (ps *prefixSnapshot) ForEach(prefix []byte, fn func(key, value []byte) error) error {
+	return ps.inner.ForEach(ps.prefixed(prefix), func(key, value []byte) error {
+		return fn(key[len(ps.prefix):], value)
+	})
+}
+
+func (ps *prefixSnapshot) NewIterator(opts IterOptions) Iterator {
+	return newPrefixIterator(ps.inner, ps.prefix, opts)
+}
+
+func (ps *prefixSnapshot) Release() {
+	ps.inner.Release()
+}
+
 // NewBatch creates a batch that prepends the prefix to all keys, delegating
 // to the inner DB's batch for atomic commits.
 func (p *PrefixDB) NewBatch() Batch {
@@ -0,0 +1,99 @@
+package storage
+
+import "errors"
+
+// ErrReadOnly is returned by write operations on a read-only DB.
+var ErrReadOnly = errors.New("database is read-only")
+
+// Reader is the read-only subset of DB.
+type Reader interface {
+	Get(key []byte) ([]byte, error)
+	Has(key []byte) (bool, error)
+	ForEach(prefix []byte, fn func(key, value []byte) error) error
+	NewIterator(opts IterOptions) Iterator
+}
+
+// Snapshot is a consistent point-in-time read view of a DB.
+// Writes committed after the snapshot was taken are not visible through it.
+// Release must be called exactly once when the snapshot is no longer needed.
+type Snapshot interface {
+	Reader
+	Release()
+}
+
+// Snapshotter is implemented by DBs that support consistent read snapshots.
+type Snapshotter interface {
+	NewSnapshot() Snapshot
+}
+
+// NewSnapshot returns a snapshot of db. DBs that do not implement
+// Snapshotter get a live view that reads through to db directly, so
+// callers always receive a usable Snapshot.
+func NewSnapshot(db DB) Snapshot {
+	if s, ok := db.(Snapshotter); ok {
+		return s.NewSnapshot()
+	}
+	return liveSnapshot{db: db}
+}
+
+// liveSnapshot forwards reads to the underlying DB without isolation.
+type liveSnapshot struct {
+	db DB
+}
+
+func (l liveSnapshot) Get(key []byte) ([]byte, error) { return l.db.Get(key) }
+func (l liveSnapshot) Has(key []byte) (bool, error)   { return l.db.Has(key) }
+func (l liveSnapshot) ForEach(prefix []byte, fn func(key, value []byte) error) error {
+	return l.db.ForEach(prefix, fn)
+}
+func (l liveSnapshot) NewIterator(opts IterOptions) Iterator {
+	return l.db.NewIterator(opts)
+}
+func (l liveSnapshot) Release() {}
+
+// ReadOnlyDB adapts a Reader to the DB interface. All writes fail with
+// ErrReadOnly and Close is a no-op. This lets stores written against DB
+// (utxo.Store, chain.BlockStore) serve reads from a Snapshot.
+type ReadOnlyDB struct {
+	r Reader
+}
+
+// NewReadOnly wraps r as a read-only DB.
+func NewReadOnly(r Reader) *ReadOnlyDB {
+	return &ReadOnlyDB{r: r}
+}
+
+// Get retrieves a value by key.
+func (ro *ReadOnlyDB) Get(key []byte) ([]byte, error) {
+	return ro.r.Get(key)
+}
+
+// Has checks if a key exists.
+func (ro *ReadOnlyDB) Has(key []byte) (bool, error) {
+	return ro.r.Has(key)
+}
+
+// ForEach iterates over all keys with the given prefix.
+func (ro *ReadOnlyDB) ForEach(prefix []byte, fn func(key, value []byte) error) error {
+	return ro.r.ForEach(prefix, fn)
+}
+
+// NewIterator returns an iterator over the wrapped reader.
+func (ro *ReadOnlyDB) NewIterator(opts IterOptions) Iterator {
+	return ro.r.NewIterator(opts)
+}
+
+// Put always fails with ErrReadOnly.
+func (ro *ReadOnlyDB) Put(_, _ []byte) error {
+	return ErrReadOnly
+}
+
+// Delete always fails with ErrReadOnly.
+func (ro *ReadOnlyDB) Delete(_ []byte) error {
+	return ErrReadOnly
+}
+
+// Close is a no-op — the snapshot owner manages its lifecycle.
+func (ro *ReadOnlyDB) Close() error {
+	return nil
+}
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// testSnapshot verifies that a snapshot is isolated from later writes.
+func testSnapshot(t *testing.T, db DB) {
+	t.Helper()
+
+	db.Put([]byte("snap/a"), []byte("1"))
+	db.Put([]byte("snap/b"), []byte("2"))
+
+	snap := NewSnapshot(db)
+	defer snap.Release()
+
+	// Mutate after the snapshot was taken.
+	db.Put([]byte("snap/a"), []byte("changed"))
+	db.Put([]byte("snap/c"), []byte("3"))
+	db.Delete([]byte("snap/b"))
+
+	val, err := snap.Get([]byte("snap/a"))
+	if err != nil {
+		t.Fatalf("snapshot Get() error: %v", err)
+	}
+	if !bytes.Equal(val, []byte("1")) {
+		t.Errorf("snapshot Get(snap/a) = %q, want %q", val, "1")
+	}
+
+	ok, err := snap.Has([]byte("snap/b"))
+	if err != nil {
+		t.Fatalf("snapshot Has() error: %v", err)
+	}
+	if !ok {
+		t.Error("snapshot Has(snap/b) = false, deleted after snapshot")
+	}
+	if ok, _ := snap.Has([]byte("snap/c")); ok {
+		t.Error("snapshot Has(snap/c) = true, written after snapshot")
+	}
+
+	var keys []string
+	if err := snap.ForEach([]byte("snap/"), func(key, _ []byte) error {
+		keys = append(keys, string(key))
+		return nil
+	}); err != nil {
+		t.Fatalf("snapshot ForEach() error: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("snapshot ForEach count = %d, want 2 (%v)", len(keys), keys)
+	}
+
+	// The live DB sees the new state.
+	val, _ = db.Get([]byte("snap/a"))
+	if !bytes.Equal(val, []byte("changed")) {
+		t.Errorf("live Get(snap/a) = %q, want %q", val, "changed")
+	}
+}
+
+func TestMemoryDB_Snapshot(t *testing.T) {
+	testSnapshot(t, NewMemory())
+}
+
+func TestMemoryDB_SnapshotVersions(t *testing.T) {
+	db := NewMemory()
+	db.Put([]byte("a"), []byte("1"))
+	db.Put([]byte("b"), []byte("1"))
+
+	s1 := db.NewSnapshot()
+	db.Put([]byte("a"), []byte("2"))
+	s2 := db.NewSnapshot()
+	db.Put([]byte("a"), []byte("3"))
+	db.Delete([]byte("b"))
+	db.Put([]byte("c"), []byte("1"))
+	db.Delete([]byte("c"))
+
+	for _, tt := range []struct {
+		snap Snapshot
+		want string
+	}{{s1, "1"}, {s2, "2"}} {
+		if v, _ := tt.snap.Get([]byte("a")); string(v) != tt.want {
+			t.Errorf("snapshot Get(a) = %q, want %q", v, tt.want)
+		}
+		if ok, _ := tt.snap.Has([]byte("b")); !ok {
+			t.Error("snapshot lost b deleted after it")
+		}
+	}
+	if _, ok := db.data["c"]; ok {
+		t.Error("key written and deleted after the snapshots is still stored")
+	}
+
+	// Once the snapshots are released, only the latest values remain.
+	s1.Release()
+	if v, _ := s2.Get([]byte("a")); string(v) != "2" {
+		t.Errorf("s2 Get(a) after releasing s1 = %q, want 2", v)
+	}
+	s2.Release()
+	s2.Release() // Idempotent.
+	if len(db.data) != 1 || len(db.data["a"]) != 1 || len(db.stale) != 0 {
+		t.Errorf("versions kept after release: %v", db.data)
+	}
+	if v, _ := db.Get([]byte("a")); string(v) != "3" {
+		t.Errorf("Get(a) = %q, want 3", v)
+	}
+}
+
+func TestBadgerDB_Snapshot(t *testing.T) {
+	db, err := NewBadger(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewBadger() error: %v", err)
+	}
+	defer db.Close()
+	testSnapshot(t, db)
+}
+
+func TestPebbleDB_Snapshot(t *testing.T) {
+	db, err := NewPebble(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewPebble() error: %v", err)
+	}
+	defer db.Close()
+	testSnapshot(t, db)
+}
+
+func TestPrefixDB_Snapshot(t *testing.T) {
+	inner := NewMemory()
+	pdb := NewPrefixDB(inner, []byte("sc/1/"))
+	testSnapshot(t, pdb)
+
+	// Keys outside the namespace must not leak into the snapshot.
+	inner.Put([]byte("other/snap/x"), []byte("x"))
+	snap := pdb.NewSnapshot()
+	defer snap.Release()
+	if ok, _ := snap.Has([]byte("other/snap/x")); ok {
+		t.Error("prefix snapshot sees key outside its namespace")
+	}
+}
+
+func TestReadOnlyDB(t *testing.T) {
+	db := NewMemory()
+	db.Put([]byte("k"), []byte("v"))
+
+	snap := NewSnapshot(db)
+	defer snap.Release()
+	ro := NewReadOnly(snap)
+
+	if val, err := ro.Get([]byte("k")); err != nil || !bytes.Equal(val, []byte("v")) {
+		t.Errorf("Get() = %q, %v; want %q", val, err, "v")
+	}
+	if err := ro.Put([]byte("k"), []byte("x")); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Put() error = %v, want ErrReadOnly", err)
+	}
+	if err := ro.Delete([]byte("k")); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Delete() error = %v, want ErrReadOnly", err)
+	}
+}
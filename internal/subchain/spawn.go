@@ -41,8 +41,7 @@ func Spawn(cfg SpawnConfig) (*SpawnResult, error) {
 	}
 
 	// Create isolated storage via PrefixDB.
-	prefix := []byte("sc/" + hex.EncodeToString(cfg.ChainID[:]) + "/")
-	db := storage.NewPrefixDB(cfg.ParentDB, prefix)
+	db := NamespaceDB(cfg.ParentDB, cfg.ChainID)
 
 	// Build genesis config from registration data.
 	gen := buildGenesis(cfg.ChainID, cfg.Registration, cfg.CreatedAtHeight)
@@ -69,6 +68,7 @@ func Spawn(cfg SpawnConfig) (*SpawnResult, error) {
 	}
 	ch.SetConsensusRules(gen.Protocol.Consensus)
 	ch.SetTokenRules(gen.Protocol.Token)
+	ch.SetForkSchedule(gen.Protocol.Forks)
 	ch.SetRegistrationValidator(NewRegistrationValidator(&gen.Protocol.SubChain))
 
 	// Initialize from genesis if this is a fresh chain.
@@ -83,6 +83,9 @@ func Spawn(cfg SpawnConfig) (*SpawnResult, error) {
 	adapter := miner.NewUTXOAdapter(utxoStore)
 	pool := mempool.New(adapter, 0)
 	pool.SetMinFeeRate(cfg.Registration.MinFeeRate)
+	if cfg.Registration.MinFeeRate > 0 {
+		pool.SetIncrementalFeeRate(cfg.Registration.MinFeeRate)
+	}
 	pool.SetCoinbaseMaturity(config.CoinbaseMaturity, ch.Height, utxoStore)
 	pool.SetMintingAllowed(gen.Protocol.Token.AllowMinting)
 	if cfg.Registration.ValidatorStake > 0 {
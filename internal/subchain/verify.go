@@ -0,0 +1,96 @@
+package subchain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Klingon-tech/klingnet-chain/internal/chain"
+	"github.com/Klingon-tech/klingnet-chain/internal/storage"
+	"github.com/Klingon-tech/klingnet-chain/internal/utxo"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// Issue kinds reported by VerifyPrefixes, in addition to chain's.
+const (
+	IssuePrefix        = "subchain_prefix" // Namespace is not "sc/<64 hex chars>/".
+	IssueOrphan        = "subchain_orphan" // Namespace with no registry entry.
+	IssueRegistry      = "registry"        // Registry entry corrupt or with the wrong ID.
+	IssueRegistryStale = "registry_stale"  // Registration output not in the root UTXO set.
+)
+
+// VerifyPrefixes checks the sub-chain namespaces in the root database
+// against the persisted registry, and each registry entry against the
+// root chain's UTXO set. It returns the IDs of the well-formed namespaces,
+// sorted, for auditing with chain.Verify. None of the issues are repaired
+// automatically: dropping sub-chain data or registrations needs an operator.
+func VerifyPrefixes(root storage.DB) ([]types.ChainID, []chain.VerifyIssue, error) {
+	issues := []chain.VerifyIssue{}
+
+	registered := make(map[types.ChainID]bool)
+	utxos := utxo.NewStore(root)
+	err := root.ForEach(prefixRegistry, func(key, value []byte) error {
+		var sc SubChain
+		if err := json.Unmarshal(value, &sc); err != nil {
+			issues = append(issues, chain.VerifyIssue{Kind: IssueRegistry, Key: hex.EncodeToString(key),
+				Detail: fmt.Sprintf("corrupt registry entry: %v", err)})
+			return nil
+		}
+		if want := DeriveChainID(sc.RegistrationTx, sc.OutputIndex); sc.ID != want || string(key) != string(registryKey(sc.ID)) {
+			issues = append(issues, chain.VerifyIssue{Kind: IssueRegistry, Key: hex.EncodeToString(key),
+				Detail: fmt.Sprintf("registry entry for %s does not match registration %s:%d (want %s)",
+					sc.ID, sc.RegistrationTx, sc.OutputIndex, want)})
+			return nil
+		}
+		registered[sc.ID] = true
+		u, err := utxos.Get(types.Outpoint{TxID: sc.RegistrationTx, Index: sc.OutputIndex})
+		if err != nil || u.Script.Type != types.ScriptTypeRegister {
+			issues = append(issues, chain.VerifyIssue{Kind: IssueRegistryStale, Key: hex.EncodeToString(key),
+				Detail: fmt.Sprintf("sub-chain %s: registration output %s:%d is not in the root UTXO set",
+					sc.ID, sc.RegistrationTx, sc.OutputIndex)})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("scan registry: %w", err)
+	}
+
+	counts, err := storage.CountKeys(root)
+	if err != nil {
+		return nil, nil, fmt.Errorf("scan namespaces: %w", err)
+	}
+	var ids []types.ChainID
+	for ns, n := range counts {
+		if ns == "" {
+			continue
+		}
+		idHex := strings.TrimSuffix(strings.TrimPrefix(ns, "sc/"), "/")
+		raw, err := hex.DecodeString(idHex)
+		if err != nil || len(raw) != types.HashSize {
+			issues = append(issues, chain.VerifyIssue{Kind: IssuePrefix, Key: hex.EncodeToString([]byte(ns)),
+				Detail: fmt.Sprintf("malformed sub-chain namespace %q (%d keys)", ns, n)})
+			continue
+		}
+		var id types.ChainID
+		copy(id[:], raw)
+		if !registered[id] {
+			issues = append(issues, chain.VerifyIssue{Kind: IssueOrphan, Key: hex.EncodeToString([]byte(ns)),
+				Detail: fmt.Sprintf("sub-chain %s has %d keys but no registry entry", id, n)})
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return string(ids[i][:]) < string(ids[j][:]) })
+	return ids, issues, nil
+}
+
+// NamespaceDB returns the PrefixDB a sub-chain keeps its data in.
+func NamespaceDB(root storage.DB, id types.ChainID) storage.DB {
+	return storage.NewPrefixDB(root, namespacePrefix(id))
+}
+
+// namespacePrefix returns the "sc/<chainid>/" key prefix for a sub-chain.
+func namespacePrefix(id types.ChainID) []byte {
+	return []byte("sc/" + hex.EncodeToString(id[:]) + "/")
+}
@@ -0,0 +1,69 @@
+package subchain
+
+import (
+	"testing"
+
+	"github.com/Klingon-tech/klingnet-chain/internal/chain"
+	"github.com/Klingon-tech/klingnet-chain/internal/storage"
+	"github.com/Klingon-tech/klingnet-chain/internal/utxo"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+func TestVerifyPrefixes(t *testing.T) {
+	root := storage.NewMemory()
+	reg := NewRegistry()
+
+	// A spawned sub-chain whose registration output is unspent.
+	liveTx := types.Hash{1}
+	liveID := DeriveChainID(liveTx, 0)
+	reg.Register(&SubChain{ID: liveID, RegistrationTx: liveTx, Registration: *validPoARegistration()})
+	utxo.NewStore(root).Put(&utxo.UTXO{
+		Outpoint: types.Outpoint{TxID: liveTx},
+		Script:   types.Script{Type: types.ScriptTypeRegister},
+	})
+	if _, err := Spawn(SpawnConfig{ChainID: liveID, Registration: validPoARegistration(), ParentDB: root}); err != nil {
+		t.Fatalf("Spawn: %v", err)
+	}
+
+	// A registered chain whose registration output is gone.
+	staleTx := types.Hash{2}
+	reg.Register(&SubChain{ID: DeriveChainID(staleTx, 0), RegistrationTx: staleTx})
+	if err := reg.SaveTo(root); err != nil {
+		t.Fatalf("SaveTo: %v", err)
+	}
+
+	// Namespaces with no registry entry, or that are not chain IDs at all.
+	orphanID := DeriveChainID(types.Hash{3}, 0)
+	NamespaceDB(root, orphanID).Put([]byte("s/tip"), []byte{1})
+	root.Put([]byte("sc/zz/s/tip"), []byte{1})
+
+	ids, issues, err := VerifyPrefixes(root)
+	if err != nil {
+		t.Fatalf("VerifyPrefixes: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Errorf("ids = %v, want the live and orphan chains", ids)
+	}
+	kinds := make(map[string]int)
+	for _, is := range issues {
+		kinds[is.Kind]++
+	}
+	want := map[string]int{IssuePrefix: 1, IssueOrphan: 1, IssueRegistryStale: 1}
+	for k, n := range want {
+		if kinds[k] != n {
+			t.Errorf("%s issues = %d, want %d (all: %+v)", k, kinds[k], n, issues)
+		}
+	}
+	if len(issues) != 3 {
+		t.Errorf("issues = %+v, want 3", issues)
+	}
+
+	// The spawned chain's own namespace verifies clean.
+	report, err := chain.Verify(NamespaceDB(root, liveID), false)
+	if err != nil {
+		t.Fatalf("chain.Verify: %v", err)
+	}
+	if len(report.Issues) != 0 {
+		t.Errorf("sub-chain issues: %+v", report.Issues)
+	}
+}
@@ -2,9 +2,9 @@ package token
 
 import (
 	"bytes"
-	"fmt"
 	"testing"
 
+	"github.com/Klingon-tech/klingnet-chain/internal/storage"
 	"github.com/Klingon-tech/klingnet-chain/pkg/block"
 	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
 	"github.com/Klingon-tech/klingnet-chain/pkg/types"
@@ -289,50 +289,7 @@ func TestExtractAndStoreMetadata_NilInputs(t *testing.T) {
 	ExtractAndStoreMetadata(store, &block.Block{Transactions: []*tx.Transaction{nil}})
 }
 
-// newMemDB returns a simple in-memory DB for testing.
-func newMemDB() *memDB {
-	return &memDB{data: make(map[string][]byte)}
-}
-
-type memDB struct {
-	data map[string][]byte
-}
-
-func (m *memDB) Get(key []byte) ([]byte, error) {
-	v, ok := m.data[string(key)]
-	if !ok {
-		return nil, fmt.Errorf("not found")
-	}
-	cp := make([]byte, len(v))
-	copy(cp, v)
-	return cp, nil
-}
-
-func (m *memDB) Put(key, value []byte) error {
-	m.data[string(key)] = append([]byte(nil), value...)
-	return nil
-}
-
-func (m *memDB) Delete(key []byte) error {
-	delete(m.data, string(key))
-	return nil
-}
-
-func (m *memDB) Has(key []byte) (bool, error) {
-	_, ok := m.data[string(key)]
-	return ok, nil
-}
-
-func (m *memDB) Close() error { return nil }
-
-func (m *memDB) ForEach(prefix []byte, fn func(key, value []byte) error) error {
-	pfx := string(prefix)
-	for k, v := range m.data {
-		if len(k) >= len(pfx) && k[:len(pfx)] == pfx {
-			if err := fn([]byte(k), v); err != nil {
-				return err
-			}
-		}
-	}
-	return nil
+// newMemDB returns an in-memory DB for testing.
+func newMemDB() *storage.MemoryDB {
+	return storage.NewMemory()
 }
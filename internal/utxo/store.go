@@ -89,24 +89,29 @@ func scriptAddress(s types.Script) (types.Address, bool) {
 
 // Put stores a UTXO and updates the address index.
 func (s *Store) Put(u *UTXO) error {
+	return putUTXO(u, s.db.Put)
+}
+
+// putUTXO writes a UTXO and its secondary index entries with put.
+func putUTXO(u *UTXO, put func(key, value []byte) error) error {
 	data, err := json.Marshal(u)
 	if err != nil {
 		return fmt.Errorf("utxo marshal: %w", err)
 	}
-	if err := s.db.Put(utxoKey(u.Outpoint), data); err != nil {
+	if err := put(utxoKey(u.Outpoint), data); err != nil {
 		return fmt.Errorf("utxo put: %w", err)
 	}
 
 	// Index by address for script types that contain one.
 	if addr, ok := scriptAddress(u.Script); ok {
-		if err := s.db.Put(addrKey(addr, u.Outpoint), []byte{}); err != nil {
+		if err := put(addrKey(addr, u.Outpoint), []byte{}); err != nil {
 			return fmt.Errorf("utxo index put: %w", err)
 		}
 	}
 
 	// Index by validator pubkey if it's a stake script.
 	if u.Script.Type == types.ScriptTypeStake && len(u.Script.Data) == compressedPubKeySize {
-		if err := s.db.Put(stakeKey(u.Script.Data, u.Outpoint), []byte{}); err != nil {
+		if err := put(stakeKey(u.Script.Data, u.Outpoint), []byte{}); err != nil {
 			return fmt.Errorf("stake index put: %w", err)
 		}
 	}
@@ -116,23 +121,65 @@ func (s *Store) Put(u *UTXO) error {
 
 // Delete removes a UTXO and its address index entry.
 func (s *Store) Delete(outpoint types.Outpoint) error {
+	return s.remove(outpoint, s.db.Delete)
+}
+
+// remove deletes a stored UTXO and its secondary index entries with del.
+func (s *Store) remove(outpoint types.Outpoint, del func(key []byte) error) error {
 	// Read first to clean up secondary indexes.
 	u, err := s.Get(outpoint)
 	if err == nil {
 		if addr, ok := scriptAddress(u.Script); ok {
-			s.db.Delete(addrKey(addr, u.Outpoint))
+			del(addrKey(addr, u.Outpoint))
 		}
 		if u.Script.Type == types.ScriptTypeStake && len(u.Script.Data) == compressedPubKeySize {
-			s.db.Delete(stakeKey(u.Script.Data, u.Outpoint))
+			del(stakeKey(u.Script.Data, u.Outpoint))
 		}
 	}
 
-	if err := s.db.Delete(utxoKey(outpoint)); err != nil {
+	if err := del(utxoKey(outpoint)); err != nil {
 		return fmt.Errorf("utxo delete: %w", err)
 	}
 	return nil
 }
 
+// Apply deletes the spent UTXOs and stores the created ones. When the
+// database supports batches all writes go through one, so applying a
+// block costs a single commit instead of one per key. spent must only
+// name stored UTXOs, not ones in created.
+func (s *Store) Apply(spent []types.Outpoint, created []*UTXO) error {
+	batcher, ok := s.db.(storage.Batcher)
+	if !ok {
+		for _, op := range spent {
+			if err := s.Delete(op); err != nil {
+				return fmt.Errorf("spend %s: %w", op, err)
+			}
+		}
+		for _, u := range created {
+			if err := s.Put(u); err != nil {
+				return fmt.Errorf("create %s: %w", u.Outpoint, err)
+			}
+		}
+		return nil
+	}
+
+	batch := batcher.NewBatch()
+	for _, op := range spent {
+		if err := s.remove(op, batch.Delete); err != nil {
+			return fmt.Errorf("spend %s: %w", op, err)
+		}
+	}
+	for _, u := range created {
+		if err := putUTXO(u, batch.Put); err != nil {
+			return fmt.Errorf("create %s: %w", u.Outpoint, err)
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		return fmt.Errorf("utxo batch commit: %w", err)
+	}
+	return nil
+}
+
 // Has checks if a UTXO exists for the given outpoint.
 func (s *Store) Has(outpoint types.Outpoint) (bool, error) {
 	return s.db.Has(utxoKey(outpoint))
@@ -237,17 +284,39 @@ func (s *Store) ClearAll() error {
 // GetByAddress returns all UTXOs belonging to the given address.
 // It scans the address index and loads each referenced UTXO.
 func (s *Store) GetByAddress(addr types.Address) ([]*UTXO, error) {
+	utxos, _, err := s.GetByAddressPage(addr, nil, 0)
+	return utxos, err
+}
+
+// GetByAddressPage returns up to limit UTXOs belonging to addr, ordered by
+// outpoint, starting after the given outpoint (nil starts at the beginning).
+// A limit of 0 returns everything. The second return value is the cursor
+// to pass as after for the next page, or nil when there are no more.
+func (s *Store) GetByAddressPage(addr types.Address, after *types.Outpoint, limit int) ([]*UTXO, *types.Outpoint, error) {
 	// Build the prefix: "a/" + addr(20).
 	prefix := make([]byte, len(prefixAddr)+types.AddressSize)
 	copy(prefix, prefixAddr)
 	copy(prefix[len(prefixAddr):], addr[:])
 
+	opts := storage.IterOptions{Prefix: prefix}
+	if after != nil {
+		// Start just past the cursor's own index key.
+		opts.Start = append(addrKey(addr, *after), 0)
+	}
+	it := s.db.NewIterator(opts)
+	defer it.Close()
+
 	var utxos []*UTXO
-	err := s.db.ForEach(prefix, func(key, _ []byte) error {
+	var last types.Outpoint
+	for it.Next() {
+		if limit > 0 && len(utxos) == limit {
+			return utxos, &last, nil
+		}
 		// Key layout: "a/" + addr(20) + txid(32) + index(4).
+		key := it.Key()
 		off := len(prefixAddr) + types.AddressSize
 		if len(key) < off+types.HashSize+4 {
-			return nil // Malformed key, skip.
+			continue // Malformed key, skip.
 		}
 		var op types.Outpoint
 		copy(op.TxID[:], key[off:off+types.HashSize])
@@ -255,13 +324,13 @@ func (s *Store) GetByAddress(addr types.Address) ([]*UTXO, error) {
 
 		u, err := s.Get(op)
 		if err != nil {
-			return nil // UTXO may have been spent, skip.
+			continue // UTXO may have been spent, skip.
 		}
 		utxos = append(utxos, u)
-		return nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("scan address index: %w", err)
+		last = op
 	}
-	return utxos, nil
+	if err := it.Err(); err != nil {
+		return nil, nil, fmt.Errorf("scan address index: %w", err)
+	}
+	return utxos, nil, nil
 }
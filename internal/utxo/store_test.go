@@ -378,3 +378,70 @@ func TestStore_GetAllStakedValidators(t *testing.T) {
 		t.Error("expected pk2 to remain")
 	}
 }
+
+func TestStore_GetByAddressPage(t *testing.T) {
+	s := testStore(t)
+	for i := uint32(0); i < 5; i++ {
+		if err := s.Put(makeUTXO("paged", i, uint64(1000+i))); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+	var addr types.Address
+	copy(addr[:], makeUTXO("paged", 0, 0).Script.Data)
+
+	all, err := s.GetByAddress(addr)
+	if err != nil {
+		t.Fatalf("GetByAddress() error: %v", err)
+	}
+	if len(all) != 5 {
+		t.Fatalf("GetByAddress() = %d UTXOs, want 5", len(all))
+	}
+
+	// Walk the same set two at a time; pages must not overlap.
+	seen := make(map[types.Outpoint]bool)
+	var cursor *types.Outpoint
+	pages := 0
+	for {
+		page, next, err := s.GetByAddressPage(addr, cursor, 2)
+		if err != nil {
+			t.Fatalf("GetByAddressPage() error: %v", err)
+		}
+		pages++
+		for _, u := range page {
+			if seen[u.Outpoint] {
+				t.Fatalf("outpoint %s returned twice", u.Outpoint)
+			}
+			seen[u.Outpoint] = true
+		}
+		if next == nil {
+			break
+		}
+		cursor = next
+	}
+	if len(seen) != 5 || pages != 3 {
+		t.Errorf("paged %d UTXOs in %d pages, want 5 in 3", len(seen), pages)
+	}
+}
+
+func TestStore_Apply(t *testing.T) {
+	s := testStore(t)
+	old := makeUTXO("tx1", 0, 5000)
+	if err := s.Put(old); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+
+	fresh := makeUTXO("tx2", 0, 4000)
+	if err := s.Apply([]types.Outpoint{old.Outpoint}, []*UTXO{fresh}); err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+	if has, _ := s.Has(old.Outpoint); has {
+		t.Error("spent UTXO still stored")
+	}
+	if has, _ := s.Has(fresh.Outpoint); !has {
+		t.Error("created UTXO not stored")
+	}
+	utxos, err := s.GetByAddress(types.Address(fresh.Script.Data))
+	if err != nil || len(utxos) != 1 || utxos[0].Outpoint != fresh.Outpoint {
+		t.Errorf("address index = %v, %v; want only the created UTXO", utxos, err)
+	}
+}
@@ -1,6 +1,9 @@
 package block
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/Klingon-tech/klingnet-chain/pkg/crypto"
 	"github.com/Klingon-tech/klingnet-chain/pkg/types"
 )
@@ -39,3 +42,59 @@ func ComputeMerkleRoot(txHashes []types.Hash) types.Hash {
 
 	return level[0]
 }
+
+// ErrProofIndex is returned when a merkle proof is requested for a leaf
+// outside the tree.
+var ErrProofIndex = errors.New("merkle proof index out of range")
+
+// MerkleProof proves that a transaction hash is a leaf of the tree built
+// by ComputeMerkleRoot.
+type MerkleProof struct {
+	Index    uint32       `json:"index"`    // Leaf position in the block.
+	Siblings []types.Hash `json:"siblings"` // Sibling hashes, leaf level first.
+}
+
+// ComputeMerkleProof returns the proof for the leaf at index.
+func ComputeMerkleProof(txHashes []types.Hash, index int) (*MerkleProof, error) {
+	if index < 0 || index >= len(txHashes) {
+		return nil, fmt.Errorf("%w: %d of %d", ErrProofIndex, index, len(txHashes))
+	}
+
+	proof := &MerkleProof{Index: uint32(index)}
+	level := make([]types.Hash, len(txHashes))
+	copy(level, txHashes)
+
+	for pos := index; len(level) > 1; pos /= 2 {
+		// Same odd-level rule as ComputeMerkleRoot.
+		if len(level)%2 != 0 {
+			level = append(level, level[len(level)-1])
+		}
+		proof.Siblings = append(proof.Siblings, level[pos^1])
+
+		next := make([]types.Hash, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			next[i/2] = crypto.HashConcat(level[i], level[i+1])
+		}
+		level = next
+	}
+	return proof, nil
+}
+
+// Root returns the merkle root implied by the proof for leaf.
+func (p *MerkleProof) Root(leaf types.Hash) types.Hash {
+	h, pos := leaf, p.Index
+	for _, sib := range p.Siblings {
+		if pos%2 == 0 {
+			h = crypto.HashConcat(h, sib)
+		} else {
+			h = crypto.HashConcat(sib, h)
+		}
+		pos /= 2
+	}
+	return h
+}
+
+// Verify reports whether the proof links leaf to root.
+func (p *MerkleProof) Verify(leaf, root types.Hash) bool {
+	return p != nil && p.Root(leaf) == root
+}
@@ -140,3 +140,66 @@ func TestComputeMerkleRoot_LargerTree(t *testing.T) {
 		t.Error("merkle root of 7 hashes is not deterministic")
 	}
 }
+
+func TestComputeMerkleProof_AllLeaves(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		hashes := make([]types.Hash, n)
+		for i := range hashes {
+			hashes[i] = crypto.Hash([]byte{byte(n), byte(i)})
+		}
+		root := ComputeMerkleRoot(hashes)
+
+		for i, leaf := range hashes {
+			proof, err := ComputeMerkleProof(hashes, i)
+			if err != nil {
+				t.Fatalf("n=%d i=%d: %v", n, i, err)
+			}
+			if !proof.Verify(leaf, root) {
+				t.Errorf("n=%d i=%d: proof does not verify", n, i)
+			}
+		}
+	}
+}
+
+func TestComputeMerkleProof_IndexOutOfRange(t *testing.T) {
+	hashes := []types.Hash{crypto.Hash([]byte("tx1"))}
+	if _, err := ComputeMerkleProof(hashes, 1); err == nil {
+		t.Error("expected error for index past the last leaf")
+	}
+	if _, err := ComputeMerkleProof(nil, 0); err == nil {
+		t.Error("expected error for empty tree")
+	}
+}
+
+func TestMerkleProof_Tampered(t *testing.T) {
+	hashes := []types.Hash{
+		crypto.Hash([]byte("tx1")),
+		crypto.Hash([]byte("tx2")),
+		crypto.Hash([]byte("tx3")),
+	}
+	root := ComputeMerkleRoot(hashes)
+
+	proof, err := ComputeMerkleProof(hashes, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof.Verify(hashes[0], root) {
+		t.Error("proof should not verify a different leaf")
+	}
+
+	proof.Index = 0
+	if proof.Verify(hashes[1], root) {
+		t.Error("proof should not verify at the wrong index")
+	}
+	proof.Index = 1
+
+	proof.Siblings[0][0] ^= 0xff
+	if proof.Verify(hashes[1], root) {
+		t.Error("proof with altered sibling should not verify")
+	}
+
+	var nilProof *MerkleProof
+	if nilProof.Verify(hashes[1], root) {
+		t.Error("nil proof should not verify")
+	}
+}
@@ -0,0 +1,387 @@
+// Package lightclient verifies Klingnet PoA headers and transaction
+// inclusion proofs without running a full node.
+//
+// A Client starts from a trusted header and the validator set at that
+// height. It accepts a header only if a tracked validator signed it with
+// the difficulty its time slot requires, follows the heaviest chain the
+// same way the full node does, and tracks validator set changes through
+// proven stake and unstake transactions.
+package lightclient
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/crypto"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// Light client errors.
+var (
+	ErrNoValidators   = errors.New("no validators")
+	ErrBadBlockTime   = errors.New("block time must be positive")
+	ErrUnknownParent  = errors.New("header does not connect to a known header")
+	ErrBadHeight      = errors.New("header height does not follow its parent")
+	ErrUnknownSigner  = errors.New("header not signed by a tracked validator")
+	ErrBadDifficulty  = errors.New("wrong PoA difficulty for slot")
+	ErrNotCanonical   = errors.New("block is not on the best header chain")
+	ErrBadProof       = errors.New("merkle proof does not match block")
+	ErrNotStake       = errors.New("output is not a validator stake")
+	ErrStakeNotSpent  = errors.New("unstake transaction does not spend the stake")
+	ErrWorkOverflow   = errors.New("cumulative difficulty overflow")
+	ErrMissingTxProof = errors.New("missing transaction proof")
+)
+
+// PoA difficulty values, as set by the consensus engine.
+const (
+	DiffInTurn = 2 // Signed by the slot's in-turn validator.
+	DiffNoTurn = 1 // Signed by any other validator.
+)
+
+// Client tracks the best PoA header chain from a trusted header.
+type Client struct {
+	mu sync.RWMutex
+
+	blockTime  int
+	genesis    map[string]bool             // Validators that can never be removed.
+	staked     map[types.Outpoint][]byte   // Live stake outputs -> pubkey.
+	validators [][]byte                    // Current set, sorted by pubkey.
+	changes    []appliedChange             // Applied stake changes, oldest first.
+	headers    map[types.Hash]*headerEntry // Every accepted header.
+	canonical  []types.Hash                // Best chain; index 0 is the trusted header.
+	base       uint64                      // Height of the trusted header.
+}
+
+type headerEntry struct {
+	header *block.Header
+	work   uint64 // Difficulty accumulated since the trusted header.
+}
+
+// appliedChange records a validator set change so it can be undone when
+// its block leaves the best chain.
+type appliedChange struct {
+	height uint64
+	out    types.Outpoint
+	pubKey []byte
+	added  bool
+}
+
+// New creates a client that trusts header and the given validator set at
+// its height. The validators are treated like genesis validators: stake
+// changes never remove them. blockTime is the chain's slot length in
+// seconds.
+func New(trusted *block.Header, validators [][]byte, blockTime int) (*Client, error) {
+	if trusted == nil {
+		return nil, errors.New("nil trusted header")
+	}
+	if len(validators) == 0 {
+		return nil, ErrNoValidators
+	}
+	if blockTime <= 0 {
+		return nil, ErrBadBlockTime
+	}
+
+	c := &Client{
+		blockTime: blockTime,
+		genesis:   make(map[string]bool, len(validators)),
+		staked:    make(map[types.Outpoint][]byte),
+		headers:   make(map[types.Hash]*headerEntry),
+		base:      trusted.Height,
+	}
+	for _, v := range validators {
+		c.genesis[hex.EncodeToString(v)] = true
+	}
+	c.rebuildValidators()
+
+	hash := trusted.Hash()
+	c.headers[hash] = &headerEntry{header: trusted}
+	c.canonical = []types.Hash{hash}
+	return c, nil
+}
+
+// Tip returns the header at the tip of the best chain.
+func (c *Client) Tip() *block.Header {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tipLocked().header
+}
+
+// Height returns the height of the best chain.
+func (c *Client) Height() uint64 {
+	return c.Tip().Height
+}
+
+// HeaderByHeight returns the best-chain header at height.
+func (c *Client) HeaderByHeight(height uint64) (*block.Header, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if height < c.base || height-c.base >= uint64(len(c.canonical)) {
+		return nil, false
+	}
+	return c.headers[c.canonical[height-c.base]].header, true
+}
+
+// Validators returns a copy of the tracked validator set.
+func (c *Client) Validators() [][]byte {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([][]byte, len(c.validators))
+	copy(out, c.validators)
+	return out
+}
+
+// AddHeaders verifies and stores headers in order, switching to a heavier
+// branch when one appears. Headers already known are skipped. It stops at
+// the first invalid header; the ones before it are kept.
+func (c *Client) AddHeaders(headers []*block.Header) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, h := range headers {
+		if err := c.addHeaderLocked(h); err != nil {
+			return fmt.Errorf("header %d: %w", h.Height, err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) addHeaderLocked(h *block.Header) error {
+	hash := h.Hash()
+	if _, ok := c.headers[hash]; ok {
+		return nil
+	}
+	parent, ok := c.headers[h.PrevHash]
+	if !ok {
+		return ErrUnknownParent
+	}
+	if h.Height != parent.header.Height+1 {
+		return fmt.Errorf("%w: parent %d, got %d", ErrBadHeight, parent.header.Height, h.Height)
+	}
+	if err := verifySigner(h, hash, c.validatorsAfterLocked(h.PrevHash), c.blockTime); err != nil {
+		return err
+	}
+	if h.Difficulty > ^uint64(0)-parent.work {
+		return ErrWorkOverflow
+	}
+
+	entry := &headerEntry{header: h, work: parent.work + h.Difficulty}
+	c.headers[hash] = entry
+
+	// Equal work keeps the current chain, as the full node does.
+	if entry.work > c.tipLocked().work {
+		c.setTipLocked(hash)
+	}
+	return nil
+}
+
+// validatorsAfterLocked returns the validator set that signs the child of
+// parent. Stake changes are only tracked on the best chain, so a side
+// branch uses the set at its fork point.
+func (c *Client) validatorsAfterLocked(parent types.Hash) [][]byte {
+	for !c.isCanonicalLocked(parent, c.headers[parent].header.Height) {
+		parent = c.headers[parent].header.PrevHash
+	}
+	height := c.headers[parent].header.Height
+	if len(c.changes) == 0 || c.changes[len(c.changes)-1].height <= height {
+		return c.validators
+	}
+
+	staked := make(map[types.Outpoint][]byte, len(c.staked))
+	for out, pub := range c.staked {
+		staked[out] = pub
+	}
+	for i := len(c.changes) - 1; i >= 0 && c.changes[i].height > height; i-- {
+		c.changes[i].undo(staked)
+	}
+	return buildValidators(c.genesis, staked)
+}
+
+// verifySigner checks the header signature against validators and the
+// difficulty against the signer's time slot, as the PoA engine does.
+func verifySigner(h *block.Header, hash types.Hash, validators [][]byte, blockTime int) error {
+	if len(h.ValidatorSig) == 0 {
+		return ErrUnknownSigner
+	}
+	for _, pub := range validators {
+		if !crypto.VerifySignature(hash[:], h.ValidatorSig, pub) {
+			continue
+		}
+		want := uint64(DiffNoTurn)
+		if bytes.Equal(pub, slotValidator(validators, h.Timestamp, blockTime)) {
+			want = DiffInTurn
+		}
+		if h.Difficulty != want {
+			return fmt.Errorf("%w: want %d, got %d", ErrBadDifficulty, want, h.Difficulty)
+		}
+		return nil
+	}
+	return ErrUnknownSigner
+}
+
+// slotValidator returns the in-turn validator for timestamp:
+// validators[timestamp / blockTime % N].
+func slotValidator(validators [][]byte, timestamp uint64, blockTime int) []byte {
+	idx := (timestamp / uint64(blockTime)) % uint64(len(validators))
+	return validators[idx]
+}
+
+// setTipLocked makes hash the best chain tip, undoing the stake changes of
+// blocks that leave the best chain.
+func (c *Client) setTipLocked(hash types.Hash) {
+	var branch []types.Hash
+	for {
+		h := c.headers[hash].header
+		if c.isCanonicalLocked(hash, h.Height) {
+			break
+		}
+		branch = append(branch, hash)
+		hash = h.PrevHash
+	}
+
+	forkHeight := c.headers[hash].header.Height
+	c.revertChangesLocked(forkHeight)
+	c.canonical = c.canonical[:forkHeight-c.base+1]
+	for i := len(branch) - 1; i >= 0; i-- {
+		c.canonical = append(c.canonical, branch[i])
+	}
+}
+
+func (c *Client) isCanonicalLocked(hash types.Hash, height uint64) bool {
+	i := height - c.base
+	return height >= c.base && i < uint64(len(c.canonical)) && c.canonical[i] == hash
+}
+
+func (c *Client) tipLocked() *headerEntry {
+	return c.headers[c.canonical[len(c.canonical)-1]]
+}
+
+// VerifyTx checks that the proven transaction is in a block on the best
+// chain and returns its number of confirmations.
+func (c *Client) VerifyTx(p *TxProof) (uint64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if err := c.verifyTxLocked(p); err != nil {
+		return 0, err
+	}
+	return c.tipLocked().header.Height - p.Height + 1, nil
+}
+
+func (c *Client) verifyTxLocked(p *TxProof) error {
+	if p == nil || p.Tx == nil {
+		return ErrMissingTxProof
+	}
+	entry, ok := c.headers[p.BlockHash]
+	if !ok || entry.header.Height != p.Height || !c.isCanonicalLocked(p.BlockHash, p.Height) {
+		return ErrNotCanonical
+	}
+	if !p.Proof.Verify(p.Tx.Hash(), entry.header.MerkleRoot) {
+		return ErrBadProof
+	}
+	return nil
+}
+
+// ApplyStakeChange verifies a stake change against the best chain and
+// updates the validator set. Applying a change twice has no effect. Call
+// it once the header of the change's block has been added, before adding
+// the headers after it.
+func (c *Client) ApplyStakeChange(sc *StakeChange) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if sc == nil || sc.Stake == nil {
+		return ErrMissingTxProof
+	}
+	if err := c.verifyTxLocked(sc.Stake); err != nil {
+		return fmt.Errorf("stake: %w", err)
+	}
+	if !isStakeOutput(sc.Stake.Tx, sc.Index) {
+		return ErrNotStake
+	}
+	out := types.Outpoint{TxID: sc.Stake.Tx.Hash(), Index: sc.Index}
+	pubKey := sc.Stake.Tx.Outputs[sc.Index].Script.Data
+
+	if sc.Unstake == nil {
+		if _, ok := c.staked[out]; ok {
+			return nil
+		}
+		c.staked[out] = pubKey
+		c.changes = append(c.changes, appliedChange{height: sc.Stake.Height, out: out, pubKey: pubKey, added: true})
+		c.rebuildValidators()
+		return nil
+	}
+
+	if err := c.verifyTxLocked(sc.Unstake); err != nil {
+		return fmt.Errorf("unstake: %w", err)
+	}
+	if !spends(sc.Unstake, out) {
+		return ErrStakeNotSpent
+	}
+	if _, ok := c.staked[out]; !ok {
+		return nil
+	}
+	delete(c.staked, out)
+	c.changes = append(c.changes, appliedChange{height: sc.Unstake.Height, out: out, pubKey: pubKey})
+	c.rebuildValidators()
+	return nil
+}
+
+func spends(p *TxProof, out types.Outpoint) bool {
+	for _, in := range p.Tx.Inputs {
+		if in.PrevOut == out {
+			return true
+		}
+	}
+	return false
+}
+
+// revertChangesLocked undoes the stake changes made above height.
+func (c *Client) revertChangesLocked(height uint64) {
+	n := len(c.changes)
+	for n > 0 && c.changes[n-1].height > height {
+		c.changes[n-1].undo(c.staked)
+		n--
+	}
+	if n < len(c.changes) {
+		c.changes = c.changes[:n]
+		c.rebuildValidators()
+	}
+}
+
+// undo reverses the change in staked.
+func (ch appliedChange) undo(staked map[types.Outpoint][]byte) {
+	if ch.added {
+		delete(staked, ch.out)
+	} else {
+		staked[ch.out] = ch.pubKey
+	}
+}
+
+// rebuildValidators recomputes the validator set from the live stakes.
+func (c *Client) rebuildValidators() {
+	c.validators = buildValidators(c.genesis, c.staked)
+}
+
+// buildValidators returns the genesis validators and the staked pubkeys,
+// sorted by pubkey like the PoA engine's set.
+func buildValidators(genesis map[string]bool, staked map[types.Outpoint][]byte) [][]byte {
+	set := make(map[string][]byte, len(genesis)+len(staked))
+	for k := range genesis {
+		pub, _ := hex.DecodeString(k)
+		set[k] = pub
+	}
+	for _, pub := range staked {
+		set[hex.EncodeToString(pub)] = pub
+	}
+	validators := make([][]byte, 0, len(set))
+	for _, pub := range set {
+		validators = append(validators, pub)
+	}
+	sort.Slice(validators, func(i, j int) bool {
+		return bytes.Compare(validators[i], validators[j]) < 0
+	})
+	return validators
+}
@@ -0,0 +1,474 @@
+package lightclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/crypto"
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+const testBlockTime = 3
+
+func genKey(t *testing.T) *crypto.PrivateKey {
+	t.Helper()
+	k, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return k
+}
+
+// sealBlock builds and signs the block after parent with the difficulty
+// the signer's slot requires under validators.
+func sealBlock(t *testing.T, parent *block.Header, signer *crypto.PrivateKey, validators [][]byte, ts uint64, txs ...*tx.Transaction) *block.Block {
+	t.Helper()
+	txs = append([]*tx.Transaction{coinbase(parent.Height + 1)}, txs...)
+	hashes := make([]types.Hash, len(txs))
+	for i, t := range txs {
+		hashes[i] = t.Hash()
+	}
+
+	sorted := append([][]byte(nil), validators...)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i], sorted[j]) < 0 })
+	diff := uint64(DiffNoTurn)
+	if bytes.Equal(sorted[(ts/testBlockTime)%uint64(len(sorted))], signer.PublicKey()) {
+		diff = DiffInTurn
+	}
+
+	h := &block.Header{
+		Version:    block.CurrentVersion,
+		PrevHash:   parent.Hash(),
+		MerkleRoot: block.ComputeMerkleRoot(hashes),
+		Timestamp:  ts,
+		Height:     parent.Height + 1,
+		Difficulty: diff,
+	}
+	hash := h.Hash()
+	sig, err := signer.Sign(hash[:])
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	h.ValidatorSig = sig
+	return block.NewBlock(h, txs)
+}
+
+func coinbase(height uint64) *tx.Transaction {
+	return tx.NewBuilder().
+		AddInput(types.Outpoint{}).
+		AddOutput(height, types.Script{Type: types.ScriptTypeP2PKH, Data: make([]byte, 20)}).
+		Build()
+}
+
+func genesisHeader() *block.Header {
+	return &block.Header{Version: block.CurrentVersion, Timestamp: 1000}
+}
+
+func newTestClient(t *testing.T, validators ...*crypto.PrivateKey) (*Client, [][]byte) {
+	t.Helper()
+	var pubs [][]byte
+	for _, k := range validators {
+		pubs = append(pubs, k.PublicKey())
+	}
+	c, err := New(genesisHeader(), pubs, testBlockTime)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c, pubs
+}
+
+func TestNew_Invalid(t *testing.T) {
+	if _, err := New(genesisHeader(), nil, testBlockTime); !errors.Is(err, ErrNoValidators) {
+		t.Errorf("no validators: got %v", err)
+	}
+	if _, err := New(genesisHeader(), [][]byte{genKey(t).PublicKey()}, 0); !errors.Is(err, ErrBadBlockTime) {
+		t.Errorf("zero block time: got %v", err)
+	}
+}
+
+func TestClient_AddHeaders(t *testing.T) {
+	k1, k2 := genKey(t), genKey(t)
+	c, vals := newTestClient(t, k1, k2)
+
+	parent := genesisHeader()
+	for i := uint64(1); i <= 5; i++ {
+		signer := k1
+		if i%2 == 0 {
+			signer = k2
+		}
+		blk := sealBlock(t, parent, signer, vals, 1000+i*testBlockTime)
+		if err := c.AddHeaders([]*block.Header{blk.Header}); err != nil {
+			t.Fatalf("height %d: %v", i, err)
+		}
+		parent = blk.Header
+	}
+	if c.Height() != 5 {
+		t.Errorf("Height = %d, want 5", c.Height())
+	}
+	if c.Tip().Hash() != parent.Hash() {
+		t.Error("tip is not the last header")
+	}
+	if h, ok := c.HeaderByHeight(3); !ok || h.Height != 3 {
+		t.Error("HeaderByHeight(3) missing")
+	}
+	if _, ok := c.HeaderByHeight(6); ok {
+		t.Error("HeaderByHeight(6) should be missing")
+	}
+
+	// Re-adding known headers is a no-op.
+	if err := c.AddHeaders([]*block.Header{parent}); err != nil {
+		t.Errorf("re-adding tip: %v", err)
+	}
+}
+
+func TestClient_AddHeaders_Rejects(t *testing.T) {
+	k1, k2 := genKey(t), genKey(t)
+	c, vals := newTestClient(t, k1, k2)
+	gen := genesisHeader()
+
+	// Signed by a key outside the validator set.
+	outsider := genKey(t)
+	blk := sealBlock(t, gen, outsider, append(vals, outsider.PublicKey()), 1003)
+	if err := c.AddHeaders([]*block.Header{blk.Header}); !errors.Is(err, ErrUnknownSigner) {
+		t.Errorf("outsider: got %v, want ErrUnknownSigner", err)
+	}
+
+	// Wrong slot difficulty. Re-sign so only the difficulty is wrong.
+	blk = sealBlock(t, gen, k1, vals, 1003)
+	blk.Header.Difficulty = DiffInTurn + DiffNoTurn - blk.Header.Difficulty
+	hash := blk.Header.Hash()
+	blk.Header.ValidatorSig, _ = k1.Sign(hash[:])
+	if err := c.AddHeaders([]*block.Header{blk.Header}); !errors.Is(err, ErrBadDifficulty) {
+		t.Errorf("difficulty: got %v, want ErrBadDifficulty", err)
+	}
+
+	// Unknown parent.
+	orphanParent := &block.Header{Height: 7, Timestamp: 1}
+	blk = sealBlock(t, orphanParent, k1, vals, 1003)
+	if err := c.AddHeaders([]*block.Header{blk.Header}); !errors.Is(err, ErrUnknownParent) {
+		t.Errorf("orphan: got %v, want ErrUnknownParent", err)
+	}
+
+	// Height that does not follow the parent.
+	blk = sealBlock(t, gen, k1, vals, 1003)
+	blk.Header.Height = 2
+	hash = blk.Header.Hash()
+	blk.Header.ValidatorSig, _ = k1.Sign(hash[:])
+	if err := c.AddHeaders([]*block.Header{blk.Header}); !errors.Is(err, ErrBadHeight) {
+		t.Errorf("height: got %v, want ErrBadHeight", err)
+	}
+
+	if c.Height() != 0 {
+		t.Errorf("Height = %d after rejected headers, want 0", c.Height())
+	}
+}
+
+func TestClient_VerifyTx(t *testing.T) {
+	k1 := genKey(t)
+	c, vals := newTestClient(t, k1)
+
+	payment := tx.NewBuilder().
+		AddInput(types.Outpoint{TxID: types.Hash{1}}).
+		AddOutput(500, types.Script{Type: types.ScriptTypeP2PKH, Data: make([]byte, 20)}).
+		Build()
+	other := tx.NewBuilder().
+		AddInput(types.Outpoint{TxID: types.Hash{2}}).
+		AddOutput(700, types.Script{Type: types.ScriptTypeP2PKH, Data: make([]byte, 20)}).
+		Build()
+
+	b1 := sealBlock(t, genesisHeader(), k1, vals, 1003, payment, other)
+	b2 := sealBlock(t, b1.Header, k1, vals, 1006)
+	if err := c.AddHeaders([]*block.Header{b1.Header, b2.Header}); err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := NewTxProof(b1, payment.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	confs, err := c.VerifyTx(proof)
+	if err != nil {
+		t.Fatalf("VerifyTx: %v", err)
+	}
+	if confs != 2 {
+		t.Errorf("confirmations = %d, want 2", confs)
+	}
+
+	// A different transaction does not match the proof.
+	forged := *proof
+	forged.Tx = other
+	if _, err := c.VerifyTx(&forged); !errors.Is(err, ErrBadProof) {
+		t.Errorf("forged tx: got %v, want ErrBadProof", err)
+	}
+
+	// A block the client does not know.
+	unknown := *proof
+	unknown.BlockHash = types.Hash{9}
+	if _, err := c.VerifyTx(&unknown); !errors.Is(err, ErrNotCanonical) {
+		t.Errorf("unknown block: got %v, want ErrNotCanonical", err)
+	}
+
+	if _, err := NewTxProof(b2, payment.Hash()); err == nil {
+		t.Error("NewTxProof should fail for a tx outside the block")
+	}
+}
+
+func TestClient_Reorg(t *testing.T) {
+	k1, k2 := genKey(t), genKey(t)
+	c, vals := newTestClient(t, k1, k2)
+	gen := genesisHeader()
+
+	// Pick timestamps in k1's and k2's slots.
+	sorted := c.Validators()
+	inTurn := func(k *crypto.PrivateKey, after uint64) uint64 {
+		for ts := after + 1; ; ts++ {
+			if bytes.Equal(sorted[(ts/testBlockTime)%2], k.PublicKey()) {
+				return ts
+			}
+		}
+	}
+
+	// Branch A: one out-of-turn block (work 1).
+	a1 := sealBlock(t, gen, k1, vals, inTurn(k2, 1000))
+	if err := c.AddHeaders([]*block.Header{a1.Header}); err != nil {
+		t.Fatal(err)
+	}
+	proofA, _ := NewTxProof(a1, a1.Transactions[0].Hash())
+
+	// Branch B: one in-turn block (work 2) wins.
+	b1 := sealBlock(t, gen, k2, vals, inTurn(k2, 1000), coinbase(42))
+	if err := c.AddHeaders([]*block.Header{b1.Header}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Tip().Hash() != b1.Header.Hash() {
+		t.Fatal("client did not switch to the heavier branch")
+	}
+	if _, err := c.VerifyTx(proofA); !errors.Is(err, ErrNotCanonical) {
+		t.Errorf("tx on abandoned branch: got %v, want ErrNotCanonical", err)
+	}
+
+	// Equal work keeps the current tip.
+	b1Alt := sealBlock(t, gen, k2, vals, inTurn(k2, 1000)+testBlockTime*2)
+	if err := c.AddHeaders([]*block.Header{b1Alt.Header}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Tip().Hash() != b1.Header.Hash() {
+		t.Error("client switched on equal work")
+	}
+}
+
+// stakingChain builds genesis -> stake -> signed by the new validator ->
+// unstake, with k1 as the only genesis validator.
+func stakingChain(t *testing.T) (k1, k3 *crypto.PrivateKey, blocks []*block.Block, changes []*StakeChange) {
+	t.Helper()
+	k1, k3 = genKey(t), genKey(t)
+	genesisVals := [][]byte{k1.PublicKey()}
+	stakedVals := [][]byte{k1.PublicKey(), k3.PublicKey()}
+
+	fund := tx.NewBuilder().
+		AddInput(types.Outpoint{}).
+		AddOutput(1000, types.Script{Type: types.ScriptTypeP2PKH, Data: make([]byte, 20)}).
+		SetLockTime(1).
+		Build()
+	stakeTx := tx.NewBuilder().
+		AddInput(types.Outpoint{TxID: fund.Hash()}).
+		AddOutput(1000, types.Script{Type: types.ScriptTypeStake, Data: k3.PublicKey()}).
+		Build()
+	unstakeTx := tx.NewBuilder().
+		AddInput(types.Outpoint{TxID: stakeTx.Hash(), Index: 0}).
+		AddOutput(1000, types.Script{Type: types.ScriptTypeP2PKH, Data: make([]byte, 20)}).
+		Build()
+
+	b1 := sealBlock(t, genesisHeader(), k1, genesisVals, 1003, fund, stakeTx)
+	b2 := sealBlock(t, b1.Header, k3, stakedVals, 1006)
+	b3 := sealBlock(t, b2.Header, k1, stakedVals, 1009, unstakeTx)
+	blocks = []*block.Block{b1, b2, b3}
+
+	byHash := map[types.Hash]*block.Block{}
+	for _, b := range blocks {
+		for _, t := range b.Transactions {
+			byHash[t.Hash()] = b
+		}
+	}
+	blockOf := func(h types.Hash) (*block.Block, error) {
+		if b, ok := byHash[h]; ok {
+			return b, nil
+		}
+		return nil, errors.New("not found")
+	}
+	for _, b := range blocks {
+		sc, err := BlockStakeChanges(b, blockOf)
+		if err != nil {
+			t.Fatalf("BlockStakeChanges: %v", err)
+		}
+		changes = append(changes, sc...)
+	}
+	return k1, k3, blocks, changes
+}
+
+// BlockStakeChanges fails if a spent transaction cannot be found.
+func TestBlockStakeChanges_UnknownInput(t *testing.T) {
+	k1 := genKey(t)
+	spend := tx.NewBuilder().
+		AddInput(types.Outpoint{TxID: types.Hash{5}}).
+		AddOutput(1, types.Script{Type: types.ScriptTypeP2PKH, Data: make([]byte, 20)}).
+		Build()
+	b := sealBlock(t, genesisHeader(), k1, [][]byte{k1.PublicKey()}, 1003, spend)
+	_, err := BlockStakeChanges(b, func(types.Hash) (*block.Block, error) { return nil, errors.New("not found") })
+	if err == nil {
+		t.Error("expected error when a spent tx cannot be found")
+	}
+}
+
+func TestClient_StakeChanges(t *testing.T) {
+	k1, k3, blocks, changes := stakingChain(t)
+	if len(changes) != 2 || changes[0].Unstake != nil || changes[1].Unstake == nil {
+		t.Fatalf("unexpected stake changes: %+v", changes)
+	}
+
+	c, err := New(genesisHeader(), [][]byte{k1.PublicKey()}, testBlockTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddHeaders([]*block.Header{blocks[0].Header}); err != nil {
+		t.Fatal(err)
+	}
+
+	// k3 cannot sign before its stake is applied.
+	if err := c.AddHeaders([]*block.Header{blocks[1].Header}); !errors.Is(err, ErrUnknownSigner) {
+		t.Fatalf("before stake: got %v, want ErrUnknownSigner", err)
+	}
+	if err := c.ApplyStakeChange(changes[0]); err != nil {
+		t.Fatalf("apply stake: %v", err)
+	}
+	if err := c.ApplyStakeChange(changes[0]); err != nil {
+		t.Fatalf("re-apply stake: %v", err)
+	}
+	if len(c.Validators()) != 2 {
+		t.Fatalf("validators = %d, want 2", len(c.Validators()))
+	}
+	if err := c.AddHeaders([]*block.Header{blocks[1].Header, blocks[2].Header}); err != nil {
+		t.Fatalf("after stake: %v", err)
+	}
+
+	if err := c.ApplyStakeChange(changes[1]); err != nil {
+		t.Fatalf("apply unstake: %v", err)
+	}
+	if len(c.Validators()) != 1 {
+		t.Fatalf("validators after unstake = %d, want 1", len(c.Validators()))
+	}
+
+	// k3 can no longer sign.
+	b4 := sealBlock(t, blocks[2].Header, k3, [][]byte{k1.PublicKey(), k3.PublicKey()}, 1012)
+	if err := c.AddHeaders([]*block.Header{b4.Header}); !errors.Is(err, ErrUnknownSigner) {
+		t.Errorf("after unstake: got %v, want ErrUnknownSigner", err)
+	}
+
+	// An unstake whose tx does not spend the stake is rejected.
+	bad := *changes[1]
+	bad.Unstake = changes[0].Stake
+	if err := c.ApplyStakeChange(&bad); !errors.Is(err, ErrStakeNotSpent) {
+		t.Errorf("bogus unstake: got %v, want ErrStakeNotSpent", err)
+	}
+
+	// A non-stake output is rejected.
+	notStake := &StakeChange{Stake: changes[0].Stake, Index: 5}
+	if err := c.ApplyStakeChange(notStake); !errors.Is(err, ErrNotStake) {
+		t.Errorf("non-stake output: got %v, want ErrNotStake", err)
+	}
+}
+
+func TestClient_ReorgRevertsStake(t *testing.T) {
+	k1, _, blocks, changes := stakingChain(t)
+	c, err := New(genesisHeader(), [][]byte{k1.PublicKey()}, testBlockTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddHeaders([]*block.Header{blocks[0].Header}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ApplyStakeChange(changes[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	// A heavier branch without the stake replaces block 1.
+	gen := genesisHeader()
+	vals := [][]byte{k1.PublicKey()}
+	f1 := sealBlock(t, gen, k1, vals, 1004)
+	f2 := sealBlock(t, f1.Header, k1, vals, 1007)
+	if err := c.AddHeaders([]*block.Header{f1.Header, f2.Header}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Tip().Hash() != f2.Header.Hash() {
+		t.Fatal("client did not switch to the heavier branch")
+	}
+	if len(c.Validators()) != 1 {
+		t.Errorf("validators = %d after reorg, want 1", len(c.Validators()))
+	}
+}
+
+// memSource serves blocks from memory.
+type memSource struct {
+	blocks  []*block.Block // blocks[i] is at height i+1
+	changes []*StakeChange
+}
+
+func (s *memSource) Headers(_ context.Context, from uint64, max uint32) ([]*block.Header, error) {
+	var out []*block.Header
+	for h := from; h <= uint64(len(s.blocks)) && uint32(len(out)) < max; h++ {
+		out = append(out, s.blocks[h-1].Header)
+	}
+	return out, nil
+}
+
+func (s *memSource) StakeChanges(_ context.Context, from uint64, max uint32) ([]*StakeChange, error) {
+	var out []*StakeChange
+	for _, sc := range s.changes {
+		if sc.Height() >= from && sc.Height() < from+uint64(max) {
+			out = append(out, sc)
+		}
+	}
+	return out, nil
+}
+
+func (s *memSource) TxProof(_ context.Context, hash types.Hash) (*TxProof, error) {
+	for _, b := range s.blocks {
+		if p, err := NewTxProof(b, hash); err == nil {
+			return p, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func TestClient_Sync(t *testing.T) {
+	k1, _, blocks, changes := stakingChain(t)
+	c, err := New(genesisHeader(), [][]byte{k1.PublicKey()}, testBlockTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := &memSource{blocks: blocks, changes: changes}
+	if err := c.Sync(context.Background(), src); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if c.Height() != 3 {
+		t.Errorf("Height = %d, want 3", c.Height())
+	}
+	if len(c.Validators()) != 1 {
+		t.Errorf("validators = %d, want 1", len(c.Validators()))
+	}
+
+	stakeHash := blocks[0].Transactions[2].Hash()
+	proof, confs, err := c.FetchTx(context.Background(), src, stakeHash)
+	if err != nil {
+		t.Fatalf("FetchTx: %v", err)
+	}
+	if proof.Height != 1 || confs != 3 {
+		t.Errorf("proof height %d confs %d, want 1 and 3", proof.Height, confs)
+	}
+}
@@ -0,0 +1,112 @@
+package lightclient
+
+import (
+	"fmt"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/tx"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// TxProof proves that a transaction is included in a block.
+type TxProof struct {
+	Tx        *tx.Transaction   `json:"tx"`
+	BlockHash types.Hash        `json:"block_hash"`
+	Height    uint64            `json:"height"`
+	Proof     block.MerkleProof `json:"proof"`
+}
+
+// StakeChange is a proven change to the validator set. A stake adds the
+// pubkey of a ScriptTypeStake output; an unstake removes it again and
+// carries the transaction that spends that output.
+type StakeChange struct {
+	Stake   *TxProof `json:"stake"`             // Transaction with the stake output.
+	Index   uint32   `json:"index"`             // Stake output index.
+	Unstake *TxProof `json:"unstake,omitempty"` // Spending transaction, for removals.
+}
+
+// Height returns the block height at which the change takes effect.
+func (c *StakeChange) Height() uint64 {
+	if c.Unstake != nil {
+		return c.Unstake.Height
+	}
+	return c.Stake.Height
+}
+
+// NewTxProof builds the inclusion proof for the transaction with the given
+// hash in blk.
+func NewTxProof(blk *block.Block, txHash types.Hash) (*TxProof, error) {
+	hashes := make([]types.Hash, len(blk.Transactions))
+	index := -1
+	for i, t := range blk.Transactions {
+		hashes[i] = t.Hash()
+		if hashes[i] == txHash {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("tx %s not in block %d", txHash, blk.Header.Height)
+	}
+	proof, err := block.ComputeMerkleProof(hashes, index)
+	if err != nil {
+		return nil, err
+	}
+	return &TxProof{
+		Tx:        blk.Transactions[index],
+		BlockHash: blk.Header.Hash(),
+		Height:    blk.Header.Height,
+		Proof:     *proof,
+	}, nil
+}
+
+// BlockStakeChanges returns the stake and unstake changes made by blk.
+// blockOf looks up the block containing a confirmed transaction, to prove
+// the stake outputs that blk spends.
+func BlockStakeChanges(blk *block.Block, blockOf func(types.Hash) (*block.Block, error)) ([]*StakeChange, error) {
+	var changes []*StakeChange
+	for _, t := range blk.Transactions {
+		for _, in := range t.Inputs {
+			if in.PrevOut.IsZero() {
+				continue // Coinbase.
+			}
+			prevBlk, err := blockOf(in.PrevOut.TxID)
+			if err != nil {
+				return nil, fmt.Errorf("load block of %s: %w", in.PrevOut.TxID, err)
+			}
+			stake, err := NewTxProof(prevBlk, in.PrevOut.TxID)
+			if err != nil {
+				return nil, err
+			}
+			if !isStakeOutput(stake.Tx, in.PrevOut.Index) {
+				continue
+			}
+			unstake, err := NewTxProof(blk, t.Hash())
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, &StakeChange{Stake: stake, Index: in.PrevOut.Index, Unstake: unstake})
+		}
+
+		for i := range t.Outputs {
+			if !isStakeOutput(t, uint32(i)) {
+				continue
+			}
+			stake, err := NewTxProof(blk, t.Hash())
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, &StakeChange{Stake: stake, Index: uint32(i)})
+		}
+	}
+	return changes, nil
+}
+
+// isStakeOutput reports whether output index of t locks a validator stake.
+// Matches the chain's stake handler: the script data is the 33-byte pubkey.
+func isStakeOutput(t *tx.Transaction, index uint32) bool {
+	if t == nil || int(index) >= len(t.Outputs) {
+		return false
+	}
+	s := t.Outputs[index].Script
+	return s.Type == types.ScriptTypeStake && len(s.Data) == 33
+}
@@ -0,0 +1,109 @@
+package lightclient
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Klingon-tech/klingnet-chain/pkg/block"
+	"github.com/Klingon-tech/klingnet-chain/pkg/types"
+)
+
+// Request types served by full nodes.
+const (
+	RequestHeaders = "headers" // Headers from From, at most Max.
+	RequestStakes  = "stakes"  // Stake changes in blocks From..From+Max-1.
+	RequestTx      = "tx"      // Inclusion proof for TxHash.
+)
+
+// Request size limits enforced by full nodes.
+const (
+	MaxHeaders     = 500 // Headers a single request may cover.
+	MaxStakeBlocks = 100 // Blocks a single stake changes request may cover.
+)
+
+// Request is a light client request, sent as JSON.
+type Request struct {
+	Type   string     `json:"type"`
+	From   uint64     `json:"from,omitempty"`
+	Max    uint32     `json:"max,omitempty"`
+	TxHash types.Hash `json:"tx_hash,omitempty"`
+}
+
+// Response answers a Request. Error is set if the request failed.
+type Response struct {
+	Headers []*block.Header `json:"headers,omitempty"`
+	Stakes  []*StakeChange  `json:"stakes,omitempty"`
+	Proof   *TxProof        `json:"proof,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// Source fetches headers and proofs from a full node.
+type Source interface {
+	Headers(ctx context.Context, from uint64, max uint32) ([]*block.Header, error)
+	StakeChanges(ctx context.Context, from uint64, max uint32) ([]*StakeChange, error)
+	TxProof(ctx context.Context, hash types.Hash) (*TxProof, error)
+}
+
+// Sync extends the best chain from src until src has no more headers,
+// applying validator set changes as it goes.
+func (c *Client) Sync(ctx context.Context, src Source) error {
+	for {
+		from := c.Height() + 1
+		headers, err := src.Headers(ctx, from, MaxHeaders)
+		if err != nil {
+			return fmt.Errorf("fetch headers from %d: %w", from, err)
+		}
+		if len(headers) == 0 {
+			return nil
+		}
+		var changes []*StakeChange
+		for off := 0; off < len(headers); off += MaxStakeBlocks {
+			start := from + uint64(off)
+			sc, err := src.StakeChanges(ctx, start, uint32(min(MaxStakeBlocks, len(headers)-off)))
+			if err != nil {
+				return fmt.Errorf("fetch stake changes from %d: %w", start, err)
+			}
+			changes = append(changes, sc...)
+		}
+
+		byHeight := make(map[uint64][]*StakeChange)
+		for _, sc := range changes {
+			if sc == nil || sc.Stake == nil {
+				return ErrMissingTxProof
+			}
+			byHeight[sc.Height()] = append(byHeight[sc.Height()], sc)
+		}
+
+		// A block's stake changes affect who may sign the blocks after it.
+		for _, h := range headers {
+			if err := c.AddHeaders([]*block.Header{h}); err != nil {
+				return err
+			}
+			for _, sc := range byHeight[h.Height] {
+				if err := c.ApplyStakeChange(sc); err != nil {
+					return fmt.Errorf("block %d stake change: %w", h.Height, err)
+				}
+			}
+		}
+		if c.Height() < from {
+			return fmt.Errorf("source returned no new headers from %d", from)
+		}
+	}
+}
+
+// FetchTx fetches the inclusion proof for a transaction from src and
+// verifies it, returning the transaction's confirmations.
+func (c *Client) FetchTx(ctx context.Context, src Source, hash types.Hash) (*TxProof, uint64, error) {
+	proof, err := src.TxProof(ctx, hash)
+	if err != nil {
+		return nil, 0, err
+	}
+	if proof == nil || proof.Tx == nil || proof.Tx.Hash() != hash {
+		return nil, 0, fmt.Errorf("proof is for a different transaction")
+	}
+	confs, err := c.VerifyTx(proof)
+	if err != nil {
+		return nil, 0, err
+	}
+	return proof, confs, nil
+}
@@ -47,6 +47,12 @@ func (b *Builder) SetLockTime(lockTime uint64) *Builder {
 	return b
 }
 
+// SetReplaceable signals that the transaction may be replaced by fee.
+func (b *Builder) SetReplaceable() *Builder {
+	b.tx.Version |= VersionReplaceable
+	return b
+}
+
 // Sign signs all inputs with the provided private key.
 // Each input gets the same signature (single-key spending).
 func (b *Builder) Sign(key *crypto.PrivateKey) error {
@@ -20,6 +20,16 @@ type Transaction struct {
 	LockTime uint64   `json:"locktime"`
 }
 
+// VersionReplaceable is a Version flag signalling that the transaction may be
+// replaced in the mempool by a conflicting spend paying a higher fee
+// (opt-in replace-by-fee). It is mempool policy only; consensus ignores it.
+const VersionReplaceable uint32 = 1 << 31
+
+// Replaceable reports whether the transaction signals replace-by-fee.
+func (tx *Transaction) Replaceable() bool {
+	return tx.Version&VersionReplaceable != 0
+}
+
 // Input references a UTXO being spent.
 type Input struct {
 	PrevOut   types.Outpoint `json:"prevout"`
@@ -85,6 +95,20 @@ func (tx *Transaction) Hash() types.Hash {
 	return crypto.Hash(tx.SigningBytes())
 }
 
+// FullHash hashes the whole transaction, signatures and public keys
+// included. Copies of a transaction that differ only in their signatures
+// share a Hash but not a FullHash.
+func (tx *Transaction) FullHash() types.Hash {
+	buf := tx.SigningBytes()
+	for _, in := range tx.Inputs {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(in.Signature)))
+		buf = append(buf, in.Signature...)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(in.PubKey)))
+		buf = append(buf, in.PubKey...)
+	}
+	return crypto.Hash(buf)
+}
+
 // SigningBytes returns the canonical byte representation used for signing.
 // Format: version(4) | input_count(4) | [prevout(36)]... | output_count(4) | [value(8) + script_type(1) + script_data_len(4) + script_data]... | locktime(8)
 func (tx *Transaction) SigningBytes() []byte {
@@ -307,3 +307,39 @@ func TestBuilder_TokenOutput(t *testing.T) {
 		t.Errorf("token amount = %d, want 100", transaction.Outputs[0].Token.Amount)
 	}
 }
+
+func TestTransaction_Replaceable(t *testing.T) {
+	plain := NewBuilder().Build()
+	if plain.Replaceable() {
+		t.Error("default transaction should not signal replace-by-fee")
+	}
+	rbf := NewBuilder().SetReplaceable().Build()
+	if !rbf.Replaceable() {
+		t.Error("SetReplaceable should signal replace-by-fee")
+	}
+	if rbf.Version&^VersionReplaceable != plain.Version {
+		t.Errorf("version = %#x, want %#x with flag", rbf.Version, plain.Version)
+	}
+	if rbf.Hash() == plain.Hash() {
+		t.Error("replace-by-fee flag must be covered by the tx hash")
+	}
+}
+
+func TestTransaction_FullHash_CoversSignature(t *testing.T) {
+	tx := &Transaction{
+		Version: 1,
+		Inputs:  []Input{{PrevOut: types.Outpoint{TxID: types.Hash{0x01}, Index: 0}, Signature: []byte("sig"), PubKey: []byte("key")}},
+		Outputs: []Output{{Value: 1000, Script: types.Script{Type: types.ScriptTypeP2PKH}}},
+	}
+	h1 := tx.FullHash()
+
+	tx.Inputs[0].Signature = []byte("other")
+	if tx.FullHash() == h1 {
+		t.Error("FullHash() should change with the signature")
+	}
+	tx.Inputs[0].Signature = []byte("sig")
+	tx.Inputs[0].PubKey = []byte("other")
+	if tx.FullHash() == h1 {
+		t.Error("FullHash() should change with the public key")
+	}
+}
@@ -32,6 +32,18 @@ type UTXOProvider interface {
 // UTXO script, that signatures are valid, and that inputs >= outputs.
 // Returns the fee (inputs - outputs).
 func (tx *Transaction) ValidateWithUTXOs(provider UTXOProvider) (uint64, error) {
+	return tx.validateWithUTXOs(provider, true)
+}
+
+// ValidateAmountsWithUTXOs is ValidateWithUTXOs without the script and
+// signature checks: inputs must still exist, be spendable and cover the
+// outputs. It is only safe for transactions in blocks already known to be
+// valid, such as ancestors of an assume-valid block.
+func (tx *Transaction) ValidateAmountsWithUTXOs(provider UTXOProvider) (uint64, error) {
+	return tx.validateWithUTXOs(provider, false)
+}
+
+func (tx *Transaction) validateWithUTXOs(provider UTXOProvider, verifyScripts bool) (uint64, error) {
 	// Basic structural validation first.
 	if err := tx.ValidateStructure(); err != nil {
 		return 0, err
@@ -58,20 +70,11 @@ func (tx *Transaction) ValidateWithUTXOs(provider UTXOProvider) (uint64, error)
 		case types.ScriptTypeRegister, types.ScriptTypeAnchor, types.ScriptTypeBurn:
 			return 0, fmt.Errorf("input %d (%s): %w: %s output cannot be spent",
 				i, in.PrevOut, ErrUnspendableOutput, script.Type)
-		case types.ScriptTypeP2PKH:
-			if err := verifyP2PKH(in.PubKey, script.Data); err != nil {
-				return 0, fmt.Errorf("input %d: %w", i, err)
-			}
-		case types.ScriptTypeMint:
-			if err := verifyAddressLock(in.PubKey, script.Data); err != nil {
-				return 0, fmt.Errorf("input %d: %w", i, err)
-			}
-		case types.ScriptTypeStake:
-			if len(script.Data) != 33 {
-				return 0, fmt.Errorf("input %d: %w: stake script data length %d, want 33", i, ErrScriptMismatch, len(script.Data))
-			}
-			if !bytes.Equal(in.PubKey, script.Data) {
-				return 0, fmt.Errorf("input %d: %w: pubkey does not match stake", i, ErrScriptMismatch)
+		case types.ScriptTypeP2PKH, types.ScriptTypeMint, types.ScriptTypeStake:
+			if verifyScripts {
+				if err := verifyScript(in, script); err != nil {
+					return 0, fmt.Errorf("input %d: %w", i, err)
+				}
 			}
 		default:
 			return 0, fmt.Errorf("input %d (%s): %w: %s", i, in.PrevOut, ErrUnsupportedScript, script.Type)
@@ -84,8 +87,10 @@ func (tx *Transaction) ValidateWithUTXOs(provider UTXOProvider) (uint64, error)
 	}
 
 	// Verify signatures.
-	if err := tx.VerifySignatures(); err != nil {
-		return 0, err
+	if verifyScripts {
+		if err := tx.VerifySignatures(); err != nil {
+			return 0, err
+		}
 	}
 
 	totalOutput, ovfErr := tx.TotalOutputValue()
@@ -100,6 +105,24 @@ func (tx *Transaction) ValidateWithUTXOs(provider UTXOProvider) (uint64, error)
 	return fee, nil
 }
 
+// verifyScript checks that an input's pubkey satisfies the spent UTXO's script.
+func verifyScript(in Input, script types.Script) error {
+	switch script.Type {
+	case types.ScriptTypeP2PKH:
+		return verifyP2PKH(in.PubKey, script.Data)
+	case types.ScriptTypeMint:
+		return verifyAddressLock(in.PubKey, script.Data)
+	case types.ScriptTypeStake:
+		if len(script.Data) != 33 {
+			return fmt.Errorf("%w: stake script data length %d, want 33", ErrScriptMismatch, len(script.Data))
+		}
+		if !bytes.Equal(in.PubKey, script.Data) {
+			return fmt.Errorf("%w: pubkey does not match stake", ErrScriptMismatch)
+		}
+	}
+	return nil
+}
+
 // ValidateStructure checks transaction structure without requiring UTXO access.
 // Same as Validate() but renamed for clarity when used alongside ValidateWithUTXOs.
 func (tx *Transaction) ValidateStructure() error {
@@ -386,3 +386,44 @@ func TestValidateWithUTXOs_UnsupportedScript(t *testing.T) {
 		t.Errorf("expected ErrUnsupportedScript, got: %v", err)
 	}
 }
+
+func TestValidateAmountsWithUTXOs_SkipsScripts(t *testing.T) {
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+	addr2 := addressFromKey(key2)
+
+	prevOut := types.Outpoint{TxID: types.Hash{0x01}, Index: 0}
+	provider := newMockProvider()
+	provider.add(prevOut, 5000, types.Script{Type: types.ScriptTypeP2PKH, Data: addr2[:]})
+
+	// Signed with the wrong key: rejected by full validation only.
+	b := NewBuilder().
+		AddInput(prevOut).
+		AddOutput(4000, types.Script{Type: types.ScriptTypeP2PKH, Data: make([]byte, 20)})
+	b.Sign(key1)
+	transaction := b.Build()
+
+	fee, err := transaction.ValidateAmountsWithUTXOs(provider)
+	if err != nil {
+		t.Fatalf("ValidateAmountsWithUTXOs: %v", err)
+	}
+	if fee != 1000 {
+		t.Errorf("fee = %d, want 1000", fee)
+	}
+
+	// Amount and existence checks still apply.
+	over := NewBuilder().
+		AddInput(prevOut).
+		AddOutput(6000, types.Script{Type: types.ScriptTypeP2PKH, Data: make([]byte, 20)})
+	over.Sign(key1)
+	if _, err := over.Build().ValidateAmountsWithUTXOs(provider); !errors.Is(err, ErrInsufficientFee) {
+		t.Errorf("expected ErrInsufficientFee, got: %v", err)
+	}
+	missing := NewBuilder().
+		AddInput(types.Outpoint{TxID: types.Hash{0x02}}).
+		AddOutput(1, types.Script{Type: types.ScriptTypeP2PKH, Data: make([]byte, 20)})
+	missing.Sign(key1)
+	if _, err := missing.Build().ValidateAmountsWithUTXOs(provider); !errors.Is(err, ErrInputNotFound) {
+		t.Errorf("expected ErrInputNotFound, got: %v", err)
+	}
+}
@@ -3,6 +3,8 @@ package types
 import (
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"strings"
 )
 
 // ScriptType identifies the type of locking/unlocking script.
@@ -43,6 +45,20 @@ func (st ScriptType) String() string {
 	}
 }
 
+// ParseScriptType returns the script type named name, as returned by
+// String, ignoring case.
+func ParseScriptType(name string) (ScriptType, error) {
+	for _, st := range []ScriptType{
+		ScriptTypeP2PKH, ScriptTypeP2SH, ScriptTypeMint, ScriptTypeBurn,
+		ScriptTypeAnchor, ScriptTypeRegister, ScriptTypeBridge, ScriptTypeStake,
+	} {
+		if strings.EqualFold(name, st.String()) {
+			return st, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown script type %q", name)
+}
+
 // Script defines the locking condition for a UTXO.
 type Script struct {
 	Type ScriptType `json:"type"`
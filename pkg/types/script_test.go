@@ -1,6 +1,9 @@
 package types
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestScriptType_String(t *testing.T) {
 	tests := []struct {
@@ -27,6 +30,21 @@ func TestScriptType_String(t *testing.T) {
 	}
 }
 
+func TestParseScriptType(t *testing.T) {
+	for _, name := range []string{"P2PKH", "p2sh", "stake", "Mint"} {
+		st, err := ParseScriptType(name)
+		if err != nil {
+			t.Fatalf("ParseScriptType(%q): %v", name, err)
+		}
+		if !strings.EqualFold(st.String(), name) {
+			t.Errorf("ParseScriptType(%q) = %s", name, st)
+		}
+	}
+	if _, err := ParseScriptType("unknown"); err == nil {
+		t.Error("ParseScriptType should reject unknown names")
+	}
+}
+
 func TestScriptType_Values(t *testing.T) {
 	// Verify the actual byte values are correct (these are protocol constants)
 	if ScriptTypeP2PKH != 0x01 {